@@ -1,9 +1,14 @@
 module github.com/googleads/google-ads-doctor
 
-go 1.11
+go 1.18
 
 require (
 	github.com/fatih/structs v1.1.0
 	github.com/kylelemons/godebug v1.1.0
 	golang.org/x/oauth2 v0.0.0-20190319182350-c85d3e98c914
 )
+
+require (
+	cloud.google.com/go v0.34.0 // indirect
+	golang.org/x/net v0.0.0-20190108225652-1e06a53dbb7e // indirect
+)
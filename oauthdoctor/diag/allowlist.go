@@ -0,0 +1,67 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// probeTimeout is how long ProbeHost waits for a host to answer.
+const probeTimeout = 10 * time.Second
+
+// AllowListHost is a host that the client libraries connect to, which a
+// firewall or a proxy must allow.
+type AllowListHost struct {
+	URL *url.URL
+	// Purpose describes what the client libraries use the host for.
+	Purpose string
+}
+
+// AllowList returns the hosts that the client libraries connect to with the
+// Google Ads API endpoint, which is googleads.googleapis.com by default.
+func AllowList(endpoint *url.URL) []AllowListHost {
+	host := func(h string) *url.URL {
+		return &url.URL{Scheme: "https", Host: h}
+	}
+	return []AllowListHost{
+		{URL: endpoint, Purpose: i18n.T("Google Ads API")},
+		{URL: host("oauth2.googleapis.com"), Purpose: i18n.T("OAuth2 tokens: refreshing access tokens and service account tokens")},
+		{URL: host("accounts.google.com"), Purpose: i18n.T("OAuth2 consent page, to generate refresh tokens")},
+		{URL: host("www.googleapis.com"), Purpose: i18n.T("OAuth2 token information and older OAuth2 token endpoint")},
+	}
+}
+
+// ProbeHost opens a TCP connection to the host of u and, for HTTPS, completes
+// a TLS handshake with config, since some firewalls only block a host by the
+// server name of the handshake.
+func ProbeHost(ctx context.Context, u *url.URL, config *tls.Config) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	if err := ConnEndpoint(ctx, u); err != nil {
+		return fmt.Errorf("TCP connection: %s", err)
+	}
+	if u.Scheme == "http" {
+		return nil
+	}
+	if err := TLSHandshake(ctx, u, config); err != nil {
+		return fmt.Errorf("TLS handshake: %s", err)
+	}
+	return nil
+}
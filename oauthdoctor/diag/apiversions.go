@@ -0,0 +1,99 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// maxProbedAPIVersion is the highest major version of the Google Ads API
+// that APIVersions looks for.
+const maxProbedAPIVersion = 30
+
+// apiVersionRegex matches a major version of the Google Ads API, e.g. v17.
+var apiVersionRegex = regexp.MustCompile(`^v(\d+)$`)
+
+// ParseAPIVersion returns the number of a major version of the Google Ads
+// API, e.g. 17 for v17.
+func ParseAPIVersion(v string) (int, error) {
+	m := apiVersionRegex.FindStringSubmatch(v)
+	if m == nil {
+		return 0, i18n.Errorf("%s is not a Google Ads API version such as v17", v)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// APIVersions returns the major versions of the Google Ads API that the
+// endpoint serves, from the oldest. The REST discovery document of each
+// version is fetched: a version that is sunset, or not released yet, returns
+// 404. Any other response than 200 and 404, e.g. of a proxy, is an error.
+func APIVersions(ctx context.Context, endpoint *url.URL, config *tls.Config) ([]int, error) {
+	type probe struct {
+		version int
+		status  int
+		err     error
+	}
+	transport := checkTransport(config)
+	defer transport.CloseIdleConnections()
+
+	probes := make(chan probe, maxProbedAPIVersion)
+	for v := 1; v <= maxProbedAPIVersion; v++ {
+		go func(v int) {
+			p := probe{version: v}
+			req, err := http.NewRequest("GET", endpoint.String()+discoveryPath+"?version=v"+strconv.Itoa(v), nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = transport.RoundTrip(req.WithContext(ctx)); err == nil {
+					resp.Body.Close()
+					p.status = resp.StatusCode
+				}
+			}
+			p.err = err
+			probes <- p
+		}(v)
+	}
+
+	served := make([]bool, maxProbedAPIVersion+1)
+	var firstErr error
+	for i := 0; i < maxProbedAPIVersion; i++ {
+		p := <-probes
+		switch {
+		case p.err != nil:
+			if firstErr == nil {
+				firstErr = p.err
+			}
+		case p.status == http.StatusOK:
+			served[p.version] = true
+		case p.status != http.StatusNotFound && firstErr == nil:
+			firstErr = i18n.Errorf("HTTP %d for the discovery document of v%d", p.status, p.version)
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	var versions []int
+	for v, ok := range served {
+		if ok {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	if v, err := ParseAPIVersion("v17"); v != 17 || err != nil {
+		t.Errorf("ParseAPIVersion(v17) = %d, %v, want: 17", v, err)
+	}
+	for _, s := range []string{"17", "V17", "v17_1", ""} {
+		if _, err := ParseAPIVersion(s); err == nil {
+			t.Errorf("ParseAPIVersion(%q) returned no error", s)
+		}
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	tests := []struct {
+		desc    string
+		handler http.HandlerFunc
+		want    []int
+		wantErr string
+	}{
+		{
+			desc: "served versions",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("version") {
+				case "v15", "v16", "v17":
+					w.Write([]byte("{}"))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			},
+			want: []int{15, 16, 17},
+		},
+		{
+			desc: "proxy error page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantErr: "HTTP 403 for the discovery document",
+		},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewTLSServer(tt.handler)
+		config := ts.Client().Transport.(*http.Transport).TLSClientConfig
+		endpoint, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := APIVersions(context.Background(), endpoint, config)
+		ts.Close()
+
+		if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("[%s] APIVersions() error: %v, want: %s", tt.desc, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] APIVersions() = %v, want: %v", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// minGoVersion is the oldest version of Go that builds this program from
+// source: the VCS settings of debug.ReadBuildInfo were added in Go 1.18, after
+// http.Transport.ForceAttemptHTTP2 (1.13) and json.Decoder.InputOffset (1.14).
+const minGoVersion = "go1.18"
+
+// readBuildInfo is replaced in tests.
+var readBuildInfo = debug.ReadBuildInfo
+
+// BuildEnv describes the build of this binary and the Go toolchain that
+// builds it from source.
+type BuildEnv struct {
+	// Compiler is the Go version and compiler that built this binary.
+	Compiler string
+	// Module is the path and version of the main module, or empty when the
+	// binary was built in GOPATH mode.
+	Module string
+	// Toolchain is the output of go version, or empty when go is not on the
+	// PATH.
+	Toolchain string
+	// GO111MODULE is the module mode of the toolchain.
+	GO111MODULE string
+}
+
+// ReadBuildEnv reads the build information of this binary and asks the go
+// command on the PATH for its version and module mode.
+func ReadBuildEnv(ctx context.Context) BuildEnv {
+	b := BuildEnv{Compiler: runtime.Version() + " " + runtime.Compiler}
+	if info, ok := readBuildInfo(); ok {
+		b.Module = strings.TrimSpace(info.Main.Path + " " + info.Main.Version)
+	}
+	if out, err := exec.CommandContext(ctx, "go", "version").Output(); err == nil {
+		b.Toolchain = strings.TrimSpace(string(out))
+	}
+	if b.Toolchain != "" {
+		if out, err := exec.CommandContext(ctx, "go", "env", "GO111MODULE").Output(); err == nil {
+			b.GO111MODULE = strings.TrimSpace(string(out))
+		}
+	}
+	return b
+}
+
+// String returns the build environment as lines of text.
+func (b BuildEnv) String() string {
+	module := b.Module
+	if module == "" {
+		module = i18n.T("none (GOPATH mode)")
+	}
+	toolchain := b.Toolchain
+	if toolchain == "" {
+		toolchain = i18n.T("not found")
+	}
+	return i18n.Sprintf("Compiler: %s\nModule: %s\nGo toolchain: %s\nGO111MODULE: %s\n",
+		b.Compiler, module, toolchain, b.GO111MODULE)
+}
+
+// Findings returns the problems of the build environment when building this
+// program from source.
+func (b BuildEnv) Findings() []Finding {
+	var findings []Finding
+	if b.Module == "" {
+		findings = append(findings, Finding{Severity: Warning, Message: i18n.T("This binary was built in GOPATH mode, " +
+			"which ignores the dependency versions of go.mod. Build it inside the repository in module mode.")})
+	}
+	if b.Toolchain == "" {
+		return findings
+	}
+	if fields := strings.Fields(b.Toolchain); len(fields) > 2 {
+		if err := checkGoVersion(fields[2]); err != nil {
+			findings = append(findings, Finding{Severity: Error, Message: err.Error()})
+		}
+	}
+	if b.GO111MODULE == "off" {
+		findings = append(findings, Finding{Severity: Warning, Message: i18n.T("GO111MODULE is off, so go builds " +
+			"in GOPATH mode. Unset GO111MODULE or set it to on to build this program from source.")})
+	}
+	return findings
+}
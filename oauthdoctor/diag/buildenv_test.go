@@ -0,0 +1,82 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestBuildEnvFindings(t *testing.T) {
+	const module = "github.com/googleads/google-ads-doctor (devel)"
+	tests := []struct {
+		desc string
+		env  BuildEnv
+		want []string
+	}{
+		{
+			desc: "module build with a recent toolchain",
+			env:  BuildEnv{Module: module, Toolchain: "go version go1.21.3 linux/amd64", GO111MODULE: ""},
+		},
+		{
+			desc: "no toolchain",
+			env:  BuildEnv{Module: module},
+		},
+		{
+			desc: "old toolchain",
+			env:  BuildEnv{Module: module, Toolchain: "go version go1.10.8 linux/amd64"},
+			want: []string{"ERROR: minimum required Go version is 1.18"},
+		},
+		{
+			desc: "GOPATH mode",
+			env:  BuildEnv{Toolchain: "go version go1.20 darwin/amd64", GO111MODULE: "off"},
+			want: []string{"WARNING: This binary was built in GOPATH mode", "WARNING: GO111MODULE is off"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.env.Findings()
+		if len(got) != len(tt.want) {
+			t.Errorf("[%s] got %d findings %v, want %d", tt.desc, len(got), got, len(tt.want))
+			continue
+		}
+		for i, f := range got {
+			if s := string(f.Severity) + ": " + f.Message; !strings.HasPrefix(s, tt.want[i]) {
+				t.Errorf("[%s] got: %s, want prefix: %s", tt.desc, s, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestReadBuildEnv(t *testing.T) {
+	defer func(f func() (*debug.BuildInfo, bool)) { readBuildInfo = f }(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Path: "example.com/doctor", Version: "v1.2.0"}}, true
+	}
+
+	b := ReadBuildEnv(context.Background())
+	if b.Module != "example.com/doctor v1.2.0" {
+		t.Errorf("ReadBuildEnv() got module %q, want example.com/doctor v1.2.0", b.Module)
+	}
+	if !strings.HasPrefix(b.Compiler, "go") {
+		t.Errorf("ReadBuildEnv() got compiler %q, want a Go version", b.Compiler)
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	if s := ReadBuildEnv(context.Background()).String(); !strings.Contains(s, "Module: none (GOPATH mode)") {
+		t.Errorf("ReadBuildEnv() got:\n%s\nwant GOPATH mode", s)
+	}
+}
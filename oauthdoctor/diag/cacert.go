@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// TLSConfig returns the TLS configuration of the connections of a
+// diagnosis, which trusts the PEM certificates of the file at caCert, e.g.
+// the root certificate of a proxy that intercepts HTTPS traffic, in addition
+// to the certificate authorities of this machine, and presents the client
+// certificate clientCert with its private key clientKey. It is nil without
+// any of them, so the connections use the settings of this machine. The TLS
+// checks take it, and NewTransport makes the transport of the HTTP client of
+// the OAuth flows and the secret references with it.
+func TLSConfig(caCert, clientCert, clientKey string) (*tls.Config, error) {
+	if caCert == "" && clientCert == "" {
+		return nil, nil
+	}
+	config := &tls.Config{}
+	if clientCert != "" {
+		cert, err := loadClientCert(clientCert, clientKey)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caCert != "" {
+		pool, err := loadCACerts(caCert)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// NewTransport returns a copy of http.DefaultTransport whose TLS connections
+// use config. http.DefaultTransport is not changed, so the diagnoses that
+// run in the same process do not share their TLS settings.
+func NewTransport(config *tls.Config) *http.Transport {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: config}
+	}
+	t = t.Clone()
+	t.TLSClientConfig = config
+	return t
+}
+
+// loadCACerts returns the certificate authorities of this machine with the
+// PEM certificates of the file at path.
+func loadCACerts(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Errorf("Cannot read the CA certificates: %s", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, i18n.Errorf("%s has no PEM certificate. Convert a DER certificate with: "+
+			"openssl x509 -inform der -in CERT.cer -out CERT.pem", path)
+	}
+	return pool, nil
+}
+
+// IsUnknownAuthority returns true when err is caused by a certificate that is
+// not issued by a certificate authority that this machine trusts.
+func IsUnknownAuthority(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := err.(x509.UnknownAuthorityError); ok {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "certificate signed by unknown authority") ||
+		strings.Contains(msg, "certificate is not trusted")
+}
+
+// googleIssuer is the organization of the certificate authority of the
+// certificates of the Google APIs.
+const googleIssuer = "Google Trust Services"
+
+// InterceptionHelp explains why the certificate of the endpoint is not
+// trusted. It connects without verifying the certificate to find out who
+// issued it: a certificate that Google did not issue means that HTTPS
+// traffic is intercepted and re-signed, e.g. by a proxy or antivirus
+// software, whose root certificate this machine does not trust.
+func InterceptionHelp(ctx context.Context, endpoint *url.URL) string {
+	issuer, err := certIssuer(ctx, endpoint)
+	if err != nil {
+		return i18n.Sprintf("The certificate of %s is not trusted by this machine, and its issuer cannot be "+
+			"read: %s", endpoint.Hostname(), err)
+	}
+	if strings.Contains(issuer, googleIssuer) {
+		return i18n.Sprintf("The certificate of %s is issued by %s, which this machine does not trust. "+
+			"The certificate authorities of this machine are missing or outdated: update them, e.g. with the "+
+			"ca-certificates package on Linux.", endpoint.Hostname(), issuer)
+	}
+	return i18n.Sprintf("The certificate of %s is issued by %s, not by %s, so a proxy, firewall or antivirus "+
+		"software of your organization intercepts HTTPS traffic and signs it with its own root certificate, "+
+		"which this machine does not trust. Ask your IT team for this root certificate in PEM format and pass "+
+		"it with -cacert. Your application needs to trust it too: add it to the certificate authorities of "+
+		"this machine, or point your client library to it, e.g. with GRPC_DEFAULT_SSL_ROOTS_FILE_PATH for "+
+		"gRPC, REQUESTS_CA_BUNDLE for Python or the javax.net.ssl.trustStore property for Java.",
+		endpoint.Hostname(), issuer, googleIssuer)
+}
+
+// certIssuer returns the issuer of the root of the certificate chain that
+// the endpoint presents, without verifying it.
+func certIssuer(ctx context.Context, endpoint *url.URL) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", EndpointAddr(endpoint))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: endpoint.Hostname(), InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("no certificate")
+	}
+	issuer := certs[len(certs)-1].Issuer
+	if len(issuer.Organization) > 0 {
+		return fmt.Sprintf("%q (%s)", issuer.CommonName, strings.Join(issuer.Organization, ", ")), nil
+	}
+	return fmt.Sprintf("%q", issuer.CommonName), nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrustCACerts(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	endpoint, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "cacert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "proxy.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := ioutil.WriteFile(certFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	derFile := filepath.Join(dir, "proxy.cer")
+	if err := ioutil.WriteFile(derFile, ts.Certificate().Raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = TLSHandshake(context.Background(), endpoint, nil)
+	if !IsUnknownAuthority(err) {
+		t.Fatalf("TLSHandshake() error: %v, want an unknown authority", err)
+	}
+	help := InterceptionHelp(context.Background(), endpoint)
+	for _, want := range []string{"Acme Co", "intercepts HTTPS traffic", "-cacert"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("InterceptionHelp() = %s\nwant substring: %s", help, want)
+		}
+	}
+
+	if _, err := TLSConfig(derFile, "", ""); !strings.Contains(errstring(err), "has no PEM certificate") {
+		t.Errorf("TLSConfig(DER) error: %s, want: has no PEM certificate", errstring(err))
+	}
+
+	config, err := TLSConfig(certFile, "", "")
+	if err != nil {
+		t.Fatalf("TLSConfig() error: %s", err)
+	}
+	if err := TLSHandshake(context.Background(), endpoint, config); err != nil {
+		t.Errorf("TLSHandshake() with the CA certificate error: %s", err)
+	}
+	resp, err := (&http.Client{Transport: NewTransport(config)}).Get(ts.URL)
+	if err != nil {
+		t.Errorf("NewTransport() with the CA certificate error: %s", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	// The CA certificate is not trusted by the other connections.
+	if err := TLSHandshake(context.Background(), endpoint, nil); !IsUnknownAuthority(err) {
+		t.Errorf("TLSHandshake() without the CA certificate error: %v, want an unknown authority", err)
+	}
+	if _, err := http.Get(ts.URL); !IsUnknownAuthority(err) {
+		t.Errorf("http.DefaultTransport error: %v, want an unknown authority", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// Change is a value of the configuration that the doctor replaced.
+type Change struct {
+	// Key is the field of ConfigKeys, e.g. RefreshToken.
+	Key string `json:"key"`
+	// OldValue is the replaced value, redacted by Redact.
+	OldValue string `json:"oldValue"`
+	// Location is where the new value was written: the configuration file,
+	// the OS credential store, or nowhere when the configuration is read
+	// from the environment (see FromEnv).
+	Location string `json:"location"`
+	// Backup is the backup of the configuration file made before the
+	// change, if any.
+	Backup string    `json:"backup,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+var (
+	changesMu sync.Mutex
+	// changes are the changes of the session, in the order they were made.
+	changes []Change
+)
+
+// Changes returns the changes made by ReplaceConfig since the doctor
+// started, from the oldest.
+func Changes() []Change {
+	changesMu.Lock()
+	defer changesMu.Unlock()
+	return append([]Change(nil), changes...)
+}
+
+// recordChange adds a change of key, whose value was old, to the changes of
+// the session.
+func recordChange(key, old, location, backup string) {
+	changesMu.Lock()
+	defer changesMu.Unlock()
+	changes = append(changes, Change{
+		Key:      key,
+		OldValue: Redact(old),
+		Location: location,
+		Backup:   backup,
+		Time:     time.Now(),
+	})
+}
+
+// Redact hides a value, so it can be printed to tell it apart from another
+// value without revealing it: only the first and last 4 characters of a long
+// value are kept.
+func Redact(v string) string {
+	switch {
+	case v == "":
+		return i18n.T("(empty)")
+	case len(v) <= 12:
+		return strings.Repeat("*", len(v))
+	}
+	return v[:4] + "..." + v[len(v)-4:]
+}
+
+// configValue returns the value of the field key of c.ConfigKeys.
+func (c *ConfigFile) configValue(key string) string {
+	f, ok := structs.New(c.ConfigKeys).FieldOk(key)
+	if !ok {
+		return ""
+	}
+	v, _ := f.Value().(string)
+	return v
+}
+
+// changesPath returns the file where SaveChanges keeps the changes of the
+// last session for UndoChanges.
+var changesPath = func() (string, error) {
+	usr, err := currentUser()
+	if err != nil {
+		return "", i18n.Errorf("Error finding user's home directory: %s", err)
+	}
+	return filepath.Join(usr.HomeDir, ".google-ads-doctor", "changes.json"), nil
+}
+
+// SaveChanges saves the changes of the session, so UndoChanges can revert
+// them in a later run. Without changes, the saved changes of an earlier
+// session are kept.
+func SaveChanges(changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	path, err := changesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// Restore is a configuration file reverted by UndoChanges.
+type Restore struct {
+	// File is the configuration file.
+	File string
+	// From is the backup made before the first change of the file in the
+	// last session, whose content was restored.
+	From string
+	// Backup is the backup of the file made before it was restored.
+	Backup string
+}
+
+// UndoChanges reverts the configuration files changed in the last session
+// saved by SaveChanges to their content before the first change, using the
+// backups made by ReplaceConfig. The changes of the OS credential store and
+// of the configuration read from the environment have no backup, and are
+// returned as skipped. Nothing is restored when a file was changed after the
+// session, since restoring it would lose those edits. The saved changes are
+// removed once they are undone.
+func UndoChanges() (restored []Restore, skipped []Change, err error) {
+	path, err := changesPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil, i18n.Errorf("There are no changes of the doctor to undo.")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var changes []Change
+	if err := json.Unmarshal(b, &changes); err != nil {
+		return nil, nil, i18n.Errorf("Cannot read the changes of the last session from %s: %s", path, err)
+	}
+
+	// The first backup of a file holds its content before the session.
+	last := make(map[string]time.Time)
+	for _, c := range changes {
+		if c.Backup == "" {
+			skipped = append(skipped, c)
+			continue
+		}
+		if _, ok := last[c.Location]; !ok {
+			restored = append(restored, Restore{File: c.Location, From: c.Backup})
+		}
+		last[c.Location] = c.Time
+	}
+
+	for _, r := range restored {
+		info, err := os.Stat(r.File)
+		if err != nil {
+			return nil, nil, i18n.Errorf("ERROR: Problem opening config file: %s", err)
+		}
+		// The file is written just before the change is recorded.
+		if info.ModTime().After(last[r.File].Add(time.Second)) {
+			return nil, nil, i18n.Errorf("ERROR: %s was changed after the last session of the doctor, so it is not "+
+				"restored. Compare it with %s and restore it manually.", r.File, r.From)
+		}
+		if _, err := os.Stat(r.From); err != nil {
+			return nil, nil, i18n.Errorf("ERROR: Cannot restore %s from its backup: %s", r.File, err)
+		}
+	}
+
+	for i, r := range restored {
+		content, err := ioutil.ReadFile(r.From)
+		if err != nil {
+			return restored[:i], nil, i18n.Errorf("ERROR: Cannot restore %s from its backup: %s", r.File, err)
+		}
+		restored[i].Backup = backupPath(r.File)
+		if err := replaceFile(r.File, restored[i].Backup, content); err != nil {
+			return restored[:i], nil, err
+		}
+	}
+	return restored, skipped, os.Remove(path)
+}
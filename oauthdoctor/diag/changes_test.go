@@ -0,0 +1,166 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		desc  string
+		value string
+		want  string
+	}{
+		{desc: "empty", value: "", want: "(empty)"},
+		{desc: "short", value: "abcdef", want: "******"},
+		{desc: "refresh token", value: "1//0gAbCdEfGhIjKlMnOp-wxyz", want: "1//0...wxyz"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.value); got != tt.want {
+			t.Errorf("[%s] Redact(%q) = %q, want: %q", tt.desc, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceConfigRecordsChanges(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "changes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := "developer_token: OldDeveloperToken1234\nclient_id: id\nclient_secret: secret\nrefresh_token: token\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "google-ads.yaml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := ParseKeyValueFile("python", filepath.Join(dir, "google-ads.yaml"), InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := ConfigFile{Lang: "php", Format: EnvFormat}
+
+	previous := len(Changes())
+	backup, err := file.ReplaceConfig(DevToken, "NewDeveloperToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.ReplaceConfig(RefreshToken, "new"); err != nil {
+		t.Fatal(err)
+	}
+	// A failed replacement is not recorded.
+	file.reader = &Reader{DecryptCommand: "decrypt"}
+	_, err = file.ReplaceConfig(ClientID, "new")
+	file.reader = nil
+	if err == nil {
+		t.Fatal("ReplaceConfig() of an encrypted file returned no error")
+	}
+
+	got := Changes()[previous:]
+	if len(got) != 2 {
+		t.Fatalf("Changes() got %d changes, want 2: %+v", len(got), got)
+	}
+	if c := got[0]; c.Key != DevToken || c.OldValue != "OldD...1234" || c.Location != file.GetFilepath() ||
+		c.Backup != backup || c.Time.IsZero() {
+		t.Errorf("Changes()[0] = %+v, want the developer token replaced in %s", c, file.GetFilepath())
+	}
+	if c := got[1]; c.Key != RefreshToken || c.OldValue != "(empty)" || c.Backup != "" ||
+		!strings.Contains(c.Location, "not saved") {
+		t.Errorf("Changes()[1] = %+v, want the refresh token that was not saved", c)
+	}
+}
+
+func TestUndoChanges(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "undo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	origPath := changesPath
+	changesPath = func() (string, error) { return filepath.Join(dir, "state", "changes.json"), nil }
+	defer func() { changesPath = origPath }()
+
+	tests := []struct {
+		desc     string
+		modified bool
+		wantErr  string
+	}{
+		{desc: "restored"},
+		{desc: "file changed after the session", modified: true, wantErr: "was changed after"},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("google-ads%d.yaml", i))
+		content := "developer_token: OldDeveloperToken1234\nclient_id: id\nclient_secret: secret\nrefresh_token: token\n"
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := ParseKeyValueFile("python", path, InstalledApp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		previous := len(Changes())
+		if _, err := c.ReplaceConfig(DevToken, "NewDeveloperToken"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ReplaceConfig(RefreshToken, "NewRefreshToken"); err != nil {
+			t.Fatal(err)
+		}
+		session := append(Changes()[previous:], Change{Key: ClientSecret, Location: "OS credential store"})
+		if err := SaveChanges(session); err != nil {
+			t.Fatal(err)
+		}
+		if tt.modified {
+			later := time.Now().Add(time.Hour)
+			if err := os.Chtimes(path, later, later); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		restored, skipped, err := UndoChanges()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("[%s] UndoChanges() got error: %v, want: %s", tt.desc, err, tt.wantErr)
+			}
+			if got, _ := ioutil.ReadFile(path); string(got) == content {
+				t.Errorf("[%s] UndoChanges() restored the file", tt.desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%s] UndoChanges() error: %s", tt.desc, err)
+		}
+		if len(restored) != 1 || restored[0].File != path || restored[0].From != session[0].Backup {
+			t.Errorf("[%s] UndoChanges() restored %+v, want %s from %s", tt.desc, restored, path, session[0].Backup)
+		}
+		if len(skipped) != 1 || skipped[0].Key != ClientSecret {
+			t.Errorf("[%s] UndoChanges() skipped %+v, want the client secret", tt.desc, skipped)
+		}
+		if got, _ := ioutil.ReadFile(path); string(got) != content {
+			t.Errorf("[%s] UndoChanges() got file:\n%s\nwant:\n%s", tt.desc, got, content)
+		}
+		if _, _, err := UndoChanges(); err == nil {
+			t.Errorf("[%s] UndoChanges() twice returned no error", tt.desc)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"crypto/tls"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// loadClientCert loads the PEM client certificate and its private key, which
+// TLSConfig presents in the TLS connections of a diagnosis. Some egress
+// proxies only let clients with a certificate through.
+func loadClientCert(certFile, keyFile string) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, i18n.Errorf("Cannot load the client certificate %s with the key %s: %s", certFile, keyFile, err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// ClientSecrets are the credentials of an OAuth client in the JSON file
+// downloaded from the Google Cloud console, e.g.
+// client_secret_1234-abcd.apps.googleusercontent.com.json.
+type ClientSecrets struct {
+	// OAuthType is InstalledApp for a desktop client and Web for a web
+	// client.
+	OAuthType    string
+	ClientID     string
+	ClientSecret string
+	ProjectID    string
+	RedirectURIs []string
+}
+
+// clientSecretsFile is the format of the JSON file of an OAuth client, whose
+// credentials are under "installed" or "web".
+type clientSecretsFile struct {
+	Installed *clientSecretsEntry `json:"installed"`
+	Web       *clientSecretsEntry `json:"web"`
+	Type      string              `json:"type"`
+}
+
+type clientSecretsEntry struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	ProjectID    string   `json:"project_id"`
+	RedirectURIs []string `json:"redirect_uris"`
+}
+
+// ReadClientSecrets reads the JSON file of an OAuth client at path.
+func ReadClientSecrets(path string) (ClientSecrets, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ClientSecrets{}, i18n.Errorf("Cannot read the client secrets file: %s", err)
+	}
+	var f clientSecretsFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return ClientSecrets{}, i18n.Errorf("%s is not a JSON file: %s", path, err)
+	}
+
+	s := ClientSecrets{OAuthType: InstalledApp}
+	entry := f.Installed
+	switch {
+	case f.Type == ServiceAccount || f.Type == ExternalAccount:
+		return s, i18n.Errorf("%s is the key of a service account, not the JSON file of an OAuth client. "+
+			"Set it as %s of the service account flow instead.", path, PrivateKeyPath)
+	case f.Web != nil:
+		s.OAuthType, entry = Web, f.Web
+	case entry == nil:
+		return s, i18n.Errorf("%s is not the JSON file of an OAuth client: it has no installed or web "+
+			"client. Download it from the Credentials page of the Google Cloud console.", path)
+	}
+	if entry.ClientID == "" || entry.ClientSecret == "" {
+		return s, i18n.Errorf("%s has no client_id or client_secret. Download it again from the "+
+			"Credentials page of the Google Cloud console.", path)
+	}
+	s.ClientID = strings.TrimSpace(entry.ClientID)
+	s.ClientSecret = strings.TrimSpace(entry.ClientSecret)
+	s.ProjectID = entry.ProjectID
+	s.RedirectURIs = entry.RedirectURIs
+	return s, nil
+}
+
+// HasRedirectURI returns true when uri is an authorized redirect URI of the
+// client. A trailing slash does not matter.
+func (s ClientSecrets) HasRedirectURI(uri string) bool {
+	for _, u := range s.RedirectURIs {
+		if strings.TrimSuffix(u, "/") == strings.TrimSuffix(uri, "/") {
+			return true
+		}
+	}
+	return false
+}
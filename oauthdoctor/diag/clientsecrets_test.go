@@ -0,0 +1,100 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadClientSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientsecrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc    string
+		content string
+		want    ClientSecrets
+		wantErr string
+	}{
+		{
+			desc: "Desktop client",
+			content: `{"installed":{"client_id":"1234-abcd.apps.googleusercontent.com","project_id":"ads-project",` +
+				`"client_secret":"GOCSPX-secret","redirect_uris":["http://localhost"]}}`,
+			want: ClientSecrets{OAuthType: InstalledApp, ClientID: "1234-abcd.apps.googleusercontent.com",
+				ClientSecret: "GOCSPX-secret", ProjectID: "ads-project", RedirectURIs: []string{"http://localhost"}},
+		},
+		{
+			desc: "Web client",
+			content: `{"web":{"client_id":"1234-web.apps.googleusercontent.com","project_id":"ads-project",` +
+				`"client_secret":"GOCSPX-web","redirect_uris":["http://localhost:8080/"]}}`,
+			want: ClientSecrets{OAuthType: Web, ClientID: "1234-web.apps.googleusercontent.com",
+				ClientSecret: "GOCSPX-web", ProjectID: "ads-project", RedirectURIs: []string{"http://localhost:8080/"}},
+		},
+		{
+			desc:    "Service account key",
+			content: `{"type":"service_account","client_email":"sa@project.iam.gserviceaccount.com"}`,
+			wantErr: "is the key of a service account",
+		},
+		{
+			desc:    "No client",
+			content: `{"client_id":"1234"}`,
+			wantErr: "it has no installed or web client",
+		},
+		{
+			desc:    "No client secret",
+			content: `{"installed":{"client_id":"1234-abcd.apps.googleusercontent.com"}}`,
+			wantErr: "has no client_id or client_secret",
+		},
+		{
+			desc:    "Not JSON",
+			content: "client_id: 1234",
+			wantErr: "is not a JSON file",
+		},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("client_secret%d.json", i))
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadClientSecrets(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("[%s] ReadClientSecrets() error: %v, want: %s", tt.desc, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] ReadClientSecrets() error: %s", tt.desc, err)
+			continue
+		}
+		if got.OAuthType != tt.want.OAuthType || got.ClientID != tt.want.ClientID || got.ClientSecret != tt.want.ClientSecret ||
+			got.ProjectID != tt.want.ProjectID || strings.Join(got.RedirectURIs, ",") != strings.Join(tt.want.RedirectURIs, ",") {
+			t.Errorf("[%s] ReadClientSecrets() = %+v, want: %+v", tt.desc, got, tt.want)
+		}
+	}
+
+	s := ClientSecrets{RedirectURIs: []string{"http://localhost:8080/"}}
+	if !s.HasRedirectURI("http://localhost:8080") || s.HasRedirectURI("http://localhost") {
+		t.Errorf("HasRedirectURI() does not match %v", s.RedirectURIs)
+	}
+}
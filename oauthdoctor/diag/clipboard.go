@@ -0,0 +1,111 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// PowerShell is the Windows PowerShell console.
+	PowerShell = "powershell"
+	// CommandPrompt is the Windows Command Prompt (cmd.exe).
+	CommandPrompt = "cmd"
+)
+
+// clipboardCommands lists the commands that write stdin to the clipboard
+// for each operating system, in order of preference.
+var clipboardCommands = map[string][][]string{
+	"windows": {{"clip"}},
+	"darwin":  {{"pbcopy"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// pasteCommands lists the commands that print the text of the clipboard for
+// each operating system, in order of preference.
+var pasteCommands = map[string][][]string{
+	"windows": {{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}},
+	"darwin":  {{"pbpaste"}},
+	"linux": {
+		{"wl-paste", "--no-newline"},
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	},
+}
+
+// CopyToClipboard writes text to the system clipboard using the clipboard
+// command available on this operating system.
+func CopyToClipboard(text string) error {
+	args, err := clipboardCommand(runtime.GOOS, exec.LookPath)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// ReadClipboard returns the text of the system clipboard, without the
+// surrounding whitespace, using the command available on this operating
+// system.
+func ReadClipboard() (string, error) {
+	args, err := findCommand(pasteCommands, runtime.GOOS, exec.LookPath)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// clipboardCommand returns the first clipboard command for goos that can
+// be found with lookPath.
+func clipboardCommand(goos string, lookPath func(string) (string, error)) ([]string, error) {
+	return findCommand(clipboardCommands, goos, lookPath)
+}
+
+// findCommand returns the first command of commands for goos that can be
+// found with lookPath.
+func findCommand(commands map[string][][]string, goos string, lookPath func(string) (string, error)) ([]string, error) {
+	for _, args := range commands[goos] {
+		if _, err := lookPath(args[0]); err == nil {
+			return args, nil
+		}
+	}
+	return nil, fmt.Errorf("no clipboard command found for %s", goos)
+}
+
+// WindowsShell returns the console the program runs in on Windows, either
+// PowerShell or CommandPrompt. The Command Prompt defines the PROMPT
+// environment variable, which PowerShell does not.
+func WindowsShell() string {
+	return windowsShell(os.Getenv)
+}
+
+func windowsShell(getenv func(string) string) string {
+	if getenv("PROMPT") == "" && getenv("PSModulePath") != "" {
+		return PowerShell
+	}
+	return CommandPrompt
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package diag
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestClipboardCommand(t *testing.T) {
+	tests := []struct {
+		desc      string
+		goos      string
+		available []string
+		want      string
+		errstr    string
+	}{
+		{
+			desc:      "Windows uses clip",
+			goos:      "windows",
+			available: []string{"clip"},
+			want:      "clip",
+		},
+		{
+			desc:      "Linux falls back to xclip",
+			goos:      "linux",
+			available: []string{"xclip", "xsel"},
+			want:      "xclip -selection clipboard",
+		},
+		{
+			desc:      "No clipboard command installed",
+			goos:      "linux",
+			available: nil,
+			errstr:    "no clipboard command",
+		},
+	}
+
+	for _, test := range tests {
+		lookPath := func(file string) (string, error) {
+			for _, a := range test.available {
+				if a == file {
+					return "/bin/" + file, nil
+				}
+			}
+			return "", fmt.Errorf("%s not found", file)
+		}
+
+		got, err := clipboardCommand(test.goos, lookPath)
+		if strings.Join(got, " ") != test.want || !strings.Contains(errstring(err), test.errstr) {
+			t.Errorf("[%s] got: %v, %s, want: %s, %s", test.desc, got, errstring(err), test.want, test.errstr)
+		}
+	}
+}
+
+func TestPasteCommand(t *testing.T) {
+	tests := []struct {
+		desc      string
+		goos      string
+		available []string
+		want      string
+	}{
+		{
+			desc:      "macOS uses pbpaste",
+			goos:      "darwin",
+			available: []string{"pbpaste"},
+			want:      "pbpaste",
+		},
+		{
+			desc:      "Linux prefers Wayland",
+			goos:      "linux",
+			available: []string{"wl-paste", "xclip"},
+			want:      "wl-paste --no-newline",
+		},
+		{
+			desc:      "Windows uses PowerShell",
+			goos:      "windows",
+			available: []string{"powershell"},
+			want:      "powershell -NoProfile -Command Get-Clipboard",
+		},
+	}
+
+	for _, test := range tests {
+		lookPath := func(file string) (string, error) {
+			for _, a := range test.available {
+				if a == file {
+					return "/bin/" + file, nil
+				}
+			}
+			return "", fmt.Errorf("%s not found", file)
+		}
+
+		got, err := findCommand(pasteCommands, test.goos, lookPath)
+		if strings.Join(got, " ") != test.want || err != nil {
+			t.Errorf("[%s] got: %v, %s, want: %s", test.desc, got, errstring(err), test.want)
+		}
+	}
+}
+
+func TestWindowsShell(t *testing.T) {
+	tests := []struct {
+		desc string
+		env  map[string]string
+		want string
+	}{
+		{
+			desc: "Command Prompt defines PROMPT",
+			env:  map[string]string{"PROMPT": "$P$G", "PSModulePath": `C:\Modules`},
+			want: CommandPrompt,
+		},
+		{
+			desc: "PowerShell",
+			env:  map[string]string{"PSModulePath": `C:\Modules`},
+			want: PowerShell,
+		},
+	}
+
+	for _, test := range tests {
+		got := windowsShell(func(k string) string { return test.env[k] })
+		if got != test.want {
+			t.Errorf("[%s] got: %s, want: %s", test.desc, got, test.want)
+		}
+	}
+}
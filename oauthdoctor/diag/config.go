@@ -15,7 +15,6 @@
 package diag
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
@@ -23,17 +22,15 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"os"
 	"os/user"
 	"path/filepath"
 	"reflect"
 	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 )
 
 const (
@@ -71,11 +68,15 @@ const (
 	Web = "web"
 	// ServiceAccount allows server-to-server interactions between a web application and a Google service.
 	ServiceAccount = "service_account"
+	// ExternalAccount is the type of the JSON credentials of workload identity
+	// federation, which a service account uses instead of a private key.
+	// Read https://cloud.google.com/iam/docs/workload-identity-federation.
+	ExternalAccount = "external_account"
 )
 
 var (
 	// PIIWords is a slice of constant strings that indicate Personally Identifiable Information
-	PIIWords = []string{DevToken, ClientID, ClientSecret, RefreshToken, ProjectID, PrivateKeyID,
+	PIIWords = []string{DevToken, ClientID, ClientSecret, RefreshToken, DelegatedAccount, ProjectID, PrivateKeyID,
 		PrivateKey, ClientEmail, ClientX509CertURL}
 
 	// RequiredKeys are the key names used in the Language structure that defines
@@ -87,7 +88,7 @@ var (
 	}
 
 	devTokenRegex  = regexp.MustCompile("[[:alnum:]_\\-]+")
-	quotedStrRegex = regexp.MustCompile("[\\w\\-\\./_@~]+")
+	quotedStrRegex = regexp.MustCompile("[\\w\\-\\./_@~:]+")
 )
 
 // Config is the collection of language specific elements.
@@ -103,8 +104,54 @@ type ConfigFile struct {
 	Filepath  string
 	Lang      string
 	OAuthType string
+	// Format is JSONFormat for a configuration file in JSON, or empty for
+	// the native file of the client library.
+	Format string
+	// Keyring is true when the secrets of KeyringKeys are kept in the OS
+	// credential store instead of the file.
+	Keyring bool
 	ConfigKeys
+	// Sources tells where each value of ConfigKeys was read from, e.g. the
+	// file and line, or the environment variable. It is empty for the
+	// values that are not set.
+	Sources ConfigKeys
 	ServiceAccountInfo
+	// Out receives the messages about the file, e.g. its backups, such as a
+	// report.Reporter. When nil, they are printed with the standard logger.
+	Out Printer
+
+	// lineErrs are the errors of the lines of the file that could not be
+	// parsed, one per line, so that ConfigFile stays comparable.
+	lineErrs string
+	// plaintext is the decrypted content of an encrypted file, set when
+	// the file is parsed.
+	plaintext string
+	// reader is how the file was parsed, so that it is read again the same
+	// way. It is nil for a ConfigFile that was not parsed.
+	reader *Reader
+}
+
+// Printer prints the messages of the diag package.
+type Printer interface {
+	Print(msg string)
+}
+
+// print prints msg with c.Out, or with the standard logger when it is nil.
+func (c *ConfigFile) print(msg string) {
+	if c.Out == nil {
+		log.Print(msg)
+		return
+	}
+	c.Out.Print(msg)
+}
+
+// LineErrors returns the errors of the lines of the file that could not be
+// parsed, which do not prevent reading the other keys.
+func (c *ConfigFile) LineErrors() []string {
+	if c.lineErrs == "" {
+		return nil
+	}
+	return strings.Split(c.lineErrs, "\n")
 }
 
 // ConfigKeys are the keys in a client configuration file.
@@ -116,6 +163,7 @@ type ConfigKeys struct {
 	LoginCustomerID  string
 	PrivateKeyPath   string
 	DelegatedAccount string
+	Endpoint         string
 }
 
 type ServiceAccountInfo struct {
@@ -153,8 +201,9 @@ var Languages = map[string]Config{
 				DevToken:         "api.googleads.developerToken",
 				RefreshToken:     "api.googleads.refreshToken",
 				LoginCustomerID:  "api.googleads.loginCustomerId",
-				PrivateKeyPath:   "api.googleads.jsonKeyFilePath",
+				PrivateKeyPath:   "api.googleads.serviceAccountSecretsPath",
 				DelegatedAccount: "api.googleads.serviceAccountUser",
+				Endpoint:         "api.googleads.endpoint",
 			}}},
 	"dotnet": {
 		Comment: Comment{
@@ -171,6 +220,7 @@ var Languages = map[string]Config{
 				LoginCustomerID:  "LoginCustomerId",
 				PrivateKeyPath:   "OAuth2SecretsJsonPath",
 				DelegatedAccount: "OAuth2PrnEmail",
+				Endpoint:         "ServerUrl",
 			}}},
 	"php": {
 		Comment: Comment{
@@ -187,6 +237,7 @@ var Languages = map[string]Config{
 				LoginCustomerID:  "loginCustomerId",
 				PrivateKeyPath:   "jsonKeyFilePath",
 				DelegatedAccount: "impersonatedEmail",
+				Endpoint:         "endpoint",
 			}}},
 	"python": {
 		Comment: Comment{
@@ -201,8 +252,43 @@ var Languages = map[string]Config{
 				DevToken:         "developer_token",
 				RefreshToken:     "refresh_token",
 				LoginCustomerID:  "login_customer_id",
-				PrivateKeyPath:   "path_to_private_key_file",
-				DelegatedAccount: "delegated_account",
+				PrivateKeyPath:   "json_key_file_path",
+				DelegatedAccount: "impersonated_email",
+				Endpoint:         "endpoint",
+			}}},
+	// Node.js integrations, e.g. with opteo/google-ads-api or the REST
+	// interface, commonly read their credentials from environment variables
+	// kept in a .env file, named like those of the Python client library.
+	"nodejs": {
+		Comment: Comment{
+			LeftMeta: "#",
+		},
+		Separator: "=",
+		Cfg: ConfigFile{
+			Filename: ".env",
+			ConfigKeys: ConfigKeys{
+				ClientID:         "GOOGLE_ADS_CLIENT_ID",
+				ClientSecret:     "GOOGLE_ADS_CLIENT_SECRET",
+				DevToken:         "GOOGLE_ADS_DEVELOPER_TOKEN",
+				RefreshToken:     "GOOGLE_ADS_REFRESH_TOKEN",
+				LoginCustomerID:  "GOOGLE_ADS_LOGIN_CUSTOMER_ID",
+				PrivateKeyPath:   "GOOGLE_ADS_JSON_KEY_FILE_PATH",
+				DelegatedAccount: "GOOGLE_ADS_IMPERSONATED_EMAIL",
+				Endpoint:         "GOOGLE_ADS_ENDPOINT",
+			}}},
+	// RESTLanguage has no configuration file, so only the environment
+	// variables are defined.
+	RESTLanguage: {
+		Cfg: ConfigFile{
+			ConfigKeys: ConfigKeys{
+				ClientID:         "GOOGLE_ADS_CLIENT_ID",
+				ClientSecret:     "GOOGLE_ADS_CLIENT_SECRET",
+				DevToken:         "GOOGLE_ADS_DEVELOPER_TOKEN",
+				RefreshToken:     "GOOGLE_ADS_REFRESH_TOKEN",
+				LoginCustomerID:  "GOOGLE_ADS_LOGIN_CUSTOMER_ID",
+				PrivateKeyPath:   "GOOGLE_ADS_JSON_KEY_FILE_PATH",
+				DelegatedAccount: "GOOGLE_ADS_IMPERSONATED_EMAIL",
+				Endpoint:         "GOOGLE_ADS_ENDPOINT",
 			}}},
 	"ruby": {
 		Comment: Comment{
@@ -219,6 +305,7 @@ var Languages = map[string]Config{
 				LoginCustomerID:  "c.login_customer_id",
 				PrivateKeyPath:   "c.keyfile",
 				DelegatedAccount: "c.impersonate",
+				Endpoint:         "c.api_endpoint",
 			},
 		},
 	},
@@ -246,134 +333,285 @@ func (c *ConfigFile) SetConfigKeys(k, v string) {
 	structs.New(&c.ConfigKeys).Field(k).Set(v)
 }
 
+// SetSource records where the value of the given key in
+// ConfigFile.ConfigKeys was read from.
+func (c *ConfigFile) SetSource(k, source string) {
+	structs.New(&c.Sources).Field(k).Set(source)
+}
+
+// Source returns where the value of the given key in ConfigFile.ConfigKeys
+// was read from, or an empty string when it is unknown.
+func (c *ConfigFile) Source(k string) string {
+	return structs.New(c.Sources).Field(k).Value().(string)
+}
+
+// updateSources records the file and line of the keys in occurrences, which
+// are the sources of the values set by UpdateConfigKeys.
+func (c *ConfigFile) updateSources(occurrences []keyOccurrence) {
+	known := c.knownKeys()
+	for _, o := range occurrences {
+		if field, ok := known[o.Key]; ok && !o.Misplaced && o.Env != "" {
+			c.SetSource(field, i18n.Sprintf("environment variable %s, referenced at %s:%d", o.Env, c.GetFilepath(), o.Line))
+		} else if ok && !o.Misplaced {
+			c.SetSource(field, fmt.Sprintf("%s:%d", c.GetFilepath(), o.Line))
+		}
+	}
+}
+
 // UpdateConfigKeys updates attributes in ConfigFile.ConfigKeys from keyValue map.
 // The keys in keyValue must match the names in ConfigFile.ConfigKeys, else
 // they will be ignored.
 func (c *ConfigFile) UpdateConfigKeys(keyValue map[string]string) {
-	swappedMap := swapMap(structs.Map(Languages[c.Lang].Cfg.ConfigKeys))
+	known := c.knownKeys()
 	for k, v := range keyValue {
-		if mappedK, ok := swappedMap[k]; ok {
-			c.SetConfigKeys(mappedK, v)
+		mappedK, ok := known[k]
+		if !ok {
+			continue
+		}
+		// The current key takes precedence over its old name.
+		if _, current := keyValue[c.GetConfigKeysInLang(mappedK)]; current && c.isLegacyKey(k) {
+			continue
 		}
+		c.SetConfigKeys(mappedK, v)
 	}
 }
 
 // ReplaceConfigFromReader reads configuration file content from io.Reader
-// according to a specific language config file syntax. It inserts the new
-// key-value pair and comments out the existing one if found.
+// according to a specific language config file syntax. It replaces the value
+// of the key in place, or adds the key where the client library reads it if
+// the key is not set. Comments, ordering, indentation and line endings (LF or
+// CRLF) of the content are preserved.
 func (c *ConfigFile) ReplaceConfigFromReader(key, value string, r io.Reader) string {
-	var buf bytes.Buffer
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		c.print(i18n.Sprintf("ERROR: Problem reading config file: %s", err))
+	}
 
-	// Insert the new key-value pair at the "top" of the file. "Top" is
-	// the topmost position that is syntactically correct based on the language.
-	// And then it finds the line with the old config key and comments it out.
-	comment := Languages[c.Lang].Comment
-	scanner := bufio.NewScanner(r)
-	for i := 0; scanner.Scan(); i++ {
-		line := scanner.Text() + "\n"
-		trimmedLine := strings.TrimSpace(line)
-		langKey := c.GetConfigKeysInLang(key)
-
-		// Found the line with old config key and comment it out
-		if !strings.HasPrefix(trimmedLine, comment.LeftMeta) && strings.Contains(trimmedLine, langKey) {
-			buf.WriteString(comment.LeftMeta + trimmedLine + comment.RightMeta + "\n")
-		} else {
-			buf.WriteString(line)
-		}
-
-		// Add a line with the new config value
-		switch c.Lang {
-		case "dotnet":
-			if !strings.HasPrefix(trimmedLine, comment.LeftMeta) && strings.Contains(trimmedLine, "<GoogleAdsApi>") {
-				buf.WriteString(c.configLineStr(key, value))
-			}
-		case "php":
-			if !strings.HasPrefix(trimmedLine, comment.LeftMeta) {
-				if (key == DevToken && strings.Contains(trimmedLine, "[GOOGLE_ADS]")) ||
-					strings.Contains(trimmedLine, "[OAUTH2]") {
-					buf.WriteString(c.configLineStr(key, value))
-				}
-			}
-		case "ruby":
-			if !strings.HasPrefix(trimmedLine, comment.LeftMeta) && strings.Contains(trimmedLine, "Google::Ads::GoogleAds::Config.new") {
-				buf.WriteString(c.configLineStr(key, value))
-			}
-		default:
-			if i == 0 {
-				buf.WriteString(c.configLineStr(key, value))
-			}
+	if c.Format == JSONFormat {
+		doc, err := scanJSON(string(content))
+		if err != nil {
+			c.print(i18n.Sprintf("ERROR: Problem parsing config file: %s", err))
+			return string(content)
 		}
+		return doc.set(c.knownKeys(), key, value)
 	}
 
-	return buf.String()
+	doc := parseConfigDocument(c.Lang, string(content))
+	doc.set(key, c.GetConfigKeysInLang(key), value)
+	return doc.String()
 }
 
-// ReplaceConfig replaces a value in ConfigFile.ConfigKeys and its
-// configuration file.
-func (c *ConfigFile) ReplaceConfig(key, value string) string {
-	c.SetConfigKeys(key, value)
+// lineEnding returns the line ending used by content, which is CRLF for
+// files written on Windows and LF otherwise.
+func lineEnding(content []byte) string {
+	if bytes.Contains(content, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
 
-	// Create a temp file
-	tmpfile, err := ioutil.TempFile("", "googleadsapi_client_lib_config")
+// ReplaceConfig replaces a value in ConfigFile.ConfigKeys and its
+// configuration file. It returns the path of the backup of the original
+// configuration file, which is empty without a file (see FromEnv) and for
+// the secrets kept in the OS credential store. Each replacement is recorded
+// in Changes.
+func (c *ConfigFile) ReplaceConfig(key, value string) (string, error) {
+	old := c.configValue(key)
+	// Without a configuration file, the new value is only used by the rest
+	// of the diagnosis.
+	if c.FromEnv() {
+		c.SetConfigKeys(key, value)
+		c.SetSource(key, i18n.T("entered at the prompt"))
+		recordChange(key, old, i18n.T("not saved (the configuration is read from the environment)"), "")
+		return "", nil
+	}
+	// A secret that is still in plaintext in the file is replaced there, as
+	// the file takes precedence over the store.
+	o := c.fileOccurrence(key)
+	fileValue := o.Value
+	if IsSecretReference(fileValue) {
+		return "", i18n.Errorf("ERROR: %s is read from %s. Update the secret there instead of the config file.", key, fileValue)
+	}
+	// Replacing the code that computes a value would hide where the
+	// value comes from.
+	if o.Env != "" {
+		return "", i18n.Errorf("ERROR: %s is read from the environment variable %s. Set the new value there instead of the config file.", key, o.Env)
+	}
+	if o.Expr != "" {
+		return "", i18n.Errorf("ERROR: %s is set by the code %s on line %d of the config file. Update the code instead.", key, o.Expr, o.Line)
+	}
+	if c.Keyring && Contains(KeyringKeys, key) && fileValue == "" {
+		account, err := c.keyringAccount(key)
+		if err != nil {
+			return "", err
+		}
+		if err := keyring.set(account, value); err != nil {
+			return "", i18n.Errorf("ERROR: Cannot write %s to the OS credential store: %s", key, err)
+		}
+		c.SetConfigKeys(key, value)
+		c.SetSource(key, i18n.T("OS credential store"))
+		recordChange(key, old, i18n.T("OS credential store"), "")
+		return "", nil
+	}
+	// A file that cannot or must not be rewritten is left to the user.
+	if reason := c.ReadOnlyReason(); reason != "" && !c.reader.encrypted() {
+		return "", c.adviseChange(key, value, old, reason)
+	}
+	backup, err := c.replaceConfigFile(key, value)
 	if err != nil {
-		log.Fatalf("ERROR: Problem creating temp file: %s", err)
+		return "", err
 	}
-	defer tmpfile.Close()
+	recordChange(key, old, c.GetFilepath(), backup)
+	return backup, nil
+}
 
-	// Open config file
+// replaceConfigFile replaces the value of key in the configuration file and
+// returns the path of the backup of the original file.
+func (c *ConfigFile) replaceConfigFile(key, value string) (string, error) {
+	// Writing the plaintext would leave the secrets on disk.
+	if c.reader.encrypted() {
+		return "", i18n.Errorf("ERROR: The config file is encrypted, so %s cannot be written to it. "+
+			"Update it with the tool that encrypted it.", key)
+	}
+	// Read config file, keeping track of its encoding
 	configFp := c.GetFilepath()
-	f, err := os.Open(configFp)
+	content, enc, err := c.reader.readTextFile(configFp)
 	if err != nil {
-		log.Fatalf("ERROR: Problem opening config file: %s", err)
+		return "", i18n.Errorf("ERROR: Problem opening config file: %s", err)
 	}
-	defer f.Close()
+	oldKeys := c.ConfigKeys
+	c.SetConfigKeys(key, value)
 
-	// Replace with new config value and write to temp file
-	newConfigStr := c.ReplaceConfigFromReader(key, value, f)
-	if _, err := tmpfile.Write([]byte(newConfigStr)); err != nil {
-		log.Fatalf("ERROR: Cannot write to temp config file (%s): %s",
-			tmpfile.Name(), err)
+	// Replace with new config value in the original encoding, then swap the
+	// new config file for the old one and backup the old file
+	newConfigStr := c.ReplaceConfigFromReader(key, value, strings.NewReader(content))
+	backupFp := backupPath(configFp)
+	c.print(i18n.Sprintf("Backing up config file %s to %s...", configFp, backupFp))
+	c.print(i18n.Sprintf("Creating a new config file %s...", configFp))
+	if err := replaceFile(configFp, backupFp, enc.encode(newConfigStr)); err != nil {
+		c.ConfigKeys = oldKeys
+		return "", err
 	}
 
-	f.Close()
-	tmpfile.Close()
+	// Re-parse the new config file, so a rewrite that corrupted it is undone
+	// instead of leaving a broken config file in place.
+	if err := c.checkReplacement(backupFp, key, value); err != nil {
+		c.ConfigKeys = oldKeys
+		c.print(i18n.Sprintf("Restoring config file %s from %s...", configFp, backupFp))
+		if rErr := restoreFile(configFp, backupFp); rErr != nil {
+			return "", i18n.Errorf("ERROR: The new config file %s is invalid (%s) and cannot be restored from %s: %s",
+				configFp, err, backupFp, rErr)
+		}
+		return "", i18n.Errorf("ERROR: The new config file %s was invalid (%s), so the original file was restored.",
+			configFp, err)
+	}
 
-	// Swap new config file for the old one, and backup the old file
-	backupFp := configFp + "_" + time.Now().Format("2006-01-02_15-04-05")
-	log.Printf("Backing up config file %s to %s...", configFp, backupFp)
-	if err = os.Rename(configFp, backupFp); err != nil {
-		log.Fatalf("ERROR: Cannot rename config file from (%s) to (%s): %s",
-			configFp, backupFp, err)
-	} else {
-		log.Printf("Creating a new config file %s...", configFp)
-		if err = os.Rename(tmpfile.Name(), configFp); err != nil {
-			log.Fatalf("ERROR: Cannot rename config file from (%s) to (%s): %s",
-				tmpfile.Name(), configFp, err)
+	// The key may have been added to the file, which moves the lines below.
+	if occurrences, err := c.fileKeys(configFp); err == nil {
+		c.Sources = ConfigKeys{}
+		c.updateSources(occurrences)
+	}
+
+	// The diff is made from the texts, since the new file is the one that
+	// passed the check.
+	secrets := secretValues(oldKeys, c.ConfigKeys)
+	if d := unifiedDiff(backupFp, configFp, redactValues(splitLines(content), secrets),
+		redactValues(splitLines(newConfigStr), secrets)); d != "" {
+		c.print(i18n.Sprintf("Changes of the config file, with the secrets redacted:\n%s", d))
+	}
+	return backupFp, nil
+}
+
+// checkReplacement parses the config file after key was replaced with value
+// and compares it with its backup. It returns an error if the file cannot be
+// parsed, another key changed, the key does not have the new value or the
+// new file fails a validation that the backup passed.
+func (c *ConfigFile) checkReplacement(backup, key, value string) error {
+	before, err := c.fileKeys(backup)
+	if err != nil {
+		return err
+	}
+	after, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return err
+	}
+
+	known := c.knownKeys()
+	if !reflect.DeepEqual(otherKeys(before, known, key), otherKeys(after, known, key)) {
+		return i18n.Errorf("keys other than %s have changed", key)
+	}
+
+	// The key-value parser only keeps the first token of a value.
+	want := value
+	if c.Lang != "dotnet" && c.Format != JSONFormat {
+		want = findFirstValue(value)
+	}
+	newCfg := ConfigFile{Lang: c.Lang, OAuthType: c.OAuthType, Format: c.Format}
+	newCfg.UpdateConfigKeys(keyValues(after))
+	if structs.New(newCfg.ConfigKeys).Field(key).Value() != want {
+		return i18n.Errorf("%s does not have the new value", key)
+	}
+
+	oldCfg := ConfigFile{Lang: c.Lang, OAuthType: c.OAuthType, Format: c.Format}
+	oldCfg.UpdateConfigKeys(keyValues(before))
+	var oldErrs []string
+	for _, f := range oldCfg.Lint("") {
+		oldErrs = append(oldErrs, f.Message)
+	}
+	// A secret moved to the OS credential store leaves its key empty.
+	for _, f := range newCfg.Lint("") {
+		if c.Keyring && value == "" && f.Key == key {
+			continue
+		}
+		if f.Severity == Error && !Contains(oldErrs, f.Message) {
+			return i18n.Errorf("%s", f.Message)
 		}
 	}
+	return nil
+}
 
-	return backupFp
+// fileOccurrence returns the occurrence of the given field of ConfigKeys
+// that the client library reads in the configuration file, which is empty
+// when the file cannot be read or does not set the field.
+func (c *ConfigFile) fileOccurrence(field string) keyOccurrence {
+	occurrences, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return keyOccurrence{}
+	}
+	known := c.knownKeys()
+	var occurrence keyOccurrence
+	for _, o := range occurrences {
+		if known[o.Key] == field && !o.Misplaced {
+			occurrence = o
+		}
+	}
+	return occurrence
 }
 
-// configLineStr returns a configuration file line formatted for the
-// specified language.
-func (c *ConfigFile) configLineStr(key, value string) (line string) {
-	separator := Languages[c.Lang].Separator
-	field := c.GetConfigKeysInLang(key)
+// otherKeys returns the keys and values of occurrences that are not the given
+// field of ConfigKeys, in the order of the file. known maps the keys to the
+// fields.
+func otherKeys(occurrences []keyOccurrence, known map[string]string, field string) []string {
+	var kvs []string
+	for _, o := range occurrences {
+		if known[o.Key] != field {
+			kvs = append(kvs, o.Key+"="+o.Value)
+		}
+	}
+	return kvs
+}
 
-	switch strings.ToLower(c.Lang) {
-	case "java":
-		line = field + separator + value
-	case "php":
-		line = field + separator + " \"" + value + "\""
-	case "ruby":
-		line = field + separator + " \"" + value + "\""
-	case "python":
-		line = field + separator + value
-	case "dotnet":
-		line = "<add key=\"" + field + "\" value=\"" + value + "\"/>"
+// keyValues returns the value of each key in occurrences. The last
+// occurrence of a key wins, like in the client libraries, and misplaced keys
+// are ignored.
+func keyValues(occurrences []keyOccurrence) map[string]string {
+	keyValue := make(map[string]string)
+	for _, o := range occurrences {
+		if !o.Misplaced {
+			keyValue[o.Key] = o.Value
+		}
 	}
-	return line + "\n"
+	return keyValue
 }
 
 // ListLanguages returns a slice of supported languages.
@@ -400,116 +638,289 @@ func Contains(s []string, str string) bool {
 func parseKeyValueLine(c ConfigFile, line string) (string, string, error) {
 	separator := Languages[c.Lang].Separator
 	if idx := strings.Index(line, separator); idx >= 0 {
-		if key := strings.TrimSpace(line[:idx]); len(key) > 0 {
+		if key := envKey(c.Lang, line[:idx]); len(key) > 0 {
 			return key, findFirstValue(line[idx+1:]), nil
 		}
 	}
-	return "", "", fmt.Errorf("Cannot parse key-value pair from this line: %s", line)
+	return "", "", i18n.Errorf("Cannot parse key-value pair from this line: %s", line)
+}
+
+// envKey returns the key of a key-value line from the text before the
+// separator. In a .env file of Node.js, the key may be exported like in a
+// shell script.
+func envKey(lang, s string) string {
+	key := strings.TrimSpace(s)
+	if lang == "nodejs" && strings.HasPrefix(key, "export ") {
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+	}
+	return key
 }
 
 // findFirstValue returns the first value that contains alphanumeric
-// characters potentially with some special characters.
+// characters potentially with some special characters. An empty quoted
+// string is an empty value.
 func findFirstValue(k string) string {
 	matches := quotedStrRegex.FindAllString(k, -1)
 	if len(matches) > 0 {
 		return matches[0]
 	}
-	return strings.TrimSpace(k)
+	v := strings.TrimSpace(k)
+	if v == `""` || v == "''" {
+		return ""
+	}
+	return v
+}
+
+// maxXMLDepth is how deep the elements of an App.config file can be nested.
+// The keys are three levels deep.
+const maxXMLDepth = 100
+
+// ParseConfigFile parses the configuration file of the client library in
+// the given language, with the zero Reader.
+func ParseConfigFile(lang, filepath, oauthType string) (ConfigFile, error) {
+	return (*Reader)(nil).ParseConfigFile(lang, filepath, oauthType)
+}
+
+// ParseConfigFile parses the configuration file of the client library in
+// the given language.
+func (r *Reader) ParseConfigFile(lang, filepath, oauthType string) (ConfigFile, error) {
+	if lang == "dotnet" && strings.HasSuffix(strings.ToLower(filepath), ".json") {
+		return r.ParseJSONFile(lang, filepath, oauthType)
+	}
+	if lang == "dotnet" {
+		return r.ParseXMLFile(filepath, oauthType)
+	}
+	return r.ParseKeyValueFile(lang, filepath, oauthType)
+}
+
+// ParseKeyValueFile reads a configuration file with keys and values separated
+// by a language specific separator with the zero Reader.
+func ParseKeyValueFile(lang, filepath, oauthType string) (ConfigFile, error) {
+	return (*Reader)(nil).ParseKeyValueFile(lang, filepath, oauthType)
 }
 
 // ParseKeyValueFile reads a configuration file with keys and values separated
 // by a language specific separator, and returns a ConfigFile.
-func ParseKeyValueFile(lang, filepath, oauthType string) (c ConfigFile, err error) {
-	keyValue := make(map[string]string, 0)
-	c = GetConfigFile(lang, filepath)
+func (r *Reader) ParseKeyValueFile(lang, filepath, oauthType string) (c ConfigFile, err error) {
+	if c, err = GetConfigFile(lang, filepath); err != nil {
+		return c, err
+	}
 	c.OAuthType = oauthType
-	separator := Languages[c.Lang].Separator
-	comment := Languages[c.Lang].Comment
+	c.reader = r
+	content, _, err := r.readTextFile(filepath)
+	if err != nil {
+		return c, err
+	}
+	c.keepPlaintext(content)
 
-	f, err := os.Open(filepath)
+	occurrences, lineErrs, err := c.scanKeyValues(content)
+	var msgs []string
+	for _, lineErr := range lineErrs {
+		msgs = append(msgs, lineErr.Error())
+	}
+	c.lineErrs = strings.Join(msgs, "\n")
 	if err != nil {
 		return c, err
 	}
-	defer f.Close()
+	c.UpdateConfigKeys(keyValues(occurrences))
+	c.updateSources(occurrences)
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+	// The errors of the key file and of an inline key are reported by Lint.
+	if c.PrivateKeyPath != "" {
+		c.parseServiceAccJSON()
+	} else {
+		c.updateInlineKey(occurrences)
+	}
+
+	return c, nil
+}
+
+// keyOccurrence is a key set on a line of a configuration file.
+type keyOccurrence struct {
+	Key   string
+	Value string
+	Line  int
+	// Section is the ini section of the key in a PHP configuration file.
+	Section string
+	// Misplaced is true when the client library does not read the key
+	// where it is set, e.g. developerToken in the [OAUTH2] section.
+	Misplaced bool
+	// Env is the environment variable that a Ruby configuration file reads
+	// the value from, and Expr the Ruby expression of a value that cannot be
+	// read without running the code.
+	Env  string
+	Expr string
+}
+
+// scanKeyValues returns the keys set in the content of a key-value
+// configuration file, in the order of the file. The lines that cannot be
+// parsed are returned as lineErrs.
+func (c *ConfigFile) scanKeyValues(content string) (occurrences []keyOccurrence, lineErrs []error, err error) {
+	if c.Lang == "ruby" {
+		occurrences, lineErrs = scanRubyKeys(content)
+		return occurrences, lineErrs, nil
+	}
+	separator := Languages[c.Lang].Separator
+	comment := Languages[c.Lang].Comment
+	known := c.knownKeys()
+
+	var section string
+	// Splitting the content has no limit on the length of a line, unlike a
+	// Scanner, so long values such as inlined keys are never truncated. A
+	// value can also continue on the next lines.
+	lines := strings.SplitAfter(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(lines[i])
 
 		// Skips comments
 		if strings.HasPrefix(line, comment.LeftMeta) {
 			continue
 		}
 
+		if c.Lang == "php" && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
 		if strings.Contains(line, separator) {
-			if k, v, err := parseKeyValueLine(c, line); err != nil {
-				log.Print(err)
+			if k, v, err := parseKeyValueLine(*c, line); err != nil {
+				lineErrs = append(lineErrs, err)
 			} else {
-				keyValue[k] = v
+				o := keyOccurrence{Key: k, Value: v, Line: n, Section: section}
+				if field, ok := known[k]; ok && c.Lang == "php" {
+					o.Misplaced = configScopeName(c.Lang, field) != section
+				}
+				if value, last, ok := c.multiLineValue(lines, i); ok {
+					o.Value, i = value, last
+				} else if c.isInlineKey(k) {
+					o.Value = c.lineValue(line)
+				}
+				occurrences = append(occurrences, o)
 			}
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return c, err
+	return occurrences, lineErrs, nil
+}
+
+// scanDotNetXML returns the settings of the client library in the content of
+// a .NET configuration file, in the order of the file.
+func scanDotNetXML(content string) ([]keyOccurrence, error) {
+	// The content is already decoded to UTF-8, so the encoding named in the
+	// XML declaration (e.g. utf-16) no longer applies.
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.CharsetReader = func(charset string, r io.Reader) (io.Reader, error) {
+		return r, nil
 	}
 
-	c.UpdateConfigKeys(keyValue)
+	var occurrences []keyOccurrence
+	var path []string
+	sawRoot := false
+	for {
+		offset := decoder.InputOffset()
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	if c.PrivateKeyPath != "" {
-		if err := c.parseServiceAccJSON(); err != nil {
-			return c, err
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !sawRoot && t.Name.Local != "configuration" {
+				return nil, i18n.Errorf("expected element type <configuration> but have <%s>", t.Name.Local)
+			}
+			sawRoot = true
+			if len(path) == maxXMLDepth {
+				return nil, i18n.Errorf("the XML elements are nested deeper than %d levels", maxXMLDepth)
+			}
+			path = append(path, t.Name.Local)
+			if strings.Join(path, ">") != "configuration>GoogleAdsApi>add" {
+				continue
+			}
+			o := keyOccurrence{Line: 1 + strings.Count(content[:offset], "\n")}
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "key":
+					o.Key = attr.Value
+				case "value":
+					o.Value = attr.Value
+				}
+			}
+			occurrences = append(occurrences, o)
+		case xml.EndElement:
+			path = path[:len(path)-1]
 		}
 	}
+	if !sawRoot {
+		return nil, io.EOF
+	}
+	return occurrences, nil
+}
 
-	return c, nil
+// ParseXMLFile parses the App.config file in filepath with the zero Reader.
+func ParseXMLFile(filepath, oauthType string) (ConfigFile, error) {
+	return (*Reader)(nil).ParseXMLFile(filepath, oauthType)
 }
 
 // ParseXMLFile parses the file content given in filepath and returns
 // a ConfigFile struct with the given attributes in the file.
-func ParseXMLFile(filepath, oauthType string) (c ConfigFile, err error) {
-	var keyValue = make(map[string]string)
-	c = GetConfigFile("dotnet", filepath)
-	c.OAuthType = oauthType
-
-	type Property struct {
-		Key   string `xml:"key,attr"`
-		Value string `xml:"value,attr"`
-	}
-
-	type DotNetXML struct {
-		XMLName    xml.Name   `xml:"configuration"`
-		Properties []Property `xml:"GoogleAdsApi>add"`
+func (r *Reader) ParseXMLFile(filepath, oauthType string) (c ConfigFile, err error) {
+	if c, err = GetConfigFile("dotnet", filepath); err != nil {
+		return c, err
 	}
+	c.OAuthType = oauthType
+	c.reader = r
 
-	input, err := ioutil.ReadFile(filepath)
+	input, _, err := r.readTextFile(filepath)
 	if err != nil {
 		return c, err
 	}
+	c.keepPlaintext(input)
 
-	options := DotNetXML{}
-	err = xml.Unmarshal(input, &options)
+	occurrences, err := scanDotNetXML(input)
 	if err != nil {
 		return c, err
 	}
 
-	for _, prop := range options.Properties {
-		keyValue[prop.Key] = prop.Value
-	}
-
-	c.UpdateConfigKeys(keyValue)
+	c.UpdateConfigKeys(keyValues(occurrences))
+	c.updateSources(occurrences)
 
+	// The errors of the key file are reported by Lint.
 	if c.PrivateKeyPath != "" {
-		if err := c.parseServiceAccJSON(); err != nil {
-			return c, err
-		}
+		c.parseServiceAccJSON()
 	}
 
 	return c, nil
 }
 
+// IsExternalAccount returns true when the JSON file of the service account
+// holds workload identity federation credentials instead of a private key.
+func (c *ConfigFile) IsExternalAccount() bool {
+	return c.OAuthType == ServiceAccount && c.ServiceAccountInfo.Type == ExternalAccount
+}
+
+// requiredKeys returns the keys that the OAuth type of c requires. Federated
+// credentials cannot use domain-wide delegation, so they need no delegated
+// account, and a private key inlined in the configuration file replaces the
+// JSON key file.
+func (c *ConfigFile) requiredKeys() []string {
+	var keys []string
+	for _, k := range RequiredKeys[c.OAuthType] {
+		switch {
+		case k == DelegatedAccount && c.IsExternalAccount():
+		case k == PrivateKeyPath && c.HasInlineKey():
+		default:
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func (c *ConfigFile) parseServiceAccJSON() error {
 	if c.PrivateKeyPath == "" {
-		return fmt.Errorf("PrivateKeyPath in the config file is empty")
+		return i18n.Errorf("PrivateKeyPath in the config file is empty")
 	}
 
 	input, err := ioutil.ReadFile(c.PrivateKeyPath)
@@ -534,7 +945,7 @@ func IsPII(s string) bool {
 // GetConfigFile returns a ConfigFile containing config filepath and filename.
 // When overridePath is an empty string, the function will retrieve the filepath and
 // filename from the default location in the file system.
-func GetConfigFile(lang, overridePath string) ConfigFile {
+func GetConfigFile(lang, overridePath string) (ConfigFile, error) {
 	if overridePath == "" {
 		return GetDefaultConfigFile(lang)
 	}
@@ -543,114 +954,103 @@ func GetConfigFile(lang, overridePath string) ConfigFile {
 	return ConfigFile{
 		Filepath: filepath.Dir(overridePath),
 		Filename: filepath.Base(overridePath),
-		Lang:     lang}
+		Lang:     lang}, nil
 }
 
-// GetDefaultConfigFile returns the default config path of Google Ads API client
-// library.
-func GetDefaultConfigFile(lang string) ConfigFile {
+// currentUser returns the user running the doctor.
+var currentUser = user.Current
+
+// GetDefaultConfigFile returns the config path of Google Ads API client
+// library found in the paths of ConfigCandidates.
+func GetDefaultConfigFile(lang string) (ConfigFile, error) {
 	var cfg ConfigFile
 
-	usr, err := user.Current()
+	chosen, _, err := ChosenConfigCandidate(lang)
 	if err != nil {
-		log.Fatalf("Error finding user's home directory: %s", err)
+		return cfg, err
 	}
 
 	if _, ok := Languages[lang]; ok {
-		cfg.Filepath = usr.HomeDir
-		cfg.Filename = Languages[lang].Cfg.Filename
+		cfg.Filepath = filepath.Dir(chosen.Path)
+		cfg.Filename = filepath.Base(chosen.Path)
 		cfg.Lang = lang
 	}
 
-	return cfg
+	return cfg, nil
 }
 
 // Print prints out the keys and values in ConfigFile.ConfigKeys.
 func (c *ConfigFile) Print(hidePII bool) {
-	log.Printf("Config keys and values:")
-	print(c.ConfigKeys, hidePII)
+	for _, line := range c.Lines(hidePII) {
+		c.print(line)
+	}
+}
+
+// Lines returns the keys and values in ConfigFile.ConfigKeys as printed by
+// Print, one line per key.
+func (c *ConfigFile) Lines(hidePII bool) []string {
+	lines := []string{i18n.T("Config keys and values:")}
+	lines = append(lines, keyValueLines(c.ConfigKeys, structs.Map(c.Sources), hidePII)...)
 
 	if c.OAuthType == ServiceAccount {
-		log.Printf("Service account JSON keys and values:")
-		print(c.ServiceAccountInfo, hidePII)
+		lines = append(lines, i18n.T("Service account JSON keys and values:"))
+		lines = append(lines, keyValueLines(c.ServiceAccountInfo, nil, hidePII)...)
 	}
+	return lines
 }
 
-func print(mapping interface{}, hidePII bool) {
+// keyValueLines returns a line for each field of mapping. The sources of
+// the values, if known, are added to the lines of the set values.
+func keyValueLines(mapping interface{}, sources map[string]interface{}, hidePII bool) []string {
+	var lines []string
 	keys := reflect.TypeOf(mapping)
 	vals := reflect.ValueOf(mapping)
 	for i := 0; i < keys.NumField(); i++ {
 		k := keys.Field(i).Name
 		v := vals.Field(i)
+		source, _ := sources[k].(string)
 		if hidePII && IsPII(k) && v.String() != "" {
-			v = reflect.ValueOf("******************* (hidden)")
+			v = reflect.ValueOf(i18n.T("******************* (hidden)"))
 		} else if v.String() == "" {
-			v = reflect.ValueOf("<empty>")
+			v = reflect.ValueOf(i18n.T("<empty>"))
+			source = ""
+		}
+		line := fmt.Sprintf("\t%s = %s", k, v)
+		if source != "" {
+			line += i18n.Sprintf(" (from %s)", source)
 		}
-		log.Printf("\t%s = %s", k, v)
+		lines = append(lines, line)
 	}
+	return lines
 }
 
 // Validate returns true when all the values in ConfigFile.ConfigKeys meet
 // the requirements. When it returns false, the returned error includes
-// each reason why the attribute fails validation.
+// each reason why the attribute fails validation. Use Lint to also get the
+// warnings about suspicious values.
 func (c *ConfigFile) Validate() (bool, error) {
-	valid := true
 	var errMsg string
-	var err error
-
-	if !devTokenRegex.MatchString(c.DevToken) {
-		valid = false
-		errMsg += fmt.Sprintf("Dev token is invalid. Value: %s\n", c.DevToken)
-	}
-
-	if c.OAuthType != ServiceAccount && !strings.HasSuffix(c.ConfigKeys.ClientID, "apps.googleusercontent.com") {
-		valid = false
-		errMsg += fmt.Sprintf("ClientID does not end with apps.googleusercontent.com. Value: %s\n", c.ConfigKeys.ClientID)
-	}
-
-	if strings.Contains(c.LoginCustomerID, "-") {
-		valid = false
-		errMsg += fmt.Sprintf("LoginCustomerID cannot have dashes. Value: %s\n", c.LoginCustomerID)
-	}
-
-	keys := reflect.TypeOf(c.ConfigKeys)
-	vals := reflect.ValueOf(c.ConfigKeys)
-	for i := 0; i < vals.NumField(); i++ {
-		k := keys.Field(i).Name
-		v := vals.Field(i)
-
-		if Contains(RequiredKeys[c.OAuthType], k) && v.String() == "" {
-			valid = false
-			errMsg += fmt.Sprintf("%s is empty.\n", k)
-		}
-
-		if strings.Contains(v.String(), "INSERT") {
-			valid = false
-			errMsg += fmt.Sprintf("%s needs to be updated. Value: %s\n", k, v.String())
+	for _, f := range c.Lint("") {
+		if f.Severity == Error {
+			errMsg += f.Message + "\n"
 		}
 	}
-
 	if errMsg != "" {
-		err = fmt.Errorf("%s", errMsg)
+		return false, fmt.Errorf("%s", errMsg)
 	}
-
-	return valid, err
-}
-
-// MinGoVersion tests for the minimum version of Go required. The current minimum
-// version supported is 1.11.
-func MinGoVersion() error {
-	return checkGoVersion(runtime.Version())
+	return true, nil
 }
 
+// checkGoVersion returns an error when the Go version v is older than
+// minGoVersion.
 func checkGoVersion(v string) error {
-	majorMin := 1
-	minorMin := 11
+	oldest := strings.Split(sanitizeVersion(minGoVersion), ".")
+	majorMin, _ := parseInt(oldest[0])
+	minorMin, _ := parseInt(oldest[1])
 
 	parts := strings.Split(sanitizeVersion(v), ".")
 	if len(parts) < 2 {
-		return fmt.Errorf("the given version is too short: %s", v)
+		return i18n.Errorf("the given version is too short: %s", v)
 	}
 
 	major, err := parseInt(parts[0])
@@ -663,8 +1063,8 @@ func checkGoVersion(v string) error {
 		return err
 	}
 
-	if major <= majorMin && minor < minorMin {
-		return fmt.Errorf("minimum required Go version is %d.%d: you are running %s", major, minor, v)
+	if major < majorMin || major == majorMin && minor < minorMin {
+		return i18n.Errorf("minimum required Go version is %d.%d: you are running %s", majorMin, minorMin, v)
 	}
 	return nil
 }
@@ -678,7 +1078,7 @@ func sanitizeVersion(v string) string {
 func parseInt(token string) (int, error) {
 	num, err := strconv.ParseInt(token, 10, 32)
 	if err != nil {
-		return -1, fmt.Errorf("could not parse version (%s): %s", token, err)
+		return -1, i18n.Errorf("could not parse version (%s): %s", token, err)
 	}
 	return int(num), nil
 }
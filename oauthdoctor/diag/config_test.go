@@ -199,14 +199,30 @@ func TestGetConfigFile(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		got := GetConfigFile(test.lang, test.filepath)
+		got, err := GetConfigFile(test.lang, test.filepath)
+		if err != nil {
+			t.Errorf("%s\nGetConfigFile() returned error: %s", test.desc, err)
+		}
 
 		if got != test.want {
-			t.Errorf("%s\ngot: %s\nwant: %s", test.desc, got, test.want)
+			t.Errorf("%s\ngot: %v\nwant: %v", test.desc, got, test.want)
 		}
 	}
 }
 
+func TestGetDefaultConfigFileNoUser(t *testing.T) {
+	origFn := currentUser
+	currentUser = func() (*user.User, error) {
+		return nil, fmt.Errorf("user: unknown userid 1000")
+	}
+	defer func() { currentUser = origFn }()
+
+	_, err := GetDefaultConfigFile("python")
+	if !strings.Contains(errstring(err), "unknown userid") {
+		t.Errorf("GetDefaultConfigFile() error: %s, want: unknown userid", errstring(err))
+	}
+}
+
 func TestPrint(t *testing.T) {
 	tests := []struct {
 		desc    string
@@ -266,6 +282,19 @@ func TestPrint(t *testing.T) {
 			hidePII: true,
 			want:    "******",
 		},
+		{
+			desc: "Print the source of a value",
+			cfg: ConfigFile{
+				ConfigKeys: ConfigKeys{
+					ClientID: "someClientID",
+				},
+				Sources: ConfigKeys{
+					ClientID: "google-ads.yaml:3",
+				},
+			},
+			hidePII: true,
+			want:    "ClientID = ******************* (hidden) (from google-ads.yaml:3)",
+		},
 	}
 
 	for _, test := range tests {
@@ -302,7 +331,10 @@ func TestReplaceConfig(t *testing.T) {
 		backup: "diag/testdata/python_config2_" + now,
 	}
 
-	backup := test.cfg.ReplaceConfig(DevToken, "randomToken")
+	backup, err := test.cfg.ReplaceConfig(DevToken, "randomToken")
+	if err != nil {
+		t.Fatalf("%s\nReplaceConfig() returned error: %s", test.desc, err)
+	}
 	config := test.cfg.GetFilepath()
 
 	defer func() {
@@ -332,12 +364,12 @@ func TestReplaceConfigFromReader(t *testing.T) {
 	}
 
 	tests := []struct {
-		desc      string
-		key       string
-		val       string
-		cfg       ConfigFile
-		commented string
-		added     string
+		desc    string
+		key     string
+		val     string
+		cfg     ConfigFile
+		want    string
+		removed string
 	}{
 		{
 			desc: "(Python) Replace refresh token correctly",
@@ -348,8 +380,8 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "python_config",
 			},
-			commented: "#refresh_token: 1/PG1",
-			added:     "\nrefresh_token:new_refresh_token",
+			want:    "\nrefresh_token: new_refresh_token\n",
+			removed: "1/PG1Ap6P-Good_Refresh_Token",
 		},
 		{
 			desc: "(Ruby) Replace client ID correctly",
@@ -360,8 +392,8 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "ruby_config",
 			},
-			commented: "#c.client_id = 'GoodClientID'",
-			added:     "\nc.client_id= \"new_client_id\"",
+			want:    "\n  c.client_id = 'new_client_id' #This comment is needed too\n",
+			removed: "GoodClientID",
 		},
 		{
 			desc: "(.NET) Replace dev token correctly",
@@ -372,8 +404,8 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "dotnet_config1",
 			},
-			commented: "<!--<add key=\"DeveloperToken\" value=\"GoodDevToken\"/>-->",
-			added:     "\n<add key=\"DeveloperToken\" value=\"new_dev_token\"/>",
+			want:    "\n    <add key=\"DeveloperToken\" value=\"new_dev_token\"/>\n",
+			removed: "GoodDevToken",
 		},
 		{
 			desc: "(.NET) Add a dev token without replacing",
@@ -384,8 +416,7 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "dotnet_config3",
 			},
-			commented: "",
-			added:     "\n<add key=\"DeveloperToken\" value=\"new_dev_token\"/>",
+			want: "  <GoogleAdsApi>\n    <add key=\"DeveloperToken\" value=\"new_dev_token\"/>\n    <!-- <add key=\"DeveloperToken\" value=\"GoodDevToken\"/>\n",
 		},
 		{
 			desc: "(PHP) Replace client secret correctly",
@@ -396,8 +427,8 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "php_config",
 			},
-			commented: ";clientSecret = \"GoodClientSecret\"",
-			added:     "\nclientSecret= \"new_client_secret\"",
+			want:    "\nclientSecret = \"new_client_secret\"\n",
+			removed: "GoodClientSecret",
 		},
 		{
 			desc: "(Java) Replace refresh token correctly",
@@ -408,8 +439,8 @@ func TestReplaceConfigFromReader(t *testing.T) {
 				Filepath: filepath.Join(dir, "testdata"),
 				Filename: "java_config",
 			},
-			commented: "#api.googleads.refreshToken=",
-			added:     "\napi.googleads.refreshToken=new_refresh_token",
+			want:    "\napi.googleads.refreshToken=new_refresh_token\n",
+			removed: "GoodRefreshToken",
 		},
 	}
 
@@ -422,12 +453,12 @@ func TestReplaceConfigFromReader(t *testing.T) {
 
 		got := test.cfg.ReplaceConfigFromReader(test.key, test.val, f)
 
-		if !strings.Contains(got, test.commented) {
-			t.Errorf("%s\ngot: %s\nMissing commented: %s", test.desc, got, test.commented)
+		if !strings.Contains(got, test.want) {
+			t.Errorf("%s\ngot: %s\nMissing: %s", test.desc, got, test.want)
 		}
 
-		if !strings.Contains(got, test.added) {
-			t.Errorf("%s\ngot: %s\nMissing added: %s", test.desc, got, test.added)
+		if test.removed != "" && strings.Contains(got, test.removed) {
+			t.Errorf("%s\ngot: %s\nStill contains: %s", test.desc, got, test.removed)
 		}
 	}
 }
@@ -438,6 +469,11 @@ func TestParseKeyValueFile(t *testing.T) {
 		t.Errorf("Error getting current dir: %s", err)
 	}
 
+	// at returns the source of a value set on a line of a testdata file.
+	at := func(name string, line int) string {
+		return fmt.Sprintf("%s:%d", filepath.Join(dir, "testdata", name), line)
+	}
+
 	tests := []struct {
 		desc       string
 		configPath string
@@ -460,6 +496,13 @@ func TestParseKeyValueFile(t *testing.T) {
 					RefreshToken:     "1/PG1Ap6P-Good_Refresh_Token",
 					DelegatedAccount: "example@some.web.site.com",
 				},
+				Sources: ConfigKeys{
+					ClientID:         at("python_config", 2),
+					ClientSecret:     at("python_config", 3),
+					DevToken:         at("python_config", 1),
+					RefreshToken:     at("python_config", 4),
+					DelegatedAccount: at("python_config", 5),
+				},
 			},
 		},
 		{
@@ -474,6 +517,10 @@ func TestParseKeyValueFile(t *testing.T) {
 				ConfigKeys: ConfigKeys{
 					ClientID: "GoodClientID",
 				},
+				Sources: ConfigKeys{
+					ClientID: at("ruby_config", 4),
+				},
+				lineErrs: "Cannot read the value of c.log_target on line 7 without running the code: STDOUT",
 			},
 		},
 		{
@@ -490,6 +537,14 @@ func TestParseKeyValueFile(t *testing.T) {
 					ClientSecret: "GoodClientSecret",
 					DevToken:     "GoodDevToken",
 					RefreshToken: "GoodRefreshToken",
+					Endpoint:     "https://googleads.googleapis.com:443/",
+				},
+				Sources: ConfigKeys{
+					ClientID:     at("php_config", 6),
+					ClientSecret: at("php_config", 7),
+					DevToken:     at("php_config", 3),
+					RefreshToken: at("php_config", 8),
+					Endpoint:     at("php_config", 14),
 				},
 			},
 		},
@@ -508,6 +563,58 @@ func TestParseKeyValueFile(t *testing.T) {
 					DevToken:     "GoodDevToken",
 					RefreshToken: "GoodRefreshToken",
 				},
+				Sources: ConfigKeys{
+					ClientID:     at("java_config", 3),
+					ClientSecret: at("java_config", 4),
+					DevToken:     at("java_config", 5),
+					RefreshToken: at("java_config", 2),
+				},
+			},
+		},
+		{
+			desc:       "(PHP) Ignores the keys in the wrong section",
+			configPath: filepath.Join(dir, "testdata", "php_config_misplaced"),
+			lang:       "php",
+			want: ConfigFile{
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "php_config_misplaced",
+				Lang:      "php",
+				OAuthType: InstalledApp,
+				ConfigKeys: ConfigKeys{
+					ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+					ClientSecret: "GoodClientSecret",
+					RefreshToken: "1//GoodRefreshToken",
+				},
+				Sources: ConfigKeys{
+					ClientID:     at("php_config_misplaced", 6),
+					ClientSecret: at("php_config_misplaced", 7),
+					RefreshToken: at("php_config_misplaced", 8),
+				},
+			},
+		},
+		{
+			desc:       "(Node.js) Parses quoted and exported variables",
+			configPath: filepath.Join(dir, "testdata", "nodejs_config"),
+			lang:       "nodejs",
+			want: ConfigFile{
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "nodejs_config",
+				Lang:      "nodejs",
+				OAuthType: InstalledApp,
+				ConfigKeys: ConfigKeys{
+					ClientID:        "0123456789-GoodClientID.apps.googleusercontent.com",
+					ClientSecret:    "GoodClientSecret",
+					DevToken:        "GoodDevToken",
+					RefreshToken:    "1/PG1Ap6P-Good_Refresh_Token",
+					LoginCustomerID: "1234567890",
+				},
+				Sources: ConfigKeys{
+					ClientID:        at("nodejs_config", 3),
+					ClientSecret:    at("nodejs_config", 4),
+					DevToken:        at("nodejs_config", 2),
+					RefreshToken:    at("nodejs_config", 5),
+					LoginCustomerID: at("nodejs_config", 6),
+				},
 			},
 		},
 	}
@@ -532,6 +639,11 @@ func TestParseXMLFile(t *testing.T) {
 		t.Errorf("Error getting current dir: %s", err)
 	}
 
+	// at returns the source of a value set on a line of a testdata file.
+	at := func(name string, line int) string {
+		return fmt.Sprintf("%s:%d", filepath.Join(dir, "testdata", name), line)
+	}
+
 	tests := []struct {
 		desc       string
 		configPath string
@@ -556,6 +668,41 @@ func TestParseXMLFile(t *testing.T) {
 					PrivateKeyPath:   "GoodPath",
 					DelegatedAccount: "example@some.website.com",
 				},
+				Sources: ConfigKeys{
+					ClientID:         at("dotnet_config1", 11),
+					ClientSecret:     at("dotnet_config1", 12),
+					DevToken:         at("dotnet_config1", 9),
+					RefreshToken:     at("dotnet_config1", 14),
+					PrivateKeyPath:   at("dotnet_config1", 15),
+					DelegatedAccount: at("dotnet_config1", 16),
+				},
+			},
+		},
+		{
+			desc:       "(.NET) UTF-16 with BOM and CRLF parses correctly",
+			configPath: filepath.Join(dir, "testdata", "dotnet_config_utf16"),
+			lang:       "dotnet",
+			want: ConfigFile{
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "dotnet_config_utf16",
+				Lang:      "dotnet",
+				OAuthType: InstalledApp,
+				ConfigKeys: ConfigKeys{
+					ClientID:         "0123456789-GoodClientID.apps.googleusercontent.com",
+					ClientSecret:     "GoodClientSecret",
+					DevToken:         "GoodDevToken",
+					RefreshToken:     "1/PG1Ap6P-Good_Refresh_Token",
+					PrivateKeyPath:   "GoodPath",
+					DelegatedAccount: "example@some.website.com",
+				},
+				Sources: ConfigKeys{
+					ClientID:         at("dotnet_config_utf16", 11),
+					ClientSecret:     at("dotnet_config_utf16", 12),
+					DevToken:         at("dotnet_config_utf16", 9),
+					RefreshToken:     at("dotnet_config_utf16", 14),
+					PrivateKeyPath:   at("dotnet_config_utf16", 15),
+					DelegatedAccount: at("dotnet_config_utf16", 16),
+				},
 			},
 		},
 		{
@@ -586,6 +733,112 @@ func TestParseXMLFile(t *testing.T) {
 	}
 }
 
+func TestParsePathologicalFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathological")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc    string
+		lang    string
+		content string
+		errstr  string
+	}{
+		{
+			desc:    "Binary key-value file",
+			lang:    "java",
+			content: "api.googleads.developerToken=\x00\x01\x02",
+			errstr:  "is a binary file",
+		},
+		{
+			desc:    "Binary XML file",
+			lang:    "dotnet",
+			content: "<configuration>\x00</configuration>",
+			errstr:  "is a binary file",
+		},
+		{
+			desc:    "Deeply nested XML",
+			lang:    "dotnet",
+			content: "<configuration>" + strings.Repeat("<a>", 1000) + strings.Repeat("</a>", 1000) + "</configuration>",
+			errstr:  "nested deeper than 100 levels",
+		},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("config%d", i))
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ParseConfigFile(tt.lang, path, InstalledApp)
+		if !strings.Contains(errstring(err), tt.errstr) {
+			t.Errorf("[%s] got error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+	}
+}
+
+func TestParseLargeValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A value of 3 MiB on a single line, far beyond the 64 KiB lines of a
+	// bufio.Scanner.
+	value := strings.Repeat("0123456789abcdef", 3<<16)
+	tests := []struct {
+		lang    string
+		format  string
+		content string
+	}{
+		{lang: "java", content: "api.googleads.developerToken=GoodDevToken\napi.googleads.refreshToken=" + value + "\n"},
+		{lang: "nodejs", content: "GOOGLE_ADS_REFRESH_TOKEN=" + value + "\nGOOGLE_ADS_DEVELOPER_TOKEN=GoodDevToken"},
+		{lang: "php", content: "[GOOGLE_ADS]\ndeveloperToken = \"GoodDevToken\"\n[OAUTH2]\nrefreshToken = \"" + value + "\"\n"},
+		{lang: "python", content: "developer_token: GoodDevToken\nrefresh_token: " + value + "\n"},
+		{lang: "ruby", content: "Google::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = 'GoodDevToken'\n" +
+			"  c.refresh_token = '" + value + "'\nend\n"},
+		{lang: "dotnet", content: "<configuration><GoogleAdsApi>\n<add key=\"DeveloperToken\" value=\"GoodDevToken\"/>\n" +
+			"<add key=\"OAuth2RefreshToken\" value=\"" + value + "\"/>\n</GoogleAdsApi></configuration>\n"},
+		{lang: "python", format: JSONFormat, content: "{\"developer_token\": \"GoodDevToken\", \"refresh_token\": \"" + value + "\"}"},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("config%d", i))
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var c ConfigFile
+		if tt.format == JSONFormat {
+			c, err = ParseJSONFile(tt.lang, path, InstalledApp)
+		} else {
+			c, err = ParseConfigFile(tt.lang, path, InstalledApp)
+		}
+		if err != nil {
+			t.Errorf("[%s %s] got error: %s", tt.lang, tt.format, err)
+			continue
+		}
+		if c.RefreshToken != value || c.DevToken != "GoodDevToken" {
+			t.Errorf("[%s %s] got a refresh token of %d bytes and developer token %q, want %d bytes and GoodDevToken",
+				tt.lang, tt.format, len(c.RefreshToken), c.DevToken, len(value))
+		}
+
+		// Rewriting another key keeps the large value.
+		if _, err := c.ReplaceConfig(DevToken, "NewDevToken"); err != nil {
+			t.Errorf("[%s %s] ReplaceConfig() error: %s", tt.lang, tt.format, err)
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), value) || !strings.Contains(string(content), "NewDevToken") {
+			t.Errorf("[%s %s] the rewritten file lost the large value or the new developer token", tt.lang, tt.format)
+		}
+	}
+}
+
 func TestParseServiceAccJSON(t *testing.T) {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -651,28 +904,33 @@ func TestCheckGoVersion(t *testing.T) {
 		want    error
 	}{
 		{
-			desc:    "Version go1.11 is supported",
-			version: "go1.11",
+			desc:    "Version go1.18 is supported",
+			version: "go1.18",
 			want:    nil,
 		},
+		{
+			desc:    "Version go1.17 is not supported",
+			version: "go1.17",
+			want:    fmt.Errorf("minimum required Go version is 1.18"),
+		},
 		{
 			desc:    "Version go2.0 is supported",
 			version: "go2.0",
 			want:    nil,
 		},
 		{
-			desc:    "Version go1.12.9 is supported",
-			version: "go1.12.9",
+			desc:    "Version go1.18.9 is supported",
+			version: "go1.18.9",
 			want:    nil,
 		},
 		{
-			desc:    "Version go1.13rc1 is supported",
-			version: "go1.13rc1",
+			desc:    "Version go1.19rc1 is supported",
+			version: "go1.19rc1",
 			want:    nil,
 		},
 		{
-			desc:    "Version 1.12 is supported",
-			version: "1.12",
+			desc:    "Version 1.18 is supported",
+			version: "1.18",
 			want:    nil,
 		},
 		{
@@ -680,6 +938,11 @@ func TestCheckGoVersion(t *testing.T) {
 			version: "go1.9",
 			want:    fmt.Errorf("minimum required"),
 		},
+		{
+			desc:    "Version go0.12 is not supported",
+			version: "go0.12",
+			want:    fmt.Errorf("minimum required Go version is 1.18"),
+		},
 		{
 			desc:    "Version go#&^% is not supported",
 			version: "go#&^%",
@@ -712,3 +975,31 @@ func errstring(err error) string {
 	}
 	return "nil"
 }
+
+func TestReplaceConfigFromReaderLineEndings(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		want    string
+	}{
+		{
+			desc:    "CRLF line endings are preserved",
+			content: "developer_token: OldDevToken\r\nclient_id: ClientID\r\n",
+			want:    "developer_token: NewDevToken\r\nclient_id: ClientID\r\n",
+		},
+		{
+			desc:    "LF line endings are preserved",
+			content: "developer_token: OldDevToken\nclient_id: ClientID\n",
+			want:    "developer_token: NewDevToken\nclient_id: ClientID\n",
+		},
+	}
+
+	for _, test := range tests {
+		cfg := ConfigFile{Lang: "python"}
+		got := cfg.ReplaceConfigFromReader(DevToken, "NewDevToken", strings.NewReader(test.content))
+
+		if got != test.want {
+			t.Errorf("%s\ngot: %q\nwant: %q", test.desc, got, test.want)
+		}
+	}
+}
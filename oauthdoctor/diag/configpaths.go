@@ -0,0 +1,142 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// ConfigPathEnvVars are the environment variables that the client libraries
+// read the path of their configuration file from, by language.
+var ConfigPathEnvVars = map[string]string{
+	"python": "GOOGLE_ADS_CONFIGURATION_FILE_PATH",
+	"ruby":   "GOOGLE_ADS_CONFIGURATION_FILE_PATH",
+}
+
+// configDirName is the directory of the configuration file in the
+// configuration directories of the OS.
+const configDirName = "google-ads"
+
+// ConfigCandidate is a path where the configuration file of a client library
+// is looked for.
+type ConfigCandidate struct {
+	Path string
+	// Origin tells where the path comes from, e.g. the home directory.
+	Origin string
+	// Default is true when the client library reads the file at Path
+	// without being given its path by the application.
+	Default bool
+	Exists  bool
+}
+
+// ConfigCandidates returns the paths where the configuration file of lang is
+// looked for, by priority. See configCandidates.
+func ConfigCandidates(lang string) ([]ConfigCandidate, error) {
+	usr, err := currentUser()
+	if err != nil {
+		return nil, i18n.Errorf("Error finding user's home directory: %s", err)
+	}
+	wd, _ := os.Getwd()
+	candidates := configCandidates(lang, runtime.GOOS, usr.HomeDir, wd, os.Getenv)
+	for i := range candidates {
+		_, err := os.Stat(candidates[i].Path)
+		candidates[i].Exists = err == nil
+	}
+	return candidates, nil
+}
+
+// configCandidates returns the paths where the configuration file of lang is
+// looked for on goos, by priority: the environment variable of the client
+// library, the default location of the client library, and then the
+// configuration directories of the OS and the working directory, where
+// applications often keep the file and pass its path to the client library.
+func configCandidates(lang, goos, home, wd string, getenv func(string) string) []ConfigCandidate {
+	filename := Languages[lang].Cfg.Filename
+	var candidates []ConfigCandidate
+	add := func(path, origin string, isDefault bool) {
+		if path == "" {
+			return
+		}
+		for _, c := range candidates {
+			if c.Path == path {
+				return
+			}
+		}
+		candidates = append(candidates, ConfigCandidate{Path: path, Origin: origin, Default: isDefault})
+	}
+
+	if env := ConfigPathEnvVars[lang]; env != "" && getenv(env) != "" {
+		add(getenv(env), "$"+env, true)
+	}
+	// A .env file belongs to the Node.js project, which is usually the
+	// working directory.
+	if lang == "nodejs" && wd != "" {
+		add(filepath.Join(wd, filename), i18n.T("working directory"), true)
+	} else {
+		add(filepath.Join(home, filename), i18n.T("home directory"), true)
+	}
+
+	switch goos {
+	case "windows":
+		if appData := getenv("APPDATA"); appData != "" {
+			add(filepath.Join(appData, configDirName, filename), "%APPDATA%", false)
+		}
+	case "darwin":
+		add(filepath.Join(home, "Library", "Application Support", configDirName, filename), "~/Library/Application Support", false)
+		fallthrough
+	default:
+		if xdg := getenv("XDG_CONFIG_HOME"); xdg != "" {
+			add(filepath.Join(xdg, configDirName, filename), "$XDG_CONFIG_HOME", false)
+		} else {
+			add(filepath.Join(home, ".config", configDirName, filename), "$XDG_CONFIG_HOME (~/.config)", false)
+		}
+	}
+	if wd != "" {
+		add(filepath.Join(wd, filename), i18n.T("working directory"), false)
+	}
+	return candidates
+}
+
+// chooseConfigCandidate returns the candidate of the configuration file that
+// is used: the one of the environment variable of the client library even
+// if it does not exist, as the client library fails then, or else the first
+// one that exists, or else the default location of the client library.
+func chooseConfigCandidate(lang string, candidates []ConfigCandidate) ConfigCandidate {
+	if len(candidates) == 0 {
+		return ConfigCandidate{}
+	}
+	if env := ConfigPathEnvVars[lang]; env != "" && candidates[0].Origin == "$"+env {
+		return candidates[0]
+	}
+	for _, c := range candidates {
+		if c.Exists {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+// ChosenConfigCandidate returns the candidate of the configuration file of
+// lang that GetDefaultConfigFile returns, with all the candidates.
+func ChosenConfigCandidate(lang string) (ConfigCandidate, []ConfigCandidate, error) {
+	candidates, err := ConfigCandidates(lang)
+	if err != nil {
+		return ConfigCandidate{}, nil, err
+	}
+	return chooseConfigCandidate(lang, candidates), candidates, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigCandidates(t *testing.T) {
+	home, wd := filepath.FromSlash("/home/ads"), filepath.FromSlash("/src/app")
+	tests := []struct {
+		desc string
+		lang string
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{
+			desc: "linux",
+			lang: "python",
+			goos: "linux",
+			want: []string{"/home/ads/google-ads.yaml", "/home/ads/.config/google-ads/google-ads.yaml", "/src/app/google-ads.yaml"},
+		},
+		{
+			desc: "environment variables",
+			lang: "python",
+			goos: "linux",
+			env:  map[string]string{"GOOGLE_ADS_CONFIGURATION_FILE_PATH": "/opt/ads.yaml", "XDG_CONFIG_HOME": "/xdg"},
+			want: []string{"/opt/ads.yaml", "/home/ads/google-ads.yaml", "/xdg/google-ads/google-ads.yaml", "/src/app/google-ads.yaml"},
+		},
+		{
+			desc: "macOS",
+			lang: "java",
+			goos: "darwin",
+			want: []string{"/home/ads/ads.properties", "/home/ads/Library/Application Support/google-ads/ads.properties",
+				"/home/ads/.config/google-ads/ads.properties", "/src/app/ads.properties"},
+		},
+		{
+			desc: "windows",
+			lang: "php",
+			goos: "windows",
+			env:  map[string]string{"APPDATA": "/home/ads/AppData/Roaming", "GOOGLE_ADS_CONFIGURATION_FILE_PATH": "/opt/ads.ini"},
+			want: []string{"/home/ads/google_ads_php.ini", "/home/ads/AppData/Roaming/google-ads/google_ads_php.ini",
+				"/src/app/google_ads_php.ini"},
+		},
+		{
+			desc: "working directory of Node.js",
+			lang: "nodejs",
+			goos: "linux",
+			want: []string{"/src/app/.env", "/home/ads/.config/google-ads/.env"},
+		},
+	}
+	for _, tt := range tests {
+		getenv := func(k string) string { return tt.env[k] }
+		var got []string
+		for _, c := range configCandidates(tt.lang, tt.goos, home, wd, getenv) {
+			got = append(got, filepath.ToSlash(c.Path))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%s] configCandidates() = %v, want: %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestChooseConfigCandidate(t *testing.T) {
+	env := ConfigCandidate{Path: "/opt/ads.yaml", Origin: "$GOOGLE_ADS_CONFIGURATION_FILE_PATH", Default: true}
+	home := ConfigCandidate{Path: "/home/ads/google-ads.yaml", Origin: "home directory", Default: true}
+	xdg := ConfigCandidate{Path: "/home/ads/.config/google-ads/google-ads.yaml", Origin: "$XDG_CONFIG_HOME"}
+	found := xdg
+	found.Exists = true
+
+	tests := []struct {
+		desc       string
+		candidates []ConfigCandidate
+		want       ConfigCandidate
+	}{
+		{desc: "missing file of the environment variable", candidates: []ConfigCandidate{env, home, found}, want: env},
+		{desc: "first file found", candidates: []ConfigCandidate{home, found}, want: found},
+		{desc: "nothing found", candidates: []ConfigCandidate{home, xdg}, want: home},
+	}
+	for _, tt := range tests {
+		if got := chooseConfigCandidate("python", tt.candidates); got != tt.want {
+			t.Errorf("[%s] chooseConfigCandidate() = %+v, want: %+v", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Container returns the container runtime that this program runs in, e.g.
+// docker, podman or kubernetes, or an empty string when it does not run in a
+// container. In a container, the browser of the user cannot be opened and
+// cannot reach a local HTTP server unless its port is published.
+func Container() string {
+	cgroup, _ := ioutil.ReadFile("/proc/1/cgroup")
+	return container(func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, os.Getenv, string(cgroup))
+}
+
+func container(exists func(string) bool, getenv func(string) string, cgroup string) string {
+	switch {
+	case getenv("KUBERNETES_SERVICE_HOST") != "":
+		return "kubernetes"
+	case exists("/.dockerenv"):
+		return "docker"
+	case exists("/run/.containerenv"):
+		return "podman"
+	}
+	// systemd-nspawn, podman and LXC set the container variable.
+	if c := getenv("container"); c != "" {
+		return c
+	}
+	for _, rt := range []string{"kubepods", "docker", "containerd", "lxc"} {
+		if strings.Contains(cgroup, "/"+rt) {
+			if rt == "kubepods" {
+				return "kubernetes"
+			}
+			return rt
+		}
+	}
+	return ""
+}
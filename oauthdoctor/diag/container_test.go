@@ -0,0 +1,75 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import "testing"
+
+func TestContainer(t *testing.T) {
+	tests := []struct {
+		desc   string
+		files  []string
+		env    map[string]string
+		cgroup string
+		want   string
+	}{
+		{
+			desc:   "Desktop",
+			cgroup: "0::/user.slice/user-1000.slice/session-2.scope\n",
+			want:   "",
+		},
+		{
+			desc:  "Docker",
+			files: []string{"/.dockerenv"},
+			want:  "docker",
+		},
+		{
+			desc:  "Podman",
+			files: []string{"/run/.containerenv"},
+			env:   map[string]string{"container": "podman"},
+			want:  "podman",
+		},
+		{
+			desc: "Kubernetes",
+			env:  map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"},
+			want: "kubernetes",
+		},
+		{
+			desc: "systemd-nspawn",
+			env:  map[string]string{"container": "systemd-nspawn"},
+			want: "systemd-nspawn",
+		},
+		{
+			desc:   "cgroup v1 of Docker",
+			cgroup: "12:memory:/docker/3f2a\n11:cpu:/docker/3f2a\n",
+			want:   "docker",
+		},
+		{
+			desc:   "cgroup of a Kubernetes pod",
+			cgroup: "1:name=systemd:/kubepods/besteffort/pod1234\n",
+			want:   "kubernetes",
+		},
+	}
+
+	for _, tt := range tests {
+		exists := func(path string) bool {
+			return Contains(tt.files, path)
+		}
+		getenv := func(k string) string {
+			return tt.env[k]
+		}
+		if got := container(exists, getenv, tt.cgroup); got != tt.want {
+			t.Errorf("[%s] container() = %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
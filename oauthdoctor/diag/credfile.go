@@ -0,0 +1,151 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// CredentialFileSuffix is appended to the name of an environment variable
+// to name the variable that holds the path of a file with its value, e.g.
+// GOOGLE_ADS_REFRESH_TOKEN_FILE=/run/secrets/google_ads_refresh_token for a
+// Docker secret.
+const CredentialFileSuffix = "_FILE"
+
+// credentialsDirEnv is the environment variable that systemd sets to the
+// directory of the credentials of a service, whose files are named after
+// the credentials, e.g. LoadCredential=GOOGLE_ADS_REFRESH_TOKEN:/etc/token.
+const credentialsDirEnv = "CREDENTIALS_DIRECTORY"
+
+// credentialEnvVars returns the names of the environment variables that
+// can be read from a file: those of all the client libraries and the
+// GoogleAdsApi section of .NET.
+func credentialEnvVars() []string {
+	var names []string
+	rest := structs.Map(Languages[RESTLanguage].Cfg.ConfigKeys)
+	dotnet := structs.Map(Languages["dotnet"].Cfg.ConfigKeys)
+	for field, name := range rest {
+		if name.(string) == "" {
+			continue
+		}
+		names = append(names, name.(string))
+		if key := dotnet[field].(string); key != "" {
+			names = append(names, dotNetEnvPrefix+key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credentialFile returns the path of the file that holds the value of the
+// environment variable name, if any, and describes how it is set: by files,
+// by the variable with CredentialFileSuffix or by a systemd credential.
+func credentialFile(files map[string]string, name string) (path, setting string) {
+	if path := files[name]; path != "" {
+		return path, i18n.T("-credential-files")
+	}
+	if path := os.Getenv(name + CredentialFileSuffix); path != "" {
+		return path, i18n.Sprintf("environment variable %s", name+CredentialFileSuffix)
+	}
+	if dir := os.Getenv(credentialsDirEnv); dir != "" {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, i18n.Sprintf("systemd credential %s", name)
+		}
+	}
+	return "", ""
+}
+
+// readCredentialFile returns the content of a credential file without the
+// trailing newline, which editors and echo add.
+func readCredentialFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// getenv returns the value of the environment variable name, or the content
+// of its credential file when the variable is not set, and describes where
+// the value is set. The errors of the credential files are reported by
+// CheckCredentialFiles.
+func (r *Reader) getenv(name string) (value, source string) {
+	if v := os.Getenv(name); v != "" {
+		return v, i18n.Sprintf("environment variable %s", name)
+	}
+	var files map[string]string
+	if r != nil {
+		files = r.CredentialFiles
+	}
+	path, setting := credentialFile(files, name)
+	if path == "" {
+		return "", ""
+	}
+	v, err := readCredentialFile(path)
+	if err != nil || v == "" {
+		return "", ""
+	}
+	return v, i18n.Sprintf("file %s, set with %s", path, setting)
+}
+
+// getenv returns the value of the environment variable name, or the content
+// of the credential file set by the environment, as the zero Reader does.
+func getenv(name string) (value, source string) {
+	return (*Reader)(nil).getenv(name)
+}
+
+// CheckCredentialFiles returns an error when files sets the file of an
+// unknown environment variable, or when a credential file set by files or
+// the environment cannot be read, is empty, or is set along with the
+// variable itself, which the variable would silently override.
+func CheckCredentialFiles(files map[string]string) error {
+	known := credentialEnvVars()
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !Contains(known, name) {
+			return i18n.Errorf("Unknown environment variable %s in -credential-files. Supported variables are %s",
+				name, strings.Join(known, ", "))
+		}
+	}
+
+	for _, name := range known {
+		path, setting := credentialFile(files, name)
+		if path == "" {
+			continue
+		}
+		if os.Getenv(name) != "" {
+			return i18n.Errorf("Both %s and %s are set. Set only one of them.", name, setting)
+		}
+		v, err := readCredentialFile(path)
+		if err != nil {
+			return i18n.Errorf("Cannot read the file of %s, set with %s: %s", name, setting, err)
+		}
+		if v == "" {
+			return i18n.Errorf("The file %s of %s, set with %s, is empty", path, name, setting)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setenvs sets the environment variables of env, or unsets those with an
+// empty value, and returns a function that restores them.
+func setenvs(env map[string]string) func() {
+	old := make(map[string]*string)
+	for k, v := range env {
+		if o, ok := os.LookupEnv(k); ok {
+			old[k] = &o
+		} else {
+			old[k] = nil
+		}
+		if v == "" {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, v)
+		}
+	}
+	return func() {
+		for k, o := range old {
+			if o == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *o)
+			}
+		}
+	}
+}
+
+func TestCredentialFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "google_ads_refresh_token")
+	if err := ioutil.WriteFile(secret, []byte("FileRefreshToken\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := ioutil.WriteFile(empty, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "GOOGLE_ADS_REFRESH_TOKEN"), []byte("SystemdRefreshToken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc       string
+		env        map[string]string
+		files      map[string]string
+		want       string
+		wantSource string
+		errstr     string
+	}{
+		{
+			desc:       "Docker secret",
+			env:        map[string]string{"GOOGLE_ADS_REFRESH_TOKEN_FILE": secret},
+			want:       "FileRefreshToken",
+			wantSource: "file " + secret + ", set with environment variable GOOGLE_ADS_REFRESH_TOKEN_FILE",
+			errstr:     "nil",
+		},
+		{
+			desc:       "Flag",
+			files:      map[string]string{"GOOGLE_ADS_REFRESH_TOKEN": secret},
+			want:       "FileRefreshToken",
+			wantSource: "file " + secret + ", set with -credential-files",
+			errstr:     "nil",
+		},
+		{
+			desc:       "systemd credential",
+			env:        map[string]string{credentialsDirEnv: dir},
+			want:       "SystemdRefreshToken",
+			wantSource: "systemd credential GOOGLE_ADS_REFRESH_TOKEN",
+			errstr:     "nil",
+		},
+		{
+			desc:   "Variable and file",
+			env:    map[string]string{"GOOGLE_ADS_REFRESH_TOKEN": "EnvRefreshToken", "GOOGLE_ADS_REFRESH_TOKEN_FILE": secret},
+			want:   "EnvRefreshToken",
+			errstr: "Both GOOGLE_ADS_REFRESH_TOKEN and environment variable GOOGLE_ADS_REFRESH_TOKEN_FILE are set",
+		},
+		{
+			desc:   "Missing file",
+			env:    map[string]string{"GOOGLE_ADS_REFRESH_TOKEN_FILE": filepath.Join(dir, "missing")},
+			errstr: "Cannot read the file of GOOGLE_ADS_REFRESH_TOKEN",
+		},
+		{
+			desc:   "Empty file",
+			files:  map[string]string{"GOOGLE_ADS_REFRESH_TOKEN": empty},
+			errstr: "is empty",
+		},
+		{
+			desc:   "Unknown variable",
+			files:  map[string]string{"GOOGLE_ADS_TOKEN": secret},
+			errstr: "Unknown environment variable GOOGLE_ADS_TOKEN",
+		},
+	}
+
+	for _, tt := range tests {
+		env := map[string]string{
+			"GOOGLE_ADS_REFRESH_TOKEN":      "",
+			"GOOGLE_ADS_REFRESH_TOKEN_FILE": "",
+			credentialsDirEnv:               "",
+		}
+		for k, v := range tt.env {
+			env[k] = v
+		}
+		restore := setenvs(env)
+
+		err := CheckCredentialFiles(tt.files)
+		got := (&Reader{CredentialFiles: tt.files}).RESTConfigFile(InstalledApp, ConfigKeys{})
+
+		restore()
+
+		if !strings.Contains(errstring(err), tt.errstr) {
+			t.Errorf("[%s] CheckCredentialFiles() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+		if got.RefreshToken != tt.want {
+			t.Errorf("[%s] RESTConfigFile() RefreshToken = %q, want: %q", tt.desc, got.RefreshToken, tt.want)
+		}
+		if tt.wantSource != "" && !strings.HasSuffix(got.Source(RefreshToken), tt.wantSource) {
+			t.Errorf("[%s] RESTConfigFile() RefreshToken source = %q, want: %q", tt.desc, got.Source(RefreshToken), tt.wantSource)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// encrypted returns true when the configuration files that r reads are
+// decrypted with DecryptCommand.
+func (r *Reader) encrypted() bool {
+	return r != nil && r.DecryptCommand != ""
+}
+
+// decrypt pipes the content of an encrypted configuration file through
+// DecryptCommand. The stderr of the command is passed through, so that it
+// can ask for a passphrase on the terminal.
+func (r *Reader) decrypt(content []byte) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", r.DecryptCommand)
+	} else {
+		cmd = exec.Command("sh", "-c", r.DecryptCommand)
+	}
+	var out bytes.Buffer
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("the decryption command %q failed: %s", r.DecryptCommand, err)
+	}
+	if strings.TrimSpace(out.String()) == "" {
+		return nil, fmt.Errorf("the decryption command %q printed nothing", r.DecryptCommand)
+	}
+	return out.Bytes(), nil
+}
+
+// keepPlaintext keeps the content of the configuration file when it was
+// decrypted, so that the checks of the file do not run DecryptCommand again.
+// An encrypted file is never rewritten, so the content stays current.
+func (c *ConfigFile) keepPlaintext(content string) {
+	if c.reader.encrypted() {
+		c.plaintext = content
+	}
+}
+
+// readText returns the content of the file at path, which is the kept
+// plaintext when path is the encrypted configuration file.
+func (c *ConfigFile) readText(path string) (string, error) {
+	if c.plaintext != "" && path == c.GetFilepath() {
+		return c.plaintext, nil
+	}
+	content, _, err := c.reader.readTextFile(path)
+	return content, err
+}
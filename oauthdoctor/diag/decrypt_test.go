@@ -0,0 +1,74 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDecryptCommand(t *testing.T) {
+	if _, err := exec.LookPath("base64"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("base64 is not available")
+	}
+	dir, err := ioutil.TempDir("", "decrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	plaintext := "developer_token: GoodDevToken\nclient_id: GoodClientID\n"
+	encrypted := base64.StdEncoding.EncodeToString([]byte(plaintext))
+	if err := ioutil.WriteFile(path, []byte(encrypted), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := &Reader{DecryptCommand: "base64 -d"}
+	c, err := r.ParseConfigFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %s", err)
+	}
+	if c.DevToken != "GoodDevToken" || c.ConfigKeys.ClientID != "GoodClientID" {
+		t.Errorf("ParseConfigFile() got DevToken %q and ClientID %q, want GoodDevToken and GoodClientID",
+			c.DevToken, c.ConfigKeys.ClientID)
+	}
+
+	_, err = c.ReplaceConfig(RefreshToken, "1//token")
+	if !strings.Contains(errstring(err), "The config file is encrypted") {
+		t.Errorf("ReplaceConfig() error: %s, want: The config file is encrypted", errstring(err))
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || string(content) != encrypted {
+		t.Errorf("ReplaceConfig() changed the encrypted file: %q", content)
+	}
+
+	// The checks of the file read the plaintext kept by the parser.
+	r.DecryptCommand = "exit 1"
+	if got, err := c.readText(path); err != nil || got != plaintext {
+		t.Errorf("readText() = %q, %v, want: %q", got, err, plaintext)
+	}
+	if _, err := r.ParseConfigFile("python", path, InstalledApp); !strings.Contains(errstring(err), "the decryption command") {
+		t.Errorf("ParseConfigFile() error: %s, want: the decryption command", errstring(err))
+	}
+
+	// Another Reader does not decrypt the file.
+	if c, err := ParseConfigFile("python", path, InstalledApp); err == nil && c.DevToken != "" {
+		t.Errorf("ParseConfigFile() of the zero Reader got DevToken %q, want it empty", c.DevToken)
+	}
+}
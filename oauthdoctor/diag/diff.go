@@ -0,0 +1,165 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/kylelemons/godebug/diff"
+)
+
+// diffContext is the number of unchanged lines around the changes of a
+// unified diff.
+const diffContext = 3
+
+// diffOp is a line of a diff: ' ' for a line of both texts, '-' for a line
+// of the old text only and '+' for a line of the new text only.
+type diffOp struct {
+	kind byte
+	text string
+	// a and b are the numbers of the line in the old and new text, or of
+	// the line before it when it is not in that text.
+	a, b int
+}
+
+// unifiedDiff returns the unified diff from the lines a of the file aName to
+// the lines b of bName, or "" when they are the same.
+func unifiedDiff(aName, bName string, a, b []string) string {
+	var ops []diffOp
+	var changes []int
+	la, lb := 0, 0
+	for _, c := range diff.DiffChunks(a, b) {
+		for _, l := range c.Deleted {
+			la++
+			changes = append(changes, len(ops))
+			ops = append(ops, diffOp{kind: '-', text: l, a: la, b: lb})
+		}
+		for _, l := range c.Added {
+			lb++
+			changes = append(changes, len(ops))
+			ops = append(ops, diffOp{kind: '+', text: l, a: la, b: lb})
+		}
+		for _, l := range c.Equal {
+			la, lb = la+1, lb+1
+			ops = append(ops, diffOp{kind: ' ', text: l, a: la, b: lb})
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for i := 0; i < len(changes); {
+		// A hunk takes the next changes whose contexts overlap.
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext+1 {
+			j++
+		}
+		start, end := changes[i]-diffContext, changes[j]+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
+		}
+		hunk := ops[start:end]
+		var countA, countB int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		// A hunk starts at its first line, or after the line before an
+		// empty range.
+		startA, startB := hunk[0].a, hunk[0].b
+		if hunk[0].kind == '+' {
+			startA++
+		}
+		if hunk[0].kind == '-' {
+			startB++
+		}
+		if countA == 0 {
+			startA--
+		}
+		if countB == 0 {
+			startB--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", startA, countA, startB, countB)
+		for _, op := range hunk {
+			out.WriteString(string(op.kind) + op.text + "\n")
+		}
+		i = j + 1
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// secretValues returns the values of the PII fields of keys.
+func secretValues(keys ...ConfigKeys) []string {
+	var secrets []string
+	for _, k := range keys {
+		for field, v := range structs.Map(k) {
+			if s, _ := v.(string); s != "" && IsPII(field) {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+	return secrets
+}
+
+// redactValues replaces the values in the lines with Redact, so a diff of
+// the configuration file can be printed. Only whole words are replaced, so a
+// short value such as "id" leaves the key client_id alone.
+func redactValues(lines, values []string) []string {
+	redacted := make([]string, len(lines))
+	for i, l := range lines {
+		for _, v := range values {
+			l = replaceWord(l, v, Redact(v))
+		}
+		redacted[i] = l
+	}
+	return redacted
+}
+
+// replaceWord replaces the occurrences of old in s that are not part of a
+// longer word with new.
+func replaceWord(s, old, new string) string {
+	isWord := func(b byte) bool {
+		return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+	}
+	if old == "" {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			break
+		}
+		end := i + len(old)
+		if (i > 0 && isWord(s[i-1])) || (end < len(s) && isWord(s[end])) {
+			b.WriteString(s[:i+1])
+			s = s[i+1:]
+			continue
+		}
+		b.WriteString(s[:i] + new)
+		s = s[end:]
+	}
+	return b.String() + s
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(s string) []string { return strings.Split(s, "\n") }
+	tests := []struct {
+		desc string
+		a, b string
+		want string
+	}{
+		{desc: "same", a: "a\nb", b: "a\nb", want: ""},
+		{
+			desc: "replaced line",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9",
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8",
+		},
+		{
+			desc: "added line",
+			a:    "1\n2",
+			b:    "1\n2\n3",
+			want: "--- old\n+++ new\n@@ -1,2 +1,3 @@\n 1\n 2\n+3",
+		},
+		{
+			desc: "added to an empty file",
+			a:    "",
+			b:    "1",
+			want: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-\n+1",
+		},
+		{
+			desc: "two hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten",
+		},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("old", "new", lines(tt.a), lines(tt.b)); got != tt.want {
+			t.Errorf("[%s] unifiedDiff() got:\n%s\nwant:\n%s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestRedactValues(t *testing.T) {
+	got := redactValues([]string{"client_id: id", "refresh_token: 1//0gAbCdEfGhIjKlMnOp-wxyz # 1//0gAbCdEfGhIjKlMnOp-wxyz"},
+		[]string{"id", "1//0gAbCdEfGhIjKlMnOp-wxyz"})
+	want := []string{"client_id: **", "refresh_token: 1//0...wxyz # 1//0...wxyz"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("redactValues() = %q, want: %q", got, want)
+	}
+}
+
+func TestReplaceConfigPrintsDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	content := "developer_token: AbCdEfGhIjKlMnOpQrStUv\nclient_id: 1234.apps.googleusercontent.com\nrefresh_token: 1//OldRefreshToken\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseKeyValueFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out messages
+	c.Out = &out
+
+	backup, err := c.ReplaceConfig(RefreshToken, "1//NewRefreshToken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- " + backup + "\n+++ " + path + "\n@@ -1,3 +1,3 @@\n developer_token: AbCd...StUv\n" +
+		" client_id: 1234....com\n-refresh_token: 1//O...oken\n+refresh_token: 1//N...oken"
+	if got := strings.Join(out, "\n"); !strings.Contains(got, want) {
+		t.Errorf("ReplaceConfig() got output:\n%s\nwant substring:\n%s", got, want)
+	}
+}
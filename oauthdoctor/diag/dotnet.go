@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/structs"
+)
+
+// EnvFormat is the format of a configuration made only of environment
+// variables, e.g. a .NET application that configures the client library
+// with GoogleAdsConfig from the environment.
+const EnvFormat = "env"
+
+// DotNetSettingsFilename is the configuration file of .NET (Core)
+// applications, whose GoogleAdsApi section has the keys of App.config.
+const DotNetSettingsFilename = "appsettings.json"
+
+// dotNetEnvPrefix is the prefix of the environment variables that set the
+// keys of the GoogleAdsApi section of a .NET (Core) application, e.g.
+// GoogleAdsApi__DeveloperToken.
+const dotNetEnvPrefix = "GoogleAdsApi__"
+
+// EnvConfigFile returns the configuration of lang read from the environment
+// variables that all the client libraries read, e.g.
+// GOOGLE_ADS_DEVELOPER_TOKEN, or from their credential files. For .NET, the
+// variables of the GoogleAdsApi section of the application settings take
+// precedence.
+func (r *Reader) EnvConfigFile(lang, oauthType string) ConfigFile {
+	c := ConfigFile{Lang: lang, OAuthType: oauthType, Format: EnvFormat, reader: r}
+	for _, field := range structs.Names(ConfigKeys{}) {
+		if v, source := r.getenv(c.EnvVar(field)); v != "" {
+			c.SetConfigKeys(field, v)
+			c.SetSource(field, source)
+		}
+	}
+	return c
+}
+
+// EnvConfigFile returns the configuration of lang read from the environment
+// variables with the zero Reader.
+func EnvConfigFile(lang, oauthType string) ConfigFile {
+	return (*Reader)(nil).EnvConfigFile(lang, oauthType)
+}
+
+// EnvVar returns the environment variable that sets the given field of
+// ConfigKeys in a configuration of EnvFormat or of RESTLanguage. For .NET,
+// it is the variable of the GoogleAdsApi section when that one is set.
+func (c *ConfigFile) EnvVar(field string) string {
+	if c.Lang == "dotnet" {
+		name := dotNetEnvPrefix + structs.New(Languages[c.Lang].Cfg.ConfigKeys).Field(field).Value().(string)
+		if v, _ := c.reader.getenv(name); v != "" {
+			return name
+		}
+	}
+	return structs.New(Languages[RESTLanguage].Cfg.ConfigKeys).Field(field).Value().(string)
+}
+
+// FromEnv returns true when the configuration has no file, so its values
+// are set with environment variables.
+func (c *ConfigFile) FromEnv() bool {
+	return c.Lang == RESTLanguage || c.Format == EnvFormat
+}
+
+// DetectDotNetFormat returns the format of the configuration of the .NET
+// client library when there is no App.config in the home directory:
+// JSONFormat when appsettings.json is in the working directory, EnvFormat
+// when the environment variables of the client library are set, or an
+// empty string for App.config.
+func (r *Reader) DetectDotNetFormat() string {
+	if c, err := GetDefaultConfigFile("dotnet"); err == nil {
+		if _, err := os.Stat(c.GetFilepath()); err == nil {
+			return ""
+		}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if _, err := os.Stat(filepath.Join(wd, DotNetSettingsFilename)); err == nil {
+			return JSONFormat
+		}
+	}
+	if c := r.EnvConfigFile("dotnet", ""); c.ConfigKeys != (ConfigKeys{}) {
+		return EnvFormat
+	}
+	return ""
+}
+
+// DetectDotNetFormat returns the format of the configuration of the .NET
+// client library with the zero Reader.
+func DetectDotNetFormat() string {
+	return (*Reader)(nil).DetectDotNetFormat()
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParseDotNetSettings(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Error getting current dir: %s", err)
+	}
+	path := filepath.Join(dir, "testdata", "dotnet_appsettings.json")
+	at := func(line int) string {
+		return fmt.Sprintf("%s:%d", path, line)
+	}
+
+	got, err := ParseConfigFile("dotnet", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() returned error: %s", err)
+	}
+	want := ConfigFile{
+		Filepath:  filepath.Join(dir, "testdata"),
+		Filename:  "dotnet_appsettings.json",
+		Lang:      "dotnet",
+		OAuthType: InstalledApp,
+		Format:    JSONFormat,
+		ConfigKeys: ConfigKeys{
+			ClientID:        "0123456789-GoodClientID.apps.googleusercontent.com",
+			ClientSecret:    "GoodClientSecret",
+			DevToken:        "GoodDevToken",
+			RefreshToken:    "1//GoodRefreshToken",
+			LoginCustomerID: "1234567890",
+		},
+		Sources: ConfigKeys{
+			ClientID:        at(8),
+			ClientSecret:    at(9),
+			DevToken:        at(6),
+			RefreshToken:    at(10),
+			LoginCustomerID: at(11),
+		},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("ParseConfigFile() returned diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestDotNetSettingsSet(t *testing.T) {
+	content := "{\n  \"GoogleAdsApi\": {\n    \"OAuth2ClientId\": \"id\"\n  }\n}\n"
+	want := "{\n  \"GoogleAdsApi\": {\n    \"OAuth2ClientId\": \"id\",\n    \"OAuth2RefreshToken\": \"tok\"\n  }\n}\n"
+
+	cfg := ConfigFile{Lang: "dotnet", Format: JSONFormat}
+	doc, err := scanJSON(content)
+	if err != nil {
+		t.Fatalf("scanJSON() returned error: %s", err)
+	}
+	if got := doc.set(cfg.knownKeys(), RefreshToken, "tok"); got != want {
+		t.Errorf("set() got: %q, want: %q", got, want)
+	}
+}
+
+func TestEnvConfigFile(t *testing.T) {
+	env := map[string]string{
+		"GOOGLE_ADS_DEVELOPER_TOKEN":       "EnvDevToken",
+		"GOOGLE_ADS_CLIENT_ID":             "EnvClientID",
+		"GoogleAdsApi__OAuth2ClientId":     "SectionClientID",
+		"GoogleAdsApi__OAuth2RefreshToken": "SectionRefreshToken",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	got := EnvConfigFile("dotnet", InstalledApp)
+	want := ConfigFile{
+		Lang:      "dotnet",
+		OAuthType: InstalledApp,
+		Format:    EnvFormat,
+		ConfigKeys: ConfigKeys{
+			DevToken:     "EnvDevToken",
+			ClientID:     "SectionClientID",
+			RefreshToken: "SectionRefreshToken",
+		},
+		Sources: ConfigKeys{
+			DevToken:     "environment variable GOOGLE_ADS_DEVELOPER_TOKEN",
+			ClientID:     "environment variable GoogleAdsApi__OAuth2ClientId",
+			RefreshToken: "environment variable GoogleAdsApi__OAuth2RefreshToken",
+		},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("EnvConfigFile() returned diff (-want +got):\n%s", diff)
+	}
+	if got, want := got.EnvVar(ClientSecret), "GOOGLE_ADS_CLIENT_SECRET"; got != want {
+		t.Errorf("EnvVar(ClientSecret) = %s, want %s", got, want)
+	}
+}
+
+func TestDetectDotNetFormat(t *testing.T) {
+	home, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	origFn := currentUser
+	currentUser = func() (*user.User, error) {
+		return &user.User{HomeDir: home}, nil
+	}
+	defer func() { currentUser = origFn }()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(home); err != nil {
+		t.Fatal(err)
+	}
+	os.Unsetenv("GOOGLE_ADS_DEVELOPER_TOKEN")
+
+	if got := DetectDotNetFormat(); got != "" {
+		t.Errorf("DetectDotNetFormat() without configuration = %q, want \"\"", got)
+	}
+	os.Setenv("GOOGLE_ADS_DEVELOPER_TOKEN", "EnvDevToken")
+	defer os.Unsetenv("GOOGLE_ADS_DEVELOPER_TOKEN")
+	if got := DetectDotNetFormat(); got != EnvFormat {
+		t.Errorf("DetectDotNetFormat() with environment variables = %q, want %q", got, EnvFormat)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, DotNetSettingsFilename), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectDotNetFormat(); got != JSONFormat {
+		t.Errorf("DetectDotNetFormat() with %s = %q, want %q", DotNetSettingsFilename, got, JSONFormat)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, Languages["dotnet"].Cfg.Filename), []byte("<configuration/>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectDotNetFormat(); got != "" {
+		t.Errorf("DetectDotNetFormat() with App.config = %q, want \"\"", got)
+	}
+}
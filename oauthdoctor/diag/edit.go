@@ -0,0 +1,63 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// EditableFields returns the fields of ConfigKeys that the configuration
+// file of c has a key for, in the order of ConfigKeys.
+func (c *ConfigFile) EditableFields() []string {
+	has := make(map[string]bool)
+	for _, field := range c.knownKeys() {
+		has[field] = true
+	}
+	var fields []string
+	for _, field := range structs.Names(ConfigKeys{}) {
+		if has[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ConfigField returns the field of ConfigKeys that key refers to: the name of
+// the field in any case, e.g. refreshtoken, or its key in the configuration
+// file of c, e.g. refresh_token for Python.
+func (c *ConfigFile) ConfigField(key string) (string, error) {
+	fields := c.EditableFields()
+	for _, field := range fields {
+		if strings.EqualFold(field, key) {
+			return field, nil
+		}
+	}
+	if field, ok := c.knownKeys()[key]; ok {
+		return field, nil
+	}
+	return "", i18n.Errorf("%s is not a key of the config file. Keys: %s", key, strings.Join(fields, ", "))
+}
+
+// EditValue returns the value of field as it is shown in the menu of the
+// editor, hidden for PII when hidePII is true.
+func (c *ConfigFile) EditValue(field string, hidePII bool) string {
+	v := c.configValue(field)
+	if hidePII && IsPII(field) && v != "" {
+		return Redact(v)
+	}
+	return v
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigField(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     ConfigFile
+		key     string
+		want    string
+		wantErr string
+	}{
+		{desc: "field", cfg: ConfigFile{Lang: "python"}, key: "refreshtoken", want: RefreshToken},
+		{desc: "key of the file", cfg: ConfigFile{Lang: "java"}, key: "api.googleads.developerToken", want: DevToken},
+		{desc: "legacy key", cfg: ConfigFile{Lang: "python"}, key: "path_to_private_key_file", want: PrivateKeyPath},
+		{desc: "key of another language", cfg: ConfigFile{Lang: "python"}, key: "api.googleads.developerToken",
+			wantErr: "is not a key of the config file"},
+	}
+	for _, tt := range tests {
+		got, err := tt.cfg.ConfigField(tt.key)
+		if got != tt.want || (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("[%s] ConfigField(%s) = %s, %v, want: %s, %s", tt.desc, tt.key, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
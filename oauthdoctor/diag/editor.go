@@ -0,0 +1,600 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/structs"
+)
+
+// rubyConfigBlock is the scope name of the configuration block of the Ruby
+// client library.
+const rubyConfigBlock = "Google::Ads::GoogleAds::Config.new"
+
+var (
+	xmlElementRegex = regexp.MustCompile(`<(/?)([\w:.-]+)[^>]*?(/?)>`)
+	xmlAttrRegex    = regexp.MustCompile(`([\w:.-]+)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// configEntry is a key-value pair in a configuration file. The value is
+// located by its byte offsets in the line, so that it can be replaced without
+// touching the rest of the line.
+type configEntry struct {
+	key        string
+	line       int
+	start, end int
+	// quote is the quote character around the value, or 0 if the value is
+	// not quoted.
+	quote byte
+	// sep is the text between the key and the value, such as " = ".
+	sep string
+}
+
+// configScope is the part of a configuration file where the client library
+// reads its keys: an ini section, the Ruby configuration block or the
+// GoogleAdsApi element of an App.config file. The scope spans the lines
+// after open up to close.
+type configScope struct {
+	name  string
+	open  int
+	close int
+}
+
+// configDocument is a configuration file split into lines. Editing a
+// document only changes the lines of the edited key, so comments, ordering
+// and indentation are preserved.
+type configDocument struct {
+	lang            string
+	lines           []string
+	newline         string
+	trailingNewline bool
+	entries         []configEntry
+	scopes          []configScope
+	// tail is the line before which new scopes are added.
+	tail int
+	// rubyVar is the block variable of a Ruby configuration block, which
+	// replaces the c of the keys.
+	rubyVar string
+}
+
+// parseConfigDocument parses the content of a configuration file of the
+// client library in the given language.
+func parseConfigDocument(lang, content string) *configDocument {
+	d := &configDocument{
+		lang:            lang,
+		newline:         lineEnding([]byte(content)),
+		trailingNewline: content == "" || strings.HasSuffix(content, "\n"),
+	}
+	if content != "" {
+		content = strings.TrimSuffix(strings.Replace(content, "\r\n", "\n", -1), "\n")
+		d.lines = strings.Split(content, "\n")
+	}
+	d.tail = len(d.lines)
+
+	if lang == "dotnet" {
+		d.scanXML()
+	} else {
+		d.scanKeyValue()
+	}
+	if lang == "ruby" {
+		d.scanRubyEntries()
+	}
+	d.closeScope(len(d.lines))
+	return d
+}
+
+// scanKeyValue finds the entries and scopes of an ini, properties, YAML or
+// Ruby configuration file.
+func (d *configDocument) scanKeyValue() {
+	separator := Languages[d.lang].Separator
+	comment := Languages[d.lang].Comment
+	for i, line := range d.lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, comment.LeftMeta) {
+			continue
+		}
+
+		switch {
+		case d.lang == "php" && strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			d.openScope(strings.TrimSpace(trimmed[1:len(trimmed)-1]), i)
+			continue
+		case d.lang == "ruby" && strings.Contains(trimmed, rubyConfigBlock):
+			d.openScope(rubyConfigBlock, i)
+			continue
+		case d.lang == "ruby" && trimmed == "end":
+			// The block ends with the "end" indented like the line that opens it.
+			if s := d.openedScope(); s != nil && leadingSpace(d.lines[s.open]) == leadingSpace(line) {
+				d.closeScope(i)
+			}
+			continue
+		case d.lang == "ruby":
+			// The assignments are found by scanRuby.
+			continue
+		}
+
+		idx := strings.Index(line, separator)
+		if idx < 0 {
+			continue
+		}
+		key := envKey(d.lang, line[:idx])
+		if key == "" {
+			continue
+		}
+		start, end, quote := valueSpan(d.lang, line, idx+len(separator))
+		sepStart := strings.Index(line, key) + len(key)
+		sepEnd := start
+		if quote != 0 {
+			sepEnd--
+		}
+		d.entries = append(d.entries, configEntry{
+			key: key, line: i, start: start, end: end, quote: quote, sep: line[sepStart:sepEnd],
+		})
+	}
+}
+
+// scanRubyEntries finds the entries of a Ruby configuration file. The value
+// of an entry may be on the line after its key.
+func (d *configDocument) scanRubyEntries() {
+	var assignments []rubyAssignment
+	assignments, d.rubyVar = scanRuby(d.lines)
+	for _, a := range assignments {
+		sep := " = "
+		if a.valueLine == a.line {
+			sep = d.lines[a.line][a.keyEnd:a.start]
+			if a.quote != 0 {
+				sep = sep[:len(sep)-1]
+			}
+		}
+		d.entries = append(d.entries, configEntry{
+			key: a.key, line: a.valueLine, start: a.start, end: a.end, quote: a.quote, sep: sep,
+		})
+	}
+}
+
+// valueSpan returns the offsets of the value that starts at offset from in
+// line, without its quotes and any trailing comment, and the quote character
+// around the value.
+func valueSpan(lang, line string, from int) (start, end int, quote byte) {
+	start = from
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+
+	// Java properties have no quoted values.
+	if start < len(line) && (line[start] == '"' || line[start] == '\'') && lang != "java" {
+		q := line[start]
+		for i := start + 1; i < len(line); i++ {
+			if line[i] == '\\' && q == '"' {
+				i++
+				continue
+			}
+			if line[i] == q {
+				return start + 1, i, q
+			}
+		}
+	}
+
+	end = len(line)
+	switch lang {
+	case "php":
+		if idx := strings.IndexByte(line[start:], ';'); idx >= 0 {
+			end = start + idx
+		}
+	case "python", "ruby", "nodejs":
+		for i := start; i < len(line); i++ {
+			if line[i] == '#' && (i == start || line[i-1] == ' ' || line[i-1] == '\t') {
+				end = i
+				break
+			}
+		}
+	}
+	for end > start && (line[end-1] == ' ' || line[end-1] == '\t') {
+		end--
+	}
+	return start, end, 0
+}
+
+// scanXML finds the entries and the GoogleAdsApi scope of an App.config
+// file. Commented elements are ignored.
+func (d *configDocument) scanXML() {
+	content := maskXMLComments(strings.Join(d.lines, "\n"))
+	lineStarts := []int{0}
+	for i, r := range content {
+		if r == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	}
+
+	for _, m := range xmlElementRegex.FindAllStringSubmatchIndex(content, -1) {
+		closing := m[3] > m[2]
+		selfClosing := m[7] > m[6]
+		line := lineOf(m[0])
+		switch name := content[m[4]:m[5]]; {
+		case name == "GoogleAdsApi" && closing:
+			d.closeScope(line)
+		case name == "GoogleAdsApi" && !selfClosing:
+			d.openScope(name, line)
+		case name == "configuration" && closing:
+			// Also closes a GoogleAdsApi element that is never closed.
+			d.closeScope(line)
+			d.tail = line
+		case name == "add" && !closing && d.openedScope() != nil:
+			d.addXMLEntry(content, m[0], m[1], lineStarts[line], line)
+		}
+	}
+}
+
+// addXMLEntry adds the entry of the <add key="..." value="..."/> element at
+// content[start:end]. The element must be in the line that starts at
+// lineStart.
+func (d *configDocument) addXMLEntry(content string, start, end, lineStart, line int) {
+	e := configEntry{line: line, start: -1}
+	for _, m := range xmlAttrRegex.FindAllStringSubmatchIndex(content[start:end], -1) {
+		name := content[start+m[2] : start+m[3]]
+		valueStart, valueEnd := start+m[4]+1, start+m[5]-1
+		switch name {
+		case "key":
+			e.key = content[valueStart:valueEnd]
+		case "value":
+			if strings.Contains(content[lineStart:valueEnd], "\n") {
+				return
+			}
+			e.start, e.end = valueStart-lineStart, valueEnd-lineStart
+			e.quote = content[valueStart-1]
+		}
+	}
+	if e.key != "" && e.start >= 0 {
+		d.entries = append(d.entries, e)
+	}
+}
+
+// maskXMLComments returns content with the XML comments replaced by spaces,
+// keeping the line breaks so that offsets and lines do not change.
+func maskXMLComments(content string) string {
+	b := []byte(content)
+	for from := 0; ; {
+		open := strings.Index(content[from:], "<!--")
+		if open < 0 {
+			break
+		}
+		open += from
+		end := len(content)
+		if close := strings.Index(content[open+4:], "-->"); close >= 0 {
+			end = open + 4 + close + 3
+		}
+		for i := open; i < end; i++ {
+			if b[i] != '\n' {
+				b[i] = ' '
+			}
+		}
+		from = end
+	}
+	return string(b)
+}
+
+// openScope opens the named scope at line, closing the scope that is still
+// open.
+func (d *configDocument) openScope(name string, line int) {
+	d.closeScope(line)
+	d.scopes = append(d.scopes, configScope{name: name, open: line, close: -1})
+}
+
+// closeScope closes the scope that is still open at line.
+func (d *configDocument) closeScope(line int) {
+	if s := d.openedScope(); s != nil {
+		s.close = line
+	}
+}
+
+// openedScope returns the scope that is still open, or nil.
+func (d *configDocument) openedScope() *configScope {
+	if n := len(d.scopes); n > 0 && d.scopes[n-1].close < 0 {
+		return &d.scopes[n-1]
+	}
+	return nil
+}
+
+// configScopeName returns the scope where the client library in lang reads
+// the given field of ConfigKeys, or "" when its configuration file has no
+// scopes.
+func configScopeName(lang, field string) string {
+	switch lang {
+	case "dotnet":
+		return "GoogleAdsApi"
+	case "ruby":
+		return rubyConfigBlock
+	case "php":
+		switch field {
+		case DevToken, "LoginCustomerID":
+			return "GOOGLE_ADS"
+		case "Endpoint":
+			return "CONNECTION"
+		}
+		return "OAUTH2"
+	}
+	return ""
+}
+
+// scopeAt returns the scope that contains line, or nil.
+func (d *configDocument) scopeAt(line int) *configScope {
+	for i, s := range d.scopes {
+		if line > s.open && line < s.close {
+			return &d.scopes[i]
+		}
+	}
+	return nil
+}
+
+// set sets the given field of ConfigKeys, which is named key in the
+// configuration file, to value. The value of each occurrence of key is
+// replaced in place. When key is not set, a line is added to the scope where
+// the client library reads it. In an ini file, the occurrences of key in
+// other sections are removed, since the client library does not read them.
+func (d *configDocument) set(field, key, value string) {
+	if d.lang == "php" {
+		d.removeMisplaced(field, key)
+	}
+	found := false
+	// Entries are in the order of the file, so editing from the last one
+	// keeps the offsets of the other entries on the same line valid.
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		e := d.entries[i]
+		if e.key != key {
+			continue
+		}
+		start, end := e.start, e.end
+		if e.quote != 0 {
+			start, end = start-1, end+1
+		}
+		line := d.lines[e.line]
+		d.lines[e.line] = line[:start] + d.formatValue(value, e.quote) + line[end:]
+		found = true
+	}
+	if !found {
+		d.add(field, key, value)
+	}
+}
+
+// add adds a line setting key to value after the last configuration key of
+// the scope of field, or at the start of the scope when it has none. A
+// missing scope is added as well.
+func (d *configDocument) add(field, key, value string) {
+	from, to, at := 0, d.tail, -1
+	indent := ""
+	if name := configScopeName(d.lang, field); name != "" {
+		s := d.scope(name)
+		if s == nil {
+			d.addScope(name, key, value)
+			return
+		}
+		from, to, at = s.open+1, s.close, s.open
+		indent = leadingSpace(d.lines[s.open])
+		if d.lang != "php" {
+			indent += "  "
+		}
+	}
+
+	known := swapMap(structs.Map(Languages[d.lang].Cfg.ConfigKeys))
+	var neighbor *configEntry
+	for i, e := range d.entries {
+		if _, ok := known[e.key]; ok && e.line >= from && e.line < to {
+			neighbor = &d.entries[i]
+		}
+	}
+	if neighbor != nil {
+		at = neighbor.line
+		indent = leadingSpace(d.lines[neighbor.line])
+	}
+	d.insertLines(at+1, indent+d.entryLine(key, value, neighbor))
+}
+
+// removeMisplaced removes the lines that set key outside of the scope where
+// the client library reads the given field.
+func (d *configDocument) removeMisplaced(field, key string) {
+	want := configScopeName(d.lang, field)
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		e := d.entries[i]
+		if s := d.scopeAt(e.line); e.key == key && (s == nil || s.name != want) {
+			d.removeLine(e.line)
+		}
+	}
+}
+
+// removeLine removes the line at index i and its entries.
+func (d *configDocument) removeLine(i int) {
+	d.lines = append(d.lines[:i], d.lines[i+1:]...)
+	entries := d.entries[:0]
+	for _, e := range d.entries {
+		if e.line == i {
+			continue
+		}
+		if e.line > i {
+			e.line--
+		}
+		entries = append(entries, e)
+	}
+	d.entries = entries
+	for j := range d.scopes {
+		if d.scopes[j].open > i {
+			d.scopes[j].open--
+		}
+		if d.scopes[j].close > i {
+			d.scopes[j].close--
+		}
+	}
+	if d.tail > i {
+		d.tail--
+	}
+}
+
+// scope returns the first scope with the given name, or nil.
+func (d *configDocument) scope(name string) *configScope {
+	for i := range d.scopes {
+		if d.scopes[i].name == name {
+			return &d.scopes[i]
+		}
+	}
+	return nil
+}
+
+// addScope adds the named scope with a line setting key to value.
+func (d *configDocument) addScope(name, key, value string) {
+	entry := d.entryLine(key, value, nil)
+	switch d.lang {
+	case "dotnet":
+		indent := "  "
+		if d.tail < len(d.lines) {
+			indent += leadingSpace(d.lines[d.tail])
+		}
+		d.insertLines(d.tail, indent+"<"+name+">", indent+"  "+entry, indent+"</"+name+">")
+	case "php":
+		d.appendLines("["+name+"]", entry)
+	case "ruby":
+		d.appendLines(rubyConfigBlock+" do |c|", "  "+entry, "end")
+	}
+}
+
+// appendLines adds lines at the end of the document, separated from the
+// previous content by a blank line.
+func (d *configDocument) appendLines(lines ...string) {
+	if n := len(d.lines); n > 0 && strings.TrimSpace(d.lines[n-1]) != "" {
+		lines = append([]string{""}, lines...)
+	}
+	d.insertLines(len(d.lines), lines...)
+}
+
+// insertLines inserts lines before the line at index i.
+func (d *configDocument) insertLines(i int, lines ...string) {
+	d.lines = append(d.lines[:i], append(lines, d.lines[i:]...)...)
+	n := len(lines)
+	for j := range d.entries {
+		if d.entries[j].line >= i {
+			d.entries[j].line += n
+		}
+	}
+	for j := range d.scopes {
+		if d.scopes[j].open >= i {
+			d.scopes[j].open += n
+		}
+		if d.scopes[j].close >= i {
+			d.scopes[j].close += n
+		}
+	}
+	if d.tail >= i {
+		d.tail += n
+	}
+}
+
+// entryLine returns a line, without indentation and line ending, setting key
+// to value. The separator and quotes follow the neighbor entry, if any.
+func (d *configDocument) entryLine(key, value string, neighbor *configEntry) string {
+	if d.lang == "dotnet" {
+		return "<add key=\"" + xmlEscape(key, '"') + "\" value=" + d.formatValue(value, '"') + "/>"
+	}
+
+	if d.lang == "ruby" && d.rubyVar != "" {
+		key = d.rubyVar + strings.TrimPrefix(key, "c")
+	}
+	sep := map[string]string{"java": "=", "python": ": ", "php": " = ", "ruby": " = ", "nodejs": "="}[d.lang]
+	var quote byte
+	if neighbor != nil {
+		sep, quote = neighbor.sep, neighbor.quote
+	}
+	return key + sep + d.formatValue(value, quote)
+}
+
+// formatValue returns value as it is written in the configuration file,
+// enclosed in quote when it is not 0. Quotes are added when the language
+// requires them.
+func (d *configDocument) formatValue(value string, quote byte) string {
+	switch d.lang {
+	case "dotnet":
+		return string(quote) + xmlEscape(value, quote) + string(quote)
+	case "java":
+		return strings.Replace(value, "\\", "\\\\", -1)
+	case "php":
+		// Single-quoted ini values cannot contain a single quote.
+		if quote == 0 || (quote == '\'' && strings.Contains(value, "'")) {
+			quote = '"'
+		}
+	case "ruby":
+		if quote == 0 {
+			quote = '"'
+		}
+	case "python":
+		if quote == 0 && yamlNeedsQuotes(value) {
+			quote = '"'
+		}
+	case "nodejs":
+		// Single-quoted .env values have no escapes.
+		if (quote == 0 && (value == "" || strings.ContainsAny(value, " \t#'\"\\"))) ||
+			(quote == '\'' && strings.Contains(value, "'")) {
+			quote = '"'
+		}
+	}
+
+	switch {
+	case quote == '"':
+		return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+	case quote == '\'' && d.lang == "python":
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	case quote == '\'' && d.lang == "nodejs":
+		return "'" + value + "'"
+	case quote == '\'':
+		return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(value) + "'"
+	}
+	return value
+}
+
+// yamlNeedsQuotes returns true when value cannot be written as a plain YAML
+// scalar, or would be read as a number, e.g. a login customer ID, see
+// yamlFindings.
+func yamlNeedsQuotes(value string) bool {
+	return value == "" || isDigits(value) || strings.TrimSpace(value) != value ||
+		strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.HasSuffix(value, ":") ||
+		strings.ContainsAny(value[:1], "!&*{}[]|>'\"%@`#,?")
+}
+
+// xmlEscape escapes value for an XML attribute enclosed in quote.
+func xmlEscape(value string, quote byte) string {
+	entity := "&quot;"
+	if quote == '\'' {
+		entity = "&apos;"
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", string(quote), entity).Replace(value)
+}
+
+// leadingSpace returns the indentation of line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// String returns the content of the document with its original line
+// endings.
+func (d *configDocument) String() string {
+	if len(d.lines) == 0 {
+		return ""
+	}
+	s := strings.Join(d.lines, d.newline)
+	if d.trailingNewline {
+		s += d.newline
+	}
+	return s
+}
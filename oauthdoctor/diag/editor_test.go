@@ -0,0 +1,251 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigDocumentSet(t *testing.T) {
+	tests := []struct {
+		desc    string
+		lang    string
+		key     string
+		val     string
+		content string
+		want    string
+	}{
+		{
+			desc:    "(PHP) Add a key after the last key of its section",
+			lang:    "php",
+			key:     RefreshToken,
+			val:     "new_token",
+			content: "[GOOGLE_ADS]\ndeveloperToken = \"tok\"\n\n[OAUTH2]\n; comment\nclientId = \"id\"\n\n[LOGGING]\n",
+			want:    "[GOOGLE_ADS]\ndeveloperToken = \"tok\"\n\n[OAUTH2]\n; comment\nclientId = \"id\"\nrefreshToken = \"new_token\"\n\n[LOGGING]\n",
+		},
+		{
+			desc:    "(PHP) Add a missing section",
+			lang:    "php",
+			key:     ClientID,
+			val:     "new_id",
+			content: "[GOOGLE_ADS]\ndeveloperToken = \"tok\"\n",
+			want:    "[GOOGLE_ADS]\ndeveloperToken = \"tok\"\n\n[OAUTH2]\nclientId = \"new_id\"\n",
+		},
+		{
+			desc:    "(PHP) Move a key from the wrong section",
+			lang:    "php",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "developerToken = \"top\"\n[GOOGLE_ADS]\nloginCustomerId = \"1\"\n\n[OAUTH2]\ndeveloperToken = \"tok\"\nclientId = \"id\"\n",
+			want:    "[GOOGLE_ADS]\nloginCustomerId = \"1\"\ndeveloperToken = \"new_tok\"\n\n[OAUTH2]\nclientId = \"id\"\n",
+		},
+		{
+			desc:    "(PHP) Keep an inline comment",
+			lang:    "php",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "[GOOGLE_ADS]\ndeveloperToken = old_tok ; comment\n",
+			want:    "[GOOGLE_ADS]\ndeveloperToken = \"new_tok\" ; comment\n",
+		},
+		{
+			desc:    "(Ruby) Replace a value on the line after its key",
+			lang:    "ruby",
+			key:     RefreshToken,
+			val:     "new_token",
+			content: "Google::Ads::GoogleAds::Config.new do |c|\n  c.refresh_token =\n    'old_token'\nend\n",
+			want:    "Google::Ads::GoogleAds::Config.new do |c|\n  c.refresh_token =\n    'new_token'\nend\n",
+		},
+		{
+			desc:    "(Ruby) Replace a %q string",
+			lang:    "ruby",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "Google::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = %q(old_tok).freeze\nend\n",
+			want:    "Google::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = \"new_tok\".freeze\nend\n",
+		},
+		{
+			desc:    "(Ruby) Add a key with the block variable",
+			lang:    "ruby",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "Google::Ads::GoogleAds::Config.new do |config|\n  config.client_id = 'id'\nend\n",
+			want:    "Google::Ads::GoogleAds::Config.new do |config|\n  config.client_id = 'id'\n  config.developer_token = 'new_tok'\nend\n",
+		},
+		{
+			desc: "(Ruby) Add a key inside the config block with the same quotes",
+			lang: "ruby",
+			key:  DevToken,
+			val:  "new_tok",
+			content: "Google::Ads::GoogleAds::Config.new do |c|\n  c.client_id = 'id'\n" +
+				"  c.log_target = STDOUT\nend\n",
+			want: "Google::Ads::GoogleAds::Config.new do |c|\n  c.client_id = 'id'\n" +
+				"  c.developer_token = 'new_tok'\n  c.log_target = STDOUT\nend\n",
+		},
+		{
+			desc:    "(Ruby) Add a key to an empty config block",
+			lang:    "ruby",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "# comment\nGoogle::Ads::GoogleAds::Config.new do |c|\nend\n",
+			want:    "# comment\nGoogle::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = \"new_tok\"\nend\n",
+		},
+		{
+			desc:    "(Ruby) Quote an expression",
+			lang:    "ruby",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "Google::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = ENV['TOKEN'] # from env\nend\n",
+			want:    "Google::Ads::GoogleAds::Config.new do |c|\n  c.developer_token = \"new_tok\" # from env\nend\n",
+		},
+		{
+			desc:    "(Python) Keep quotes and inline comments",
+			lang:    "python",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "developer_token: 'old_tok' # comment\n",
+			want:    "developer_token: 'new_tok' # comment\n",
+		},
+		{
+			desc:    "(Python) Quote a value that is not a plain scalar",
+			lang:    "python",
+			key:     ClientSecret,
+			val:     "a: \"b\"",
+			content: "client_secret: old\n",
+			want:    "client_secret: \"a: \\\"b\\\"\"\n",
+		},
+		{
+			desc:    "(Python) Quote a number",
+			lang:    "python",
+			key:     "LoginCustomerID",
+			val:     "1234567890",
+			content: "login_customer_id: 123-456-7890\n",
+			want:    "login_customer_id: \"1234567890\"\n",
+		},
+		{
+			desc:    "(Python) Add a key after the last config key",
+			lang:    "python",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "client_id: id\nlogging:\n  version: 1\n",
+			want:    "client_id: id\ndeveloper_token: new_tok\nlogging:\n  version: 1\n",
+		},
+		{
+			desc:    "(Python) Add a key to an empty file",
+			lang:    "python",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "",
+			want:    "developer_token: new_tok\n",
+		},
+		{
+			desc:    "(Python) Replace every occurrence",
+			lang:    "python",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "developer_token: old\nclient_id: id\ndeveloper_token: older",
+			want:    "developer_token: new_tok\nclient_id: id\ndeveloper_token: new_tok",
+		},
+		{
+			desc:    "(Node.js) Replace an exported variable",
+			lang:    "nodejs",
+			key:     ClientSecret,
+			val:     "new secret",
+			content: "export GOOGLE_ADS_CLIENT_SECRET='old' # comment\n",
+			want:    "export GOOGLE_ADS_CLIENT_SECRET='new secret' # comment\n",
+		},
+		{
+			desc:    "(Node.js) Quote a value with spaces",
+			lang:    "nodejs",
+			key:     DevToken,
+			val:     "new tok",
+			content: "GOOGLE_ADS_CLIENT_ID=id\nDATABASE_URL=db\n",
+			want:    "GOOGLE_ADS_CLIENT_ID=id\nGOOGLE_ADS_DEVELOPER_TOKEN=\"new tok\"\nDATABASE_URL=db\n",
+		},
+		{
+			desc:    "(Java) Follow the separator of the other keys",
+			lang:    "java",
+			key:     DevToken,
+			val:     "new_tok",
+			content: "# comment\napi.googleads.clientId = id\n",
+			want:    "# comment\napi.googleads.clientId = id\napi.googleads.developerToken = new_tok\n",
+		},
+		{
+			desc: "(.NET) Add a missing GoogleAdsApi element",
+			lang: "dotnet",
+			key:  DevToken,
+			val:  "new_tok",
+			content: "<configuration>\n  <appSettings>\n    <add key=\"DeveloperToken\" value=\"app\"/>\n" +
+				"  </appSettings>\n</configuration>\n",
+			want: "<configuration>\n  <appSettings>\n    <add key=\"DeveloperToken\" value=\"app\"/>\n" +
+				"  </appSettings>\n  <GoogleAdsApi>\n    <add key=\"DeveloperToken\" value=\"new_tok\"/>\n" +
+				"  </GoogleAdsApi>\n</configuration>\n",
+		},
+		{
+			desc: "(.NET) Escape the value and keep the other elements on the line",
+			lang: "dotnet",
+			key:  DevToken,
+			val:  "new&tok",
+			content: "<configuration>\n  <GoogleAdsApi>\n" +
+				"    <add key=\"OAuth2ClientId\" value=\"id\"/><add value='old' key=\"DeveloperToken\"/>\n" +
+				"  </GoogleAdsApi>\n</configuration>\n",
+			want: "<configuration>\n  <GoogleAdsApi>\n" +
+				"    <add key=\"OAuth2ClientId\" value=\"id\"/><add value='new&amp;tok' key=\"DeveloperToken\"/>\n" +
+				"  </GoogleAdsApi>\n</configuration>\n",
+		},
+	}
+
+	for _, test := range tests {
+		cfg := ConfigFile{Lang: test.lang}
+		doc := parseConfigDocument(test.lang, test.content)
+		doc.set(test.key, cfg.GetConfigKeysInLang(test.key), test.val)
+
+		if got := doc.String(); got != test.want {
+			t.Errorf("%s\ngot: %q\nwant: %q", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestConfigDocumentSetYAMLFindings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc    string
+		key     string
+		val     string
+		content string
+	}{
+		{desc: "Replace a login customer ID", key: "LoginCustomerID", val: "1234567890", content: "login_customer_id: 123-456-7890\n"},
+		{desc: "Add a login customer ID", key: "LoginCustomerID", val: "0123456789", content: "client_id: id\n"},
+		{desc: "Replace a developer token", key: DevToken, val: "12345", content: "developer_token: 'old'\n"},
+	}
+
+	for _, tt := range tests {
+		cfg := ConfigFile{Lang: "python", Filepath: dir, Filename: "google-ads.yaml"}
+		doc := parseConfigDocument(cfg.Lang, tt.content)
+		doc.set(tt.key, cfg.GetConfigKeysInLang(tt.key), tt.val)
+		if err := ioutil.WriteFile(filepath.Join(dir, cfg.Filename), []byte(doc.String()), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if findings := cfg.yamlFindings(); len(findings) > 0 {
+			t.Errorf("[%s] yamlFindings() of %q got: %v, want none", tt.desc, doc.String(), findings)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// textEncoding describes how the text of a configuration file is stored on
+// disk, so that a rewritten file can be stored the same way. Visual Studio,
+// for example, may save App.config as UTF-16 with a byte order mark.
+type textEncoding struct {
+	// bom is the byte order mark at the start of the file, if any.
+	bom       []byte
+	utf16     bool
+	bigEndian bool
+}
+
+// String returns the name of the encoding.
+func (e textEncoding) String() string {
+	name := "UTF-8"
+	if e.utf16 {
+		name = "UTF-16LE"
+		if e.bigEndian {
+			name = "UTF-16BE"
+		}
+	}
+	if len(e.bom) > 0 {
+		name += " with BOM"
+	}
+	return name
+}
+
+// detectEncoding determines the encoding of b from its byte order mark. When
+// there is none, UTF-16 is recognized by the zero bytes of ASCII characters.
+func detectEncoding(b []byte) textEncoding {
+	switch {
+	case bytes.HasPrefix(b, utf8BOM):
+		return textEncoding{bom: utf8BOM}
+	case bytes.HasPrefix(b, utf16LEBOM):
+		return textEncoding{bom: utf16LEBOM, utf16: true}
+	case bytes.HasPrefix(b, utf16BEBOM):
+		return textEncoding{bom: utf16BEBOM, utf16: true, bigEndian: true}
+	case len(b) >= 2 && b[0] != 0 && b[1] == 0:
+		return textEncoding{utf16: true}
+	case len(b) >= 2 && b[0] == 0 && b[1] != 0:
+		return textEncoding{utf16: true, bigEndian: true}
+	}
+	return textEncoding{}
+}
+
+// decodeText converts the content of a file to a UTF-8 string without a byte
+// order mark and returns the detected encoding.
+func decodeText(b []byte) (string, textEncoding, error) {
+	enc := detectEncoding(b)
+	b = b[len(enc.bom):]
+	if !enc.utf16 {
+		return string(b), enc, nil
+	}
+
+	if len(b)%2 != 0 {
+		return "", enc, i18n.Errorf("Invalid %s content: odd number of bytes", enc)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if enc.bigEndian {
+		order = binary.BigEndian
+	}
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = order.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units)), enc, nil
+}
+
+// encode converts a UTF-8 string to bytes in this encoding, including the
+// byte order mark.
+func (e textEncoding) encode(s string) []byte {
+	buf := bytes.NewBuffer(append([]byte{}, e.bom...))
+	if !e.utf16 {
+		buf.WriteString(s)
+		return buf.Bytes()
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if e.bigEndian {
+		order = binary.BigEndian
+	}
+	unit := make([]byte, 2)
+	for _, u := range utf16.Encode([]rune(s)) {
+		order.PutUint16(unit, u)
+		buf.Write(unit)
+	}
+	return buf.Bytes()
+}
+
+// readTextFile reads a file and returns its content as a UTF-8 string along
+// with the encoding of the file. The file is decrypted with DecryptCommand,
+// if set.
+func (r *Reader) readTextFile(path string) (string, textEncoding, error) {
+	b, err := ioutil.ReadFile(path)
+	if err == nil && r.encrypted() {
+		b, err = r.decrypt(b)
+	}
+	if err != nil {
+		return "", textEncoding{}, err
+	}
+	s, enc, err := decodeText(b)
+	// Text never has NUL characters, which also stop the parsers of the
+	// client libraries.
+	if err == nil && strings.ContainsRune(s, 0) {
+		return "", enc, i18n.Errorf("%s is a binary file, not a text file", path)
+	}
+	return s, enc, err
+}
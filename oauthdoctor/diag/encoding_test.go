@@ -0,0 +1,137 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    string
+		wantEnc string
+		errstr  string
+	}{
+		{
+			desc:    "Plain UTF-8",
+			input:   []byte("key=value\n"),
+			want:    "key=value\n",
+			wantEnc: "UTF-8",
+		},
+		{
+			desc:    "UTF-8 with BOM",
+			input:   []byte("\xEF\xBB\xBFkey=value\n"),
+			want:    "key=value\n",
+			wantEnc: "UTF-8 with BOM",
+		},
+		{
+			desc:    "UTF-16LE with BOM",
+			input:   []byte("\xFF\xFEk\x00=\x00v\x00\r\x00\n\x00"),
+			want:    "k=v\r\n",
+			wantEnc: "UTF-16LE with BOM",
+		},
+		{
+			desc:    "UTF-16BE with BOM",
+			input:   []byte("\xFE\xFF\x00k\x00=\x00v"),
+			want:    "k=v",
+			wantEnc: "UTF-16BE with BOM",
+		},
+		{
+			desc:    "UTF-16LE without BOM",
+			input:   []byte("<\x00?\x00"),
+			want:    "<?",
+			wantEnc: "UTF-16LE",
+		},
+		{
+			desc:    "Truncated UTF-16",
+			input:   []byte("\xFF\xFEk\x00="),
+			wantEnc: "UTF-16LE with BOM",
+			errstr:  "odd number of bytes",
+		},
+	}
+
+	for _, test := range tests {
+		got, enc, err := decodeText(test.input)
+
+		if !strings.Contains(errstring(err), test.errstr) || (err == nil) != (test.errstr == "") {
+			t.Errorf("%s\ndecodeText(%q) error: %s, want: %s", test.desc, test.input, errstring(err), test.errstr)
+		}
+		if got != test.want {
+			t.Errorf("%s\ndecodeText(%q) = %q, want: %q", test.desc, test.input, got, test.want)
+		}
+		if enc.String() != test.wantEnc {
+			t.Errorf("%s\ndecodeText(%q) encoding = %s, want: %s", test.desc, test.input, enc, test.wantEnc)
+		}
+		if err == nil && !bytes.Equal(enc.encode(got), test.input) {
+			t.Errorf("%s\nencode(%q) = %q, want: %q", test.desc, got, enc.encode(got), test.input)
+		}
+	}
+}
+
+func TestReplaceConfigKeepsEncoding(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig, err := ioutil.ReadFile(filepath.Join("testdata", "dotnet_config_utf16"))
+	if err != nil {
+		t.Fatalf("Error reading test config: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "App.config"), orig, 0644); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	cfg := ConfigFile{Filepath: dir, Filename: "App.config", Lang: "dotnet"}
+	if _, err := cfg.ReplaceConfig(DevToken, "NewDevToken"); err != nil {
+		t.Fatalf("ReplaceConfig() returned error: %s", err)
+	}
+
+	got, err := ioutil.ReadFile(cfg.GetFilepath())
+	if err != nil {
+		t.Fatalf("Error reading new config: %s", err)
+	}
+	if !bytes.HasPrefix(got, utf16LEBOM) {
+		t.Errorf("ReplaceConfig() dropped the UTF-16LE BOM: % x", got[:4])
+	}
+
+	content, enc, err := decodeText(got)
+	if err != nil {
+		t.Fatalf("Error decoding new config: %s", err)
+	}
+	if enc.String() != "UTF-16LE with BOM" {
+		t.Errorf("ReplaceConfig() wrote %s, want: UTF-16LE with BOM", enc)
+	}
+	if !strings.Contains(content, `value="NewDevToken"/>`+"\r\n") {
+		t.Errorf("ReplaceConfig() did not write the new value with CRLF:\n%s", content)
+	}
+
+	// The test config points to a missing service account key, so only the
+	// parsed keys are checked.
+	parsed, _ := ParseXMLFile(cfg.GetFilepath(), InstalledApp)
+	if parsed.DevToken != "NewDevToken" {
+		t.Errorf("ParseXMLFile() DevToken = %s, want: NewDevToken", parsed.DevToken)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// DefaultEndpoint is the Google Ads API endpoint used when neither the
+// command line nor the configuration file sets one.
+const DefaultEndpoint = "https://googleads.googleapis.com"
+
+// ParseEndpoint parses a Google Ads API endpoint, which is either a URL or a
+// host name with an optional port, e.g. localhost:8080. HTTPS is assumed
+// when no scheme is given, and DefaultEndpoint is returned for an empty
+// string. The returned URL has no trailing slash.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, i18n.Errorf("Invalid endpoint %s: %s", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, i18n.Errorf("Invalid endpoint %s: the scheme must be http or https", endpoint)
+	}
+	if u.Hostname() == "" {
+		return nil, i18n.Errorf("Invalid endpoint %s: missing host name", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// EndpointAddr returns the host:port of the endpoint, using the default
+// port of its scheme when the URL has none.
+func EndpointAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		desc     string
+		endpoint string
+		want     string
+		wantAddr string
+		errstr   string
+	}{
+		{
+			desc:     "Empty endpoint is the default one",
+			endpoint: "",
+			want:     "https://googleads.googleapis.com",
+			wantAddr: "googleads.googleapis.com:443",
+			errstr:   "nil",
+		},
+		{
+			desc:     "Host name without scheme uses HTTPS",
+			endpoint: "ads.example.com",
+			want:     "https://ads.example.com",
+			wantAddr: "ads.example.com:443",
+			errstr:   "nil",
+		},
+		{
+			desc:     "HTTP endpoint with port",
+			endpoint: "http://localhost:8080/",
+			want:     "http://localhost:8080",
+			wantAddr: "localhost:8080",
+			errstr:   "nil",
+		},
+		{
+			desc:     "HTTP endpoint without port",
+			endpoint: "http://proxy.internal",
+			want:     "http://proxy.internal",
+			wantAddr: "proxy.internal:80",
+			errstr:   "nil",
+		},
+		{
+			desc:     "Unsupported scheme",
+			endpoint: "ftp://ads.example.com",
+			errstr:   "the scheme must be http or https",
+		},
+		{
+			desc:     "Missing host name",
+			endpoint: "https://",
+			errstr:   "missing host name",
+		},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseEndpoint(tt.endpoint)
+		if errstring(err) != "nil" {
+			if !strings.Contains(errstring(err), tt.errstr) {
+				t.Errorf("[%s] ParseEndpoint(%q) error: %s, want: %s", tt.desc, tt.endpoint, errstring(err), tt.errstr)
+			}
+			continue
+		}
+		if tt.errstr != "nil" {
+			t.Errorf("[%s] ParseEndpoint(%q) error: nil, want: %s", tt.desc, tt.endpoint, tt.errstr)
+			continue
+		}
+		if got := u.String(); got != tt.want {
+			t.Errorf("[%s] ParseEndpoint(%q) = %s, want: %s", tt.desc, tt.endpoint, got, tt.want)
+		}
+		if got := EndpointAddr(u); got != tt.wantAddr {
+			t.Errorf("[%s] EndpointAddr(%s) = %s, want: %s", tt.desc, u, got, tt.wantAddr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// replaceFile replaces the content of the file at path with data, after
+// saving a copy of the original file to backup. The new content is written
+// to a temp file in the same directory and renamed over the original, so the
+// config file is never left half-written. The file mode and, where
+// supported, ownership of the original file are preserved.
+func replaceFile(path, backup string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return i18n.Errorf("ERROR: Problem opening config file: %s", err)
+	}
+
+	if err := copyFile(path, backup, info.Mode()); err != nil {
+		return i18n.Errorf("ERROR: Cannot back up config file from (%s) to (%s): %s", path, backup, err)
+	}
+
+	tmpfile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return i18n.Errorf("ERROR: Problem creating temp file: %s", err)
+	}
+	tmpName := tmpfile.Name()
+	defer os.Remove(tmpName)
+
+	if err := writeAndSync(tmpfile, data); err != nil {
+		return i18n.Errorf("ERROR: Cannot write to temp config file (%s): %s", tmpName, err)
+	}
+	if err := os.Chmod(tmpName, info.Mode().Perm()); err != nil {
+		return i18n.Errorf("ERROR: Cannot write to temp config file (%s): %s", tmpName, err)
+	}
+	// Changing the owner requires privileges the user may not have, in which
+	// case the new file is owned by the user running the doctor.
+	chown(tmpName, info)
+
+	if err := os.Rename(tmpName, path); err != nil {
+		// Renaming fails on Windows when another process has the config file
+		// open, so overwrite the original file in place instead.
+		if cErr := copyFile(tmpName, path, info.Mode()); cErr != nil {
+			return i18n.Errorf("ERROR: Cannot rename config file from (%s) to (%s): %s", tmpName, path, cErr)
+		}
+	}
+	return nil
+}
+
+// restoreFile replaces the file at path with its backup, which is removed.
+func restoreFile(path, backup string) error {
+	if err := os.Rename(backup, path); err != nil {
+		// Like in replaceFile, renaming fails on Windows when another process
+		// has the config file open.
+		info, sErr := os.Stat(backup)
+		if sErr != nil {
+			return sErr
+		}
+		if cErr := copyFile(backup, path, info.Mode()); cErr != nil {
+			return cErr
+		}
+		return os.Remove(backup)
+	}
+	return nil
+}
+
+// copyFile copies the content of src to dst, creating dst with the given
+// mode if it does not exist.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeAndSync writes data to f, flushes it to disk and closes f.
+func writeAndSync(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReplaceFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "google-ads.yaml")
+	backup := path + "_backup"
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	if err := replaceFile(path, backup, []byte("new")); err != nil {
+		t.Fatalf("replaceFile() returned error: %s", err)
+	}
+
+	if got, _ := ioutil.ReadFile(path); string(got) != "new" {
+		t.Errorf("replaceFile() wrote %q, want: %q", got, "new")
+	}
+	if got, _ := ioutil.ReadFile(backup); string(got) != "old" {
+		t.Errorf("replaceFile() backup is %q, want: %q", got, "old")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Error reading new config: %s", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("replaceFile() set mode %v, want: %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Error reading temp dir: %s", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("replaceFile() left %d files in %s, want: 2", len(files), dir)
+	}
+}
+
+func TestReplaceFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "google-ads.yaml")
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	tests := []struct {
+		desc   string
+		path   string
+		backup string
+		errstr string
+	}{
+		{
+			desc:   "Config file does not exist",
+			path:   filepath.Join(dir, "missing"),
+			backup: filepath.Join(dir, "missing_backup"),
+			errstr: "Problem opening config file",
+		},
+		{
+			desc:   "Backup cannot be written",
+			path:   path,
+			backup: filepath.Join(dir, "nodir", "backup"),
+			errstr: "Cannot back up config file",
+		},
+	}
+
+	for _, test := range tests {
+		err := replaceFile(test.path, test.backup, []byte("new"))
+		if !strings.Contains(errstring(err), test.errstr) {
+			t.Errorf("%s\nreplaceFile(%s) error: %s, want: %s", test.desc, test.path, errstring(err), test.errstr)
+		}
+	}
+
+	if got, _ := ioutil.ReadFile(path); string(got) != "old" {
+		t.Errorf("replaceFile() modified the config file after a failed backup: %q", got)
+	}
+}
+
+func TestReplaceConfigMissingFile(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	cfg := ConfigFile{Filepath: "testdata", Filename: "does_not_exist", Lang: "python"}
+	backup, err := cfg.ReplaceConfig(DevToken, "NewDevToken")
+	if err == nil {
+		t.Errorf("ReplaceConfig() on a missing file returned backup %s and no error", backup)
+	}
+	if cfg.DevToken != "" {
+		t.Errorf("ReplaceConfig() on a missing file updated DevToken to %s", cfg.DevToken)
+	}
+}
+
+// messages is a Printer that keeps the printed messages.
+type messages []string
+
+func (m *messages) Print(msg string) {
+	*m = append(*m, msg)
+}
+
+func TestReplaceConfigRestoresInvalidFile(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig := "developer_token: OldDevToken\nclient_id: ClientID\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "google-ads.yaml"), []byte(orig), 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	tests := []struct {
+		desc   string
+		key    string
+		value  string
+		errstr string
+	}{
+		{
+			desc:   "Value breaks the syntax of the file",
+			key:    DevToken,
+			value:  "NewDevToken\nclient_id: Other",
+			errstr: "was invalid",
+		},
+		{
+			desc:   "Value fails a validation",
+			key:    "LoginCustomerID",
+			value:  "123-456-7890",
+			errstr: "LoginCustomerID cannot have dashes",
+		},
+	}
+
+	for _, test := range tests {
+		var out messages
+		cfg := ConfigFile{Filepath: dir, Filename: "google-ads.yaml", Lang: "python", Out: &out}
+		cfg.DevToken = "OldDevToken"
+
+		backup, err := cfg.ReplaceConfig(test.key, test.value)
+		if !strings.Contains(errstring(err), test.errstr) {
+			t.Errorf("%s\nReplaceConfig() error: %s, want: %s", test.desc, errstring(err), test.errstr)
+		}
+		if backup != "" {
+			t.Errorf("%s\nReplaceConfig() returned backup %s for a restored file", test.desc, backup)
+		}
+		if cfg.DevToken != "OldDevToken" {
+			t.Errorf("%s\nReplaceConfig() updated DevToken to %q", test.desc, cfg.DevToken)
+		}
+		if got, _ := ioutil.ReadFile(cfg.GetFilepath()); string(got) != orig {
+			t.Errorf("%s\nReplaceConfig() left %q, want: %q", test.desc, got, orig)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("%s\nReplaceConfig() left %d files in %s, want: 1", test.desc, len(files), dir)
+		}
+		for _, want := range []string{"Backing up config file", "Creating a new config file", "Restoring config file"} {
+			if !strings.Contains(strings.Join(out, "\n"), want) {
+				t.Errorf("%s\nReplaceConfig() printed %q, want substring: %s", test.desc, out, want)
+			}
+		}
+	}
+}
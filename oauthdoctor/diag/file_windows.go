@@ -0,0 +1,26 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import "os"
+
+// chown is a no-op on Windows, where a new file inherits the permissions of
+// its directory.
+func chown(path string, info os.FileInfo) {}
+
+// ownedByOther is always false on Windows, where the access to a file is
+// set by its permissions instead of its owner.
+func ownedByOther(info os.FileInfo) bool {
+	return false
+}
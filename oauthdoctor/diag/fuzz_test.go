@@ -0,0 +1,92 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package diag
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keyValueLanguages are the languages with a key-value configuration file.
+var keyValueLanguages = []string{"java", "nodejs", "php", "python", "ruby"}
+
+// addTestdata adds the files of testdata whose names match pattern to the
+// seed corpus of f.
+func addTestdata(f *testing.F, pattern string) {
+	paths, err := filepath.Glob(filepath.Join("testdata", pattern))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(content))
+	}
+}
+
+func FuzzScanKeyValues(f *testing.F) {
+	addTestdata(f, "*_config*")
+	f.Fuzz(func(t *testing.T, content string) {
+		lines := strings.Count(content, "\n") + 1
+		for _, lang := range keyValueLanguages {
+			c := ConfigFile{Lang: lang}
+			occurrences, _, _ := c.scanKeyValues(content)
+			for _, o := range occurrences {
+				if o.Line < 1 || o.Line > lines {
+					t.Errorf("%s: key %q on line %d of %d", lang, o.Key, o.Line, lines)
+				}
+			}
+			// A document is written with the line ending of its first line.
+			got := parseConfigDocument(lang, content).String()
+			if unixLines(got) != unixLines(content) {
+				t.Errorf("%s: parseConfigDocument() changed the content to %q", lang, got)
+			}
+		}
+	})
+}
+
+// unixLines replaces the Windows line endings of s.
+func unixLines(s string) string {
+	return strings.Replace(s, "\r\n", "\n", -1)
+}
+
+func FuzzScanDotNetXML(f *testing.F) {
+	addTestdata(f, "dotnet_config*")
+	f.Fuzz(func(t *testing.T, content string) {
+		occurrences, err := scanDotNetXML(content)
+		if err != nil && len(occurrences) > 0 {
+			t.Errorf("scanDotNetXML() returned %d keys with error %s", len(occurrences), err)
+		}
+	})
+}
+
+func FuzzScanJSON(f *testing.F) {
+	addTestdata(f, "*.json")
+	f.Fuzz(func(t *testing.T, content string) {
+		doc, err := scanJSON(content)
+		if err != nil {
+			return
+		}
+		known := Languages["python"].Cfg.ConfigKeys
+		c := ConfigFile{Lang: "python", Format: JSONFormat}
+		doc.set(c.knownKeys(), DevToken, known.DevToken)
+	})
+}
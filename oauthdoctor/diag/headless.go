@@ -0,0 +1,66 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Kinds of headless sessions, in which the browser and the clipboard of the
+// user are on another machine.
+const (
+	// SSHSession is a remote shell opened with SSH.
+	SSHSession = "ssh"
+	// NoDisplay is a Unix session without an X11 or Wayland display.
+	NoDisplay = "nodisplay"
+	// ServiceSession is a Windows service, which has no desktop.
+	ServiceSession = "service"
+)
+
+// Headless returns the kind of headless session this program runs in, or an
+// empty string in a desktop session.
+func Headless() string {
+	return headless(runtime.GOOS, os.Getenv)
+}
+
+func headless(goos string, getenv func(string) string) string {
+	if getenv("SSH_CONNECTION") != "" || getenv("SSH_CLIENT") != "" || getenv("SSH_TTY") != "" {
+		return SSHSession
+	}
+	switch goos {
+	case "windows":
+		// Services run in session 0, named Services, often as the
+		// LocalSystem account, whose user name is the machine name with $.
+		if getenv("SESSIONNAME") == "Services" || strings.HasSuffix(getenv("USERNAME"), "$") {
+			return ServiceSession
+		}
+	case "darwin", "android", "ios":
+	default:
+		if getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+			return NoDisplay
+		}
+	}
+	return ""
+}
+
+// SSHServer returns the address of this machine in the SSH session, e.g.
+// 10.0.0.5, or an empty string outside an SSH session.
+func SSHServer() string {
+	if f := strings.Fields(os.Getenv("SSH_CONNECTION")); len(f) == 4 {
+		return f[2]
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import "testing"
+
+func TestHeadless(t *testing.T) {
+	tests := []struct {
+		desc string
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{
+			desc: "Linux desktop",
+			goos: "linux",
+			env:  map[string]string{"DISPLAY": ":0"},
+			want: "",
+		},
+		{
+			desc: "Wayland desktop",
+			goos: "linux",
+			env:  map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+			want: "",
+		},
+		{
+			desc: "Linux server",
+			goos: "linux",
+			want: NoDisplay,
+		},
+		{
+			desc: "SSH with X11 forwarding",
+			goos: "linux",
+			env:  map[string]string{"DISPLAY": "localhost:10.0", "SSH_CONNECTION": "10.0.0.1 50000 10.0.0.5 22"},
+			want: SSHSession,
+		},
+		{
+			desc: "macOS",
+			goos: "darwin",
+			want: "",
+		},
+		{
+			desc: "SSH to macOS",
+			goos: "darwin",
+			env:  map[string]string{"SSH_TTY": "/dev/ttys001"},
+			want: SSHSession,
+		},
+		{
+			desc: "Windows desktop",
+			goos: "windows",
+			env:  map[string]string{"SESSIONNAME": "Console", "USERNAME": "alice"},
+			want: "",
+		},
+		{
+			desc: "Windows service",
+			goos: "windows",
+			env:  map[string]string{"USERNAME": "BUILD01$"},
+			want: ServiceSession,
+		},
+	}
+
+	for _, tt := range tests {
+		getenv := func(k string) string {
+			return tt.env[k]
+		}
+		if got := headless(tt.goos, getenv); got != tt.want {
+			t.Errorf("[%s] headless() = %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
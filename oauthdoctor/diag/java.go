@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// javaOptionsVars are the environment variables whose -D options set system
+// properties of the JVM, either directly or through a build tool.
+var javaOptionsVars = []string{"JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS", "_JAVA_OPTIONS", "JAVA_OPTS", "MAVEN_OPTS", "GRADLE_OPTS"}
+
+// JavaSource is a value of ConfigKeys that the Java client library reads
+// from an environment variable or a system property instead of
+// ads.properties.
+type JavaSource struct {
+	Field string
+	Value string
+	// Source describes where the value is set, e.g. "environment variable
+	// GOOGLE_ADS_CLIENT_ID".
+	Source string
+	// Method is the method of GoogleAdsClient.Builder that reads the source.
+	Method string
+}
+
+// JavaSources returns the values of ConfigKeys set by the GOOGLE_ADS_*
+// environment variables and by the -D system properties in the options of
+// the JVM, in this order.
+func (r *Reader) JavaSources() []JavaSource {
+	fields := structs.Names(ConfigKeys{})
+	var sources []JavaSource
+	env := structs.New(Languages[RESTLanguage].Cfg.ConfigKeys)
+	for _, field := range fields {
+		name := env.Field(field).Value().(string)
+		if v, source := r.getenv(name); v != "" {
+			sources = append(sources, JavaSource{Field: field, Value: v,
+				Source: source, Method: "fromEnvironment()"})
+		}
+	}
+
+	known := swapMap(structs.Map(Languages["java"].Cfg.ConfigKeys))
+	for _, name := range javaOptionsVars {
+		for _, opt := range strings.Fields(os.Getenv(name)) {
+			if !strings.HasPrefix(opt, "-D") {
+				continue
+			}
+			kv := strings.SplitN(strings.TrimPrefix(opt, "-D"), "=", 2)
+			field, ok := known[kv[0]]
+			if !ok || len(kv) < 2 {
+				continue
+			}
+			sources = append(sources, JavaSource{Field: field, Value: strings.Trim(kv[1], "\"'"),
+				Source: i18n.Sprintf("system property %s in %s", kv[0], name), Method: "fromSystemProperties()"})
+		}
+	}
+	return sources
+}
+
+// JavaSources returns the values of ConfigKeys set by the environment
+// variables and the options of the JVM with the zero Reader.
+func JavaSources() []JavaSource {
+	return (*Reader)(nil).JavaSources()
+}
+
+// MergeJavaSources compares the values of sources with those of the
+// configuration file. The values missing from the file are taken from
+// sources. It returns warnings about the values that differ, or that the
+// client library only reads when the code loads their source, since the
+// source loaded last silently overrides the others.
+func (c *ConfigFile) MergeJavaSources(sources []JavaSource) []string {
+	file := c.ConfigKeys
+	var warnings []string
+	for _, s := range sources {
+		key := structs.New(file).Field(s.Field).Value().(string)
+		switch {
+		case key == "":
+			c.SetConfigKeys(s.Field, s.Value)
+			c.SetSource(s.Field, s.Source)
+			warnings = append(warnings, i18n.Sprintf("%s is not in the config file, so the value of the %s is used. "+
+				"The client library only reads it when your code calls GoogleAdsClient.newBuilder().%s.",
+				s.Field, s.Source, s.Method))
+		case key != s.Value:
+			warnings = append(warnings, i18n.Sprintf("%s in the config file differs from the %s. The client library "+
+				"uses the source loaded last: with GoogleAdsClient.newBuilder().fromPropertiesFile().fromEnvironment()"+
+				".fromSystemProperties(), the system properties override the environment variables, which override "+
+				"the config file. The doctor tests the value of the config file.", s.Field, s.Source))
+		}
+	}
+	return warnings
+}
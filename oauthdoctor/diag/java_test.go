@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/structs"
+)
+
+func TestJavaSources(t *testing.T) {
+	for _, name := range structs.Values(Languages[RESTLanguage].Cfg.ConfigKeys) {
+		if v, ok := os.LookupEnv(name.(string)); ok {
+			defer os.Setenv(name.(string), v)
+			os.Unsetenv(name.(string))
+		}
+	}
+	for _, name := range javaOptionsVars {
+		if v, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, v)
+			os.Unsetenv(name)
+		}
+	}
+	os.Setenv("GOOGLE_ADS_CLIENT_SECRET", "EnvClientSecret")
+	defer os.Unsetenv("GOOGLE_ADS_CLIENT_SECRET")
+	os.Setenv("GOOGLE_ADS_LOGIN_CUSTOMER_ID", "1234567890")
+	defer os.Unsetenv("GOOGLE_ADS_LOGIN_CUSTOMER_ID")
+	os.Setenv("JAVA_TOOL_OPTIONS", "-Xmx1g -Dapi.googleads.refreshToken='PropRefreshToken' -Dfile.encoding=UTF-8")
+	defer os.Unsetenv("JAVA_TOOL_OPTIONS")
+
+	sources := JavaSources()
+	want := []JavaSource{
+		{Field: ClientSecret, Value: "EnvClientSecret", Source: "environment variable GOOGLE_ADS_CLIENT_SECRET", Method: "fromEnvironment()"},
+		{Field: "LoginCustomerID", Value: "1234567890", Source: "environment variable GOOGLE_ADS_LOGIN_CUSTOMER_ID", Method: "fromEnvironment()"},
+		{Field: RefreshToken, Value: "PropRefreshToken", Source: "system property api.googleads.refreshToken in JAVA_TOOL_OPTIONS",
+			Method: "fromSystemProperties()"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Fatalf("JavaSources() =\n%+v\nwant\n%+v", sources, want)
+	}
+
+	c := ConfigFile{Lang: "java", ConfigKeys: ConfigKeys{ClientSecret: "FileClientSecret", RefreshToken: "PropRefreshToken"}}
+	warnings := c.MergeJavaSources(sources)
+	wantKeys := ConfigKeys{ClientSecret: "FileClientSecret", RefreshToken: "PropRefreshToken", LoginCustomerID: "1234567890"}
+	if c.ConfigKeys != wantKeys {
+		t.Errorf("MergeJavaSources() keys = %+v, want %+v", c.ConfigKeys, wantKeys)
+	}
+	if got, want := c.Source("LoginCustomerID"), "environment variable GOOGLE_ADS_LOGIN_CUSTOMER_ID"; got != want {
+		t.Errorf("MergeJavaSources() source of LoginCustomerID = %s, want %s", got, want)
+	}
+	wantWarnings := []string{
+		"ClientSecret in the config file differs from the environment variable GOOGLE_ADS_CLIENT_SECRET.",
+		"LoginCustomerID is not in the config file, so the value of the environment variable GOOGLE_ADS_LOGIN_CUSTOMER_ID is used.",
+	}
+	if len(warnings) != len(wantWarnings) {
+		t.Fatalf("MergeJavaSources() warnings = %q, want %d", warnings, len(wantWarnings))
+	}
+	for i, w := range wantWarnings {
+		if !strings.HasPrefix(warnings[i], w) {
+			t.Errorf("MergeJavaSources() warning %d = %s, want prefix %s", i, warnings[i], w)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// JSONFormat is the format of configuration files in JSON, which some users
+// keep instead of the native file of their client library, e.g. for custom
+// wrappers.
+const JSONFormat = "json"
+
+// JSONKeys maps the fields of ConfigKeys to the keys of a JSON configuration
+// file. The first key is in camelCase, like google_ads_php.json, and the
+// second in snake_case, like the Node.js client library. Keys are matched in
+// nested objects too, so sections like {"OAUTH2": {...}} are supported.
+var JSONKeys = map[string][]string{
+	ClientID:          {"clientId", "client_id"},
+	ClientSecret:      {"clientSecret", "client_secret"},
+	DevToken:          {"developerToken", "developer_token"},
+	RefreshToken:      {"refreshToken", "refresh_token"},
+	"LoginCustomerID": {"loginCustomerId", "login_customer_id"},
+	PrivateKeyPath:    {"jsonKeyFilePath", "json_key_file_path"},
+	DelegatedAccount:  {"impersonatedEmail", "impersonated_email"},
+	"Endpoint":        {"endpoint", "endpoint"},
+}
+
+// JSONFilename returns the default name of a JSON configuration file, which
+// is the name of the native file with a .json extension, or google-ads.json
+// when the native file is a dotfile like .env. For .NET, it is
+// appsettings.json.
+func JSONFilename(lang string) string {
+	if lang == "dotnet" {
+		return DotNetSettingsFilename
+	}
+	name := Languages[lang].Cfg.Filename
+	switch i := strings.LastIndex(name, "."); {
+	case i == 0:
+		name = "google-ads"
+	case i > 0:
+		name = name[:i]
+	}
+	return name + ".json"
+}
+
+// ParseJSONFile parses a configuration file in JSON with the zero Reader.
+func ParseJSONFile(lang, filepath, oauthType string) (ConfigFile, error) {
+	return (*Reader)(nil).ParseJSONFile(lang, filepath, oauthType)
+}
+
+// ParseJSONFile parses a configuration file in JSON, whose keys are mapped
+// to ConfigKeys with JSONKeys.
+func (r *Reader) ParseJSONFile(lang, filepath, oauthType string) (c ConfigFile, err error) {
+	if c, err = GetConfigFile(lang, filepath); err != nil {
+		return c, err
+	}
+	c.OAuthType = oauthType
+	c.Format = JSONFormat
+	c.reader = r
+
+	content, _, err := r.readTextFile(filepath)
+	if err != nil {
+		return c, err
+	}
+	c.keepPlaintext(content)
+
+	doc, err := scanJSON(content)
+	if err != nil {
+		return c, err
+	}
+	c.UpdateConfigKeys(keyValues(doc.occurrences()))
+	c.updateSources(doc.occurrences())
+
+	// The errors of the key file are reported by Lint.
+	if c.PrivateKeyPath != "" {
+		c.parseServiceAccJSON()
+	}
+
+	return c, nil
+}
+
+// knownKeys maps the keys of the configuration file to the fields of
+// ConfigKeys.
+func (c *ConfigFile) knownKeys() map[string]string {
+	if c.Format != JSONFormat {
+		known := swapMap(structs.Map(Languages[c.Lang].Cfg.ConfigKeys))
+		for k, field := range legacyKeys[c.Lang] {
+			known[k] = field
+		}
+		return known
+	}
+	known := make(map[string]string)
+	for field, keys := range JSONKeys {
+		for _, k := range keys {
+			known[k] = field
+		}
+	}
+	// appsettings.json has the keys of App.config.
+	if c.Lang == "dotnet" {
+		for k, field := range swapMap(structs.Map(Languages[c.Lang].Cfg.ConfigKeys)) {
+			known[k] = field
+		}
+	}
+	return known
+}
+
+// jsonEntry is a member of a JSON object with a scalar value. The raw value
+// is at content[start:end].
+type jsonEntry struct {
+	keyOccurrence
+	start, end int
+	object     int
+}
+
+// jsonObject is an object of a JSON document, from the "{" at start to the
+// "}" at end. The last member ends at lastEnd, which is -1 for an empty
+// object.
+type jsonObject struct {
+	start, end, lastEnd int
+}
+
+// jsonDocument is the content of a JSON configuration file with the
+// positions of its members, so that values can be edited in place.
+type jsonDocument struct {
+	content string
+	entries []jsonEntry
+	objects []jsonObject
+}
+
+// scanJSON parses the content of a JSON configuration file, which must be
+// an object.
+func scanJSON(content string) (*jsonDocument, error) {
+	type frame struct {
+		object    bool
+		index     int
+		key       string
+		keyEnd    int
+		expectKey bool
+	}
+
+	doc := &jsonDocument{content: content}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	var stack []frame
+
+	// valueDone marks the end of a value in the innermost object.
+	valueDone := func(end int) {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].expectKey = true
+			doc.objects[stack[n-1].index].lastEnd = end
+		}
+	}
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{':
+				doc.objects = append(doc.objects, jsonObject{start: end - 1, end: -1, lastEnd: -1})
+				stack = append(stack, frame{object: true, index: len(doc.objects) - 1, expectKey: true})
+			case '[':
+				if len(stack) == 0 {
+					return nil, i18n.Errorf("expected a JSON object")
+				}
+				stack = append(stack, frame{})
+			case '}', ']':
+				if top := stack[len(stack)-1]; top.object {
+					doc.objects[top.index].end = end - 1
+				}
+				stack = stack[:len(stack)-1]
+				valueDone(end)
+			}
+			continue
+		}
+
+		if len(stack) == 0 {
+			return nil, i18n.Errorf("expected a JSON object")
+		}
+		top := &stack[len(stack)-1]
+		if top.object && top.expectKey {
+			top.key, top.keyEnd, top.expectKey = tok.(string), end, false
+			continue
+		}
+		if top.object {
+			start := top.keyEnd + strings.IndexFunc(content[top.keyEnd:], func(r rune) bool {
+				return r != ':' && r != ' ' && r != '\t' && r != '\r' && r != '\n'
+			})
+			value := ""
+			if tok != nil {
+				value = fmt.Sprint(tok)
+			}
+			doc.entries = append(doc.entries, jsonEntry{
+				keyOccurrence: keyOccurrence{Key: top.key, Value: value, Line: 1 + strings.Count(content[:start], "\n")},
+				start:         start,
+				end:           end,
+				object:        top.index,
+			})
+		}
+		valueDone(end)
+	}
+
+	if len(doc.objects) == 0 {
+		return nil, io.EOF
+	}
+	// The decoder stops without an error in an unclosed object.
+	if len(stack) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return doc, nil
+}
+
+// occurrences returns the keys set in the document, in the order of the
+// document.
+func (d *jsonDocument) occurrences() []keyOccurrence {
+	var occurrences []keyOccurrence
+	for _, e := range d.entries {
+		occurrences = append(occurrences, e.keyOccurrence)
+	}
+	return occurrences
+}
+
+// set sets the given field of ConfigKeys to value and returns the new
+// content. The value of each key of field is replaced in place. When field
+// is not set, a member is added to the object with the other configuration
+// keys, named in the same case as them.
+func (d *jsonDocument) set(known map[string]string, field, value string) string {
+	content := d.content
+	found := false
+	// Editing from the last entry keeps the offsets of the others valid.
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		e := d.entries[i]
+		if known[e.Key] != field {
+			continue
+		}
+		content = content[:e.start] + jsonValue(value, content[e.start:e.end]) + content[e.end:]
+		found = true
+	}
+	if found {
+		return content
+	}
+
+	obj, keys, indent := 0, JSONKeys[field], ""
+	name := keys[0]
+	dotnet := structs.Map(Languages["dotnet"].Cfg.ConfigKeys)
+	for _, e := range d.entries {
+		if f, ok := known[e.Key]; ok {
+			obj = e.object
+			indent = lineIndent(content, e.start)
+			switch {
+			case e.Key == dotnet[f] && e.Key != JSONKeys[f][0]:
+				name = dotnet[field].(string)
+			case strings.Contains(e.Key, "_"):
+				name = keys[1]
+			default:
+				name = keys[0]
+			}
+		}
+	}
+
+	o := d.objects[obj]
+	newline := lineEnding([]byte(content))
+	closingIndent := lineIndent(content, o.end)
+	if indent == "" {
+		indent = closingIndent + "  "
+	}
+	member := jsonValue(name, "") + ": " + jsonValue(value, "")
+	if o.lastEnd < 0 {
+		return content[:o.start+1] + newline + indent + member + newline + closingIndent + content[o.end:]
+	}
+	return content[:o.lastEnd] + "," + newline + indent + member + content[o.lastEnd:]
+}
+
+// jsonValue returns value encoded as a JSON string, or as a number when the
+// raw value it replaces is a number and value is one too.
+func jsonValue(value, raw string) string {
+	if raw != "" && raw[0] != '"' {
+		var n json.Number
+		if json.Unmarshal([]byte(value), &n) == nil {
+			return value
+		}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// lineIndent returns the indentation of the line of content at offset.
+func lineIndent(content string, offset int) string {
+	return leadingSpace(content[strings.LastIndex(content[:offset], "\n")+1 : offset])
+}
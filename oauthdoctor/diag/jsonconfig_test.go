@@ -0,0 +1,170 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONFile(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Error getting current dir: %s", err)
+	}
+
+	tests := []struct {
+		desc     string
+		filename string
+		want     ConfigKeys
+		errstr   string
+	}{
+		{
+			desc:     "camelCase keys in sections",
+			filename: "php_config.json",
+			want: ConfigKeys{
+				ClientID:        "GoodClientID",
+				ClientSecret:    "GoodClientSecret",
+				DevToken:        "GoodDevToken",
+				RefreshToken:    "GoodRefreshToken",
+				LoginCustomerID: "1234567890",
+			},
+			errstr: "nil",
+		},
+		{
+			desc:     "snake_case keys",
+			filename: "node_config.json",
+			want: ConfigKeys{
+				ClientID:     "GoodClientID",
+				ClientSecret: "GoodClientSecret",
+				DevToken:     "GoodDevToken",
+				RefreshToken: "GoodRefreshToken",
+			},
+			errstr: "nil",
+		},
+		{
+			desc:     "Not JSON",
+			filename: "python_config",
+			errstr:   "invalid character",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseJSONFile("php", filepath.Join(dir, "testdata", tt.filename), InstalledApp)
+		if !strings.Contains(errstring(err), tt.errstr) {
+			t.Errorf("[%s] ParseJSONFile() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.ConfigKeys != tt.want {
+			t.Errorf("[%s] ParseJSONFile() got: %+v, want: %+v", tt.desc, got.ConfigKeys, tt.want)
+		}
+		if got.Format != JSONFormat {
+			t.Errorf("[%s] ParseJSONFile() Format = %q, want: %q", tt.desc, got.Format, JSONFormat)
+		}
+	}
+}
+
+func TestJSONDocumentSet(t *testing.T) {
+	tests := []struct {
+		desc    string
+		key     string
+		val     string
+		content string
+		want    string
+	}{
+		{
+			desc:    "Replace a value in place",
+			key:     DevToken,
+			val:     "new\"tok",
+			content: "{\n  \"developer_token\": \"old\", \"client_id\": \"id\"\n}\n",
+			want:    "{\n  \"developer_token\": \"new\\\"tok\", \"client_id\": \"id\"\n}\n",
+		},
+		{
+			desc:    "Keep a number",
+			key:     "LoginCustomerID",
+			val:     "1234567890",
+			content: "{\"loginCustomerId\": 111}",
+			want:    "{\"loginCustomerId\": 1234567890}",
+		},
+		{
+			desc:    "Add a key next to the other keys in the same case",
+			key:     RefreshToken,
+			val:     "tok",
+			content: "{\n  \"logging\": {},\n  \"OAUTH2\": {\n    \"client_id\": \"id\"\n  }\n}\n",
+			want:    "{\n  \"logging\": {},\n  \"OAUTH2\": {\n    \"client_id\": \"id\",\n    \"refresh_token\": \"tok\"\n  }\n}\n",
+		},
+		{
+			desc:    "Add a key to an empty object",
+			key:     DevToken,
+			val:     "tok",
+			content: "{}\r\n",
+			want:    "{\r\n  \"developerToken\": \"tok\"\r\n}\r\n",
+		},
+	}
+
+	cfg := ConfigFile{Lang: "php", Format: JSONFormat}
+	for _, tt := range tests {
+		doc, err := scanJSON(tt.content)
+		if err != nil {
+			t.Fatalf("[%s] scanJSON() returned error: %s", tt.desc, err)
+		}
+		if got := doc.set(cfg.knownKeys(), tt.key, tt.val); got != tt.want {
+			t.Errorf("[%s] set() got: %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceConfigJSON(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig, err := ioutil.ReadFile(filepath.Join("testdata", "php_config.json"))
+	if err != nil {
+		t.Fatalf("Error reading test config: %s", err)
+	}
+	path := filepath.Join(dir, "google_ads_php.json")
+	if err := ioutil.WriteFile(path, orig, 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	cfg, err := ParseJSONFile("php", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseJSONFile() returned error: %s", err)
+	}
+	if _, err := cfg.ReplaceConfig(RefreshToken, "NewRefreshToken"); err != nil {
+		t.Fatalf("ReplaceConfig() returned error: %s", err)
+	}
+
+	parsed, err := ParseJSONFile("php", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseJSONFile() of the new config returned error: %s", err)
+	}
+	want := cfg.ConfigKeys
+	want.RefreshToken = "NewRefreshToken"
+	if parsed.ConfigKeys != want {
+		t.Errorf("ReplaceConfig() wrote %+v, want: %+v", parsed.ConfigKeys, want)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// legacyKeys are the keys of older versions of the client libraries, by
+// language, which are read like the keys of Languages.
+var legacyKeys = map[string]map[string]string{
+	"python": {
+		"path_to_private_key_file": PrivateKeyPath,
+		"delegated_account":        DelegatedAccount,
+	},
+}
+
+// isLegacyKey returns true when key is a key of an older version of the
+// client library of c.
+func (c *ConfigFile) isLegacyKey(key string) bool {
+	_, ok := legacyKeys[c.Lang][key]
+	return ok && c.Format != JSONFormat
+}
+
+// legacyKeyFindings warns about the keys of older versions of the client
+// library in the configuration file.
+func (c *ConfigFile) legacyKeyFindings() []Finding {
+	occurrences, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return nil
+	}
+	var findings []Finding
+	for _, o := range occurrences {
+		if !c.isLegacyKey(o.Key) {
+			continue
+		}
+		field := legacyKeys[c.Lang][o.Key]
+		findings = append(findings, Finding{Severity: Warning, Key: field,
+			Message: i18n.Sprintf("%s on line %d is the old name of %s, which recent versions of the client "+
+				"library read instead. Rename it.", o.Key, o.Line, c.GetConfigKeysInLang(field))})
+	}
+	return findings
+}
+
+// keyFileFindings checks that the JSON key file of PrivateKeyPath exists, can
+// be read and holds the credentials of a service account, and that the
+// delegated account is an email.
+func (c *ConfigFile) keyFileFindings() []Finding {
+	var findings []Finding
+	add := func(sev Severity, key, msg string) {
+		findings = append(findings, Finding{Severity: sev, Key: key, Message: msg})
+	}
+
+	if c.DelegatedAccount != "" && !strings.Contains(c.DelegatedAccount, "@") {
+		add(Error, DelegatedAccount, i18n.Sprintf("DelegatedAccount is not an email. Value: %s", c.DelegatedAccount))
+	}
+	if c.PrivateKeyPath == "" {
+		return findings
+	}
+
+	info, err := os.Stat(c.PrivateKeyPath)
+	if os.IsNotExist(err) {
+		msg := i18n.Sprintf("PrivateKeyPath %s does not exist.", c.PrivateKeyPath)
+		if !filepath.IsAbs(c.PrivateKeyPath) {
+			msg += " " + i18n.T("The client library resolves a relative path from the working directory of "+
+				"your application; use an absolute path.")
+		}
+		add(Error, PrivateKeyPath, msg)
+		return findings
+	}
+	if err == nil && info.IsDir() {
+		add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s is a directory, not a JSON key file.", c.PrivateKeyPath))
+		return findings
+	}
+	content, err := ioutil.ReadFile(c.PrivateKeyPath)
+	if err != nil {
+		add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s cannot be read: %s", c.PrivateKeyPath, err))
+		return findings
+	}
+
+	var key map[string]interface{}
+	if err := json.Unmarshal(content, &key); err != nil {
+		if strings.HasSuffix(strings.ToLower(c.PrivateKeyPath), ".p12") {
+			add(Error, PrivateKeyPath, i18n.T("PrivateKeyPath is a P12 key, which the client libraries do not "+
+				"support. Create a JSON key for the service account in the Google Cloud console."))
+		} else {
+			add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s is not a JSON key file: %s", c.PrivateKeyPath, err))
+		}
+		return findings
+	}
+	_, installed := key["installed"]
+	_, web := key["web"]
+	switch typ, _ := key["type"].(string); {
+	case installed || web:
+		add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s is the JSON file of an OAuth client, which "+
+			"the installed app and web flows use. A service account needs the JSON key of the service account.",
+			c.PrivateKeyPath))
+	case typ == ExternalAccount:
+		// The credential source is checked by the OAuth flow.
+	case typ != ServiceAccount:
+		add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s is not the key of a service account (type %q).",
+			c.PrivateKeyPath, typ))
+	case key["private_key"] == nil || key["client_email"] == nil:
+		add(Error, PrivateKeyPath, i18n.Sprintf("PrivateKeyPath %s has no private_key or client_email. "+
+			"Download the JSON key of the service account again.", c.PrivateKeyPath))
+	}
+	return findings
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyFileFindings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		desc string
+		keys ConfigKeys
+		want []string
+	}{
+		{
+			desc: "Valid key",
+			keys: ConfigKeys{PrivateKeyPath: filepath.Join("testdata", "service_account.json"), DelegatedAccount: "a@example.com"},
+		},
+		{
+			desc: "Federated credentials",
+			keys: ConfigKeys{PrivateKeyPath: filepath.Join("testdata", "external_account.json")},
+		},
+		{
+			desc: "Relative path that does not exist",
+			keys: ConfigKeys{PrivateKeyPath: "key.json"},
+			want: []string{"ERROR PrivateKeyPath key.json does not exist. The client library resolves a relative path"},
+		},
+		{
+			desc: "Directory",
+			keys: ConfigKeys{PrivateKeyPath: dir},
+			want: []string{"ERROR PrivateKeyPath " + dir + " is a directory"},
+		},
+		{
+			desc: "P12 key",
+			keys: ConfigKeys{PrivateKeyPath: write("key.p12", "\x30\x82")},
+			want: []string{"ERROR PrivateKeyPath is a P12 key"},
+		},
+		{
+			desc: "OAuth client",
+			keys: ConfigKeys{PrivateKeyPath: write("client_secrets.json", `{"installed": {"client_id": "id"}}`)},
+			want: []string{"ERROR PrivateKeyPath " + filepath.Join(dir, "client_secrets.json") + " is the JSON file of an OAuth client"},
+		},
+		{
+			desc: "Authorized user",
+			keys: ConfigKeys{PrivateKeyPath: write("user.json", `{"type": "authorized_user"}`)},
+			want: []string{"ERROR PrivateKeyPath " + filepath.Join(dir, "user.json") + " is not the key of a service account"},
+		},
+		{
+			desc: "Incomplete key",
+			keys: ConfigKeys{PrivateKeyPath: write("incomplete.json", `{"type": "service_account"}`)},
+			want: []string{"ERROR PrivateKeyPath " + filepath.Join(dir, "incomplete.json") + " has no private_key"},
+		},
+		{
+			desc: "Delegated account is not an email",
+			keys: ConfigKeys{DelegatedAccount: "admin"},
+			want: []string{"ERROR DelegatedAccount is not an email"},
+		},
+	}
+
+	for _, tt := range tests {
+		c := ConfigFile{Lang: "python", OAuthType: ServiceAccount, ConfigKeys: tt.keys}
+		var got []string
+		for _, f := range c.keyFileFindings() {
+			got = append(got, string(f.Severity)+" "+f.Message)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("[%s] keyFileFindings() got: %q, want: %q", tt.desc, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !strings.HasPrefix(got[i], tt.want[i]) {
+				t.Errorf("[%s] keyFileFindings() got: %q, want prefix: %q", tt.desc, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestLegacyKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	config := "developer_token: GoodDevToken\n" +
+		"delegated_account: old@example.com\n" +
+		"impersonated_email: new@example.com\n" +
+		"path_to_private_key_file: /old.json\n"
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ParseConfigFile("python", path, ServiceAccount)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %s", err)
+	}
+	if c.DelegatedAccount != "new@example.com" || c.PrivateKeyPath != "/old.json" {
+		t.Errorf("ParseConfigFile() got DelegatedAccount %q and PrivateKeyPath %q, want new@example.com and /old.json",
+			c.DelegatedAccount, c.PrivateKeyPath)
+	}
+
+	var got []string
+	for _, f := range c.legacyKeyFindings() {
+		got = append(got, f.Message)
+	}
+	want := []string{
+		"delegated_account on line 2 is the old name of impersonated_email, which recent versions of the client library read instead. Rename it.",
+		"path_to_private_key_file on line 4 is the old name of json_key_file_path, which recent versions of the client library read instead. Rename it.",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("legacyKeyFindings() got: %q, want: %q", got, want)
+	}
+}
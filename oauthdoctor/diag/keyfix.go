@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// KeyFix is a value of the configuration file that fixes a problem found by
+// Lint.
+type KeyFix struct {
+	// Field is the field of ConfigKeys to set, and Value its new value.
+	Field string
+	Value string
+	// Reason describes the fix.
+	Reason string
+}
+
+// KeyFixes returns the fixes of the problems found by Lint whose fix needs no
+// input: an empty value that is set by a misplaced or misspelled key, a
+// login customer ID with dashes and whitespace in the developer token.
+func (c *ConfigFile) KeyFixes() []KeyFix {
+	var fixes []KeyFix
+	fixed := make(map[string]bool)
+	empty := func(field string) bool {
+		v := reflect.ValueOf(c.ConfigKeys).FieldByName(field)
+		return v.IsValid() && v.String() == "" && !fixed[field]
+	}
+
+	misspelled, misplaced := c.unreadKeys()
+	known := c.knownKeys()
+	for _, o := range misplaced {
+		if field := known[o.Key]; o.Value != "" && empty(field) {
+			fixed[field] = true
+			fixes = append(fixes, KeyFix{Field: field, Value: o.Value,
+				Reason: i18n.Sprintf("Move %s on line %d to where the client library reads it.", o.Key, o.Line)})
+		}
+	}
+	for _, m := range misspelled {
+		if m.Value != "" && empty(m.Field) {
+			fixed[m.Field] = true
+			fixes = append(fixes, KeyFix{Field: m.Field, Value: m.Value,
+				Reason: i18n.Sprintf("Set %s to the value of %s on line %d, which is not a key of the client library.",
+					m.Known, m.Key, m.Line)})
+		}
+	}
+
+	if strings.Contains(c.LoginCustomerID, "-") {
+		fixes = append(fixes, KeyFix{Field: "LoginCustomerID", Value: strings.Replace(c.LoginCustomerID, "-", "", -1),
+			Reason: i18n.Sprintf("Remove the dashes from %s.", c.GetConfigKeysInLang("LoginCustomerID"))})
+	}
+	if strings.IndexFunc(c.DevToken, unicode.IsSpace) >= 0 {
+		fixes = append(fixes, KeyFix{Field: DevToken, Value: strings.Join(strings.Fields(c.DevToken), ""),
+			Reason: i18n.Sprintf("Remove the whitespace from %s.", c.GetConfigKeysInLang(DevToken))})
+	}
+	return fixes
+}
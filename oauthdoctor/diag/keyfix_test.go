@@ -0,0 +1,94 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyFixes(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc string
+		cfg  ConfigFile
+		want []string
+	}{
+		{
+			desc: "Misspelled keys",
+			cfg: ConfigFile{
+				Lang:       "python",
+				OAuthType:  InstalledApp,
+				Filepath:   filepath.Join(dir, "testdata"),
+				Filename:   "python_config_misspelled",
+				ConfigKeys: ConfigKeys{ClientID: "0123456789-GoodClientID.apps.googleusercontent.com"},
+			},
+			want: []string{
+				"DevToken=GoodDevToken: Set developer_token to the value of developer_tokn on line 1, which is not a key of the client library.",
+				"LoginCustomerID=1234567890: Set login_customer_id to the value of loginCustomerId on line 6, which is not a key of the client library.",
+			},
+		},
+		{
+			desc: "(PHP) Keys in the wrong section",
+			cfg: ConfigFile{
+				Lang:      "php",
+				OAuthType: InstalledApp,
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "php_config_misplaced",
+			},
+			want: []string{
+				"LoginCustomerID=1234567890: Move loginCustomerId on line 1 to where the client library reads it.",
+				"DevToken=GoodDevToken: Move developerToken on line 5 to where the client library reads it.",
+			},
+		},
+		{
+			desc: "Values",
+			cfg: ConfigFile{
+				Lang:       "python",
+				OAuthType:  InstalledApp,
+				ConfigKeys: ConfigKeys{DevToken: "Good Dev Token ", LoginCustomerID: "123-456-7890"},
+			},
+			want: []string{
+				"LoginCustomerID=1234567890: Remove the dashes from login_customer_id.",
+				"DevToken=GoodDevToken: Remove the whitespace from developer_token.",
+			},
+		},
+		{
+			desc: "Value already set",
+			cfg: ConfigFile{
+				Lang:       "python",
+				OAuthType:  InstalledApp,
+				Filepath:   filepath.Join(dir, "testdata"),
+				Filename:   "python_config_misspelled",
+				ConfigKeys: ConfigKeys{DevToken: "OtherDevToken", LoginCustomerID: "1111111111"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, f := range tt.cfg.KeyFixes() {
+			got = append(got, fmt.Sprintf("%s=%s: %s", f.Field, f.Value, f.Reason))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("[%s] KeyFixes() got:\n%q\nwant:\n%q", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// KeyringService is the service name of the secrets in the OS credential
+// store.
+const KeyringService = "google-ads-api"
+
+// KeyringKeys are the keys whose values can be kept in the OS credential store
+// instead of the configuration file.
+var KeyringKeys = []string{ClientSecret, RefreshToken}
+
+// secretStore reads and writes secrets by account.
+type secretStore interface {
+	get(account string) (string, error)
+	set(account, secret string) error
+}
+
+// keyring is the OS credential store: the macOS Keychain, the Windows
+// Credential Manager or a Secret Service such as GNOME Keyring through
+// libsecret.
+var keyring secretStore = osKeyring{goos: runtime.GOOS}
+
+// osKeyring runs the command line tool of the OS credential store. The
+// secrets are passed on stdin, so they do not show in the process list.
+type osKeyring struct {
+	goos string
+}
+
+func (k osKeyring) get(account string) (string, error) {
+	args, stdin := keyringGetCommand(k.goos, account)
+	return runKeyringCommand(k.goos, args, stdin)
+}
+
+func (k osKeyring) set(account, secret string) error {
+	args, stdin := keyringSetCommand(k.goos, account, secret)
+	_, err := runKeyringCommand(k.goos, args, stdin)
+	return err
+}
+
+// runKeyringCommand runs args with stdin and returns the output without the
+// trailing newline.
+func runKeyringCommand(goos string, args []string, stdin string) (string, error) {
+	if args == nil {
+		return "", fmt.Errorf("no OS credential store is supported on %s", goos)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", fmt.Errorf("the OS credential store cannot be used: %s", err)
+	}
+	var out, stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(out.String(), "\r\n"), nil
+}
+
+// windowsPasswordVault loads the Windows Credential Manager API in
+// PowerShell.
+const windowsPasswordVault = "[void][Windows.Security.Credentials.PasswordVault,Windows.Security.Credentials,ContentType=WindowsRuntime];" +
+	"$v=New-Object Windows.Security.Credentials.PasswordVault;"
+
+// keyringGetCommand returns the command that prints the secret of account,
+// and its stdin. It returns nil when goos has no supported credential store.
+func keyringGetCommand(goos, account string) ([]string, string) {
+	switch goos {
+	case "darwin":
+		return []string{"security", "find-generic-password", "-s", KeyringService, "-a", account, "-w"}, ""
+	case "windows":
+		return []string{"powershell", "-NoProfile", "-Command", windowsPasswordVault +
+			"$c=$v.Retrieve(" + psQuote(KeyringService) + "," + psQuote(account) + ");$c.RetrievePassword();" +
+			"[Console]::Out.Write($c.Password)"}, ""
+	case "linux", "freebsd", "openbsd":
+		return []string{"secret-tool", "lookup", "service", KeyringService, "account", account}, ""
+	}
+	return nil, ""
+}
+
+// keyringSetCommand returns the command that stores secret for account, and
+// its stdin. It returns nil when goos has no supported credential store.
+func keyringSetCommand(goos, account, secret string) ([]string, string) {
+	switch goos {
+	case "darwin":
+		// The interactive mode reads the command from stdin.
+		return []string{"security", "-i"}, fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n",
+			KeyringService, account, secret)
+	case "windows":
+		return []string{"powershell", "-NoProfile", "-Command", windowsPasswordVault +
+			"$v.Add((New-Object Windows.Security.Credentials.PasswordCredential(" + psQuote(KeyringService) + "," +
+			psQuote(account) + ",[Console]::In.ReadToEnd())))"}, secret
+	case "linux", "freebsd", "openbsd":
+		return []string{"secret-tool", "store", "--label=" + KeyringService + " " + account,
+			"service", KeyringService, "account", account}, secret
+	}
+	return nil, ""
+}
+
+// psQuote quotes s as a PowerShell string literal.
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// keyringAccount returns the account of the secret of key in the OS
+// credential store. Secrets are tied to the OAuth client, so several
+// configurations can keep theirs in the store.
+func (c *ConfigFile) keyringAccount(key string) (string, error) {
+	if c.ConfigKeys.ClientID == "" {
+		return "", i18n.Errorf("A client ID is required to keep the secrets in the OS credential store")
+	}
+	return c.ConfigKeys.ClientID + "/" + key, nil
+}
+
+// LoadKeyring makes c keep the secrets of KeyringKeys in the OS credential
+// store, so ReplaceConfig writes them there instead of the configuration
+// file. The secrets that the configuration does not set are read from the
+// store, and their keys are returned.
+func (c *ConfigFile) LoadKeyring() ([]string, error) {
+	c.Keyring = true
+	var loaded []string
+	for _, key := range KeyringKeys {
+		if structs.New(c.ConfigKeys).Field(key).Value() != "" {
+			continue
+		}
+		account, err := c.keyringAccount(key)
+		if err != nil {
+			return loaded, err
+		}
+		// A secret that is not in the store cannot be told apart from
+		// other errors of the command, so it is left empty for the
+		// validation to report.
+		if v, err := keyring.get(account); err == nil && v != "" {
+			c.SetConfigKeys(key, v)
+			c.SetSource(key, i18n.T("OS credential store"))
+			loaded = append(loaded, key)
+		}
+	}
+	return loaded, nil
+}
+
+// PlaintextSecrets returns the keys of KeyringKeys that are set in the
+// configuration.
+func (c *ConfigFile) PlaintextSecrets() []string {
+	var keys []string
+	for _, key := range KeyringKeys {
+		if structs.New(c.ConfigKeys).Field(key).Value() != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// MigrateToKeyring moves the secrets of KeyringKeys from the configuration
+// file to the OS credential store. The values are removed from the file, but
+// kept in c.
+func (c *ConfigFile) MigrateToKeyring() error {
+	c.Keyring = true
+	for _, key := range c.PlaintextSecrets() {
+		value := structs.New(c.ConfigKeys).Field(key).Value().(string)
+		account, err := c.keyringAccount(key)
+		if err != nil {
+			return err
+		}
+		if err := keyring.set(account, value); err != nil {
+			return i18n.Errorf("Cannot write %s to the OS credential store: %s", key, err)
+		}
+		if _, err := c.replaceConfigFile(key, ""); err != nil {
+			return err
+		}
+		c.SetConfigKeys(key, value)
+		c.SetSource(key, i18n.T("OS credential store"))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring is an in-memory secretStore.
+type fakeKeyring map[string]string
+
+func (k fakeKeyring) get(account string) (string, error) {
+	return k[account], nil
+}
+
+func (k fakeKeyring) set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func TestKeyringCommands(t *testing.T) {
+	tests := []struct {
+		desc      string
+		goos      string
+		wantGet   string
+		wantSet   string
+		wantStdin string
+	}{
+		{
+			desc:      "macOS Keychain",
+			goos:      "darwin",
+			wantGet:   "security find-generic-password -s google-ads-api -a id/RefreshToken -w",
+			wantSet:   "security -i",
+			wantStdin: "add-generic-password -U -s \"google-ads-api\" -a \"id/RefreshToken\" -w \"secret\"\n",
+		},
+		{
+			desc:      "libsecret",
+			goos:      "linux",
+			wantGet:   "secret-tool lookup service google-ads-api account id/RefreshToken",
+			wantSet:   "secret-tool store --label=google-ads-api id/RefreshToken service google-ads-api account id/RefreshToken",
+			wantStdin: "secret",
+		},
+		{
+			desc: "Unsupported",
+			goos: "plan9",
+		},
+	}
+
+	for _, test := range tests {
+		get, _ := keyringGetCommand(test.goos, "id/RefreshToken")
+		if got := strings.Join(get, " "); got != test.wantGet {
+			t.Errorf("[%s] keyringGetCommand() = %s, want: %s", test.desc, got, test.wantGet)
+		}
+		set, stdin := keyringSetCommand(test.goos, "id/RefreshToken", "secret")
+		if got := strings.Join(set, " "); got != test.wantSet || stdin != test.wantStdin {
+			t.Errorf("[%s] keyringSetCommand() = %s, %q, want: %s, %q", test.desc, got, stdin, test.wantSet, test.wantStdin)
+		}
+	}
+}
+
+func TestMigrateToKeyring(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	origKeyring := keyring
+	store := fakeKeyring{}
+	keyring = store
+	defer func() { keyring = origKeyring }()
+
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "python_config"))
+	if err != nil {
+		t.Fatalf("Error reading test config: %s", err)
+	}
+	path := filepath.Join(dir, "google-ads.yaml")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	cfg, err := ParseConfigFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() error: %s", err)
+	}
+	clientID := cfg.ConfigKeys.ClientID
+
+	// A plaintext refresh token is replaced in the file, which takes
+	// precedence over the keyring.
+	cfg.Keyring = true
+	if _, err := cfg.ReplaceConfig(RefreshToken, "1/PG1Ap6P-Good_Refresh_Token"); err != nil {
+		t.Fatalf("ReplaceConfig() error: %s", err)
+	}
+	if len(store) != 0 {
+		t.Errorf("ReplaceConfig() of a plaintext secret wrote to the keyring: %v", store)
+	}
+
+	if err := cfg.MigrateToKeyring(); err != nil {
+		t.Fatalf("MigrateToKeyring() error: %s", err)
+	}
+	if cfg.ClientSecret != "GoodClientSecret" || cfg.RefreshToken != "1/PG1Ap6P-Good_Refresh_Token" {
+		t.Errorf("MigrateToKeyring() changed the secrets to %q, %q", cfg.ClientSecret, cfg.RefreshToken)
+	}
+	if got := store[clientID+"/"+ClientSecret]; got != "GoodClientSecret" {
+		t.Errorf("MigrateToKeyring() stored client secret %q, want: GoodClientSecret", got)
+	}
+
+	// The migrated file has no secrets, which are read from the keyring.
+	migrated, err := ParseConfigFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatalf("ParseConfigFile() of the migrated file error: %s", err)
+	}
+	if got := migrated.PlaintextSecrets(); len(got) != 0 {
+		t.Errorf("PlaintextSecrets() of the migrated file = %v, want none", got)
+	}
+	loaded, err := migrated.LoadKeyring()
+	if err != nil {
+		t.Fatalf("LoadKeyring() error: %s", err)
+	}
+	if len(loaded) != 2 || migrated.RefreshToken != "1/PG1Ap6P-Good_Refresh_Token" {
+		t.Errorf("LoadKeyring() loaded %v, refresh token %q", loaded, migrated.RefreshToken)
+	}
+
+	// New refresh tokens are written to the keyring, not the file.
+	migratedContent, _ := ioutil.ReadFile(path)
+	backup, err := migrated.ReplaceConfig(RefreshToken, "1/New_Refresh_Token")
+	if err != nil || backup != "" {
+		t.Errorf("ReplaceConfig() = %q, %s, want no backup", backup, errstring(err))
+	}
+	if got := store[clientID+"/"+RefreshToken]; got != "1/New_Refresh_Token" {
+		t.Errorf("ReplaceConfig() stored refresh token %q, want: 1/New_Refresh_Token", got)
+	}
+	if got, _ := ioutil.ReadFile(path); string(got) != string(migratedContent) {
+		t.Errorf("ReplaceConfig() changed the file to %q", got)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// Severity tells whether a Finding must be fixed.
+type Severity string
+
+const (
+	// Error is a problem that makes the client library fail.
+	Error Severity = "ERROR"
+	// Warning is a suspicious value that may cause failures.
+	Warning Severity = "WARNING"
+)
+
+// Finding is a problem found in a configuration file by Lint.
+type Finding struct {
+	Severity Severity
+	// Key is the name of the ConfigKeys field, if the problem is with a
+	// single value.
+	Key     string
+	Message string
+}
+
+// Lint checks the values of the configuration file and returns the errors
+// found by Validate followed by warnings about suspicious values. When
+// customerID is not empty, it is checked as the account used with the
+// configuration.
+func (c *ConfigFile) Lint(customerID string) []Finding {
+	var findings []Finding
+	known := c.knownKeys()
+	add := func(sev Severity, key, msg string) {
+		findings = append(findings, Finding{Severity: sev, Key: key, Message: strings.TrimSuffix(msg, "\n")})
+	}
+
+	if !devTokenRegex.MatchString(c.DevToken) {
+		add(Error, DevToken, i18n.Sprintf("Dev token is invalid. Value: %s\n", c.DevToken))
+	}
+
+	if c.OAuthType != ServiceAccount && !strings.HasSuffix(c.ConfigKeys.ClientID, "apps.googleusercontent.com") {
+		add(Error, ClientID, i18n.Sprintf("ClientID does not end with apps.googleusercontent.com. Value: %s\n", c.ConfigKeys.ClientID))
+	}
+
+	if strings.Contains(c.LoginCustomerID, "-") {
+		add(Error, "LoginCustomerID", i18n.Sprintf("LoginCustomerID cannot have dashes. Value: %s\n", c.LoginCustomerID))
+	}
+
+	if c.Endpoint != "" {
+		if _, err := ParseEndpoint(c.Endpoint); err != nil {
+			add(Error, "Endpoint", err.Error())
+		}
+	}
+
+	misspelled, misplaced := c.unreadKeys()
+	reported := make(map[string]bool)
+	keys := reflect.TypeOf(c.ConfigKeys)
+	vals := reflect.ValueOf(c.ConfigKeys)
+	for i := 0; i < vals.NumField(); i++ {
+		k := keys.Field(i).Name
+		v := vals.Field(i).String()
+
+		if Contains(c.requiredKeys(), k) && v == "" {
+			if o, ok := misplacedField(misplaced, known, k); ok {
+				add(Error, k, i18n.Sprintf("%s is empty: %s\n", k, c.misplacedMessage(o)))
+				reported[k] = true
+			} else if m, ok := misspelledField(misspelled, k); ok {
+				add(Error, k, i18n.Sprintf("%s is empty: %s on line %d is not a key of the client library. Did you mean %s?\n",
+					k, m.Key, m.Line, m.Known))
+				reported[k] = true
+			} else {
+				add(Error, k, i18n.Sprintf("%s is empty.\n", k))
+			}
+		}
+
+		if strings.Contains(v, "INSERT") {
+			add(Error, k, i18n.Sprintf("%s needs to be updated. Value: %s\n", k, v))
+		}
+	}
+
+	for _, o := range misplaced {
+		if !reported[known[o.Key]] {
+			add(Error, known[o.Key], c.misplacedMessage(o))
+		}
+	}
+	var keyFileWarnings []Finding
+	for _, f := range c.keyFileFindings() {
+		if f.Severity == Error {
+			findings = append(findings, f)
+		} else {
+			keyFileWarnings = append(keyFileWarnings, f)
+		}
+	}
+	findings = append(findings, c.inlineKeyFindings()...)
+	findings = append(findings, c.transportFindings()...)
+	var yamlWarnings []Finding
+	if c.Lang == "python" && c.Format != JSONFormat {
+		for _, f := range c.yamlFindings() {
+			if f.Severity == Error {
+				findings = append(findings, f)
+			} else {
+				yamlWarnings = append(yamlWarnings, f)
+			}
+		}
+	}
+
+	// Warnings
+	if strings.HasPrefix(c.RefreshToken, "ya29.") {
+		add(Warning, RefreshToken, i18n.T("RefreshToken looks like an access token (it starts with ya29.), "+
+			"which expires after an hour. Use a refresh token instead."))
+	}
+	if strings.HasPrefix(c.ClientSecret, "AIza") {
+		add(Warning, ClientSecret, i18n.T("ClientSecret looks like an API key (it starts with AIza). "+
+			"Copy the client secret of your OAuth client from the Google Cloud console."))
+	}
+	if strings.IndexFunc(c.DevToken, unicode.IsSpace) >= 0 {
+		add(Warning, DevToken, i18n.T("Dev token contains whitespace, which is not part of a developer token."))
+	}
+	if cid := strings.Replace(customerID, "-", "", -1); cid != "" {
+		if !isCustomerID(cid) {
+			add(Warning, "", i18n.Sprintf("Customer ID %s does not have 10 digits.", customerID))
+		}
+		if cid == c.LoginCustomerID {
+			add(Warning, "LoginCustomerID", i18n.T("LoginCustomerID is the customer ID itself. It is only "+
+				"needed to access a client account through its manager account, so it can be removed."))
+		}
+	}
+	if c.LoginCustomerID != "" && !strings.Contains(c.LoginCustomerID, "-") && !isCustomerID(c.LoginCustomerID) {
+		add(Warning, "LoginCustomerID", i18n.Sprintf("LoginCustomerID does not have 10 digits. Value: %s", c.LoginCustomerID))
+	}
+	for _, m := range misspelled {
+		if !reported[m.Field] {
+			add(Warning, m.Field, i18n.Sprintf("%s on line %d is not a key of the client library, so it is ignored. "+
+				"Did you mean %s?", m.Key, m.Line, m.Known))
+		}
+	}
+	for _, dups := range c.duplicateKeys() {
+		var lines []string
+		conflicting := false
+		for _, o := range dups {
+			lines = append(lines, strconv.Itoa(o.Line))
+			conflicting = conflicting || o.Value != dups[0].Value
+		}
+		last := dups[len(dups)-1]
+		if conflicting {
+			add(Warning, "", i18n.Sprintf("%s is set more than once in the config file (lines %s) with different "+
+				"values; the client library uses the value on line %d.", last.Key, strings.Join(lines, ", "), last.Line))
+		} else {
+			add(Warning, "", i18n.Sprintf("%s is set more than once in the config file (lines %s) with the same "+
+				"value; remove all but the one on line %d.", last.Key, strings.Join(lines, ", "), last.Line))
+		}
+	}
+
+	findings = append(findings, keyFileWarnings...)
+	findings = append(findings, c.legacyKeyFindings()...)
+	findings = append(findings, yamlWarnings...)
+
+	return findings
+}
+
+// isCustomerID returns true when s is a customer ID without dashes.
+func isCustomerID(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// duplicateKeys returns the occurrences of each configuration key that is set
+// more than once in the file, in the order of the file. The client libraries
+// use the last occurrence. Read errors are ignored, since the file was already
+// parsed.
+func (c *ConfigFile) duplicateKeys() [][]keyOccurrence {
+	occurrences, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return nil
+	}
+	known := c.knownKeys()
+	byKey := make(map[string][]keyOccurrence)
+	var order []string
+	for _, o := range occurrences {
+		if _, ok := known[o.Key]; !ok || o.Misplaced {
+			continue
+		}
+		if _, ok := byKey[o.Key]; !ok {
+			order = append(order, o.Key)
+		}
+		byKey[o.Key] = append(byKey[o.Key], o)
+	}
+
+	var dups [][]keyOccurrence
+	for _, k := range order {
+		if len(byKey[k]) > 1 {
+			dups = append(dups, byKey[k])
+		}
+	}
+	return dups
+}
+
+// misspelling is a key of a configuration file that the client library does
+// not know, but that looks like one it knows.
+type misspelling struct {
+	keyOccurrence
+	// Known is the key that was likely meant, and Field its field in
+	// ConfigKeys.
+	Known string
+	Field string
+}
+
+// unreadKeys returns the keys of the configuration file that the client
+// library ignores, in the order of the file: the unknown keys that are
+// similar to a known key, and the known keys that are misplaced. Read errors
+// are ignored, since the file was already parsed.
+func (c *ConfigFile) unreadKeys() (misspelled []misspelling, misplaced []keyOccurrence) {
+	occurrences, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return nil, nil
+	}
+	known := c.knownKeys()
+	for _, o := range occurrences {
+		if _, ok := known[o.Key]; ok {
+			if o.Misplaced {
+				misplaced = append(misplaced, o)
+			}
+			continue
+		}
+		if c.isInlineKey(o.Key) {
+			continue
+		}
+		if k := similarKey(o.Key, known); k != "" {
+			misspelled = append(misspelled, misspelling{keyOccurrence: o, Known: k, Field: known[k]})
+		}
+	}
+	return misspelled, misplaced
+}
+
+// misplacedMessage explains where the client library reads the misplaced
+// key of o.
+func (c *ConfigFile) misplacedMessage(o keyOccurrence) string {
+	want := configScopeName(c.Lang, c.knownKeys()[o.Key])
+	if o.Section == "" {
+		return i18n.Sprintf("%s on line %d is not in a section, but the client library only reads it in [%s].",
+			o.Key, o.Line, want)
+	}
+	return i18n.Sprintf("%s on line %d is in [%s], but the client library only reads it in [%s].",
+		o.Key, o.Line, o.Section, want)
+}
+
+// misplacedField returns the first misplaced occurrence of the key of field.
+// known maps the keys to the fields.
+func misplacedField(misplaced []keyOccurrence, known map[string]string, field string) (keyOccurrence, bool) {
+	for _, o := range misplaced {
+		if known[o.Key] == field {
+			return o, true
+		}
+	}
+	return keyOccurrence{}, false
+}
+
+// misspelledField returns the first misspelling of the key of field.
+func misspelledField(misspelled []misspelling, field string) (misspelling, bool) {
+	for _, m := range misspelled {
+		if m.Field == field {
+			return m, true
+		}
+	}
+	return misspelling{}, false
+}
+
+// fileKeys returns the keys set in the configuration file at path, in the
+// order of the file.
+func (c *ConfigFile) fileKeys(path string) ([]keyOccurrence, error) {
+	content, err := c.readText(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.Format == JSONFormat:
+		doc, err := scanJSON(content)
+		if err != nil {
+			return nil, err
+		}
+		return doc.occurrences(), nil
+	case c.Lang == "dotnet":
+		return scanDotNetXML(content)
+	}
+	occurrences, _, err := c.scanKeyValues(content)
+	return occurrences, err
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := ConfigKeys{
+		ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+		ClientSecret: "GoodClientSecret",
+		DevToken:     "GoodDevToken",
+		RefreshToken: "1//GoodRefreshToken",
+	}
+
+	tests := []struct {
+		desc       string
+		cfg        ConfigFile
+		customerID string
+		want       []string
+	}{
+		{
+			desc: "No findings",
+			cfg:  ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: valid},
+		},
+		{
+			desc: "Access token instead of refresh token",
+			cfg: ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.RefreshToken = "ya29.a0AfH6SMB"
+				return k
+			}()},
+			want: []string{"WARNING RefreshToken looks like an access token"},
+		},
+		{
+			desc: "API key instead of client secret",
+			cfg: ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.ClientSecret = "AIzaSyD-fake"
+				return k
+			}()},
+			want: []string{"WARNING ClientSecret looks like an API key"},
+		},
+		{
+			desc: "Dev token with whitespace",
+			cfg: ConfigFile{Lang: "dotnet", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.DevToken = "GoodDevToken "
+				return k
+			}()},
+			want: []string{"WARNING Dev token contains whitespace"},
+		},
+		{
+			desc: "Workload identity federation needs no delegated account",
+			cfg: ConfigFile{Lang: "python", OAuthType: ServiceAccount,
+				ConfigKeys:         ConfigKeys{DevToken: "GoodDevToken", PrivateKeyPath: filepath.Join(dir, "testdata", "external_account.json")},
+				ServiceAccountInfo: ServiceAccountInfo{Type: ExternalAccount}},
+		},
+		{
+			desc: "Service account needs a delegated account",
+			cfg: ConfigFile{Lang: "python", OAuthType: ServiceAccount,
+				ConfigKeys:         ConfigKeys{DevToken: "GoodDevToken", PrivateKeyPath: filepath.Join(dir, "testdata", "service_account.json")},
+				ServiceAccountInfo: ServiceAccountInfo{Type: ServiceAccount}},
+			want: []string{"ERROR DelegatedAccount is empty."},
+		},
+		{
+			desc:       "Customer IDs",
+			customerID: "123-456-789",
+			cfg: ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.LoginCustomerID = "12345678901"
+				return k
+			}()},
+			want: []string{
+				"WARNING Customer ID 123-456-789 does not have 10 digits.",
+				"WARNING LoginCustomerID does not have 10 digits. Value: 12345678901",
+			},
+		},
+		{
+			desc:       "Login customer ID is the customer ID",
+			customerID: "123-456-7890",
+			cfg: ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.LoginCustomerID = "1234567890"
+				return k
+			}()},
+			want: []string{"WARNING LoginCustomerID is the customer ID itself"},
+		},
+		{
+			desc: "Duplicate keys",
+			cfg: ConfigFile{
+				Lang:       "python",
+				OAuthType:  InstalledApp,
+				Filepath:   filepath.Join(dir, "testdata"),
+				Filename:   "python_config_duplicates",
+				ConfigKeys: valid,
+			},
+			want: []string{"WARNING refresh_token is set more than once in the config file (lines 4, 6) with " +
+				"different values; the client library uses the value on line 6."},
+		},
+		{
+			desc: "Duplicate keys with the same value",
+			cfg: ConfigFile{
+				Lang:       "dotnet",
+				OAuthType:  InstalledApp,
+				Filepath:   filepath.Join(dir, "testdata"),
+				Filename:   "dotnet_config_duplicates",
+				ConfigKeys: valid,
+			},
+			want: []string{"WARNING DeveloperToken is set more than once in the config file (lines 4, 8) with " +
+				"the same value; remove all but the one on line 8."},
+		},
+		{
+			desc: "Misspelled keys",
+			cfg: ConfigFile{
+				Lang:      "python",
+				OAuthType: InstalledApp,
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "python_config_misspelled",
+				ConfigKeys: func() ConfigKeys {
+					k := valid
+					k.DevToken = ""
+					return k
+				}(),
+			},
+			want: []string{
+				"ERROR Dev token is invalid. Value: ",
+				"ERROR DevToken is empty: developer_tokn on line 1 is not a key of the client library. Did you mean developer_token?",
+				"WARNING loginCustomerId on line 6 is not a key of the client library, so it is ignored. Did you mean login_customer_id?",
+			},
+		},
+		{
+			desc: "(PHP) Keys in the wrong section",
+			cfg: ConfigFile{
+				Lang:      "php",
+				OAuthType: InstalledApp,
+				Filepath:  filepath.Join(dir, "testdata"),
+				Filename:  "php_config_misplaced",
+				ConfigKeys: func() ConfigKeys {
+					k := valid
+					k.DevToken = ""
+					return k
+				}(),
+			},
+			want: []string{
+				"ERROR Dev token is invalid. Value: ",
+				"ERROR DevToken is empty: developerToken on line 5 is in [OAUTH2], but the client library only reads it in [GOOGLE_ADS].",
+				"ERROR loginCustomerId on line 1 is not in a section, but the client library only reads it in [GOOGLE_ADS].",
+			},
+		},
+		{
+			desc: "(Python) YAML pitfalls",
+			cfg: ConfigFile{
+				Lang:       "python",
+				OAuthType:  InstalledApp,
+				Filepath:   filepath.Join(dir, "testdata"),
+				Filename:   "python_config_yaml_pitfalls",
+				ConfigKeys: valid,
+			},
+			want: []string{
+				"ERROR Line 7 is indented with a tab, which YAML does not allow.",
+				"WARNING The value of developer_token on line 1 is wrapped in two pairs of quotes",
+				"WARNING The value of client_secret on line 3 has spaces inside the quotes",
+				"WARNING The value of refresh_token on line 4 ends with a quote but does not start with one",
+				"WARNING The value of login_customer_id on line 5 is not quoted and starts with 0",
+			},
+		},
+		{
+			desc: "Errors come first",
+			cfg: ConfigFile{Lang: "python", OAuthType: InstalledApp, ConfigKeys: func() ConfigKeys {
+				k := valid
+				k.DevToken = ""
+				k.RefreshToken = "ya29.token"
+				return k
+			}()},
+			want: []string{
+				"ERROR Dev token is invalid. Value: ",
+				"ERROR DevToken is empty.",
+				"WARNING RefreshToken looks like an access token",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, f := range tt.cfg.Lint(tt.customerID) {
+			got = append(got, string(f.Severity)+" "+f.Message)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("[%s] Lint() got: %q, want: %q", tt.desc, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !strings.HasPrefix(got[i], tt.want[i]) {
+				t.Errorf("[%s] Lint() finding %d got: %s, want prefix: %s", tt.desc, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
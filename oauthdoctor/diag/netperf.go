@@ -0,0 +1,98 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Latency is the time taken by each phase of a request to an endpoint,
+// measured from the start of the request.
+type Latency struct {
+	// Connect is the duration of the TCP connection.
+	Connect time.Duration
+	// TLS is the duration of the TLS handshake. It is zero for plain HTTP
+	// endpoints.
+	TLS time.Duration
+	// FirstByte is the time until the first byte of the response.
+	FirstByte time.Duration
+}
+
+// MeasureLatency sends a request to the endpoint over a new connection and
+// measures the time taken by each phase. Any HTTP response counts, since
+// only the network is measured.
+func MeasureLatency(ctx context.Context, endpoint *url.URL, config *tls.Config) (Latency, error) {
+	var l Latency
+	var connectStart, tlsStart time.Time
+	start := time.Now()
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) { connectStart = time.Now() },
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				l.Connect = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err == nil {
+				l.TLS = time.Since(tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() { l.FirstByte = time.Since(start) },
+	}
+
+	req, err := http.NewRequest("GET", endpoint.String()+"/", nil)
+	if err != nil {
+		return l, err
+	}
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+
+	transport := checkTransport(config)
+	defer transport.CloseIdleConnections()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return l, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return l, nil
+}
+
+// Percentile returns the p-th percentile, from 0 to 100, of the durations
+// using the nearest-rank method. It returns 0 for an empty slice.
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
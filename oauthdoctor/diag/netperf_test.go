@@ -0,0 +1,75 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{5, 1, 4, 2, 3, 9, 7, 6, 8, 10}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: 1},
+		{p: 50, want: 5},
+		{p: 90, want: 9},
+		{p: 95, want: 10},
+		{p: 100, want: 10},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(durations, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %d, want: %d", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil) = %d, want: 0", got)
+	}
+	if durations[0] != 5 {
+		t.Error("Percentile() sorted its input")
+	}
+}
+
+func TestMeasureLatency(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	endpoint, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := MeasureLatency(context.Background(), endpoint, nil)
+	if err != nil {
+		t.Fatalf("MeasureLatency() error: %s", err)
+	}
+	if l.FirstByte < 10*time.Millisecond {
+		t.Errorf("MeasureLatency() first byte = %s, want at least 10ms", l.FirstByte)
+	}
+	if l.FirstByte < l.Connect {
+		t.Errorf("MeasureLatency() first byte = %s, before connect = %s", l.FirstByte, l.Connect)
+	}
+	if l.TLS != 0 {
+		t.Errorf("MeasureLatency() TLS = %s, want: 0 for HTTP", l.TLS)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+// CredentialsOAuthType returns the OAuth type of the credentials in k:
+// ServiceAccount for a JSON key file, InstalledApp for a client with a refresh
+// token and Web for a client without one. It returns an empty string when k
+// has the credentials of both kinds or of none.
+func CredentialsOAuthType(k ConfigKeys) string {
+	serviceAccount := k.PrivateKeyPath != ""
+	user := k.ClientID != "" || k.ClientSecret != "" || k.RefreshToken != ""
+	switch {
+	case serviceAccount && !user:
+		return ServiceAccount
+	case user && !serviceAccount && k.RefreshToken != "":
+		return InstalledApp
+	case user && !serviceAccount:
+		return Web
+	}
+	return ""
+}
+
+// DetectOAuthType returns the OAuth type of the credentials in k, like
+// CredentialsOAuthType. A delegated account without the credentials of a user
+// also implies a service account. It returns an empty string when the OAuth
+// type cannot be told.
+func DetectOAuthType(k ConfigKeys) string {
+	if t := CredentialsOAuthType(k); t != "" {
+		return t
+	}
+	if k.DelegatedAccount != "" && k.ClientID == "" && k.ClientSecret == "" && k.RefreshToken == "" {
+		return ServiceAccount
+	}
+	return ""
+}
+
+// ThreeLegged returns true when a user authorizes the client of the OAuth
+// type, as opposed to a service account, which authorizes itself.
+func ThreeLegged(oauthType string) bool {
+	return oauthType == InstalledApp || oauthType == Web
+}
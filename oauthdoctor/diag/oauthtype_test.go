@@ -0,0 +1,83 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import "testing"
+
+func TestCredentialsOAuthType(t *testing.T) {
+	tests := []struct {
+		desc string
+		keys ConfigKeys
+		want string
+	}{
+		{
+			desc: "Service account",
+			keys: ConfigKeys{DevToken: "token", PrivateKeyPath: "/key.json", DelegatedAccount: "a@example.com"},
+			want: ServiceAccount,
+		},
+		{
+			desc: "User with a refresh token",
+			keys: ConfigKeys{ClientID: "id", ClientSecret: "secret", RefreshToken: "1//token"},
+			want: InstalledApp,
+		},
+		{
+			desc: "User without a refresh token",
+			keys: ConfigKeys{ClientID: "id", ClientSecret: "secret"},
+			want: Web,
+		},
+		{
+			desc: "Both kinds of credentials",
+			keys: ConfigKeys{ClientID: "id", PrivateKeyPath: "/key.json"},
+		},
+		{
+			desc: "No credentials",
+			keys: ConfigKeys{DevToken: "token"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := CredentialsOAuthType(tt.keys); got != tt.want {
+			t.Errorf("[%s] CredentialsOAuthType() = %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestDetectOAuthType(t *testing.T) {
+	tests := []struct {
+		desc string
+		keys ConfigKeys
+		want string
+	}{
+		{
+			desc: "Delegated account",
+			keys: ConfigKeys{DevToken: "token", DelegatedAccount: "a@example.com"},
+			want: ServiceAccount,
+		},
+		{
+			desc: "Delegated account with a refresh token",
+			keys: ConfigKeys{DelegatedAccount: "a@example.com", ClientID: "id", RefreshToken: "1//token"},
+			want: InstalledApp,
+		},
+		{
+			desc: "No credentials",
+			keys: ConfigKeys{DevToken: "token"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := DetectOAuthType(tt.keys); got != tt.want {
+			t.Errorf("[%s] DetectOAuthType() = %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+// Reader reads the configuration of the client libraries from the
+// configuration files and the environment variables. Each diagnosis has its
+// own Reader, so the diagnoses that run in the same process do not share
+// their options. The zero Reader, and a nil *Reader, read the files and the
+// variables as they are.
+type Reader struct {
+	// DecryptCommand is a shell command that decrypts the configuration
+	// files kept encrypted with tools such as SOPS, age or ansible-vault,
+	// e.g. "sops -d --input-type yaml --output-type yaml /dev/stdin". The
+	// encrypted file is written to its stdin and the plaintext is read from
+	// its stdout, so the plaintext is never written to disk. When empty,
+	// the configuration files are read as they are.
+	DecryptCommand string
+	// CredentialFiles are the paths of the files that hold the values of
+	// the environment variables, by name of the variable, as if the
+	// variables with CredentialFileSuffix were set. They take precedence
+	// over those variables.
+	CredentialFiles map[string]string
+}
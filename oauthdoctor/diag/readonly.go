@@ -0,0 +1,111 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// managedDirs are the directories whose files are usually written by
+// configuration management, or mounted from a secret store, e.g. the secret
+// volumes of Kubernetes and Docker.
+var managedDirs = []string{"/etc/", "/run/secrets/", "/var/run/secrets/", "/var/secrets/"}
+
+// managedPath returns true if path is in one of managedDirs, or is a file of
+// a Kubernetes volume, which links to a ..data directory.
+func managedPath(path string) bool {
+	for _, dir := range managedDirs {
+		if strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return strings.Contains(path, "/..data/")
+}
+
+// ReadOnlyReason returns why the doctor must not rewrite the configuration
+// file, or "" when it can. Replacing the file would fail after the backup was
+// made when the file or its directory is read-only or owned by another user,
+// and would replace a link with a copy, or be undone by the tool that
+// manages the file.
+func (c *ConfigFile) ReadOnlyReason() string {
+	path := c.GetFilepath()
+	info, err := os.Lstat(path)
+	if err != nil {
+		// The error is reported when the file is written.
+		return ""
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return i18n.Sprintf("The config file %s is a broken link", path)
+		}
+		if managedPath(target) {
+			return i18n.Sprintf("The config file %s is a link to %s, which is managed by configuration management or mounted from a secret store", path, target)
+		}
+		return i18n.Sprintf("The config file %s is a link to %s, which the doctor would replace with a copy", path, target)
+	}
+	if managedPath(path) {
+		return i18n.Sprintf("The config file %s is in a directory that is usually managed by configuration management", path)
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return i18n.Sprintf("The config file %s is read-only", path)
+	}
+	if ownedByOther(info) {
+		return i18n.Sprintf("The config file %s is owned by another user", path)
+	}
+	// The backup and the new file are created next to the config file.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return i18n.Sprintf("The directory of the config file %s is not writable", path)
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	return ""
+}
+
+// adviseChange prints the changes of the configuration file that set key to
+// value, for the user to make, instead of writing the file. The value is
+// still used by the rest of the diagnosis. old is the current value, which is
+// redacted.
+func (c *ConfigFile) adviseChange(key, value, old, reason string) error {
+	content, _, err := c.reader.readTextFile(c.GetFilepath())
+	if err != nil {
+		return i18n.Errorf("ERROR: Problem opening config file: %s", err)
+	}
+	newContent := c.ReplaceConfigFromReader(key, value, strings.NewReader(content))
+
+	// The current secrets are redacted, but the new value is needed to make
+	// the change.
+	secrets := secretValues(c.ConfigKeys)
+	d := unifiedDiff(c.GetFilepath(), c.GetFilepath(), redactValues(splitLines(content), secrets),
+		redactValues(splitLines(newContent), secrets))
+	c.print(i18n.Sprintf("%s, so the doctor does not change it. Make this change to the config file yourself, "+
+		"or where it is managed:\n%s", reason, d))
+
+	c.SetConfigKeys(key, value)
+	c.SetSource(key, i18n.T("entered at the prompt"))
+	recordChange(key, old, i18n.Sprintf("not saved (%s)", reason), "")
+	return nil
+}
+
+// splitLines splits content into lines without their line endings.
+func splitLines(content string) []string {
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
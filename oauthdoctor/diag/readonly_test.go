@@ -0,0 +1,121 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyReason(t *testing.T) {
+	dir, err := ioutil.TempDir("", "readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := []byte("developer_token: OldDeveloperToken1234\n")
+
+	tests := []struct {
+		desc  string
+		setup func(path string) error
+		want  string
+	}{
+		{
+			desc:  "writable file",
+			setup: func(path string) error { return ioutil.WriteFile(path, content, 0600) },
+		},
+		{
+			desc:  "read-only file",
+			setup: func(path string) error { return ioutil.WriteFile(path, content, 0400) },
+			want:  "is read-only",
+		},
+		{
+			desc: "link",
+			setup: func(path string) error {
+				if err := ioutil.WriteFile(path+".target", content, 0600); err != nil {
+					return err
+				}
+				return os.Symlink(path+".target", path)
+			},
+			want: "which the doctor would replace with a copy",
+		},
+		{
+			desc:  "broken link",
+			setup: func(path string) error { return os.Symlink(path+".missing", path) },
+			want:  "is a broken link",
+		},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, "google-ads.yaml"+string(rune('a'+i)))
+		if err := tt.setup(path); err != nil {
+			t.Fatal(err)
+		}
+		c := ConfigFile{Filepath: dir, Filename: filepath.Base(path), Lang: "python"}
+		got := c.ReadOnlyReason()
+		if (tt.want == "" && got != "") || !strings.Contains(got, tt.want) {
+			t.Errorf("[%s] ReadOnlyReason() = %q, want substring: %q", tt.desc, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"/etc/google-ads/google-ads.yaml", "/var/run/secrets/ads/..data/google-ads.yaml"} {
+		if !managedPath(path) {
+			t.Errorf("managedPath(%s) = false, want true", path)
+		}
+	}
+}
+
+func TestReplaceConfigReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "readonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	content := "developer_token: OldDeveloperToken1234\nclient_id: id\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0400); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseKeyValueFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out messages
+	c.Out = &out
+
+	backup, err := c.ReplaceConfig(DevToken, "NewDeveloperToken")
+	if err != nil || backup != "" {
+		t.Fatalf("ReplaceConfig() = %q, %v, want no backup and no error", backup, err)
+	}
+	if got, _ := ioutil.ReadFile(path); string(got) != content {
+		t.Errorf("ReplaceConfig() changed the read-only file:\n%s", got)
+	}
+	if c.DevToken != "NewDeveloperToken" {
+		t.Errorf("ReplaceConfig() got DevToken %q, want NewDeveloperToken", c.DevToken)
+	}
+	got := strings.Join(out, "\n")
+	for _, want := range []string{"is read-only", "@@ -1,2 +1,2 @@\n-developer_token: OldD...1234\n+developer_token: NewDeveloperToken\n client_id: **"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ReplaceConfig() got output:\n%s\nwant substring: %s", got, want)
+		}
+	}
+	if strings.Contains(got, "OldDeveloperToken1234") {
+		t.Errorf("ReplaceConfig() printed the old value:\n%s", got)
+	}
+	if files, _ := filepath.Glob(path + "_*"); len(files) > 0 {
+		t.Errorf("ReplaceConfig() made backups %v of the read-only file", files)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// Thresholds below which the resources of the machine are reported as low.
+const (
+	lowMemory = 256 << 20
+	lowDisk   = 100 << 20
+	// lowOpenFiles is the open files limit under which gRPC connections
+	// and log files of long-running applications may fail.
+	lowOpenFiles = 1024
+)
+
+// unlimited is the value of a resource limit without limit.
+const unlimited = ^uint64(0)
+
+// Resources are the resources of this machine that may run out in a
+// long-running application. A value of 0 is unknown.
+type Resources struct {
+	// MemAvailable is the memory available to new processes, in bytes.
+	MemAvailable uint64 `json:"memAvailable,omitempty"`
+	MemTotal     uint64 `json:"memTotal,omitempty"`
+	// DiskFree is the free space of the volume of DiskPath, in bytes.
+	DiskPath string `json:"diskPath"`
+	DiskFree uint64 `json:"diskFree,omitempty"`
+	// OpenFiles and OpenFilesMax are the soft and hard limits of open file
+	// descriptors, which Windows does not have.
+	OpenFiles    uint64 `json:"openFiles,omitempty"`
+	OpenFilesMax uint64 `json:"openFilesMax,omitempty"`
+}
+
+// ReadResources reads the memory and open file limits of this machine and
+// the free space of the volume of dir, e.g. the directory of the
+// configuration file, where the client libraries may write logs.
+func ReadResources(dir string) Resources {
+	r := Resources{DiskPath: dir}
+	r.MemAvailable, r.MemTotal = memory()
+	r.DiskFree, _ = diskFree(dir)
+	r.OpenFiles, r.OpenFilesMax = openFiles()
+	return r
+}
+
+// String returns the resources as lines of text.
+func (r Resources) String() string {
+	var s string
+	switch {
+	case r.MemAvailable > 0:
+		s += i18n.Sprintf("Memory: %s available of %s\n", formatBytes(r.MemAvailable), formatBytes(r.MemTotal))
+	case r.MemTotal > 0:
+		s += i18n.Sprintf("Memory: %s\n", formatBytes(r.MemTotal))
+	}
+	if r.DiskFree > 0 {
+		s += i18n.Sprintf("Disk free: %s on %s\n", formatBytes(r.DiskFree), r.DiskPath)
+	}
+	if r.OpenFiles > 0 {
+		s += i18n.Sprintf("Open files limit: %s (hard limit %s)\n", formatLimit(r.OpenFiles), formatLimit(r.OpenFilesMax))
+	}
+	return s
+}
+
+// Warnings returns the resources that are low.
+func (r Resources) Warnings() []string {
+	var warnings []string
+	if r.MemAvailable > 0 && r.MemAvailable < lowMemory {
+		warnings = append(warnings, i18n.Sprintf("Only %s of memory is available, which may not be enough "+
+			"for your application and the client library.", formatBytes(r.MemAvailable)))
+	}
+	if r.DiskFree > 0 && r.DiskFree < lowDisk {
+		warnings = append(warnings, i18n.Sprintf("Only %s of disk space is free on %s. The logs of the "+
+			"client library cannot be written when the disk is full.", formatBytes(r.DiskFree), r.DiskPath))
+	}
+	if r.OpenFiles > 0 && r.OpenFiles < lowOpenFiles {
+		warnings = append(warnings, i18n.Sprintf("The open files limit is %d, which long-running applications "+
+			"with many connections exceed. Raise it with ulimit -n or LimitNOFILE of systemd.", r.OpenFiles))
+	}
+	return warnings
+}
+
+// meminfo returns the available and total memory of the content of
+// /proc/meminfo, in bytes.
+func meminfo(content string) (available, total uint64) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemAvailable:":
+			available = kb << 10
+		case "MemTotal:":
+			total = kb << 10
+		}
+	}
+	return available, total
+}
+
+// formatBytes returns n in the largest binary unit, e.g. 1.5 GiB.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTP"[exp])
+}
+
+// formatLimit returns a resource limit as text.
+func formatLimit(n uint64) string {
+	if n == unlimited {
+		return i18n.T("unlimited")
+	}
+	return strconv.FormatUint(n, 10)
+}
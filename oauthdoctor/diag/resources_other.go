@@ -0,0 +1,34 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package diag
+
+import "errors"
+
+// memory returns 0 since the memory is unknown on this system.
+func memory() (available, total uint64) {
+	return 0, 0
+}
+
+// diskFree returns an error since the free space is unknown on this system.
+func diskFree(path string) (uint64, error) {
+	return 0, errors.New("not supported")
+}
+
+// openFiles returns 0 since the limits are unknown on this system.
+func openFiles() (soft, hard uint64) {
+	return 0, 0
+}
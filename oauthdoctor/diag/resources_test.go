@@ -0,0 +1,101 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMeminfo(t *testing.T) {
+	content := "MemTotal:        8052736 kB\nMemFree:          512000 kB\nMemAvailable:    3145728 kB\nBuffers:  oops kB\n"
+	available, total := meminfo(content)
+	if available != 3<<30 || total != 8052736<<10 {
+		t.Errorf("meminfo() got: %d, %d, want: %d, %d", available, total, uint64(3<<30), uint64(8052736<<10))
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 512, want: "512 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 100 << 20, want: "100.0 MiB"},
+		{n: 5 << 30, want: "5.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) got: %s, want: %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestResourcesWarnings(t *testing.T) {
+	tests := []struct {
+		desc string
+		r    Resources
+		want []string
+	}{
+		{
+			desc: "enough resources",
+			r:    Resources{MemAvailable: 4 << 30, DiskPath: "/home/ads", DiskFree: 10 << 30, OpenFiles: 4096, OpenFilesMax: unlimited},
+		},
+		{
+			desc: "unknown resources",
+			r:    Resources{DiskPath: "/home/ads"},
+		},
+		{
+			desc: "low resources",
+			r:    Resources{MemAvailable: 100 << 20, DiskPath: "/home/ads", DiskFree: 1 << 20, OpenFiles: 256, OpenFilesMax: 4096},
+			want: []string{"Only 100.0 MiB of memory", "Only 1.0 MiB of disk space is free on /home/ads", "open files limit is 256"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.r.Warnings()
+		if len(got) != len(tt.want) {
+			t.Errorf("[%s] got %d warnings %v, want %d", tt.desc, len(got), got, len(tt.want))
+			continue
+		}
+		for i, w := range got {
+			if !strings.Contains(w, tt.want[i]) {
+				t.Errorf("[%s] got: %s, want substring: %s", tt.desc, w, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestReadResources(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the resources are only read in full on Linux")
+	}
+	dir, err := ioutil.TempDir("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := ReadResources(dir)
+	if r.MemTotal == 0 || r.DiskFree == 0 || r.OpenFiles == 0 || r.DiskPath != dir {
+		t.Errorf("ReadResources() got: %+v, want the memory, free disk space and open files limit", r)
+	}
+	if s := r.String(); !strings.Contains(s, "Disk free: ") || !strings.Contains(s, "Open files limit: ") {
+		t.Errorf("Resources.String() got:\n%s", s)
+	}
+}
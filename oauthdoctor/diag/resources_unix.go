@@ -0,0 +1,60 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package diag
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// memory returns the available and total memory of this machine, in bytes.
+func memory() (available, total uint64) {
+	if b, err := ioutil.ReadFile("/proc/meminfo"); err == nil {
+		return meminfo(string(b))
+	}
+	// macOS and FreeBSD only tell the physical memory.
+	for _, name := range []string{"hw.memsize", "hw.physmem"} {
+		if out, err := exec.Command("sysctl", "-n", name).Output(); err == nil {
+			if n, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64); err == nil {
+				return 0, n
+			}
+		}
+	}
+	return 0, 0
+}
+
+// diskFree returns the space of the volume of path available to this user,
+// in bytes.
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// openFiles returns the soft and hard limits of open file descriptors.
+func openFiles() (soft, hard uint64) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, 0
+	}
+	return uint64(rl.Cur), uint64(rl.Max)
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// RESTLanguage is the language of users who call the REST interface of the
+// Google Ads API directly, e.g. with curl or Perl, without a client library.
+// It has no configuration file; its ConfigKeys are the names of the
+// environment variables that hold the values.
+const RESTLanguage = "rest"
+
+// RESTConfigFile returns the configuration of RESTLanguage made of keys, with
+// the empty values read from the environment variables or their credential
+// files. The values of keys are given on the command line.
+func (r *Reader) RESTConfigFile(oauthType string, keys ConfigKeys) ConfigFile {
+	c := ConfigFile{Lang: RESTLanguage, OAuthType: oauthType, ConfigKeys: keys, reader: r}
+	values := structs.New(&c.ConfigKeys)
+	for field, env := range structs.Map(Languages[RESTLanguage].Cfg.ConfigKeys) {
+		f := values.Field(field)
+		if f.Value().(string) != "" {
+			c.SetSource(field, i18n.T("command line"))
+		} else if v, source := r.getenv(env.(string)); v != "" {
+			f.Set(v)
+			c.SetSource(field, source)
+		}
+	}
+	return c
+}
+
+// RESTConfigFile returns the configuration of RESTLanguage made of keys with
+// the zero Reader.
+func RESTConfigFile(oauthType string, keys ConfigKeys) ConfigFile {
+	return (*Reader)(nil).RESTConfigFile(oauthType, keys)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRESTConfigFile(t *testing.T) {
+	for k, v := range map[string]string{
+		"GOOGLE_ADS_DEVELOPER_TOKEN": "EnvDevToken",
+		"GOOGLE_ADS_CLIENT_ID":       "EnvClientID",
+	} {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		if ok {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+
+	got := RESTConfigFile(InstalledApp, ConfigKeys{ClientID: "FlagClientID"})
+	if got.DevToken != "EnvDevToken" {
+		t.Errorf("RESTConfigFile() DevToken = %q, want: %q", got.DevToken, "EnvDevToken")
+	}
+	if got.ConfigKeys.ClientID != "FlagClientID" {
+		t.Errorf("RESTConfigFile() ClientID = %q, want: %q", got.ConfigKeys.ClientID, "FlagClientID")
+	}
+	if src := got.Source(DevToken); src != "environment variable GOOGLE_ADS_DEVELOPER_TOKEN" {
+		t.Errorf("RESTConfigFile() DevToken source = %q, want the environment variable", src)
+	}
+	if src := got.Source(ClientID); src != "command line" {
+		t.Errorf("RESTConfigFile() ClientID source = %q, want: %q", src, "command line")
+	}
+	if got.Lang != RESTLanguage || got.OAuthType != InstalledApp {
+		t.Errorf("RESTConfigFile() = %s %s, want: %s %s", got.Lang, got.OAuthType, RESTLanguage, InstalledApp)
+	}
+
+	backup, err := got.ReplaceConfig(RefreshToken, "NewRefreshToken")
+	if backup != "" || err != nil {
+		t.Errorf("ReplaceConfig() = %q, %v, want no backup and no error", backup, err)
+	}
+	if got.RefreshToken != "NewRefreshToken" {
+		t.Errorf("ReplaceConfig() RefreshToken = %q, want: %q", got.RefreshToken, "NewRefreshToken")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+var (
+	rubyBlockRegex  = regexp.MustCompile(`Config\.new\s*(?:do\s*|\{\s*)\|\s*(\w+)\s*\|`)
+	rubyAssignRegex = regexp.MustCompile(`^\s*(\w+)\.(\w+)\s*=`)
+	rubyEnvRegex    = regexp.MustCompile(`^ENV\s*\[\s*(['"])(\w+)['"]\s*\]`)
+	rubyFetchRegex  = regexp.MustCompile(`^ENV\.fetch\s*\(\s*['"](\w+)['"]\s*(?:,\s*(?:'([^']*)'|"([^"#]*)")\s*)?\)`)
+	rubyIntRegex    = regexp.MustCompile(`^[0-9][0-9_]*\b`)
+	rubyCallRegex   = regexp.MustCompile(`^(?:\.(?:freeze|strip|to_s))*\s*(?:#.*)?$`)
+)
+
+// rubyAssignment is the assignment of an attribute of the configuration
+// block of the Ruby client library, e.g. c.client_id = 'ID'.
+type rubyAssignment struct {
+	// key is the attribute with the c. prefix of the known keys, whatever
+	// the name of the block variable.
+	key string
+	// line is the line of the key, keyEnd the offset after the key and
+	// valueLine the line of the value, which may follow the key line.
+	line, keyEnd, valueLine int
+	// start and end are the offsets of the value in valueLine, without its
+	// quotes when quote is not 0.
+	start, end int
+	quote      byte
+	value      string
+	// env is the environment variable that the value is read from.
+	env string
+	// expr is the expression of a value that cannot be read without
+	// running the code, e.g. with string interpolation.
+	expr string
+}
+
+// scanRuby returns the assignments of the attributes of the block variable
+// in the lines of a Ruby configuration file, and the name of the block
+// variable. Comments, including =begin and =end blocks, are skipped.
+func scanRuby(lines []string) ([]rubyAssignment, string) {
+	blockVar := "c"
+	var assignments []rubyAssignment
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(line, "=begin") {
+			for i < len(lines) && !strings.HasPrefix(lines[i], "=end") {
+				i++
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if m := rubyBlockRegex.FindStringSubmatch(line); m != nil {
+			blockVar = m[1]
+			continue
+		}
+		m := rubyAssignRegex.FindStringSubmatchIndex(line)
+		if m == nil || line[m[2]:m[3]] != blockVar || strings.HasPrefix(line[m[1]:], "=") {
+			continue
+		}
+		a := rubyAssignment{key: "c." + line[m[4]:m[5]], line: i, keyEnd: m[5]}
+		a.valueLine, a.start = i, m[1]
+		// The value may start on the next line.
+		for a.valueLine < len(lines) {
+			rest := lines[a.valueLine][a.start:]
+			if t := strings.TrimSpace(rest); t != "" && !strings.HasPrefix(t, "#") {
+				a.start += len(rest) - len(strings.TrimLeft(rest, " \t"))
+				break
+			}
+			a.valueLine, a.start = a.valueLine+1, 0
+		}
+		if a.valueLine == len(lines) {
+			continue
+		}
+		a.parseValue(lines[a.valueLine])
+		assignments = append(assignments, a)
+		i = a.valueLine
+	}
+	return assignments, blockVar
+}
+
+// parseValue reads the value that starts at a.start in line.
+func (a *rubyAssignment) parseValue(line string) {
+	s := line[a.start:]
+	end := -1
+	switch {
+	case s[0] == '\'' || s[0] == '"':
+		if close := rubyStringEnd(s, s[0]); close > 0 {
+			a.quote, a.value = s[0], rubyUnescape(s[1:close], s[0])
+			a.start, a.end, end = a.start+1, a.start+close, close+1
+			if s[0] == '"' && strings.Contains(a.value, "#{") {
+				a.expr, a.value = s[:end], ""
+			}
+		}
+	case strings.HasPrefix(s, "%q") || strings.HasPrefix(s, "%Q") || (len(s) > 1 && s[0] == '%' && strings.ContainsRune("([{<|!/", rune(s[1]))):
+		open := 1
+		if s[1] == 'q' || s[1] == 'Q' {
+			open = 2
+		}
+		if open < len(s) {
+			closing := map[byte]byte{'(': ')', '[': ']', '{': '}', '<': '>'}[s[open]]
+			if closing == 0 {
+				closing = s[open]
+			}
+			if close := strings.IndexByte(s[open+1:], closing); close >= 0 {
+				a.value = s[open+1 : open+1+close]
+				end = open + 2 + close
+				if s[1] != 'q' && strings.Contains(a.value, "#{") {
+					a.expr, a.value = s[:end], ""
+				}
+				a.end = a.start + end
+			}
+		}
+	case rubyEnvRegex.MatchString(s):
+		m := rubyEnvRegex.FindStringSubmatch(s)
+		a.env, a.value, end = m[2], os.Getenv(m[2]), len(m[0])
+		a.end = a.start + end
+	case rubyFetchRegex.MatchString(s):
+		m := rubyFetchRegex.FindStringSubmatch(s)
+		a.env, end = m[1], len(m[0])
+		a.value = os.Getenv(m[1])
+		if a.value == "" {
+			a.value = m[2] + m[3]
+		}
+		a.end = a.start + end
+	case strings.HasPrefix(s, "nil") && (len(s) == 3 || !isWordByte(s[3])):
+		end = 3
+		a.end = a.start + end
+	case rubyIntRegex.MatchString(s):
+		end = len(rubyIntRegex.FindString(s))
+		a.value = strings.Replace(s[:end], "_", "", -1)
+		a.end = a.start + end
+	}
+
+	// Method calls that keep the value, such as .freeze, and a comment may
+	// follow the value. Anything else makes it an expression.
+	if end < 0 || !rubyCallRegex.MatchString(s[end:]) {
+		expr := s
+		if i := strings.Index(expr, " #"); i >= 0 {
+			expr = expr[:i]
+		}
+		a.expr = strings.TrimSpace(expr)
+		a.quote, a.value, a.env = 0, "", ""
+		a.end = a.start + len(a.expr)
+	}
+}
+
+// rubyStringEnd returns the offset of the quote that closes the string
+// literal at the start of s, or -1.
+func rubyStringEnd(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// rubyUnescape returns the content of a string literal without its escape
+// sequences. Single-quoted strings only escape the quote and the backslash.
+func rubyUnescape(s string, quote byte) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch {
+		case s[i] == quote || s[i] == '\\':
+			b.WriteByte(s[i])
+		case quote == '"' && s[i] == 'n':
+			b.WriteByte('\n')
+		case quote == '"' && s[i] == 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// scanRubyKeys returns the keys set in the content of a Ruby configuration
+// file, in the order of the file. The values that cannot be read without
+// running the code are returned as lineErrs, and so are the environment
+// variables that are not set.
+func scanRubyKeys(content string) (occurrences []keyOccurrence, lineErrs []error) {
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	assignments, _ := scanRuby(lines)
+	for _, a := range assignments {
+		o := keyOccurrence{Key: a.key, Value: a.value, Line: a.line + 1, Env: a.env, Expr: a.expr}
+		switch {
+		case a.expr != "":
+			lineErrs = append(lineErrs, i18n.Errorf("Cannot read the value of %s on line %d without running the code: %s",
+				a.key, o.Line, a.expr))
+		case a.env != "" && a.value == "":
+			lineErrs = append(lineErrs, i18n.Errorf("%s on line %d reads the environment variable %s, which is not set.",
+				a.key, o.Line, a.env))
+		}
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, lineErrs
+}
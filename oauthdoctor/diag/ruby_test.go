@@ -0,0 +1,100 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanRubyKeys(t *testing.T) {
+	os.Setenv("OAUTHDOCTOR_TEST_SECRET", "EnvSecret")
+	defer os.Unsetenv("OAUTHDOCTOR_TEST_SECRET")
+	os.Unsetenv("OAUTHDOCTOR_TEST_UNSET")
+
+	content := "# c.client_id = 'commented'\n" +
+		"=begin\n" +
+		"c.client_id = 'in a block comment'\n" +
+		"=end\n" +
+		"Google::Ads::GoogleAds::Config.new do |config|\n" +
+		"  config.client_id = 'It\\'s'.freeze # comment\n" +
+		"  config.client_secret = ENV['OAUTHDOCTOR_TEST_SECRET']\n" +
+		"  config.developer_token = %q(DevToken)\n" +
+		"  config.refresh_token =\n" +
+		"    \"Refresh\\tToken\"\n" +
+		"  config.login_customer_id = 123_456_7890\n" +
+		"  config.api_endpoint = ENV.fetch('OAUTHDOCTOR_TEST_UNSET', 'https://example.com')\n" +
+		"  config.impersonate = \"#{user}@example.com\"\n" +
+		"  config.keyfile = nil\n" +
+		"  config.log_level = 'INFO' if config.client_id == 'x'\n" +
+		"  c.client_id = 'not the block variable'\n" +
+		"end\n"
+
+	want := []keyOccurrence{
+		{Key: "c.client_id", Value: "It's", Line: 6},
+		{Key: "c.client_secret", Value: "EnvSecret", Line: 7, Env: "OAUTHDOCTOR_TEST_SECRET"},
+		{Key: "c.developer_token", Value: "DevToken", Line: 8},
+		{Key: "c.refresh_token", Value: "Refresh\tToken", Line: 9},
+		{Key: "c.login_customer_id", Value: "1234567890", Line: 11},
+		{Key: "c.api_endpoint", Value: "https://example.com", Line: 12, Env: "OAUTHDOCTOR_TEST_UNSET"},
+		{Key: "c.impersonate", Line: 13, Expr: "\"#{user}@example.com\""},
+		{Key: "c.keyfile", Line: 14},
+		{Key: "c.log_level", Line: 15, Expr: "'INFO' if config.client_id == 'x'"},
+	}
+	got, lineErrs := scanRubyKeys(content)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanRubyKeys() =\n%+v\nwant\n%+v", got, want)
+	}
+	if len(lineErrs) != 2 {
+		t.Errorf("scanRubyKeys() returned %d line errors, want 2 for the expressions: %v", len(lineErrs), lineErrs)
+	}
+}
+
+func TestReplaceConfigRubyEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig := "Google::Ads::GoogleAds::Config.new do |c|\n" +
+		"  c.refresh_token = ENV.fetch('OAUTHDOCTOR_TEST_TOKEN')\n" +
+		"  c.client_secret = File.read('secret').strip\n" +
+		"end\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "google_ads_config.rb"), []byte(orig), 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	cfg := ConfigFile{Filepath: dir, Filename: "google_ads_config.rb", Lang: "ruby"}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{RefreshToken, "RefreshToken is read from the environment variable OAUTHDOCTOR_TEST_TOKEN"},
+		{ClientSecret, "ClientSecret is set by the code File.read('secret').strip on line 3"},
+	}
+	for _, tt := range tests {
+		_, err = cfg.ReplaceConfig(tt.key, "NewValue")
+		if !strings.Contains(errstring(err), tt.want) {
+			t.Errorf("ReplaceConfig(%s) error: %s, want: %s", tt.key, errstring(err), tt.want)
+		}
+	}
+	if got, _ := ioutil.ReadFile(cfg.GetFilepath()); string(got) != orig {
+		t.Errorf("ReplaceConfig() changed the file to %q, want: %q", got, orig)
+	}
+}
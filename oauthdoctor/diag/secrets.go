@@ -0,0 +1,194 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+// This file contains functions that resolve configuration values that
+// reference secrets in Google Secret Manager or HashiCorp Vault.
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// SecretManagerPrefix starts a reference to a secret in Google Secret
+	// Manager: sm://PROJECT/SECRET or sm://PROJECT/SECRET/VERSION.
+	SecretManagerPrefix = "sm://"
+	// VaultPrefix starts a reference to a secret in HashiCorp Vault:
+	// vault://PATH:FIELD, e.g. vault://secret/data/google-ads:refresh_token.
+	// The server and token are read from VAULT_ADDR and VAULT_TOKEN.
+	VaultPrefix = "vault://"
+)
+
+// cloudPlatformScope is the OAuth2 scope of Secret Manager.
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+var (
+	secretManagerURL = "https://secretmanager.googleapis.com/v1/"
+
+	// defaultTokenSource returns the Application Default Credentials used
+	// to access Secret Manager and Service Usage.
+	defaultTokenSource = func(ctx context.Context) (oauth2.TokenSource, error) {
+		creds, err := google.FindDefaultCredentials(ctx, cloudPlatformScope)
+		if err != nil {
+			return nil, err
+		}
+		return creds.TokenSource, nil
+	}
+)
+
+// IsSecretReference returns true when a configuration value references a
+// secret in Secret Manager or Vault instead of holding it.
+func IsSecretReference(v string) bool {
+	return strings.HasPrefix(v, SecretManagerPrefix) || strings.HasPrefix(v, VaultPrefix)
+}
+
+// ResolveSecrets replaces the values of ConfigKeys that reference secrets
+// with the secrets fetched with client, and returns the keys that were
+// resolved. It stops at the first secret that cannot be fetched.
+func (c *ConfigFile) ResolveSecrets(ctx context.Context, client *http.Client) ([]string, error) {
+	var resolved []string
+	vals := reflect.ValueOf(&c.ConfigKeys).Elem()
+	for i := 0; i < vals.NumField(); i++ {
+		ref := vals.Field(i).String()
+		if !IsSecretReference(ref) {
+			continue
+		}
+		key := vals.Type().Field(i).Name
+		secret, err := fetchSecret(ctx, client, ref)
+		if err != nil {
+			return resolved, i18n.Errorf("Cannot resolve %s from %s: %s", key, ref, err)
+		}
+		vals.Field(i).SetString(strings.TrimSpace(secret))
+		if source := c.Source(key); source != "" {
+			c.SetSource(key, i18n.Sprintf("%s, referenced at %s", ref, source))
+		} else {
+			c.SetSource(key, ref)
+		}
+		resolved = append(resolved, key)
+	}
+	return resolved, nil
+}
+
+// fetchSecret returns the secret referenced by ref, fetched with client. The
+// OAuth2 library sends the token requests of Secret Manager with the client
+// of the context.
+func fetchSecret(ctx context.Context, client *http.Client, ref string) (string, error) {
+	if strings.HasPrefix(ref, SecretManagerPrefix) {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+		return fetchSecretManager(ctx, strings.TrimPrefix(ref, SecretManagerPrefix))
+	}
+	return fetchVault(ctx, client, strings.TrimPrefix(ref, VaultPrefix))
+}
+
+// fetchSecretManager accesses the secret version PROJECT/SECRET[/VERSION]
+// with the Application Default Credentials. The latest version is used by
+// default.
+func fetchSecretManager(ctx context.Context, name string) (string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) == 2 {
+		parts = append(parts, "latest")
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("want %sPROJECT/SECRET or %[1]sPROJECT/SECRET/VERSION", SecretManagerPrefix)
+	}
+	ts, err := defaultTokenSource(ctx)
+	if err != nil {
+		return "", fmt.Errorf("no Application Default Credentials: %s", err)
+	}
+
+	u := fmt.Sprintf("%sprojects/%s/secrets/%s/versions/%s:access", secretManagerURL, parts[0], parts[1], parts[2])
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getJSON(ctx, oauth2.NewClient(ctx, ts), u, nil, &resp); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// fetchVault reads the field of the secret at PATH:FIELD from the Vault
+// server in VAULT_ADDR with VAULT_TOKEN. Both versions of the key-value
+// secrets engine are supported.
+func fetchVault(ctx context.Context, client *http.Client, ref string) (string, error) {
+	idx := strings.LastIndex(ref, ":")
+	if idx <= 0 || idx == len(ref)-1 {
+		return "", fmt.Errorf("want %sPATH:FIELD", VaultPrefix)
+	}
+	path, field := ref[:idx], ref[idx+1:]
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	u := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	header := http.Header{"X-Vault-Token": {os.Getenv("VAULT_TOKEN")}}
+	if err := getJSON(ctx, client, u, header, &resp); err != nil {
+		return "", err
+	}
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	v, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("the secret at %s has no field %s", path, field)
+	}
+	return v, nil
+}
+
+// getJSON sends a GET request to u with header and decodes the JSON response
+// into v.
+func getJSON(ctx context.Context, client *http.Client, u string, header http.Header, v interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/projects/my-project/secrets/client-secret/versions/latest:access", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer adc-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// base64 of "SecretFromSecretManager\n"
+		w.Write([]byte(`{"name": "projects/1/secrets/client-secret/versions/3", "payload": {"data": "U2VjcmV0RnJvbVNlY3JldE1hbmFnZXIK"}}`))
+	})
+	mux.HandleFunc("/v1/secret/data/google-ads", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"refresh_token": "1/TokenFromVault"}, "metadata": {"version": 1}}}`))
+	})
+	mux.HandleFunc("/v1/kv/google-ads", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"developer_token": "TokenFromVaultV1"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	origURL, origTokenSource := secretManagerURL, defaultTokenSource
+	secretManagerURL = server.URL + "/v1/"
+	defaultTokenSource = func(context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "adc-token"}), nil
+	}
+	origAddr, origToken := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	os.Setenv("VAULT_ADDR", server.URL)
+	os.Setenv("VAULT_TOKEN", "vault-token")
+	defer func() {
+		secretManagerURL, defaultTokenSource = origURL, origTokenSource
+		os.Setenv("VAULT_ADDR", origAddr)
+		os.Setenv("VAULT_TOKEN", origToken)
+	}()
+
+	tests := []struct {
+		desc         string
+		keys         ConfigKeys
+		want         ConfigKeys
+		wantResolved string
+		errstr       string
+	}{
+		{
+			desc: "Secret Manager and Vault references",
+			keys: ConfigKeys{
+				ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+				ClientSecret: "sm://my-project/client-secret",
+				DevToken:     "vault://kv/google-ads:developer_token",
+				RefreshToken: "vault://secret/data/google-ads:refresh_token",
+			},
+			want: ConfigKeys{
+				ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+				ClientSecret: "SecretFromSecretManager",
+				DevToken:     "TokenFromVaultV1",
+				RefreshToken: "1/TokenFromVault",
+			},
+			wantResolved: "ClientSecret,DevToken,RefreshToken",
+		},
+		{
+			desc:   "Missing Vault field",
+			keys:   ConfigKeys{RefreshToken: "vault://secret/data/google-ads:token"},
+			want:   ConfigKeys{RefreshToken: "vault://secret/data/google-ads:token"},
+			errstr: "has no field token",
+		},
+		{
+			desc:   "Missing secret",
+			keys:   ConfigKeys{ClientSecret: "sm://my-project/other-secret/1"},
+			want:   ConfigKeys{ClientSecret: "sm://my-project/other-secret/1"},
+			errstr: "Cannot resolve ClientSecret from sm://my-project/other-secret/1: HTTP status 404",
+		},
+		{
+			desc:   "Invalid reference",
+			keys:   ConfigKeys{ClientSecret: "sm://my-project"},
+			want:   ConfigKeys{ClientSecret: "sm://my-project"},
+			errstr: "want sm://PROJECT/SECRET",
+		},
+	}
+
+	for _, test := range tests {
+		c := ConfigFile{ConfigKeys: test.keys}
+		resolved, err := c.ResolveSecrets(context.Background(), http.DefaultClient)
+		if !strings.Contains(errstring(err), test.errstr) || (test.errstr == "" && err != nil) {
+			t.Errorf("[%s] ResolveSecrets() error: %s, want: %s", test.desc, errstring(err), test.errstr)
+		}
+		if got := strings.Join(resolved, ","); got != test.wantResolved {
+			t.Errorf("[%s] ResolveSecrets() resolved %s, want: %s", test.desc, got, test.wantResolved)
+		}
+		if c.ConfigKeys != test.want {
+			t.Errorf("[%s] ResolveSecrets() got: %+v, want: %+v", test.desc, c.ConfigKeys, test.want)
+		}
+	}
+}
+
+func TestReplaceConfigSecretReference(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	orig := "client_id: ClientID\nrefresh_token: sm://my-project/refresh-token\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "google-ads.yaml"), []byte(orig), 0600); err != nil {
+		t.Fatalf("Error writing test config: %s", err)
+	}
+
+	cfg := ConfigFile{Filepath: dir, Filename: "google-ads.yaml", Lang: "python"}
+	_, err = cfg.ReplaceConfig(RefreshToken, "1/NewToken")
+	if want := "RefreshToken is read from sm://my-project/refresh-token"; !strings.Contains(errstring(err), want) {
+		t.Errorf("ReplaceConfig() error: %s, want: %s", errstring(err), want)
+	}
+	if got, _ := ioutil.ReadFile(cfg.GetFilepath()); string(got) != orig {
+		t.Errorf("ReplaceConfig() changed the file to %q, want: %q", got, orig)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// FileSystemTest is the name of the result of the file system checks of
+// SelfTest.
+const FileSystemTest = "filesystem"
+
+// selfTestDir is created by SelfTest with spaces and non-ASCII characters,
+// like the home directories of many users.
+const selfTestDir = "self test äöü"
+
+// goldenKeys are the values of the goldenConfigs, and updatedKeys the
+// values that SelfTest writes to them.
+var (
+	goldenKeys = ConfigKeys{DevToken: "GoldenDevToken", ClientID: "0123456789-golden.apps.googleusercontent.com",
+		ClientSecret: "GoldenClientSecret", RefreshToken: "1//0GoldenRefreshToken", LoginCustomerID: "1234567890"}
+	updatedKeys = ConfigKeys{DevToken: "UpdatedDevToken", ClientID: "9876543210-updated.apps.googleusercontent.com",
+		ClientSecret: "UpdatedClientSecret", RefreshToken: "1//0UpdatedRefreshToken", LoginCustomerID: "9876543210"}
+)
+
+// selfTestFields are the fields of ConfigKeys that SelfTest replaces.
+var selfTestFields = []string{DevToken, ClientID, ClientSecret, RefreshToken, "LoginCustomerID"}
+
+// goldenConfigs are the configuration files of each language with the
+// values of goldenKeys, laid out like the examples of the client libraries.
+var goldenConfigs = map[string]string{
+	"java": `# Google Ads API client library for Java
+api.googleads.developerToken=GoldenDevToken
+api.googleads.clientId=0123456789-golden.apps.googleusercontent.com
+api.googleads.clientSecret=GoldenClientSecret
+api.googleads.refreshToken=1//0GoldenRefreshToken
+api.googleads.loginCustomerId=1234567890
+`,
+	"dotnet": `<?xml version="1.0" encoding="utf-8" ?>
+<configuration>
+  <configSections>
+    <section name="GoogleAdsApi" type="System.Configuration.DictionarySectionHandler"/>
+  </configSections>
+  <GoogleAdsApi>
+    <!-- Google Ads API client library for .NET -->
+    <add key="DeveloperToken" value="GoldenDevToken"/>
+    <add key="LoginCustomerId" value="1234567890"/>
+    <add key="AuthorizationMethod" value="OAuth2"/>
+    <add key="OAuth2Mode" value="APPLICATION"/>
+    <add key="OAuth2ClientId" value="0123456789-golden.apps.googleusercontent.com"/>
+    <add key="OAuth2ClientSecret" value="GoldenClientSecret"/>
+    <add key="OAuth2RefreshToken" value="1//0GoldenRefreshToken"/>
+  </GoogleAdsApi>
+</configuration>
+`,
+	"php": `[GOOGLE_ADS]
+; Google Ads API client library for PHP
+developerToken = "GoldenDevToken"
+loginCustomerId = "1234567890"
+
+[OAUTH2]
+clientId = "0123456789-golden.apps.googleusercontent.com"
+clientSecret = "GoldenClientSecret"
+refreshToken = "1//0GoldenRefreshToken"
+`,
+	"python": `# Google Ads API client library for Python
+developer_token: GoldenDevToken
+login_customer_id: "1234567890"
+client_id: 0123456789-golden.apps.googleusercontent.com
+client_secret: GoldenClientSecret
+refresh_token: 1//0GoldenRefreshToken
+use_proto_plus: True
+`,
+	"nodejs": `# Google Ads API credentials
+GOOGLE_ADS_DEVELOPER_TOKEN=GoldenDevToken
+GOOGLE_ADS_LOGIN_CUSTOMER_ID=1234567890
+GOOGLE_ADS_CLIENT_ID=0123456789-golden.apps.googleusercontent.com
+GOOGLE_ADS_CLIENT_SECRET=GoldenClientSecret
+GOOGLE_ADS_REFRESH_TOKEN=1//0GoldenRefreshToken
+`,
+	"ruby": `# Google Ads API client library for Ruby
+Google::Ads::GoogleAds::Config.new do |c|
+  c.developer_token = 'GoldenDevToken'
+  c.login_customer_id = '1234567890'
+  c.client_id = '0123456789-golden.apps.googleusercontent.com'
+  c.client_secret = 'GoldenClientSecret'
+  c.refresh_token = '1//0GoldenRefreshToken'
+end
+`,
+}
+
+// SelfTestResult is the result of a test of SelfTest: a language, or the
+// file system checks. Err is nil when the test passed.
+type SelfTestResult struct {
+	Name string
+	Err  error
+	// Notes describe the platform, e.g. that the file system is case
+	// insensitive.
+	Notes []string
+}
+
+// SelfTest checks that this binary parses and rewrites the configuration
+// file of each language on this platform before it changes the files of the
+// user. In a directory under dir, whose name has spaces and non-ASCII
+// characters, it parses the golden configuration file of each language,
+// replaces its values and restores them, and checks that the file is
+// unchanged. The languages are tested in parallel. The results are sorted by
+// language, followed by the file system checks.
+func SelfTest(dir string) []SelfTestResult {
+	root := filepath.Join(dir, selfTestDir)
+	fs := SelfTestResult{Name: FileSystemTest}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		fs.Err = i18n.Errorf("cannot create a directory with spaces and non-ASCII characters in its name: %s", err)
+		return []SelfTestResult{fs}
+	}
+	insensitive, err := caseInsensitive(root)
+	switch {
+	case err != nil:
+		fs.Err = err
+	case insensitive:
+		fs.Notes = append(fs.Notes, i18n.T("The file system is case insensitive, so file names that only differ in case are the same file."))
+	default:
+		fs.Notes = append(fs.Notes, i18n.T("The file system is case sensitive, so the file names must match the case that the client library expects."))
+	}
+
+	var langs []string
+	for lang := range goldenConfigs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	results := make([]SelfTestResult, len(langs))
+	var wg sync.WaitGroup
+	for i, lang := range langs {
+		wg.Add(1)
+		go func(i int, lang string) {
+			defer wg.Done()
+			results[i] = SelfTestResult{Name: lang, Err: roundTrip(filepath.Join(root, lang), lang)}
+		}(i, lang)
+	}
+	wg.Wait()
+	return append(results, fs)
+}
+
+// roundTrip writes the golden configuration file of lang in dir, parses it,
+// replaces its values with updatedKeys and then with goldenKeys, and checks
+// that the values are parsed after each round and that the file is unchanged
+// at the end.
+func roundTrip(dir, lang string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, Languages[lang].Cfg.Filename)
+	golden := goldenConfigs[lang]
+	if err := ioutil.WriteFile(path, []byte(golden), 0600); err != nil {
+		return err
+	}
+	c, err := ParseConfigFile(lang, path, InstalledApp)
+	if err != nil {
+		return i18n.Errorf("cannot parse the golden config file: %s", err)
+	}
+	if c.ConfigKeys != goldenKeys {
+		return i18n.Errorf("the golden config file was parsed as %+v, want %+v", c.ConfigKeys, goldenKeys)
+	}
+
+	for _, want := range []ConfigKeys{updatedKeys, goldenKeys} {
+		for _, field := range selfTestFields {
+			if _, err := c.ReplaceConfig(field, structs.New(want).Field(field).Value().(string)); err != nil {
+				return i18n.Errorf("cannot replace %s: %s", field, err)
+			}
+		}
+		parsed, err := ParseConfigFile(lang, path, InstalledApp)
+		if err != nil {
+			return i18n.Errorf("cannot parse the rewritten config file: %s", err)
+		}
+		if parsed.ConfigKeys != want {
+			return i18n.Errorf("the rewritten config file was parsed as %+v, want %+v", parsed.ConfigKeys, want)
+		}
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if string(content) != golden {
+		return i18n.Errorf("the config file changed after its values were replaced and restored:\n%s", content)
+	}
+	// Windows files have no permission bits.
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		return i18n.Errorf("the permissions of the config file changed from 0600 to %#o", info.Mode().Perm())
+	}
+	return nil
+}
+
+// caseInsensitive returns true when the file system of dir does not tell
+// file names that only differ in case apart.
+func caseInsensitive(dir string) (bool, error) {
+	probe := filepath.Join(dir, "CaseProbe")
+	if err := ioutil.WriteFile(probe, nil, 0600); err != nil {
+		return false, err
+	}
+	defer os.Remove(probe)
+	_, err := os.Stat(filepath.Join(dir, "caseprobe"))
+	return err == nil, nil
+}
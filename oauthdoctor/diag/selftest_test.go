@@ -0,0 +1,46 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	results := SelfTest(dir)
+	names := make(map[string]bool)
+	for _, r := range results {
+		names[r.Name] = true
+		if r.Err != nil {
+			t.Errorf("SelfTest() %s failed: %s", r.Name, r.Err)
+		}
+	}
+	// A new language needs a golden config file.
+	for lang := range Languages {
+		if lang != RESTLanguage && !names[lang] {
+			t.Errorf("SelfTest() does not test %s", lang)
+		}
+	}
+	if last := results[len(results)-1]; last.Name != FileSystemTest || len(last.Notes) == 0 {
+		t.Errorf("SelfTest() got last result %+v, want the file system checks", last)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"golang.org/x/oauth2"
+)
+
+// GoogleAdsService is the name of the Google Ads API in the Google Cloud
+// projects.
+const GoogleAdsService = "googleads.googleapis.com"
+
+// serviceUsageURL is the Service Usage API, which tells whether an API is
+// enabled in a project.
+var serviceUsageURL = "https://serviceusage.googleapis.com/v1/"
+
+// disabledProjectRegex matches the project number in the errors of an API
+// that is disabled, e.g. "consumer": "projects/123456789" in the details or
+// "project 123456789" in the message. DEVELOPER_TOKEN_PROHIBITED errors quote
+// it: "project '123456789'".
+var disabledProjectRegex = regexp.MustCompile(`(?:projects/|project[ =]'?)([0-9]+)`)
+
+// DisabledAPIProject returns the number of the Google Cloud project in the
+// error of a disabled API, or an empty string.
+func DisabledAPIProject(errstr string) string {
+	if m := disabledProjectRegex.FindStringSubmatch(errstr); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// EnableAPIURL is the page of the Google Cloud console that enables the
+// Google Ads API in project.
+func EnableAPIURL(project string) string {
+	return "https://console.cloud.google.com/apis/library/" + GoogleAdsService + "?project=" + project
+}
+
+// GoogleAdsAPIEnabled asks the Service Usage API with the Application
+// Default Credentials whether the Google Ads API is enabled in project. The
+// credentials need the serviceusage.services.get permission in the project.
+func GoogleAdsAPIEnabled(ctx context.Context, project string) (bool, error) {
+	ts, err := defaultTokenSource(ctx)
+	if err != nil {
+		return false, fmt.Errorf("no Application Default Credentials: %s", err)
+	}
+	var resp struct {
+		State string `json:"state"`
+	}
+	u := fmt.Sprintf("%sprojects/%s/services/%s", serviceUsageURL, project, GoogleAdsService)
+	if err := getJSON(ctx, oauth2.NewClient(ctx, ts), u, nil, &resp); err != nil {
+		return false, err
+	}
+	return resp.State == "ENABLED", nil
+}
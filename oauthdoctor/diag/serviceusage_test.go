@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestDisabledAPIProject(t *testing.T) {
+	tests := []struct {
+		desc   string
+		errstr string
+		want   string
+	}{
+		{
+			desc: "Error info",
+			errstr: `{"error": {"code": 403, "status": "PERMISSION_DENIED", "details": [{"reason": "SERVICE_DISABLED", ` +
+				`"metadata": {"consumer": "projects/123456789", "service": "googleads.googleapis.com"}}]}}`,
+			want: "123456789",
+		},
+		{
+			desc:   "Message",
+			errstr: `{"error": {"message": "Google Ads API has not been used in project 987654 before or it is disabled."}}`,
+			want:   "987654",
+		},
+		{
+			desc:   "Quoted project",
+			errstr: `{"message": "Developer token is not allowed with project '123456789'."}`,
+			want:   "123456789",
+		},
+		{
+			desc:   "No project",
+			errstr: `{"error": {"status": "PERMISSION_DENIED"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := DisabledAPIProject(tt.errstr); got != tt.want {
+			t.Errorf("[%s] DisabledAPIProject() = %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestGoogleAdsAPIEnabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer adc-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v1/projects/1/services/googleads.googleapis.com":
+			w.Write([]byte(`{"name": "projects/1/services/googleads.googleapis.com", "state": "ENABLED"}`))
+		case r.URL.Path == "/v1/projects/2/services/googleads.googleapis.com":
+			w.Write([]byte(`{"name": "projects/2/services/googleads.googleapis.com", "state": "DISABLED"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "status": "PERMISSION_DENIED"}}`))
+		}
+	}))
+	defer server.Close()
+
+	origURL, origTokenSource := serviceUsageURL, defaultTokenSource
+	serviceUsageURL = server.URL + "/v1/"
+	defaultTokenSource = func(context.Context) (oauth2.TokenSource, error) {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "adc-token"}), nil
+	}
+	defer func() {
+		serviceUsageURL, defaultTokenSource = origURL, origTokenSource
+	}()
+
+	tests := []struct {
+		desc    string
+		project string
+		want    bool
+		wantErr bool
+	}{
+		{desc: "Enabled", project: "1", want: true},
+		{desc: "Disabled", project: "2", want: false},
+		{desc: "No permission", project: "3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := GoogleAdsAPIEnabled(context.Background(), tt.project)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("[%s] GoogleAdsAPIEnabled() error: %v, want error: %t", tt.desc, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] GoogleAdsAPIEnabled() = %t, want: %t", tt.desc, got, tt.want)
+		}
+	}
+}
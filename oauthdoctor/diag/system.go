@@ -14,27 +14,48 @@
 package diag
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"runtime"
-)
+	"strings"
+	"time"
 
-const (
-	// ENDPOINT is the googleapis host.
-	ENDPOINT = "googleads.googleapis.com.:80"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 )
 
 // SysInfo stores the relevant system information.
 type SysInfo struct {
-	Host     string
-	CPUs     int
-	OS       string
-	Arch     string
-	GOROOT   string
-	PageSize int
-	Heap     uint64
+	Host     string `json:"host"`
+	CPUs     int    `json:"cpus"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	GOROOT   string `json:"goroot"`
+	PageSize int    `json:"pageSize"`
+	// OSVersion is the distribution and kernel on Linux, and the version of
+	// macOS or Windows.
+	OSVersion string `json:"osVersion,omitempty"`
+	Locale    string `json:"locale,omitempty"`
+	Timezone  string `json:"timezone"`
+	// ProxyEnv are the names of the proxy environment variables that are
+	// set. Their values may hold passwords.
+	ProxyEnv   []string `json:"proxyEnv"`
+	DNSServers []string `json:"dnsServers"`
+	// VPNInterfaces are the network interfaces that are up and named like
+	// those of VPN clients.
+	VPNInterfaces []string `json:"vpnInterfaces"`
+	// Container is the container runtime, if any, see Container.
+	Container string `json:"container,omitempty"`
+	// Resources is set by the caller, which knows the directory of the
+	// configuration file.
+	Resources Resources `json:"resources"`
 }
 
 // Init intializes the struct with the runtime system parameters.
@@ -51,25 +72,201 @@ func (s *SysInfo) Init() {
 	s.GOROOT = runtime.GOROOT()
 	s.CPUs = runtime.NumCPU()
 	s.PageSize = os.Getpagesize()
-	s.Heap = heap()
+	s.OSVersion = osVersion(runtime.GOOS, ioutil.ReadFile, func(name string, args ...string) ([]byte, error) {
+		return exec.Command(name, args...).Output()
+	})
+	s.Locale = locale(os.Getenv)
+	s.Timezone = timezone(time.Now())
+	s.ProxyEnv = proxyEnv(os.Getenv)
+	s.DNSServers = []string{}
+	if b, err := ioutil.ReadFile("/etc/resolv.conf"); err == nil {
+		s.DNSServers = nameservers(string(b))
+	}
+	var up []string
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagUp != 0 {
+				up = append(up, iface.Name)
+			}
+		}
+	}
+	s.VPNInterfaces = vpnInterfaces(up)
+	s.Container = Container()
+}
+
+// String returns the contents of a Sysinfo structure as lines of text.
+func (s *SysInfo) String() string {
+	str := i18n.Sprintf("Host: %s\nCPUs: %d\nOS: %s\nArch: %s\nPageSize: %d bytes\n",
+		s.Host, s.CPUs, s.OS, s.Arch, s.PageSize)
+	if s.OSVersion != "" {
+		str += i18n.Sprintf("OS version: %s\n", s.OSVersion)
+	}
+	if s.Locale != "" {
+		str += i18n.Sprintf("Locale: %s\n", s.Locale)
+	}
+	str += i18n.Sprintf("Timezone: %s\n", s.Timezone)
+	if len(s.ProxyEnv) > 0 {
+		str += i18n.Sprintf("Proxy variables: %s\n", strings.Join(s.ProxyEnv, ", "))
+	}
+	if len(s.DNSServers) > 0 {
+		str += i18n.Sprintf("DNS servers: %s\n", strings.Join(s.DNSServers, ", "))
+	}
+	if len(s.VPNInterfaces) > 0 {
+		str += i18n.Sprintf("VPN interfaces: %s\n", strings.Join(s.VPNInterfaces, ", "))
+	}
+	if s.Container != "" {
+		str += i18n.Sprintf("Container: %s\n", s.Container)
+	}
+	return str + s.Resources.String()
+}
+
+// osVersion returns the distribution and kernel version on Linux, and the
+// version of macOS or Windows.
+func osVersion(goos string, readFile func(string) ([]byte, error), output func(string, ...string) ([]byte, error)) string {
+	switch goos {
+	case "linux":
+		var v []string
+		if b, err := readFile("/etc/os-release"); err == nil {
+			if name := osRelease(string(b)); name != "" {
+				v = append(v, name)
+			}
+		}
+		if b, err := readFile("/proc/sys/kernel/osrelease"); err == nil {
+			v = append(v, i18n.Sprintf("kernel %s", strings.TrimSpace(string(b))))
+		}
+		return strings.Join(v, ", ")
+	case "darwin":
+		if b, err := output("sw_vers", "-productVersion"); err == nil {
+			return "macOS " + strings.TrimSpace(string(b))
+		}
+	case "windows":
+		if b, err := output("cmd", "/c", "ver"); err == nil {
+			return strings.TrimSpace(string(b))
+		}
+	}
+	return ""
+}
+
+// osRelease returns the PRETTY_NAME of the content of /etc/os-release.
+func osRelease(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "PRETTY_NAME=") {
+			return strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), `"'`)
+		}
+	}
+	return ""
+}
+
+// locale returns the locale of the messages of the POSIX environment
+// variables, which the client libraries may use to format numbers and dates.
+func locale(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// timezone returns the name and UTC offset of the time zone of t, which
+// matters for the clock skew of the OAuth2 tokens and the dates of reports.
+func timezone(t time.Time) string {
+	name, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s (UTC%s%02d:%02d)", name, sign, offset/3600, offset%3600/60)
+}
+
+// proxyVars are the environment variables that configure the proxy of HTTP
+// clients, in upper and lower case.
+var proxyVars = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"http_proxy", "https_proxy", "no_proxy", "all_proxy", "GRPC_PROXY", "grpc_proxy"}
+
+// proxyEnv returns the names of the proxy environment variables that are
+// set.
+func proxyEnv(getenv func(string) string) []string {
+	names := []string{}
+	for _, name := range proxyVars {
+		if getenv(name) != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// nameservers returns the DNS servers of the content of /etc/resolv.conf.
+func nameservers(content string) []string {
+	servers := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// vpnPrefixes are the prefixes of the names of the network interfaces of
+// common VPN clients: OpenVPN, WireGuard, the macOS and PPP tunnels, and
+// IPsec.
+var vpnPrefixes = []string{"tun", "tap", "wg", "utun", "ppp", "ipsec", "gpd", "cscotun"}
+
+// vpnInterfaces returns the names that look like the interfaces of VPN
+// clients.
+func vpnInterfaces(names []string) []string {
+	vpn := []string{}
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		for _, prefix := range vpnPrefixes {
+			if strings.HasPrefix(lower, prefix) || strings.Contains(lower, "vpn") {
+				vpn = append(vpn, name)
+				break
+			}
+		}
+	}
+	return vpn
 }
 
-// Print outputs the contents of a Sysinfo structure to stdout.
-func (s *SysInfo) Print() {
-	fmt.Printf("Host: %s\nCPUs: %d\nOS: %s\nArch: %s\nPageSize: %d bytes\nHeap: %d bytes\n",
-		s.Host, s.CPUs, s.OS, s.Arch, s.PageSize, s.Heap)
+// discoveryPath is the path of the REST discovery document of the Google
+// Ads API, which can be fetched without credentials.
+const discoveryPath = "/$discovery/rest"
+
+// checkTransport returns a transport of a new connection for each request,
+// with the proxy of the environment and the TLS configuration of the checks,
+// see TLSConfig.
+func checkTransport(config *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+		TLSClientConfig:   config,
+	}
 }
 
-// heap returns the amount of heap in bytes for this runtime.
-func heap() uint64 {
-	mstats := &runtime.MemStats{}
-	runtime.ReadMemStats(mstats)
-	return mstats.TotalAlloc
+// HTTPSHealth fetches the REST discovery document of the endpoint through
+// the proxy of the environment, which goes through the TCP, TLS and HTTP
+// layers like the requests of the client libraries, and returns the HTTP
+// status code. Any response shows that the endpoint can be reached.
+func HTTPSHealth(ctx context.Context, endpoint *url.URL, config *tls.Config) (int, error) {
+	req, err := http.NewRequest("GET", endpoint.String()+discoveryPath, nil)
+	if err != nil {
+		return 0, err
+	}
+	transport := checkTransport(config)
+	defer transport.CloseIdleConnections()
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
 }
 
 // ConnEndpoint opens a tcp connection to the endpoint
-func ConnEndpoint() error {
-	conn, err := net.Dial("tcp", ENDPOINT)
+func ConnEndpoint(ctx context.Context, endpoint *url.URL) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", EndpointAddr(endpoint))
 	if err != nil {
 		return err
 	}
@@ -77,17 +274,99 @@ func ConnEndpoint() error {
 	return nil
 }
 
+// ResolveEndpoint looks up the IP addresses of the endpoint host.
+func ResolveEndpoint(ctx context.Context, endpoint *url.URL) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, endpoint.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, addr := range addrs {
+		ips = append(ips, addr.String())
+	}
+	return ips, nil
+}
+
+// TLSHandshake opens a TLS connection to the endpoint with config, see
+// TLSConfig, and verifies its certificate. It fails when a proxy intercepts
+// HTTPS traffic with a certificate that is not trusted by this machine.
+func TLSHandshake(ctx context.Context, endpoint *url.URL, config *tls.Config) error {
+	_, err := tlsConnect(ctx, endpoint, config, nil)
+	return err
+}
+
+// NegotiatedProtocol opens a TLS connection to the endpoint offering HTTP/2
+// and HTTP/1.1 with ALPN, and returns the protocol selected by the server,
+// e.g. "h2". An empty string means that no protocol was negotiated, which
+// happens when a proxy terminates TLS without ALPN support.
+func NegotiatedProtocol(ctx context.Context, endpoint *url.URL, config *tls.Config) (string, error) {
+	state, err := tlsConnect(ctx, endpoint, config, []string{"h2", "http/1.1"})
+	if err != nil {
+		return "", err
+	}
+	return state.NegotiatedProtocol, nil
+}
+
+// tlsConnect completes a TLS handshake with the endpoint with config,
+// offering the given application protocols, and returns the state of the
+// connection.
+func tlsConnect(ctx context.Context, endpoint *url.URL, config *tls.Config, nextProtos []string) (tls.ConnectionState, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", EndpointAddr(endpoint))
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	config = config.Clone()
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config.ServerName = endpoint.Hostname()
+	config.NextProtos = nextProtos
+	tlsConn := tls.Client(conn, config)
+	errc := make(chan error, 1)
+	go func() { errc <- tlsConn.Handshake() }()
+	select {
+	case err := <-errc:
+		if err != nil {
+			return tls.ConnectionState{}, err
+		}
+		return tlsConn.ConnectionState(), nil
+	case <-ctx.Done():
+		conn.Close()
+		return tls.ConnectionState{}, ctx.Err()
+	}
+}
+
 // PrintIPv4 prints local non-loopback IPv4 addresses
 func PrintIPv4(host string) {
-	addrs, err := net.LookupIP(host)
+	addrs, err := IPv4Addrs(context.Background(), host)
 	if err != nil {
-		log.Printf("ERROR: PrintIPV4: %v\n", err)
+		log.Print(i18n.Sprintf("ERROR: PrintIPV4: %v\n", err))
 	}
 
-	for _, addr := range addrs {
-		if ipv4 := addr.To4(); ipv4 != nil {
-			fmt.Printf("IPV4:%s\n ", ipv4)
+	for _, ipv4 := range addrs {
+		fmt.Printf("IPV4:%s\n ", ipv4)
+	}
+}
 
+// IPv4Addrs returns the IPv4 addresses of the given host.
+func IPv4Addrs(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var ipv4s []net.IP
+	for _, addr := range addrs {
+		if ipv4 := addr.IP.To4(); ipv4 != nil {
+			ipv4s = append(ipv4s, ipv4)
 		}
 	}
+	return ipv4s, nil
 }
@@ -0,0 +1,347 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/kylelemons/godebug/diff"
+)
+
+// hiddenValue replaces the values of PII keys in a template diff.
+const hiddenValue = "********"
+
+// templateFields are the fields of ConfigKeys in the order of the
+// configuration files distributed with the client libraries.
+var templateFields = []string{DevToken, "LoginCustomerID", ClientID, ClientSecret, RefreshToken,
+	PrivateKeyPath, DelegatedAccount, "Endpoint"}
+
+// template returns the configuration file that the client library expects
+// with the values of c: the required keys of the OAuth type and the other
+// keys that are set, in the scopes where the client library reads them.
+// Missing values are placeholders such as INSERT_DEV_TOKEN_HERE.
+func (c *ConfigFile) template(hidePII bool) *configDocument {
+	doc := parseConfigDocument(c.Lang, "")
+	for _, field := range templateFields {
+		value := c.fieldValue(field)
+		switch {
+		case value == "" && !Contains(c.requiredKeys(), field):
+			continue
+		case value == "":
+			value = "INSERT_" + snakeCase(field) + "_HERE"
+		case hidePII && IsPII(field):
+			value = hiddenValue
+		}
+		doc.set(field, c.GetConfigKeysInLang(field), value)
+		// Scopes added by set are only known after parsing the document
+		// again.
+		doc = parseConfigDocument(c.Lang, doc.String())
+	}
+	return doc
+}
+
+// fieldValue returns the value of the given field of ConfigKeys.
+func (c *ConfigFile) fieldValue(field string) string {
+	return structs.New(c.ConfigKeys).Field(field).Value().(string)
+}
+
+// snakeCase returns the field name in upper snake case, e.g. DEV_TOKEN for
+// DevToken.
+func snakeCase(field string) string {
+	var b strings.Builder
+	for i, r := range field {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(field[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// TemplateDiff compares the configuration file with the one the client
+// library expects, rendered with the values of the file. It returns a line
+// diff of the configuration keys, where the lines of the file to remove
+// start with "-" and the expected lines start with "+", and the problems
+// that the diff shows: missing keys, keys in the wrong section and
+// misspelled keys. The diff is empty when the file has the expected keys.
+// Comments, blank lines and the keys unknown to the doctor are left out.
+func (c *ConfigFile) TemplateDiff(hidePII bool) (string, []Finding, error) {
+	if c.Format == JSONFormat || c.FromEnv() {
+		return "", nil, i18n.Errorf("There is no template of the config file of %s", c.describe())
+	}
+	content, err := c.readText(c.GetFilepath())
+	if err != nil {
+		return "", nil, err
+	}
+	doc := parseConfigDocument(c.Lang, content)
+	known := c.knownKeys()
+	fields := make(map[string]string)
+	var findings []Finding
+	for _, e := range doc.entries {
+		if field, ok := known[e.key]; ok {
+			fields[e.key] = field
+			continue
+		}
+		if key := similarKey(e.key, known); key != "" {
+			fields[e.key] = known[key]
+			findings = append(findings, Finding{Severity: Warning, Key: known[key],
+				Message: i18n.Sprintf("%s is not a key of the client library: did you mean %s?", e.key, key)})
+		}
+	}
+
+	// The values set in the wrong scope are not read by the client
+	// library, but belong in the expected file.
+	expected := *c
+	present := make(map[string]bool)
+	for _, e := range doc.entries {
+		field, ok := known[e.key]
+		if !ok {
+			continue
+		}
+		present[field] = true
+		want := configScopeName(c.Lang, field)
+		if s := doc.scopeAt(e.line); want != "" && (s == nil || s.name != want) {
+			findings = append(findings, Finding{Severity: Error, Key: field,
+				Message: i18n.Sprintf("%s is set outside of %s, where the client library reads it.", e.key, scopeLabel(c.Lang, want))})
+			if expected.fieldValue(field) == "" {
+				expected.SetConfigKeys(field, doc.lines[e.line][e.start:e.end])
+			}
+		}
+	}
+	for _, field := range templateFields {
+		if Contains(c.requiredKeys(), field) && !present[field] {
+			findings = append(findings, Finding{Severity: Error, Key: field,
+				Message: i18n.Sprintf("%s is missing from the config file.", c.GetConfigKeysInLang(field))})
+		}
+	}
+
+	if hidePII {
+		doc.hideValues(fields)
+	}
+	return diffLines(doc.keyLines(fields), expected.template(hidePII).keyLines(nil)), findings, nil
+}
+
+// diffLines returns the line diff from got to want, or "" when they are the
+// same. Lines that only differ in quotes and spaces, e.g. key="value" and
+// key = value, are the same.
+func diffLines(got, want []string) string {
+	squeeze := func(lines []string) []string {
+		squeezed := make([]string, len(lines))
+		for i, line := range lines {
+			squeezed[i] = strings.Map(func(r rune) rune {
+				if r == ' ' || r == '\t' || r == '"' || r == '\'' {
+					return -1
+				}
+				return r
+			}, line)
+		}
+		return squeezed
+	}
+
+	var b strings.Builder
+	changed := false
+	g, w := 0, 0
+	for _, c := range diff.DiffChunks(squeeze(got), squeeze(want)) {
+		for range c.Added {
+			b.WriteString("+" + want[w] + "\n")
+			w++
+		}
+		for range c.Deleted {
+			b.WriteString("-" + got[g] + "\n")
+			g++
+		}
+		for range c.Equal {
+			b.WriteString(" " + got[g] + "\n")
+			g, w = g+1, w+1
+		}
+		changed = changed || len(c.Added) > 0 || len(c.Deleted) > 0
+	}
+	if !changed {
+		return ""
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// describe returns the language of the configuration file, and its format
+// if it is not the native one.
+func (c *ConfigFile) describe() string {
+	if c.Format != "" {
+		return c.Lang + " (" + c.Format + ")"
+	}
+	return c.Lang
+}
+
+// scopeLabel returns the scope name as it is written in the configuration
+// file of lang.
+func scopeLabel(lang, name string) string {
+	switch lang {
+	case "php":
+		return "[" + name + "]"
+	case "dotnet":
+		return "<" + name + ">"
+	}
+	return name
+}
+
+// hideValues replaces the values of the entries of PII fields with
+// hiddenValue. fields maps the keys of the entries to the fields of
+// ConfigKeys.
+func (d *configDocument) hideValues(fields map[string]string) {
+	// Entries are in the order of the file, so editing from the last one
+	// keeps the offsets of the other entries on the same line valid.
+	for i := len(d.entries) - 1; i >= 0; i-- {
+		e := d.entries[i]
+		if field, ok := fields[e.key]; ok && IsPII(field) {
+			line := d.lines[e.line]
+			d.lines[e.line] = line[:e.start] + hiddenValue + line[e.end:]
+		}
+	}
+}
+
+// keyLines returns the lines of the document that set the keys of fields,
+// or all the keys when fields is nil, and the lines that open and close the
+// scopes of the client library. The lines are trimmed and those inside a
+// scope are indented by two spaces, so that the indentation of the file does
+// not show in a diff.
+func (d *configDocument) keyLines(fields map[string]string) []string {
+	include := make(map[int]bool)
+	for _, e := range d.entries {
+		if _, ok := fields[e.key]; ok || fields == nil {
+			include[e.line] = true
+		}
+	}
+	indented := make(map[int]bool)
+	for _, s := range d.scopes {
+		if !d.libraryScope(s, include) {
+			continue
+		}
+		include[s.open] = true
+		if s.close < len(d.lines) && d.closesScope(s.close) {
+			include[s.close] = true
+		}
+		if d.lang != "php" {
+			for i := s.open + 1; i < s.close; i++ {
+				indented[i] = true
+			}
+		}
+	}
+
+	var lines []string
+	for i, line := range d.lines {
+		if !include[i] {
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if indented[i] {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// libraryScope returns true when s is read by the client library, or when
+// one of the included lines is in s.
+func (d *configDocument) libraryScope(s configScope, include map[int]bool) bool {
+	for _, field := range templateFields {
+		if configScopeName(d.lang, field) == s.name {
+			return true
+		}
+	}
+	for i := s.open + 1; i < s.close; i++ {
+		if include[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// closesScope returns true when the line at index i is the end of a Ruby
+// configuration block or of a GoogleAdsApi element. The scopes of an ini
+// file end where the next one starts.
+func (d *configDocument) closesScope(i int) bool {
+	line := strings.TrimSpace(d.lines[i])
+	switch d.lang {
+	case "ruby":
+		return line == "end"
+	case "dotnet":
+		return strings.HasPrefix(line, "</GoogleAdsApi")
+	}
+	return false
+}
+
+// similarKey returns the key of known that key is likely a misspelling of,
+// or "" when there is none. Keys are compared without case and separators,
+// so that keys written in the style of another client library, such as
+// developerToken in a YAML file, are also found.
+func similarKey(key string, known map[string]string) string {
+	normalized := normalizeKey(key)
+	best, bestDist := "", 3
+	for k := range known {
+		d := editDistance(normalized, normalizeKey(k))
+		if d < bestDist || (d == bestDist && best != "" && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// normalizeKey returns key in lower case without separators and without the
+// prefixes of the Java and Ruby keys.
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	for _, prefix := range []string{"api.googleads.", "c."} {
+		key = strings.TrimPrefix(key, prefix)
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '.' || r == '-' {
+			return -1
+		}
+		return r
+	}, key)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc         string
+		lang         string
+		content      string
+		hidePII      bool
+		wantDiff     string
+		wantFindings []string
+	}{
+		{
+			desc: "(Python) Same keys with other quotes and comments",
+			lang: "python",
+			content: "# Credentials\n" +
+				"developer_token: \"DevToken\"\n" +
+				"client_id: ClientID.apps.googleusercontent.com\n" +
+				"client_secret: 'ClientSecret'\n" +
+				"refresh_token : RefreshToken\n" +
+				"use_proto_plus: True\n",
+		},
+		{
+			desc: "(PHP) Dev token in the wrong section and misspelled key",
+			lang: "php",
+			content: "[GOOGLE_ADS]\n" +
+				"loginCustomerId = \"1234567890\"\n" +
+				"\n" +
+				"[OAUTH2]\n" +
+				"developerToken = \"DevToken\"\n" +
+				"clientId = \"ClientID.apps.googleusercontent.com\"\n" +
+				"clientSecret = \"ClientSecret\"\n" +
+				"refreshTokn = \"RefreshToken\"\n",
+			wantDiff: " [GOOGLE_ADS]\n" +
+				"+developerToken = \"DevToken\"\n" +
+				" loginCustomerId = \"1234567890\"\n" +
+				" [OAUTH2]\n" +
+				"-developerToken = \"DevToken\"\n" +
+				" clientId = \"ClientID.apps.googleusercontent.com\"\n" +
+				" clientSecret = \"ClientSecret\"\n" +
+				"-refreshTokn = \"RefreshToken\"\n" +
+				"+refreshToken = \"INSERT_REFRESH_TOKEN_HERE\"",
+			wantFindings: []string{
+				"refreshTokn is not a key of the client library: did you mean refreshToken?",
+				"developerToken is set outside of [GOOGLE_ADS], where the client library reads it.",
+				"refreshToken is missing from the config file.",
+			},
+		},
+		{
+			desc: "(Ruby) Missing key and PII hidden",
+			lang: "ruby",
+			content: "Google::Ads::GoogleAds::Config.new do |c|\n" +
+				"    c.developer_token = 'DevToken'\n" +
+				"    c.client_id = 'ClientID.apps.googleusercontent.com'\n" +
+				"    c.refresh_token = 'RefreshToken'\n" +
+				"end\n",
+			hidePII: true,
+			wantDiff: " Google::Ads::GoogleAds::Config.new do |c|\n" +
+				"   c.developer_token = '********'\n" +
+				"   c.client_id = '********'\n" +
+				"+  c.client_secret = \"INSERT_CLIENT_SECRET_HERE\"\n" +
+				"   c.refresh_token = '********'\n" +
+				" end",
+			wantFindings: []string{"c.client_secret is missing from the config file."},
+		},
+		{
+			desc: "(.NET) Key written in the style of another library",
+			lang: "dotnet",
+			content: "<configuration>\n" +
+				"  <GoogleAdsApi>\n" +
+				"    <add key=\"DeveloperToken\" value=\"DevToken\"/>\n" +
+				"    <add key=\"OAuth2ClientId\" value=\"ClientID.apps.googleusercontent.com\"/>\n" +
+				"    <add key=\"OAuth2ClientSecret\" value=\"ClientSecret\"/>\n" +
+				"    <add key=\"OAuth2_refresh_token\" value=\"RefreshToken\"/>\n" +
+				"  </GoogleAdsApi>\n" +
+				"</configuration>\n",
+			wantDiff: " <GoogleAdsApi>\n" +
+				"   <add key=\"DeveloperToken\" value=\"DevToken\"/>\n" +
+				"   <add key=\"OAuth2ClientId\" value=\"ClientID.apps.googleusercontent.com\"/>\n" +
+				"   <add key=\"OAuth2ClientSecret\" value=\"ClientSecret\"/>\n" +
+				"-  <add key=\"OAuth2_refresh_token\" value=\"RefreshToken\"/>\n" +
+				"+  <add key=\"OAuth2RefreshToken\" value=\"INSERT_REFRESH_TOKEN_HERE\"/>\n" +
+				" </GoogleAdsApi>",
+			wantFindings: []string{
+				"OAuth2_refresh_token is not a key of the client library: did you mean OAuth2RefreshToken?",
+				"OAuth2RefreshToken is missing from the config file.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, Languages[tt.lang].Cfg.Filename)
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := ParseConfigFile(tt.lang, path, InstalledApp)
+		if err != nil {
+			t.Fatalf("%s: ParseConfigFile() returned error: %s", tt.desc, err)
+		}
+		diff, findings, err := c.TemplateDiff(tt.hidePII)
+		if err != nil {
+			t.Errorf("%s: TemplateDiff() returned error: %s", tt.desc, err)
+			continue
+		}
+		if diff != tt.wantDiff {
+			t.Errorf("%s: TemplateDiff() diff =\n%s\nwant\n%s", tt.desc, diff, tt.wantDiff)
+		}
+		var got []string
+		for _, f := range findings {
+			got = append(got, f.Message)
+		}
+		if !reflect.DeepEqual(got, tt.wantFindings) {
+			t.Errorf("%s: TemplateDiff() findings = %q, want %q", tt.desc, got, tt.wantFindings)
+		}
+	}
+}
+
+func TestSimilarKey(t *testing.T) {
+	known := swapMap(map[string]interface{}{
+		DevToken: "developer_token",
+		ClientID: "client_id",
+	})
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"developer_tokn", "developer_token"},
+		{"developerToken", "developer_token"},
+		{"Client-ID", "client_id"},
+		{"use_proto_plus", ""},
+		{"customer_id", ""},
+	}
+	for _, tt := range tests {
+		if got := similarKey(tt.key, known); got != tt.want {
+			t.Errorf("similarKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeLayout is the layout of the time suffix of the backups of the
+// configuration file, see replaceConfigFile.
+const backupTimeLayout = "2006-01-02_15-04-05"
+
+// backupPath returns a new path for a backup of the file at path. Several
+// values are often replaced within a second, so the time of a backup that
+// already exists is moved forward instead of overwriting the backup, which
+// may hold the original file.
+func backupPath(path string) string {
+	t := time.Now()
+	for {
+		backup := path + "_" + t.Format(backupTimeLayout)
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			return backup
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+// TokenHistory is what the configuration file and its backups tell about the
+// refresh token. Google does not say when a refresh token was issued.
+type TokenHistory struct {
+	// FromFile is true when the refresh token is read from the configuration
+	// file.
+	FromFile bool
+	// Since is the earliest time the refresh token is known to be in the
+	// configuration file: the time of the oldest backup with the same token.
+	// The modification time of the file does not tell when the token was
+	// written, so Since is zero without such a backup.
+	Since time.Time
+	// Backups are the backups of the configuration file with the same OAuth
+	// client and a different refresh token, from the oldest.
+	Backups []string
+	// Previous is the number of distinct refresh tokens in Backups.
+	Previous int
+}
+
+// RefreshTokenHistory returns the history of the refresh token of c. The
+// backups of the configuration file are the ones written when the doctor
+// replaced a value.
+func (c *ConfigFile) RefreshTokenHistory() TokenHistory {
+	var h TokenHistory
+	if c.FromEnv() || c.RefreshToken == "" || !strings.HasPrefix(c.Source(RefreshToken), c.GetFilepath()+":") {
+		return h
+	}
+	h.FromFile = true
+
+	paths, _ := filepath.Glob(c.GetFilepath() + "_*")
+	sort.Strings(paths)
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		t, err := time.ParseInLocation(backupTimeLayout, strings.TrimPrefix(path, c.GetFilepath()+"_"), time.Local)
+		if err != nil {
+			continue
+		}
+		values := c.fileValues(path)
+		token := values[RefreshToken]
+		switch {
+		case token == "" || values[ClientID] != c.ConfigKeys.ClientID:
+		case token == c.RefreshToken:
+			if h.Since.IsZero() || t.Before(h.Since) {
+				h.Since = t
+			}
+		default:
+			h.Backups = append(h.Backups, path)
+			if !seen[token] {
+				seen[token] = true
+				h.Previous++
+			}
+		}
+	}
+	return h
+}
+
+// fileValues returns the values of the configuration file at path by field
+// of ConfigKeys. Read errors are ignored.
+func (c *ConfigFile) fileValues(path string) map[string]string {
+	occurrences, _ := c.fileKeys(path)
+	known := c.knownKeys()
+	values := make(map[string]string)
+	for _, o := range occurrences {
+		if field, ok := known[o.Key]; ok && !o.Misplaced {
+			values[field] = o.Value
+		}
+	}
+	return values
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokenhistory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	config := func(clientID, token string) []byte {
+		return []byte("developer_token: GoodDevToken\n" +
+			"client_id: " + clientID + "\n" +
+			"client_secret: GoodClientSecret\n" +
+			"refresh_token: " + token + "\n")
+	}
+	write := func(path string, content []byte) {
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := "0123456789-client.apps.googleusercontent.com"
+	write(path, config(client, "1/current"))
+	c, err := ParseConfigFile("python", path, InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := c.RefreshTokenHistory(); !h.FromFile || !h.Since.IsZero() {
+		t.Errorf("RefreshTokenHistory() without backups got %+v, want no Since", h)
+	}
+
+	write(path+"_2024-01-02_10-00-00", config(client, "1/old"))
+	write(path+"_2024-01-05_10-00-00", config(client, "1/older"))
+	write(path+"_2024-01-09_10-00-00", config(client, "1/old"))
+	write(path+"_2024-02-01_09-30-00", config(client, "1/current"))
+	write(path+"_2024-02-03_10-00-00", config("9876543210-other.apps.googleusercontent.com", "1/other"))
+	write(path+"_not-a-backup", config(client, "1/ignored"))
+
+	h := c.RefreshTokenHistory()
+	if want := time.Date(2024, 2, 1, 9, 30, 0, 0, time.Local); !h.Since.Equal(want) {
+		t.Errorf("RefreshTokenHistory() got Since %s, want %s", h.Since, want)
+	}
+	if h.Previous != 2 || len(h.Backups) != 3 {
+		t.Errorf("RefreshTokenHistory() got %d previous tokens in %q, want 2 in 3 backups", h.Previous, h.Backups)
+	}
+
+	c.SetSource(RefreshToken, "OS credential store")
+	if h := c.RefreshTokenHistory(); h.FromFile || !h.Since.IsZero() || h.Previous != 0 {
+		t.Errorf("RefreshTokenHistory() of a token that is not in the file got %+v, want nothing", h)
+	}
+}
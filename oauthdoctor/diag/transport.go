@@ -0,0 +1,156 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// The transports of the client libraries: gRPC over HTTP/2, or REST with
+// JSON requests.
+const (
+	GRPCTransport = "grpc"
+	RESTTransport = "rest"
+)
+
+// transportKeys are the keys that choose the transport of the client library,
+// by language. The other client libraries only use gRPC, except
+// RESTLanguage.
+var transportKeys = map[string]string{
+	"php":    "transport",
+	"python": "use_proto_plus",
+}
+
+// grpcProbePath is a method of the Google Ads API, which answers a gRPC
+// request without credentials with the UNAUTHENTICATED status.
+const grpcProbePath = "/google.ads.googleads.v8.services.CustomerService/ListAccessibleCustomers"
+
+// Transport returns the transport of the client library of c, and the key
+// and line in the configuration file that choose it, if any.
+func (c *ConfigFile) Transport() (transport, source string) {
+	switch c.Lang {
+	case RESTLanguage:
+		return RESTTransport, ""
+	case "php":
+		if o, ok := c.transportKey(); ok {
+			if v := strings.ToLower(unquote(o.Value)); v == RESTTransport || v == GRPCTransport {
+				return v, i18n.Sprintf("%s on line %d", o.Key, o.Line)
+			}
+		}
+	}
+	return GRPCTransport, ""
+}
+
+// transportKey returns the last occurrence of the transport key of the
+// client library of c in its configuration file.
+func (c *ConfigFile) transportKey() (keyOccurrence, bool) {
+	key, ok := transportKeys[c.Lang]
+	if !ok || c.Format == JSONFormat {
+		return keyOccurrence{}, false
+	}
+	occurrences, err := c.fileKeys(c.GetFilepath())
+	if err != nil {
+		return keyOccurrence{}, false
+	}
+	var last keyOccurrence
+	found := false
+	for _, o := range occurrences {
+		if o.Key == key && (c.Lang != "php" || o.Section == "CONNECTION") {
+			last, found = o, true
+		}
+	}
+	return last, found
+}
+
+// transportFindings checks the value of the transport key of the client
+// library of c.
+func (c *ConfigFile) transportFindings() []Finding {
+	o, ok := c.transportKey()
+	if !ok {
+		return nil
+	}
+	v := unquote(o.Value)
+	switch c.Lang {
+	case "php":
+		if l := strings.ToLower(v); l != GRPCTransport && l != RESTTransport {
+			return []Finding{{Severity: Error, Message: i18n.Sprintf("%s on line %d is %q, but the client "+
+				"library only accepts grpc or rest.", o.Key, o.Line, v)}}
+		}
+	case "python":
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off":
+		default:
+			return []Finding{{Severity: Error, Message: i18n.Sprintf("%s on line %d is %q, but the client "+
+				"library needs True or False.", o.Key, o.Line, v)}}
+		}
+	}
+	return nil
+}
+
+// unquote removes the quotes around the value of a key-value configuration
+// file.
+func unquote(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// GRPCHealth sends a gRPC request without credentials to the endpoint
+// through the proxy of the environment, like the gRPC client libraries, and
+// returns the gRPC status. Any status, e.g. 16 for UNAUTHENTICATED, shows
+// that gRPC requests reach the endpoint; a response without one comes from
+// a proxy or a server that does not speak gRPC.
+func GRPCHealth(ctx context.Context, endpoint *url.URL, config *tls.Config) (string, error) {
+	// An empty message: no compression and a length of 0.
+	req, err := http.NewRequest("POST", endpoint.String()+grpcProbePath, bytes.NewReader(make([]byte, 5)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	transport := checkTransport(config)
+	transport.ForceAttemptHTTP2 = true
+	defer transport.CloseIdleConnections()
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		return "", i18n.Errorf("the response is %s, not HTTP/2 (HTTP %d)", resp.Proto, resp.StatusCode)
+	}
+	// The status is in the trailers, or in the headers of a response
+	// without a body.
+	status := resp.Header.Get("Grpc-Status")
+	if status == "" {
+		io.Copy(ioutil.Discard, resp.Body)
+		status = resp.Trailer.Get("Grpc-Status")
+	}
+	if status == "" {
+		return "", i18n.Errorf("the response has no gRPC status (HTTP %d, %s)", resp.StatusCode,
+			resp.Header.Get("Content-Type"))
+	}
+	return status, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc       string
+		lang       string
+		config     string
+		want       string
+		wantSource string
+		wantFind   string
+	}{
+		{
+			desc: "PHP default",
+			lang: "php",
+			config: "[GOOGLE_ADS]\ndeveloperToken = \"GoodDevToken\"\n" +
+				"[CONNECTION]\n",
+			want: GRPCTransport,
+		},
+		{
+			desc: "PHP REST",
+			lang: "php",
+			config: "[GOOGLE_ADS]\ndeveloperToken = \"GoodDevToken\"\n" +
+				"[CONNECTION]\ntransport = \"rest\"\n",
+			want:       RESTTransport,
+			wantSource: "transport on line 4",
+		},
+		{
+			desc:   "PHP transport outside of CONNECTION",
+			lang:   "php",
+			config: "[GOOGLE_ADS]\ntransport = \"rest\"\n",
+			want:   GRPCTransport,
+		},
+		{
+			desc:     "PHP invalid transport",
+			lang:     "php",
+			config:   "[CONNECTION]\ntransport = \"http\"\n",
+			want:     GRPCTransport,
+			wantFind: `transport on line 2 is "http", but the client library only accepts grpc or rest.`,
+		},
+		{
+			desc:   "Python proto-plus",
+			lang:   "python",
+			config: "developer_token: GoodDevToken\nuse_proto_plus: True\n",
+			want:   GRPCTransport,
+		},
+		{
+			desc:     "Python invalid proto-plus",
+			lang:     "python",
+			config:   "developer_token: GoodDevToken\nuse_proto_plus: rest\n",
+			want:     GRPCTransport,
+			wantFind: `use_proto_plus on line 2 is "rest", but the client library needs True or False.`,
+		},
+		{
+			desc: "REST",
+			lang: RESTLanguage,
+			want: RESTTransport,
+		},
+	}
+
+	for i, tt := range tests {
+		c := ConfigFile{Lang: tt.lang, Filepath: dir, Filename: fmt.Sprintf("config%d", i)}
+		if err := ioutil.WriteFile(c.GetFilepath(), []byte(tt.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		got, source := c.Transport()
+		if got != tt.want || source != tt.wantSource {
+			t.Errorf("[%s] Transport() = %s, %q, want: %s, %q", tt.desc, got, source, tt.want, tt.wantSource)
+		}
+		var find []string
+		for _, f := range c.transportFindings() {
+			find = append(find, f.Message)
+		}
+		if strings.Join(find, "\n") != tt.wantFind {
+			t.Errorf("[%s] transportFindings() got: %q, want: %q", tt.desc, find, tt.wantFind)
+		}
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	tests := []struct {
+		desc    string
+		http2   bool
+		handler http.HandlerFunc
+		want    string
+		wantErr string
+	}{
+		{
+			desc:  "Unauthenticated",
+			http2: true,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Type") != "application/grpc" {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				w.Header().Set("Trailer", "Grpc-Status")
+				w.Header().Set("Content-Type", "application/grpc")
+				w.Write(nil)
+				w.Header().Set("Grpc-Status", "16")
+			},
+			want: "16",
+		},
+		{
+			desc:  "Trailers-only response",
+			http2: true,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/grpc")
+				w.Header().Set("Grpc-Status", "16")
+			},
+			want: "16",
+		},
+		{
+			desc:  "Proxy error page",
+			http2: true,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantErr: "no gRPC status (HTTP 403, text/html)",
+		},
+		{
+			desc:    "HTTP/1.1 only",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			wantErr: "not HTTP/2",
+		},
+	}
+
+	for _, tt := range tests {
+		var path string
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			tt.handler(w, r)
+		}))
+		ts.EnableHTTP2 = tt.http2
+		ts.StartTLS()
+		config := ts.Client().Transport.(*http.Transport).TLSClientConfig
+		endpoint, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := GRPCHealth(context.Background(), endpoint, config)
+		ts.Close()
+
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("[%s] GRPCHealth() error: %s", tt.desc, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("[%s] GRPCHealth() error: %v, want: %s", tt.desc, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] GRPCHealth() = %q, want: %q", tt.desc, got, tt.want)
+		}
+		if path != grpcProbePath {
+			t.Errorf("[%s] GRPCHealth() requested %s, want: %s", tt.desc, path, grpcProbePath)
+		}
+	}
+}
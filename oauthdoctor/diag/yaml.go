@@ -0,0 +1,96 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// yamlFindings returns the problems of google-ads.yaml that come from the
+// YAML syntax rather than the values: indentation with tabs, quotes that
+// become part of a value, spaces inside quotes and IDs that YAML reads as
+// numbers. Read errors are ignored, since the file was already parsed.
+func (c *ConfigFile) yamlFindings() []Finding {
+	content, err := c.readText(c.GetFilepath())
+	if err != nil {
+		return nil
+	}
+	known := c.knownKeys()
+	var findings []Finding
+	add := func(sev Severity, key, msg string) {
+		findings = append(findings, Finding{Severity: sev, Key: key, Message: msg})
+	}
+
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.Contains(leadingSpace(line), "\t") {
+			add(Error, "", i18n.Sprintf("Line %d is indented with a tab, which YAML does not allow. Indent it with spaces.", n))
+		}
+
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:idx])
+		field, ok := known[key]
+		if !ok {
+			continue
+		}
+		start, end, quote := valueSpan(c.Lang, line, idx+1)
+		value := line[start:end]
+		switch {
+		case quote == 0 && start < len(line) && (line[start] == '"' || line[start] == '\''):
+			add(Warning, field, i18n.Sprintf("The value of %s on line %d has an opening quote but no closing quote.", key, n))
+		case quote == 0 && value != "" && strings.ContainsAny(value[len(value)-1:], "\"'"):
+			add(Warning, field, i18n.Sprintf("The value of %s on line %d ends with a quote but does not start with one, "+
+				"so the quote is part of the value.", key, n))
+		case quote != 0 && len(value) >= 2 && strings.ContainsAny(value[:1], "\"'") && value[len(value)-1] == value[0]:
+			add(Warning, field, i18n.Sprintf("The value of %s on line %d is wrapped in two pairs of quotes, "+
+				"so the inner quotes are part of the value.", key, n))
+		case quote != 0 && value != strings.TrimSpace(value):
+			add(Warning, field, i18n.Sprintf("The value of %s on line %d has spaces inside the quotes, "+
+				"which are part of the value.", key, n))
+		case quote == 0 && (field == DevToken || field == "LoginCustomerID") && isDigits(value):
+			if strings.HasPrefix(value, "0") {
+				add(Warning, field, i18n.Sprintf("The value of %s on line %d is not quoted and starts with 0, so YAML "+
+					"may read it as a number without its leading zero, or as an octal number. Quote it: %s: \"%s\"",
+					key, n, key, value))
+			} else {
+				add(Warning, field, i18n.Sprintf("The value of %s on line %d is not quoted, so YAML reads it as a "+
+					"number instead of a string. Quote it: %s: \"%s\"", key, n, key, value))
+			}
+		}
+	}
+	return findings
+}
+
+// isDigits returns true when s is not empty and only has decimal digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
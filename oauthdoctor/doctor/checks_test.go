@@ -0,0 +1,419 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/oauth"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestRunTasks(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	newTask := func(id string, delay time.Duration, err error) task {
+		return task{
+			run: func(ctx context.Context, out report.Reporter) (report.Check, error) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				out.Print("start " + id)
+				time.Sleep(delay)
+				out.Print("end " + id)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return report.Check{ID: id, Status: report.Pass}, err
+			},
+		}
+	}
+
+	tasks := []task{
+		newTask("a", 30*time.Millisecond, nil),
+		newTask("b", 10*time.Millisecond, fmt.Errorf("b failed")),
+		newTask("c", 0, nil),
+		newTask("d", 20*time.Millisecond, fmt.Errorf("d failed")),
+		newTask("e", 0, nil),
+	}
+
+	reporter := &fakeReporter{}
+	var ids []string
+	durations := make(map[string]time.Duration)
+	err := runTasks(context.Background(), tasks, 2, 0, reporter, func(c report.Check) {
+		ids = append(ids, c.ID)
+		durations[c.ID] = c.Duration
+	})
+
+	if errstring(err) != "b failed" {
+		t.Errorf("runTasks() error: %s, want: b failed", errstring(err))
+	}
+	if got := strings.Join(ids, ","); got != "a,c,e" {
+		t.Errorf("runTasks() added checks %s, want: a,c,e", got)
+	}
+	want := "start a,end a,start b,end b,start c,end c,start d,end d,start e,end e"
+	if got := strings.Join(reporter.msgs, ","); got != want {
+		t.Errorf("runTasks() printed %s, want: %s", got, want)
+	}
+	if maxRunning > 2 {
+		t.Errorf("runTasks() ran %d tasks at the same time, want at most 2", maxRunning)
+	}
+	if durations["a"] < 30*time.Millisecond {
+		t.Errorf("runTasks() got duration of a %s, want at least 30ms", durations["a"])
+	}
+}
+
+func TestNetperfTask(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	endpoint, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := &fakeReporter{}
+	chk, err := netperfTask(endpoint, nil).run(context.Background(), reporter)
+	if err != nil {
+		t.Fatalf("netperfTask() error: %s", err)
+	}
+	if chk.Status != report.Pass {
+		t.Errorf("netperfTask() status = %s, want: %s (%s)", chk.Status, report.Pass, chk.Message)
+	}
+	got := strings.Join(reporter.msgs, "\n")
+	for _, want := range []string{"over 5 requests", "TCP connect: p50", "First byte: p50"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("netperfTask() printed %s\nwant substring: %s", got, want)
+		}
+	}
+	if strings.Contains(got, "TLS handshake") {
+		t.Errorf("netperfTask() printed TLS latency for an HTTP endpoint: %s", got)
+	}
+
+	ts.Close()
+	chk, _ = netperfTask(endpoint, nil).run(context.Background(), &fakeReporter{})
+	if chk.Status != report.Fail {
+		t.Errorf("netperfTask() with a closed server status = %s, want: %s", chk.Status, report.Fail)
+	}
+}
+
+func TestAllowListTask(t *testing.T) {
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer open.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	var hosts []diag.AllowListHost
+	for _, u := range []string{open.URL, closed.URL} {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hosts = append(hosts, diag.AllowListHost{URL: parsed, Purpose: "test"})
+	}
+	openAddr, closedAddr := strings.TrimPrefix(open.URL, "http://"), strings.TrimPrefix(closed.URL, "http://")
+
+	reporter := &fakeReporter{}
+	chk, err := allowListTask(hosts, nil).run(context.Background(), reporter)
+	if err != nil {
+		t.Fatalf("allowListTask() error: %s", err)
+	}
+	if chk.Status != report.Fail || chk.Message != "blocked: "+closedAddr {
+		t.Errorf("allowListTask() = %s %s, want: %s blocked: %s", chk.Status, chk.Message, report.Fail, closedAddr)
+	}
+	got := strings.Join(reporter.msgs, "\n")
+	for _, want := range []string{"OK " + openAddr, "BLOCKED " + closedAddr, "  " + openAddr + "  # test\n  " + closedAddr} {
+		if !strings.Contains(got, want) {
+			t.Errorf("allowListTask() printed %s\nwant substring: %s", got, want)
+		}
+	}
+}
+
+func TestRunTasksTimeout(t *testing.T) {
+	newTask := func(id string, status report.Status, block bool) task {
+		return task{
+			run: func(ctx context.Context, out report.Reporter) (report.Check, error) {
+				if block {
+					<-ctx.Done()
+				}
+				return report.Check{ID: id, Name: id, Status: status}, nil
+			},
+		}
+	}
+	tasks := []task{
+		newTask("blocked", report.Fail, true),
+		newTask("failed", report.Fail, false),
+		newTask("passed", report.Pass, false),
+	}
+
+	reporter := &fakeReporter{}
+	var got []string
+	err := runTasks(context.Background(), tasks, 3, 10*time.Millisecond, reporter, func(c report.Check) {
+		got = append(got, c.ID+"="+string(c.Status))
+	})
+	if err != nil {
+		t.Fatalf("runTasks() error: %s", err)
+	}
+	if want := "blocked=TIMEOUT,failed=FAIL,passed=PASS"; strings.Join(got, ",") != want {
+		t.Errorf("runTasks() added %s, want: %s", strings.Join(got, ","), want)
+	}
+	if want := "ERROR: blocked did not complete within 10ms."; strings.Join(reporter.msgs, ",") != want {
+		t.Errorf("runTasks() printed %s, want: %s", strings.Join(reporter.msgs, ","), want)
+	}
+}
+
+func TestConnectivityTask(t *testing.T) {
+	tests := []struct {
+		desc string
+		code int
+		want report.Status
+	}{
+		{
+			desc: "Discovery document",
+			code: http.StatusOK,
+			want: report.Pass,
+		},
+		{
+			desc: "Proxy authentication",
+			code: http.StatusProxyAuthRequired,
+			want: report.Fail,
+		},
+		{
+			desc: "Server error",
+			code: http.StatusBadGateway,
+			want: report.Warn,
+		},
+	}
+
+	for _, tt := range tests {
+		var path string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(tt.code)
+		}))
+		endpoint, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		chk, err := connectivityTask(endpoint, nil).run(context.Background(), &fakeReporter{})
+		ts.Close()
+
+		if err != nil {
+			t.Fatalf("[%s] connectivityTask() error: %s", tt.desc, err)
+		}
+		if chk.Status != tt.want {
+			t.Errorf("[%s] connectivityTask() status = %s, want: %s (%s)", tt.desc, chk.Status, tt.want, chk.Message)
+		}
+		if path != "/$discovery/rest" {
+			t.Errorf("[%s] connectivityTask() requested %s, want: /$discovery/rest", tt.desc, path)
+		}
+	}
+}
+
+func TestTransportResult(t *testing.T) {
+	blocked := fmt.Errorf("blocked")
+	tests := []struct {
+		desc      string
+		lang      string
+		transport string
+		restErr   error
+		grpcErr   error
+		want      report.Status
+		wantMsg   string
+	}{
+		{
+			desc:      "Both reach the endpoint",
+			lang:      "java",
+			transport: diag.GRPCTransport,
+			want:      report.Pass,
+		},
+		{
+			desc:      "PHP with gRPC blocked",
+			lang:      "php",
+			transport: diag.GRPCTransport,
+			grpcErr:   blocked,
+			want:      report.Fail,
+			wantMsg:   `Set transport = "rest"`,
+		},
+		{
+			desc:      "PHP with REST blocked",
+			lang:      "php",
+			transport: diag.RESTTransport,
+			restErr:   blocked,
+			want:      report.Fail,
+			wantMsg:   `Set transport = "grpc"`,
+		},
+		{
+			desc:      "gRPC-only library with gRPC blocked",
+			lang:      "python",
+			transport: diag.GRPCTransport,
+			grpcErr:   blocked,
+			want:      report.Fail,
+			wantMsg:   "The python client library only uses gRPC",
+		},
+		{
+			desc:      "REST blocked but not used",
+			lang:      "ruby",
+			transport: diag.GRPCTransport,
+			restErr:   blocked,
+			want:      report.Warn,
+			wantMsg:   "blocks REST requests",
+		},
+		{
+			desc:      "gRPC blocked but not used",
+			lang:      diag.RESTLanguage,
+			transport: diag.RESTTransport,
+			grpcErr:   blocked,
+			want:      report.Warn,
+			wantMsg:   "blocks gRPC requests",
+		},
+		{
+			desc:      "Both blocked",
+			lang:      "java",
+			transport: diag.GRPCTransport,
+			restErr:   blocked,
+			grpcErr:   blocked,
+			want:      report.Fail,
+			wantMsg:   "Neither REST nor gRPC",
+		},
+	}
+
+	for _, tt := range tests {
+		chk := transportResult(tt.lang, tt.transport, tt.restErr, tt.grpcErr, "googleads.googleapis.com")
+		if chk.ID != report.TransportCheck || chk.Status != tt.want {
+			t.Errorf("[%s] transportResult() = %s %s, want: %s", tt.desc, chk.ID, chk.Status, tt.want)
+		}
+		if !strings.Contains(chk.Message, tt.wantMsg) {
+			t.Errorf("[%s] transportResult() message: %s, want substring: %s", tt.desc, chk.Message, tt.wantMsg)
+		}
+	}
+}
+
+func TestAPIVersionsResult(t *testing.T) {
+	served := []int{15, 16, 17}
+	tests := []struct {
+		desc     string
+		versions []int
+		target   string
+		want     report.Status
+		wantMsg  string
+	}{
+		{desc: "Unknown version", versions: served, want: report.Pass, wantMsg: "versions served are v15, v16, v17"},
+		{desc: "Current version", versions: served, target: "v16", want: report.Pass},
+		{desc: "Oldest version", versions: served, target: "v15", want: report.Warn, wantMsg: "v15 is the oldest"},
+		{desc: "Sunset version", versions: served, target: "v8", want: report.Fail, wantMsg: "uses v17 or later"},
+		{desc: "Future version", versions: served, target: "v18", want: report.Fail, wantMsg: "not released yet"},
+		{desc: "Gap", versions: []int{15, 17}, target: "v16", want: report.Fail, wantMsg: "v16 is not served"},
+		{desc: "Nothing served", want: report.Warn, wantMsg: "serves no version"},
+	}
+	for _, tt := range tests {
+		got := apiVersionsResult(tt.versions, tt.target)
+		if got.Status != tt.want || !strings.Contains(got.Message, tt.wantMsg) {
+			t.Errorf("[%s] apiVersionsResult() = %s %q, want: %s with %q", tt.desc, got.Status, got.Message, tt.want, tt.wantMsg)
+		}
+	}
+}
+
+func TestAPIVersionsTaskUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer blocked.Close()
+	hanging, err := url.Parse(blocked.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc     string
+		endpoint *url.URL
+		timeout  time.Duration
+		want     report.Status
+	}{
+		{desc: "Connection refused", endpoint: closed, want: report.Warn},
+		{desc: "Deadline", endpoint: hanging, timeout: 50 * time.Millisecond, want: report.Timeout},
+	}
+	for _, tt := range tests {
+		reporter := &fakeReporter{}
+		chk, err := runTask(context.Background(), apiVersionsTask(tt.endpoint, nil, "v17", new([]int)), tt.timeout, reporter)
+		if err != nil {
+			t.Fatalf("[%s] apiVersionsTask() error: %s", tt.desc, err)
+		}
+		if chk.ID != report.APIVersionsCheck || chk.Status != tt.want || chk.Message == "" {
+			t.Errorf("[%s] apiVersionsTask() = %s %s %q, want: %s %s", tt.desc, chk.ID, chk.Status, chk.Message,
+				report.APIVersionsCheck, tt.want)
+		}
+	}
+}
+
+func TestRequestAPIVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("version") {
+		case "v16", "v17":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		desc     string
+		endpoint string
+		served   []int
+		want     string
+		wantMsg  string
+	}{
+		{desc: "Known versions", endpoint: closed.URL, served: []int{15, 16, 17}, want: "v17"},
+		{desc: "Looked for versions", endpoint: ts.URL, want: "v17"},
+		{desc: "Nothing served", endpoint: ts.URL, served: []int{}, want: oauth.DefaultAPIVersion, wantMsg: "serves no version"},
+		{desc: "Unreachable endpoint", endpoint: closed.URL, want: oauth.DefaultAPIVersion, wantMsg: "Cannot list"},
+	}
+	for _, tt := range tests {
+		reporter := &fakeReporter{}
+		got := requestAPIVersion(context.Background(), tt.endpoint, nil, tt.served, reporter)
+		msgs := strings.Join(reporter.msgs, "\n")
+		if got != tt.want || !strings.Contains(msgs, tt.wantMsg) || (tt.wantMsg == "") != (msgs == "") {
+			t.Errorf("[%s] requestAPIVersion() = %s, printed %q, want: %s with %q", tt.desc, got, msgs, tt.want, tt.wantMsg)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/oauth"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// importClientSecrets writes the client ID and client secret of the JSON
+// file of opts.ClientSecrets to the configuration, and sets opts.OAuthType to
+// the type of the client when it is empty. An OAuth client of another type
+// than opts.OAuthType is an error, since its flow cannot work.
+func importClientSecrets(language string, opts *Options, out report.Reporter) error {
+	s, err := diag.ReadClientSecrets(opts.ClientSecrets)
+	if err != nil {
+		return err
+	}
+	out.Print(i18n.Sprintf("Importing the %s OAuth client %s of project %s from %s\n", s.OAuthType,
+		s.ClientID, s.ProjectID, opts.ClientSecrets))
+	if opts.OAuthType != "" && opts.OAuthType != s.OAuthType {
+		return i18n.Errorf("%s is the file of a %s OAuth client, but the OAuth type is %s, whose flow "+
+			"cannot work with it. Use -oauthtype %s, or download the file of a %s client.",
+			opts.ClientSecrets, s.OAuthType, opts.OAuthType, s.OAuthType, opts.OAuthType)
+	}
+	opts.OAuthType = s.OAuthType
+	if s.OAuthType == diag.Web && !s.HasRedirectURI(oauth.WebRedirectURL) {
+		out.Print(i18n.Sprintf("WARNING: %s is not an authorized redirect URI of the client (%s), so the web "+
+			"flow will fail with redirect_uri_mismatch. Add it to the client in the Google Cloud console.",
+			oauth.WebRedirectURL, strings.Join(s.RedirectURIs, ", ")))
+	}
+
+	values := map[string]string{diag.ClientID: s.ClientID, diag.ClientSecret: s.ClientSecret}
+	if language == diag.RESTLanguage {
+		opts.Credentials.ClientID, opts.Credentials.ClientSecret = s.ClientID, s.ClientSecret
+		return nil
+	}
+	if configFormat(language, *opts) == diag.EnvFormat {
+		c := opts.reader().EnvConfigFile(language, opts.OAuthType)
+		for _, key := range []string{diag.ClientID, diag.ClientSecret} {
+			out.Print(i18n.Sprintf("The configuration is read from the environment variables: set %s to "+
+				"the %s of the file.", c.EnvVar(key), key))
+		}
+		return nil
+	}
+	c, err := readConfigFile(language, *opts, loadConfigFile(language, *opts), out)
+	if err == nil && (opts.Keyring || opts.MigrateKeyring) {
+		_, err = c.LoadKeyring()
+	}
+	if err != nil {
+		return err
+	}
+	current := map[string]string{diag.ClientID: c.ConfigKeys.ClientID, diag.ClientSecret: c.ConfigKeys.ClientSecret}
+	for _, key := range []string{diag.ClientID, diag.ClientSecret} {
+		if current[key] == values[key] {
+			out.Print(i18n.Sprintf("%s is already set in the config file.", key))
+			continue
+		}
+		if _, err := c.ReplaceConfig(key, values[key]); err != nil {
+			return err
+		}
+		out.Print(i18n.Sprintf("%s was written to the config file.", key))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestImportClientSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientsecrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secrets := filepath.Join(dir, "client_secret.json")
+	content := `{"web":{"client_id":"1234-web.apps.googleusercontent.com","project_id":"ads-project",` +
+		`"client_secret":"GOCSPX-web","redirect_uris":["https://example.com/oauth"]}}`
+	if err := ioutil.WriteFile(secrets, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc      string
+		oauthType string
+		want      string
+		wantErr   string
+		wantMsg   string
+	}{
+		{
+			desc: "OAuth type of the client",
+			want: "client_id: 1234-web.apps.googleusercontent.com\nclient_secret: GOCSPX-web\n" +
+				"refresh_token: 1//OldRefreshToken\n",
+			wantMsg: "http://localhost:8080 is not an authorized redirect URI",
+		},
+		{
+			desc:      "Mismatched OAuth type",
+			oauthType: diag.InstalledApp,
+			want:      "client_id: old.apps.googleusercontent.com\nclient_secret: OldSecret\nrefresh_token: 1//OldRefreshToken\n",
+			wantErr:   "is the file of a web OAuth client, but the OAuth type is installed_app",
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, "google-ads.yaml")
+		config := "client_id: old.apps.googleusercontent.com\nclient_secret: OldSecret\nrefresh_token: 1//OldRefreshToken\n"
+		if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{Language: "python", OAuthType: tt.oauthType, ConfigPath: path, ClientSecrets: secrets}
+		out := &fakeReporter{}
+
+		err := importClientSecrets("python", &opts, out)
+
+		if !strings.Contains(errstring(err), tt.wantErr) || (tt.wantErr == "" && err != nil) {
+			t.Errorf("[%s] importClientSecrets() error: %v, want: %s", tt.desc, err, tt.wantErr)
+		}
+		if err == nil && opts.OAuthType != diag.Web {
+			t.Errorf("[%s] importClientSecrets() set OAuth type %s, want: %s", tt.desc, opts.OAuthType, diag.Web)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("[%s] importClientSecrets() wrote:\n%s\nwant:\n%s", tt.desc, got, tt.want)
+		}
+		if msgs := strings.Join(out.msgs, "\n"); !strings.Contains(msgs, tt.wantMsg) {
+			t.Errorf("[%s] importClientSecrets() printed: %s\nwant substring: %s", tt.desc, msgs, tt.wantMsg)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/oauth"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// customerIDRegex matches a customer ID without dashes.
+var customerIDRegex = regexp.MustCompile(`^[0-9]{10}$`)
+
+// ReadCustomerIDs reads the customer IDs in the first column of a CSV file,
+// e.g. an export of the accounts of a manager account. The IDs may have
+// dashes, and a header row is skipped. Duplicate IDs are only returned once.
+func ReadCustomerIDs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var cids []string
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		cid := strings.ReplaceAll(strings.TrimSpace(record[0]), "-", "")
+		switch {
+		case cid == "" || diag.Contains(cids, cid):
+			continue
+		case !customerIDRegex.MatchString(cid) && n == 1:
+			// The header row.
+			continue
+		case !customerIDRegex.MatchString(cid):
+			return nil, i18n.Errorf("%s, line %d: %s is not a customer ID", path, n, record[0])
+		}
+		cids = append(cids, cid)
+	}
+	if len(cids) == 0 {
+		return nil, i18n.Errorf("%s has no customer IDs", path)
+	}
+	return cids, nil
+}
+
+// mutateTask validates a mutate call in the customer account of c, see
+// oauth.Config.CheckMutate.
+func mutateTask(c *oauth.Config) task {
+	return task{
+		run: func(ctx context.Context, out report.Reporter) (report.Check, error) {
+			cc := *c
+			cc.Reporter = out
+			return cc.CheckMutate(ctx)
+		},
+	}
+}
+
+// customerTask checks the access of the credentials of c to the customer
+// account cid, and prints a line of the per-account report.
+func customerTask(c *oauth.Config, cid string) task {
+	return task{
+		run: func(ctx context.Context, out report.Reporter) (report.Check, error) {
+			cc := *c
+			cc.Reporter = out
+			chk, err := cc.CheckCustomer(ctx, cid)
+			if err != nil {
+				return chk, err
+			}
+			line := fmt.Sprintf("%-12s %s", report.FormatCustomerID(cid), chk.Status)
+			if chk.Status != report.Pass {
+				line += fmt.Sprintf(" %s: %s", chk.Code, chk.Message)
+			}
+			out.Print(line)
+			return chk, nil
+		},
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCustomerIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc    string
+		content string
+		want    string
+		errstr  string
+	}{
+		{
+			desc:    "Customer IDs with a header and other columns",
+			content: "Customer ID,Account name\n123-456-7890,Shoes\n2345678901,\"Hats, caps\"\n\n123-456-7890,Shoes again\n",
+			want:    "1234567890,2345678901",
+		},
+		{
+			desc:    "Invalid customer ID",
+			content: "1234567890\n12345\n",
+			errstr:  "line 2: 12345 is not a customer ID",
+		},
+		{
+			desc:    "No customer IDs",
+			content: "Customer ID\n",
+			errstr:  "has no customer IDs",
+		},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, string(rune('a'+i))+".csv")
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadCustomerIDs(path)
+		if !strings.Contains(errstring(err), tt.errstr) || (tt.errstr == "" && err != nil) {
+			t.Errorf("[%s] ReadCustomerIDs() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("[%s] ReadCustomerIDs() = %v, want: %s", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,817 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doctor runs the diagnostics of the Google Ads API client library
+// configuration. It is used by the oauthdoctor command, and can be embedded
+// in other tools that provide their own user interface.
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/oauth"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/replay"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// OAuthTypes are the supported OAuth2 types.
+var OAuthTypes = []string{diag.InstalledApp, diag.Web, diag.ServiceAccount}
+
+// Options configures a diagnosis.
+type Options struct {
+	// Language is the programming language of the client library, e.g.
+	// python.
+	Language string
+	// OAuthType is one of OAuthTypes. When empty, it is detected from the
+	// credentials in the configuration.
+	OAuthType string
+	// ConfigPath is the path of the client library configuration file. When
+	// empty, the default location of the language is used.
+	ConfigPath string
+	// ConfigFormat is diag.JSONFormat for a configuration file in JSON,
+	// diag.EnvFormat for a configuration made of environment variables, or
+	// empty for the native file of the client library. For .NET, an empty
+	// format is detected with diag.Reader.DetectDotNetFormat.
+	ConfigFormat string
+	// DecryptCommand decrypts an encrypted configuration file, see
+	// diag.Reader.DecryptCommand. The configuration file is then not written
+	// to.
+	DecryptCommand string
+	// Credentials are the configuration values of diag.RESTLanguage, which
+	// has no configuration file. Empty values are read from the environment
+	// variables of the language, and the missing required values are asked
+	// for.
+	Credentials diag.ConfigKeys
+	// CACert is a PEM file of certificate authorities to trust in addition
+	// to those of this machine, e.g. the root certificate of a proxy that
+	// intercepts HTTPS traffic.
+	CACert string
+	// ClientCert and ClientKey are the PEM client certificate and its
+	// private key presented in all the TLS connections, e.g. to an egress
+	// proxy that requires client certificates.
+	ClientCert string
+	ClientKey  string
+	// CredentialFiles are the paths of the files that hold the values of
+	// environment variables, e.g. Docker secrets, see
+	// diag.Reader.CredentialFiles.
+	CredentialFiles map[string]string
+	// ClientSecrets is the JSON file of an OAuth client downloaded from the
+	// Google Cloud console. Its client ID and client secret are written to
+	// the configuration before the diagnosis, and its type sets the OAuth
+	// type when OAuthType is empty.
+	ClientSecrets string
+	// Keyring reads the secrets that the configuration file does not set
+	// from the OS credential store, writes new secrets to the store instead
+	// of the file, and warns about secrets stored in plaintext in the file.
+	Keyring bool
+	// MigrateKeyring moves the secrets of the configuration file to the OS
+	// credential store. It implies Keyring.
+	MigrateKeyring bool
+	// CustomerID is the Google Ads account used to test API access. When
+	// empty, the user is asked for one.
+	CustomerID string
+	// CustomerIDs are other Google Ads accounts whose access is checked with
+	// the credentials once the OAuth flow passed, e.g. to audit which
+	// accounts of an agency the credentials can reach. See ReadCustomerIDs.
+	CustomerIDs []string
+	// Endpoint overrides the Google Ads API endpoint, e.g. to go through a
+	// testing proxy. When empty, the endpoint in the configuration file is
+	// used, or diag.DefaultEndpoint when the file does not set one.
+	Endpoint string
+	// APIVersion is the version of the Google Ads API that the client
+	// library uses, e.g. v17, which the API versions check of SysInfo
+	// looks for among the versions the endpoint serves.
+	APIVersion string
+	// HidePII masks sensitive configuration values in the output.
+	HidePII bool
+	// SysInfo adds the system information and a connectivity check.
+	SysInfo bool
+	// TCPCheck adds the TCP connection check, which the connectivity check
+	// of SysInfo replaced, to compare both. It requires SysInfo.
+	TCPCheck bool
+	// NetPerf adds a check that measures the network latency to the Google
+	// Ads API endpoint.
+	NetPerf bool
+	// Mutate adds a check that validates a mutate call in the customer
+	// account once the OAuth flow passed, see oauth.Config.CheckMutate.
+	Mutate bool
+	// AuthURL and TokenURL override the OAuth2 consent page and token
+	// endpoint, e.g. to go through a proxy or to use an emulator.
+	AuthURL  string
+	TokenURL string
+	// HTTPClient sends the HTTP requests of the OAuth flow, the Google Ads
+	// API and the secret references, e.g. through a proxy. When nil, a
+	// client that trusts CACert and presents ClientCert is used, or
+	// http.DefaultClient without them. Record and Replay wrap its transport.
+	HTTPClient *http.Client
+	// KnowledgeBase recognizes and explains the errors of the OAuth flow,
+	// see oauth.LoadKnowledgeBase. When nil, the built-in knowledge base is
+	// used.
+	KnowledgeBase oauth.KnowledgeBase
+	// Scopes are OAuth2 scopes requested and verified in addition to the
+	// Google Ads API scope.
+	Scopes []string
+	// RequestTimeout is the deadline of the Google Ads API request, e.g. the
+	// one of the client library, to reproduce DEADLINE_EXCEEDED errors. Zero
+	// means no deadline.
+	RequestTimeout time.Duration
+	// CheckTimeout is the deadline of each check that runs without asking
+	// for input, e.g. the network checks, the custom checks and the checks
+	// of the customer accounts. A check that does not complete in time is
+	// reported with report.Timeout. Zero means no deadline.
+	CheckTimeout time.Duration
+	// Record saves the HTTP traffic of the diagnosis, with secrets redacted,
+	// to this fixture file.
+	Record string
+	// Traces keeps the HTTP traffic of the diagnosis, with secrets redacted,
+	// in the Traces of the report, e.g. for the HTML report.
+	Traces bool
+	// Replay runs the diagnosis against the HTTP traffic recorded in this
+	// fixture file instead of the network.
+	Replay string
+	// Plugins are the paths of executables that add custom checks, which
+	// run after the configuration check. See plugin.go for the protocol.
+	Plugins []string
+	// FailFast stops the diagnosis at the first step with a failed check,
+	// and reports OAuth errors without asking the user to fix them.
+	FailFast bool
+	// AutoFix applies the fixes that need no input without asking, e.g.
+	// renaming a misspelled key, see report.Offer.
+	AutoFix bool
+	// QRCode also prints the URL of the consent page as a QR code, see
+	// oauth.Config.QRCode.
+	QRCode bool
+	// Clipboard reads the auth code and the new developer token from the
+	// clipboard when the user presses Enter without typing them.
+	Clipboard bool
+	// Verbose prints debugging info, such as JSON responses.
+	Verbose bool
+	// TraceToken prints the requests to the OAuth2 token endpoint and their
+	// responses, with the secrets redacted.
+	TraceToken bool
+	// Prompter asks the user for input. When nil, the input is read from
+	// stdin.
+	Prompter prompt.Prompter
+	// Reporter receives the output of the diagnosis. When nil, the output is
+	// printed with the standard logger.
+	Reporter report.Reporter
+}
+
+// endpoints returns the endpoints of the OAuth flows overridden by o.
+func (o Options) endpoints() oauth.Endpoints {
+	return oauth.Endpoints{AuthURL: o.AuthURL, TokenURL: o.TokenURL}
+}
+
+// reader returns the diag.Reader of the configuration files and the
+// environment variables of the diagnosis.
+func (o Options) reader() *diag.Reader {
+	return &diag.Reader{DecryptCommand: o.DecryptCommand, CredentialFiles: o.CredentialFiles}
+}
+
+// tlsConfig returns the TLS configuration of the connections of the
+// diagnosis, see diag.TLSConfig.
+func (o Options) tlsConfig() (*tls.Config, error) {
+	return diag.TLSConfig(o.CACert, o.ClientCert, o.ClientKey)
+}
+
+// httpClient returns o.HTTPClient or, when it is nil, a client whose TLS
+// connections use config, which is http.DefaultClient for a nil config.
+func (o Options) httpClient(config *tls.Config) *http.Client {
+	switch {
+	case o.HTTPClient != nil:
+		return o.HTTPClient
+	case config != nil:
+		return &http.Client{Transport: diag.NewTransport(config)}
+	}
+	return http.DefaultClient
+}
+
+// withTransport returns a copy of client that sends the requests with t.
+func withTransport(client *http.Client, t http.RoundTripper) *http.Client {
+	c := *client
+	c.Transport = t
+	return &c
+}
+
+// Validate returns an error when the language or OAuth type is not
+// supported, an endpoint is not a valid URL, or the options conflict.
+func (o *Options) Validate() error {
+	languages := diag.ListLanguages()
+	if !diag.Contains(languages, strings.ToLower(o.Language)) {
+		return i18n.Errorf("You specified %s. Supported languages are %s\n", o.Language, strings.Join(languages, ","))
+	}
+	if o.OAuthType != "" && !diag.Contains(OAuthTypes, o.OAuthType) {
+		return i18n.Errorf("OAuth type not supported: %s", o.OAuthType)
+	}
+	if strings.ToLower(o.Language) == diag.RESTLanguage && o.OAuthType == diag.ServiceAccount {
+		return i18n.Errorf("The %s language supports the %s and %s OAuth types", diag.RESTLanguage, diag.InstalledApp, diag.Web)
+	}
+	if strings.ToLower(o.Language) == diag.RESTLanguage && (o.Keyring || o.MigrateKeyring) {
+		return i18n.Errorf("The OS credential store cannot be used with the %s language", diag.RESTLanguage)
+	}
+	if o.ConfigFormat != "" && o.ConfigFormat != diag.JSONFormat && o.ConfigFormat != diag.EnvFormat {
+		return i18n.Errorf("Config format not supported: %s. Supported formats are %s", o.ConfigFormat,
+			strings.Join([]string{diag.JSONFormat, diag.EnvFormat}, ", "))
+	}
+	if o.Endpoint != "" {
+		if _, err := diag.ParseEndpoint(o.Endpoint); err != nil {
+			return err
+		}
+	}
+	for _, u := range []string{o.AuthURL, o.TokenURL} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return i18n.Errorf("Invalid OAuth2 endpoint %s: an absolute http or https URL is required", u)
+		}
+	}
+	if o.APIVersion != "" {
+		if _, err := diag.ParseAPIVersion(o.APIVersion); err != nil {
+			return err
+		}
+	}
+	if o.Replay != "" && o.Record != "" {
+		return i18n.Errorf("Recording and replaying HTTP traffic cannot be combined")
+	}
+	if (o.ClientCert == "") != (o.ClientKey == "") {
+		return i18n.Errorf("The client certificate and its private key must be given together")
+	}
+	if err := diag.CheckCredentialFiles(o.CredentialFiles); err != nil {
+		return err
+	}
+	if o.DecryptCommand != "" && o.MigrateKeyring {
+		return i18n.Errorf("The secrets of an encrypted config file cannot be moved to the OS credential store")
+	}
+	if o.ClientSecrets != "" && o.OAuthType == diag.ServiceAccount {
+		return i18n.Errorf("A client secrets file holds an OAuth client, which the %s and %s OAuth types use",
+			diag.InstalledApp, diag.Web)
+	}
+	if o.Replay != "" && (o.SysInfo || o.NetPerf) {
+		return i18n.Errorf("The system and network checks cannot run against recorded HTTP traffic")
+	}
+	return nil
+}
+
+// reportedConfig returns the values of keys that are set, with the PII
+// redacted, for report.Report.Config.
+func reportedConfig(keys diag.ConfigKeys) map[string]string {
+	var values map[string]string
+	for k, v := range structs.Map(keys) {
+		s, _ := v.(string)
+		if s == "" {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		if diag.IsPII(k) {
+			s = diag.Redact(s)
+		}
+		values[k] = s
+	}
+	return values
+}
+
+// Run diagnoses the client library configuration and returns the results of
+// the checks. An error is returned when the diagnosis cannot run, e.g. the
+// configuration file cannot be read; problems found by the checks are
+// reported in the returned report.
+func Run(ctx context.Context, opts Options) (r *report.Report, err error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	reporter := opts.Reporter
+	if reporter == nil {
+		reporter = report.LogReporter{}
+	}
+
+	language := strings.ToLower(opts.Language)
+	reporter.Print(i18n.Sprintf("Client library language: %s\n", language))
+	// The changes of earlier diagnoses in the same process are left out.
+	previous := len(diag.Changes())
+	defer func() {
+		if r != nil {
+			r.Changes = diag.Changes()[previous:]
+		}
+	}()
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := opts.httpClient(tlsConfig)
+	// The secrets are fetched without recording or replaying them.
+	secretsClient := client
+	var replayer *replay.Replayer
+	if opts.Replay != "" {
+		if replayer, err = replay.Load(opts.Replay); err != nil {
+			return nil, err
+		}
+		reporter.Print(i18n.Sprintf("Replaying the HTTP traffic recorded in %s", opts.Replay))
+		client = withTransport(client, replayer)
+	}
+	var recorder *replay.Recorder
+	if opts.Record != "" || opts.Traces {
+		recorder = replay.NewRecorder(client.Transport)
+		client = withTransport(client, recorder)
+	}
+	if opts.Record != "" {
+		defer func() {
+			if rErr := recorder.Save(opts.Record); rErr != nil && err == nil {
+				err = rErr
+			}
+		}()
+	}
+	if opts.Traces {
+		defer func() {
+			if r != nil {
+				r.Traces = recorder.Fixture().Interactions
+			}
+		}()
+	}
+
+	build := oauth.Build()
+	r = &report.Report{
+		Language:  language,
+		OAuthType: opts.OAuthType,
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildDate: build.Date,
+	}
+	add := func(c report.Check) {
+		if c.Link == "" {
+			c.Link = opts.KnowledgeBase.Link(c.Code)
+		}
+		r.Add(c)
+		reporter.Result(c)
+	}
+
+	// The imported client sets the OAuth type.
+	if opts.ClientSecrets != "" {
+		if err := importClientSecrets(language, &opts, reporter); err != nil {
+			return r, err
+		}
+	}
+
+	var parsed parsedConfig
+	if language != diag.RESTLanguage {
+		parsed = loadConfigFile(language, opts)
+	}
+
+	// The OAuth type may be changed, which must be done before the checks
+	// run.
+	if opts.OAuthType == "" {
+		opts.OAuthType, err = detectOAuthType(language, opts, parsed, reporter)
+	} else {
+		opts.OAuthType, err = matchOAuthType(language, opts, parsed, reporter)
+	}
+	if err != nil {
+		return r, err
+	}
+	r.OAuthType = opts.OAuthType
+
+	// The credentials of the REST interface may be asked for, which cannot
+	// be done while the checks run.
+	var cfg diag.ConfigFile
+	if language == diag.RESTLanguage {
+		if cfg, err = restCredentials(opts, diag.RequiredKeys[opts.OAuthType]); err != nil {
+			return nil, err
+		}
+		opts.Credentials = cfg.ConfigKeys
+	}
+
+	prog := newProgress(reporter)
+	defer func() { reporter.Print(prog.summary()) }()
+
+	// The system and network checks do not depend on the configuration
+	// file, so they run while the file is parsed.
+	var tasks []task
+	var sysInfo diag.SysInfo
+	var served []int
+	if opts.SysInfo || opts.NetPerf {
+		prog.begin(i18n.T("Checking the configuration file and the network"))
+	} else {
+		prog.begin(i18n.T("Checking the configuration file"))
+	}
+	if opts.SysInfo || opts.NetPerf {
+		endpoint := networkEndpoint(language, opts, parsed)
+		if opts.SysInfo {
+			// Prebuilt release binaries are not built here.
+			if !oauth.Release() {
+				tasks = append(tasks, buildEnvTask())
+			}
+			// The free disk space is of the volume of the config file,
+			// where the client libraries may write logs.
+			dir := "."
+			c, err := configFile(language, opts)
+			if err == nil && c.Filepath != "" {
+				dir = c.Filepath
+			}
+			c.Lang, c.Format = language, configFormat(language, opts)
+			tasks = append(tasks, sysInfoTask(&sysInfo, dir), dnsTask(endpoint), connectivityTask(endpoint, tlsConfig),
+				tlsTask(endpoint, tlsConfig), http2Task(endpoint, tlsConfig), transportTask(endpoint, tlsConfig, c),
+				apiVersionsTask(endpoint, tlsConfig, opts.APIVersion, &served), allowListTask(diag.AllowList(endpoint), tlsConfig))
+			if opts.TCPCheck {
+				tasks = append(tasks, tcpTask(endpoint))
+			}
+		}
+		if opts.NetPerf {
+			tasks = append(tasks, netperfTask(endpoint, tlsConfig))
+		}
+	}
+	tasks = append(tasks, configTask(language, opts, parsed, secretsClient, &cfg))
+	err = runTasks(ctx, tasks, maxWorkers, opts.CheckTimeout, reporter, add)
+	if _, ok := r.Check(report.SysInfoCheck); ok {
+		r.SysInfo = &sysInfo
+	}
+	r.Config = reportedConfig(cfg.ConfigKeys)
+	// The messages of the config task were buffered until it completed.
+	cfg.Out = reporter
+	if err != nil {
+		return r, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return r, err
+	}
+	if opts.FailFast && r.Failed() {
+		return r, nil
+	}
+	if !opts.FailFast {
+		offerFixes(r.Checks, opts, reporter)
+	}
+
+	if recorder != nil {
+		recorder.Redact(cfg.ClientSecret, cfg.DevToken, cfg.RefreshToken, cfg.PrivateKey, cfg.PrivateKeyID)
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = cfg.Endpoint
+	}
+
+	if len(opts.Plugins) > 0 {
+		prog.begin(i18n.T("Running the custom checks"))
+		req := newPluginRequest(language, opts, cfg, endpoint)
+		var plugins []task
+		for _, path := range opts.Plugins {
+			plugins = append(plugins, pluginTask(path, req))
+		}
+		if err := runTasks(ctx, plugins, maxWorkers, opts.CheckTimeout, reporter, add); err != nil {
+			return r, err
+		}
+		if opts.FailFast && r.Failed() {
+			return r, nil
+		}
+	}
+
+	prog.begin(i18n.T("Testing the OAuth flow and the Google Ads API call"))
+	// The recorded requests are replayed with the version they were sent
+	// with, since the replayed endpoint cannot be asked for its versions.
+	apiVersion := oauth.DefaultAPIVersion
+	if replayer != nil {
+		if v := replayer.APIVersion(); v != "" {
+			apiVersion = v
+		}
+	} else {
+		apiVersion = requestAPIVersion(ctx, endpoint, tlsConfig, served, reporter)
+	}
+	if recorder != nil {
+		recorder.SetAPIVersion(apiVersion)
+	}
+	c := oauth.Config{
+		ConfigFile:     cfg,
+		CustomerID:     strings.ReplaceAll(strings.TrimSpace(opts.CustomerID), "-", ""),
+		Endpoint:       endpoint,
+		APIVersion:     apiVersion,
+		OAuthType:      opts.OAuthType,
+		Verbose:        opts.Verbose,
+		Endpoints:      opts.endpoints(),
+		Scopes:         opts.Scopes,
+		RequestTimeout: opts.RequestTimeout,
+		TraceToken:     opts.TraceToken,
+		FailFast:       opts.FailFast,
+		AutoFix:        opts.AutoFix,
+		QRCode:         opts.QRCode,
+		Clipboard:      opts.Clipboard,
+		HTTPClient:     client,
+		KnowledgeBase:  opts.KnowledgeBase,
+		Prompter:       opts.Prompter,
+		Reporter:       reporter,
+	}
+	if c.CustomerID == "" {
+		cid, err := c.ReadCustomerID()
+		if err != nil {
+			return r, err
+		}
+		c.CustomerID = cid
+	}
+	oauthCheck := c.SimulateOAuthFlow(ctx)
+	// The user may have chosen a client account of a manager account.
+	r.CustomerID = c.CustomerID
+	add(oauthCheck)
+	// Only the flows of a user have a refresh token.
+	if diag.ThreeLegged(c.OAuthType) && ctx.Err() == nil {
+		add(c.RefreshTokenHealth(oauthCheck))
+	}
+
+	// The request can be reproduced outside the doctor, e.g. to share it
+	// with support. Without a client library, the working request is the
+	// deliverable of the diagnosis.
+	if ctx.Err() == nil {
+		if cmd, err := c.CurlCommand(opts.HidePII); err == nil {
+			reporter.Print(i18n.Sprintf("Reproduce the Google Ads API request with curl:\n%s\n", cmd))
+		}
+	}
+
+	if opts.Mutate && ctx.Err() == nil {
+		if oauthCheck.Status != report.Pass {
+			reporter.Print(i18n.T("The mutate call is not validated because the OAuth test failed."))
+		} else {
+			prog.begin(i18n.T("Validating a mutate call"))
+			if err := runTasks(ctx, []task{mutateTask(&c)}, 1, opts.CheckTimeout, reporter, add); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	if len(opts.CustomerIDs) > 0 && ctx.Err() == nil {
+		if oauthCheck.Status != report.Pass {
+			reporter.Print(i18n.T("The customer accounts are not checked because the OAuth test failed."))
+		} else {
+			prog.begin(i18n.Sprintf("Checking the access to %d customer accounts", len(opts.CustomerIDs)))
+			var tasks []task
+			for _, cid := range opts.CustomerIDs {
+				tasks = append(tasks, customerTask(&c, cid))
+			}
+			if err := runTasks(ctx, tasks, maxWorkers, opts.CheckTimeout, reporter, add); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	// A deadline error is usually caused by a slow network, so measure it
+	// unless it was already done.
+	if oauthCheck.Code == "DEADLINE_EXCEEDED" && !opts.NetPerf && !opts.FailFast && opts.Replay == "" && ctx.Err() == nil {
+		if u, err := diag.ParseEndpoint(endpoint); err == nil {
+			prog.begin(i18n.T("Measuring the network latency to find the cause of the deadline error"))
+			if err := runTasks(ctx, []task{netperfTask(u, tlsConfig)}, 1, opts.CheckTimeout, reporter, add); err != nil {
+				return r, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// MintToken runs the OAuth consent flow of the client in the configuration
+// and prints a new refresh token, without running the other checks. The user
+// is then asked whether to write the token to the configuration file.
+func MintToken(ctx context.Context, opts Options) error {
+	c, err := tokenConfig(ctx, opts, []string{diag.ClientID, diag.ClientSecret})
+	if err != nil {
+		return err
+	}
+	if c.ConfigFile.ConfigKeys.ClientID == "" || c.ConfigFile.ClientSecret == "" {
+		return i18n.Errorf("A client ID and a client secret are required to generate a refresh token")
+	}
+
+	token, err := c.MintRefreshToken(ctx)
+	if err != nil {
+		return err
+	}
+	c.Reporter.Print(i18n.Sprintf("Refresh token: %s\n", token))
+
+	if c.ConfigFile.FromEnv() {
+		c.Reporter.Print(i18n.Sprintf("Set %s to use it.", c.ConfigFile.EnvVar(diag.RefreshToken)))
+		return nil
+	}
+	return c.SaveRefreshToken(token)
+}
+
+// RevokeToken revokes the refresh token in the configuration after the user
+// confirms it, e.g. when rotating credentials or when the token was
+// generated under the wrong account. It returns false when the token was not
+// revoked.
+func RevokeToken(ctx context.Context, opts Options) (bool, error) {
+	c, err := tokenConfig(ctx, opts, []string{diag.RefreshToken})
+	if err != nil {
+		return false, err
+	}
+	return c.RevokeRefreshToken(ctx)
+}
+
+// TemplateDiff reads the configuration file and compares it with the one
+// the client library expects. It returns the diff and the findings of
+// diag.ConfigFile.TemplateDiff.
+func TemplateDiff(opts Options) (string, []diag.Finding, error) {
+	if err := opts.Validate(); err != nil {
+		return "", nil, err
+	}
+	language := strings.ToLower(opts.Language)
+	if language == diag.RESTLanguage {
+		return "", nil, i18n.Errorf("%s has no config file to compare", language)
+	}
+	reporter := opts.Reporter
+	if reporter == nil {
+		reporter = report.LogReporter{}
+	}
+	parsed := loadConfigFile(language, opts)
+	if opts.OAuthType == "" {
+		var err error
+		if opts.OAuthType, err = detectOAuthType(language, opts, parsed, reporter); err != nil {
+			return "", nil, err
+		}
+	}
+	cfg, err := readConfigFile(language, opts, parsed, reporter)
+	if err != nil {
+		return "", nil, err
+	}
+	return cfg.TemplateDiff(opts.HidePII)
+}
+
+// tokenConfig reads the configuration of the refresh token commands, which
+// do not apply to service accounts. For diag.RESTLanguage, the missing values
+// of restKeys are asked for.
+func tokenConfig(ctx context.Context, opts Options, restKeys []string) (oauth.Config, error) {
+	if err := opts.Validate(); err != nil {
+		return oauth.Config{}, err
+	}
+	reporter := opts.Reporter
+	if reporter == nil {
+		reporter = report.LogReporter{}
+	}
+
+	language := strings.ToLower(opts.Language)
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return oauth.Config{}, err
+	}
+	client := opts.httpClient(tlsConfig)
+	var parsed parsedConfig
+	if language != diag.RESTLanguage {
+		parsed = loadConfigFile(language, opts)
+	}
+	if opts.OAuthType == "" {
+		if opts.OAuthType, err = detectOAuthType(language, opts, parsed, reporter); err != nil {
+			return oauth.Config{}, err
+		}
+	}
+	if opts.OAuthType == diag.ServiceAccount {
+		return oauth.Config{}, i18n.Errorf("The %s OAuth type does not use refresh tokens", opts.OAuthType)
+	}
+	var cfg diag.ConfigFile
+	if language == diag.RESTLanguage {
+		cfg, err = restCredentials(opts, restKeys)
+	} else if cfg, err = readConfigFile(language, opts, parsed, reporter); err == nil && (opts.Keyring || opts.MigrateKeyring) {
+		_, err = cfg.LoadKeyring()
+	}
+	if err == nil {
+		err = resolveSecrets(ctx, &cfg, client, reporter)
+	}
+	if err != nil {
+		return oauth.Config{}, err
+	}
+
+	return oauth.Config{
+		ConfigFile:    cfg,
+		OAuthType:     opts.OAuthType,
+		Verbose:       opts.Verbose,
+		Endpoints:     opts.endpoints(),
+		HTTPClient:    client,
+		KnowledgeBase: opts.KnowledgeBase,
+		QRCode:        opts.QRCode,
+		Clipboard:     opts.Clipboard,
+		Scopes:        opts.Scopes,
+		TraceToken:    opts.TraceToken,
+		Prompter:      opts.Prompter,
+		Reporter:      reporter,
+	}, nil
+}
+
+// networkEndpoint returns the endpoint tested by the network checks. As they
+// run before the configuration check finishes, the endpoint is taken from
+// the parsed configuration file; its errors are ignored since the
+// configuration check reports them, and an invalid endpoint in the file falls
+// back to diag.DefaultEndpoint.
+func networkEndpoint(language string, opts Options, parsed parsedConfig) *url.URL {
+	endpoint := opts.Endpoint
+	if endpoint == "" && language == diag.RESTLanguage {
+		endpoint = opts.Credentials.Endpoint
+	} else if endpoint == "" && parsed.err == nil {
+		endpoint = parsed.file.Endpoint
+	}
+	u, err := diag.ParseEndpoint(endpoint)
+	if err != nil {
+		u, _ = diag.ParseEndpoint(diag.DefaultEndpoint)
+	}
+	return u
+}
+
+// configFile returns the location of the configuration file, which is the
+// default file of the language unless opts.ConfigPath is set.
+func configFile(language string, opts Options) (diag.ConfigFile, error) {
+	c, err := diag.GetConfigFile(language, opts.ConfigPath)
+	if err == nil && opts.ConfigPath == "" && configFormat(language, opts) == diag.JSONFormat {
+		c.Filename = diag.JSONFilename(language)
+		// appsettings.json belongs to the .NET project, which is usually
+		// the working directory.
+		if wd, err := os.Getwd(); err == nil && language == "dotnet" {
+			c.Filepath = wd
+		}
+	}
+	return c, err
+}
+
+// configFormat returns opts.ConfigFormat, or the format detected for .NET,
+// which is configured with App.config, appsettings.json or environment
+// variables.
+func configFormat(language string, opts Options) string {
+	if opts.ConfigFormat != "" || language != "dotnet" {
+		return opts.ConfigFormat
+	}
+	if opts.ConfigPath != "" {
+		if strings.HasSuffix(strings.ToLower(opts.ConfigPath), ".json") {
+			return diag.JSONFormat
+		}
+		return ""
+	}
+	return opts.reader().DetectDotNetFormat()
+}
+
+// parseConfigFile parses the configuration file at path in the format of
+// configFormat. A configuration of diag.EnvFormat has no file.
+func parseConfigFile(language, path string, opts Options) (diag.ConfigFile, error) {
+	switch configFormat(language, opts) {
+	case diag.JSONFormat:
+		return opts.reader().ParseJSONFile(language, path, opts.OAuthType)
+	case diag.EnvFormat:
+		return opts.reader().EnvConfigFile(language, opts.OAuthType), nil
+	}
+	return opts.reader().ParseConfigFile(language, path, opts.OAuthType)
+}
+
+// parsedConfig is the configuration file parsed once per diagnosis, and the
+// error of parsing it. The steps that read the file share it, since parsing
+// an encrypted file runs opts.DecryptCommand, which may ask for a passphrase.
+type parsedConfig struct {
+	file diag.ConfigFile
+	err  error
+}
+
+// loadConfigFile finds and parses the configuration file of language.
+func loadConfigFile(language string, opts Options) parsedConfig {
+	c, err := configFile(language, opts)
+	if err == nil {
+		c, err = parseConfigFile(language, c.GetFilepath(), opts)
+	}
+	return parsedConfig{file: c, err: err}
+}
+
+// restCredentials returns the configuration of diag.RESTLanguage made of
+// opts.Credentials and the environment variables, and asks for the missing
+// values of keys. In non-interactive mode, the values are left empty for the
+// configuration check to report.
+func restCredentials(opts Options, keys []string) (diag.ConfigFile, error) {
+	c := opts.reader().RESTConfigFile(opts.OAuthType, opts.Credentials)
+	p := opts.Prompter
+	if p == nil {
+		p = prompt.NewTerminal(os.Stdin, os.Stdout)
+	}
+	for _, key := range keys {
+		if structs.Map(c.ConfigKeys)[key] != "" {
+			continue
+		}
+		v, err := p.ReadLine(i18n.Sprintf("%s (or set %s) >> ", key, c.GetConfigKeysInLang(key)))
+		if err == prompt.ErrNoInput {
+			break
+		}
+		if err != nil {
+			return c, err
+		}
+		c.SetConfigKeys(key, strings.TrimSpace(v))
+		c.SetSource(key, i18n.T("entered at the prompt"))
+	}
+	return c, nil
+}
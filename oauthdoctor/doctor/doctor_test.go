@@ -0,0 +1,463 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+type fakeReporter struct {
+	msgs    []string
+	results []report.Check
+}
+
+func (r *fakeReporter) Print(msg string) {
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *fakeReporter) Result(c report.Check) {
+	r.results = append(r.results, c)
+}
+
+func errstring(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "nil"
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		opts   Options
+		errstr string
+	}{
+		{
+			desc:   "Supported language and OAuth type",
+			opts:   Options{Language: "Python", OAuthType: diag.InstalledApp},
+			errstr: "nil",
+		},
+		{
+			desc:   "OAuth type to detect",
+			opts:   Options{Language: "python"},
+			errstr: "nil",
+		},
+		{
+			desc:   "Unsupported language",
+			opts:   Options{Language: "cobol", OAuthType: diag.InstalledApp},
+			errstr: "Supported languages are",
+		},
+		{
+			desc:   "Unsupported OAuth type",
+			opts:   Options{Language: "python", OAuthType: "magic"},
+			errstr: "OAuth type not supported",
+		},
+		{
+			desc:   "Service account without a client library",
+			opts:   Options{Language: "rest", OAuthType: diag.ServiceAccount},
+			errstr: "The rest language supports",
+		},
+		{
+			desc:   "OS credential store without a config file",
+			opts:   Options{Language: "rest", OAuthType: diag.InstalledApp, Keyring: true},
+			errstr: "The OS credential store cannot be used with the rest language",
+		},
+		{
+			desc:   "Encrypted config file moved to the OS credential store",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, DecryptCommand: "sops -d /dev/stdin", MigrateKeyring: true},
+			errstr: "The secrets of an encrypted config file cannot be moved",
+		},
+		{
+			desc:   "Client certificate without a key",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, ClientCert: "client.pem"},
+			errstr: "The client certificate and its private key must be given together",
+		},
+		{
+			desc:   "Unsupported config format",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, ConfigFormat: "toml"},
+			errstr: "Config format not supported: toml",
+		},
+		{
+			desc:   "Invalid endpoint",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, Endpoint: "ftp://proxy"},
+			errstr: "Invalid endpoint",
+		},
+		{
+			desc:   "Relative token endpoint",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, TokenURL: "/token"},
+			errstr: "Invalid OAuth2 endpoint /token",
+		},
+		{
+			desc:   "Custom OAuth2 endpoints",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, AuthURL: "http://localhost:9000/auth", TokenURL: "https://oauth.example.com/token"},
+			errstr: "nil",
+		},
+		{
+			desc:   "Invalid API version",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, APIVersion: "17"},
+			errstr: "17 is not a Google Ads API version",
+		},
+		{
+			desc:   "Client secrets of a service account",
+			opts:   Options{Language: "python", OAuthType: diag.ServiceAccount, ClientSecrets: "client_secret.json"},
+			errstr: "A client secrets file holds an OAuth client",
+		},
+		{
+			desc:   "Replay with network checks",
+			opts:   Options{Language: "python", OAuthType: diag.InstalledApp, Replay: "traffic.json", SysInfo: true},
+			errstr: "cannot run against recorded HTTP traffic",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.opts.Validate()
+		if !strings.Contains(errstring(err), test.errstr) {
+			t.Errorf("%s\nValidate() error: %s, want: %s", test.desc, errstring(err), test.errstr)
+		}
+	}
+}
+
+func TestRunReplay(t *testing.T) {
+	tests := []struct {
+		desc     string
+		fixture  string
+		wantCode string
+	}{
+		{
+			desc:    "Recorded success",
+			fixture: "replay_success.json",
+		},
+		{
+			desc:     "Recorded inactive customer",
+			fixture:  "replay_customer_not_active.json",
+			wantCode: "CUSTOMER_NOT_ACTIVE",
+		},
+	}
+
+	for _, tt := range tests {
+		reporter := &fakeReporter{}
+		r, err := Run(context.Background(), Options{
+			Language:   "python",
+			OAuthType:  diag.InstalledApp,
+			ConfigPath: filepath.Join("..", "diag", "testdata", "python_config"),
+			CustomerID: "123-456-7890",
+			Replay:     filepath.Join("testdata", tt.fixture),
+			Traces:     true,
+			Prompter:   prompt.NonInteractive{},
+			Reporter:   reporter,
+		})
+		if err != nil {
+			t.Fatalf("[%s] Run() error: %s", tt.desc, err)
+		}
+
+		c, ok := r.Check(report.OAuthCheck)
+		if !ok {
+			t.Fatalf("[%s] Run() did not run the OAuth check", tt.desc)
+		}
+		if tt.wantCode == "" && c.Status != report.Pass {
+			t.Errorf("[%s] Run() OAuth check status: %s, want: %s\n%s", tt.desc, c.Status, report.Pass,
+				strings.Join(reporter.msgs, "\n"))
+		}
+		if c.Code != tt.wantCode {
+			t.Errorf("[%s] Run() OAuth check code: %q, want: %q\n%s", tt.desc, c.Code, tt.wantCode,
+				strings.Join(reporter.msgs, "\n"))
+		}
+		if (c.Link != "") != (tt.wantCode != "") {
+			t.Errorf("[%s] Run() OAuth check link: %q", tt.desc, c.Link)
+		}
+		if len(r.Traces) == 0 {
+			t.Errorf("[%s] Run() kept no HTTP traces", tt.desc)
+		}
+		if got, want := r.Config[diag.RefreshToken], diag.Redact("1/PG1Ap6P-Good_Refresh_Token"); got != want {
+			t.Errorf("[%s] Run() reported RefreshToken %q, want: %q", tt.desc, got, want)
+		}
+		if msgs := strings.Join(reporter.msgs, "\n"); !strings.Contains(msgs, "Reproduce the Google Ads API request with curl") {
+			t.Errorf("[%s] Run() did not print the curl command:\n%s", tt.desc, msgs)
+		}
+	}
+}
+
+func TestRunDecryptsOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "decrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	runs := filepath.Join(dir, "runs")
+
+	reporter := &fakeReporter{}
+	r, err := Run(context.Background(), Options{
+		Language:       "python",
+		ConfigPath:     filepath.Join("..", "diag", "testdata", "python_config"),
+		DecryptCommand: "echo run >> '" + runs + "'; cat",
+		CustomerID:     "123-456-7890",
+		Replay:         filepath.Join("testdata", "replay_success.json"),
+		Prompter:       prompt.NonInteractive{},
+		Reporter:       reporter,
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %s\n%s", err, strings.Join(reporter.msgs, "\n"))
+	}
+	if c, ok := r.Check(report.ConfigCheck); !ok || c.Status == report.Fail {
+		t.Errorf("Run() config check: %+v\n%s", c, strings.Join(reporter.msgs, "\n"))
+	}
+	got, _ := ioutil.ReadFile(runs)
+	if n := strings.Count(string(got), "run"); n != 1 {
+		t.Errorf("Run() ran the decryption command %d times, want: 1", n)
+	}
+}
+
+func TestRunREST(t *testing.T) {
+	creds := diag.ConfigKeys{
+		ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+		ClientSecret: "GoodClientSecret",
+		DevToken:     "GoodDevToken",
+		RefreshToken: "1/PG1Ap6P-Good_Refresh_Token",
+	}
+	tests := []struct {
+		desc       string
+		creds      diag.ConfigKeys
+		wantStatus report.Status
+		wantCurl   bool
+	}{
+		{
+			desc:       "Credentials from the command line",
+			creds:      creds,
+			wantStatus: report.Pass,
+			wantCurl:   true,
+		},
+		{
+			desc:       "Missing credentials",
+			creds:      diag.ConfigKeys{DevToken: "GoodDevToken"},
+			wantStatus: report.Fail,
+			wantCurl:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		reporter := &fakeReporter{}
+		r, err := Run(context.Background(), Options{
+			Language:    "rest",
+			OAuthType:   diag.InstalledApp,
+			Credentials: tt.creds,
+			CustomerID:  "123-456-7890",
+			Replay:      filepath.Join("testdata", "replay_success.json"),
+			Prompter:    prompt.NonInteractive{},
+			Reporter:    reporter,
+		})
+		if err != nil {
+			t.Fatalf("[%s] Run() error: %s", tt.desc, err)
+		}
+
+		if c, _ := r.Check(report.ConfigCheck); c.Status != tt.wantStatus {
+			t.Errorf("[%s] Run() config check status: %s, want: %s\n%s", tt.desc, c.Status, tt.wantStatus,
+				strings.Join(reporter.msgs, "\n"))
+		}
+		gotCurl := strings.Contains(strings.Join(reporter.msgs, "\n"),
+			"curl -s 'https://googleads.googleapis.com/v8/customers/1234567890'")
+		if gotCurl != tt.wantCurl {
+			t.Errorf("[%s] Run() printed the curl command: %t, want: %t\n%s", tt.desc, gotCurl, tt.wantCurl,
+				strings.Join(reporter.msgs, "\n"))
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	testdata := filepath.Join("..", "diag", "testdata")
+
+	tests := []struct {
+		desc       string
+		configPath string
+		wantChecks []string
+		errstr     string
+	}{
+		{
+			desc:       "Config file is missing",
+			configPath: filepath.Join(testdata, "does_not_exist"),
+			errstr:     "Cannot find config file",
+		},
+		{
+			desc:       "Config file is checked before OAuth",
+			configPath: filepath.Join(testdata, "python_config"),
+			wantChecks: []string{report.ConfigCheck},
+			errstr:     context.Canceled.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		// Cancel the context, so the OAuth flow does not run.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		reporter := &fakeReporter{}
+		r, err := Run(ctx, Options{
+			Language:   "python",
+			OAuthType:  diag.InstalledApp,
+			ConfigPath: test.configPath,
+			CustomerID: "123-456-7890",
+			Reporter:   reporter,
+		})
+
+		if !strings.Contains(errstring(err), test.errstr) {
+			t.Errorf("%s\nRun() error: %s, want: %s", test.desc, errstring(err), test.errstr)
+		}
+
+		var got []string
+		for _, c := range r.Checks {
+			got = append(got, c.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(test.wantChecks, ",") {
+			t.Errorf("%s\nRun() checks: %v, want: %v", test.desc, got, test.wantChecks)
+		}
+		if len(reporter.results) != len(r.Checks) {
+			t.Errorf("%s\nReporter received %d results, want: %d", test.desc, len(reporter.results), len(r.Checks))
+		}
+	}
+}
+
+func TestRunFailFast(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	config := "client_id: 0123456789-GoodClientID.apps.googleusercontent.com\n" +
+		"client_secret: GoodClientSecret\n" +
+		"refresh_token: 1/PG1Ap6P-Good_Refresh_Token\n"
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The context is not cancelled: the OAuth flow must not run after the
+	// failed configuration check.
+	r, err := Run(context.Background(), Options{
+		Language:   "python",
+		OAuthType:  diag.InstalledApp,
+		ConfigPath: path,
+		CustomerID: "123-456-7890",
+		FailFast:   true,
+		Prompter:   prompt.NonInteractive{},
+		Reporter:   &fakeReporter{},
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %s", err)
+	}
+	if len(r.Checks) != 1 || r.Checks[0].ID != report.ConfigCheck || r.Checks[0].Status != report.Fail {
+		t.Errorf("Run() checks: %+v, want a failed %s check only", r.Checks, report.ConfigCheck)
+	}
+	if !r.Failed() {
+		t.Errorf("Report.Failed() = false, want: true")
+	}
+}
+
+func TestTokenCommandErrors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		revoke bool
+		opts   Options
+		want   string
+	}{
+		{
+			desc: "Mint for a service account",
+			opts: Options{Language: "python", OAuthType: diag.ServiceAccount},
+			want: "does not use refresh tokens",
+		},
+		{
+			desc: "Mint without client secret",
+			opts: Options{
+				Language:    "rest",
+				OAuthType:   diag.InstalledApp,
+				Credentials: diag.ConfigKeys{ClientID: "0123456789-GoodClientID.apps.googleusercontent.com"},
+			},
+			want: "client secret are required",
+		},
+		{
+			desc:   "Revoke for a service account",
+			revoke: true,
+			opts:   Options{Language: "python", OAuthType: diag.ServiceAccount},
+			want:   "does not use refresh tokens",
+		},
+		{
+			desc:   "Revoke without refresh token",
+			revoke: true,
+			opts:   Options{Language: "rest", OAuthType: diag.InstalledApp},
+			want:   "no refresh token",
+		},
+	}
+
+	for _, tt := range tests {
+		tt.opts.Prompter = prompt.NonInteractive{}
+		tt.opts.Reporter = &fakeReporter{}
+		var err error
+		if tt.revoke {
+			_, err = RevokeToken(context.Background(), tt.opts)
+		} else {
+			err = MintToken(context.Background(), tt.opts)
+		}
+		if !strings.Contains(errstring(err), tt.want) {
+			t.Errorf("[%s] error: %s, want: %s", tt.desc, errstring(err), tt.want)
+		}
+	}
+}
+
+func TestNetworkEndpoint(t *testing.T) {
+	proxy := diag.ConfigFile{ConfigKeys: diag.ConfigKeys{Endpoint: "https://proxy.example.com"}}
+	tests := []struct {
+		desc   string
+		opts   Options
+		parsed parsedConfig
+		want   string
+	}{
+		{
+			desc:   "Endpoint of the config file",
+			opts:   Options{Language: "python"},
+			parsed: parsedConfig{file: proxy},
+			want:   "https://proxy.example.com",
+		},
+		{
+			desc:   "Endpoint option",
+			opts:   Options{Language: "python", Endpoint: "https://other.example.com"},
+			parsed: parsedConfig{file: proxy},
+			want:   "https://other.example.com",
+		},
+		{
+			desc:   "Config file that cannot be parsed",
+			opts:   Options{Language: "python"},
+			parsed: parsedConfig{file: proxy, err: os.ErrNotExist},
+			want:   diag.DefaultEndpoint,
+		},
+		{
+			desc: "REST credentials",
+			opts: Options{Language: diag.RESTLanguage,
+				Credentials: diag.ConfigKeys{Endpoint: "https://rest.example.com"}},
+			want: "https://rest.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := networkEndpoint(strings.ToLower(tt.opts.Language), tt.opts, tt.parsed); got.String() != tt.want {
+			t.Errorf("[%s] networkEndpoint() = %s, want: %s", tt.desc, got, tt.want)
+		}
+	}
+}
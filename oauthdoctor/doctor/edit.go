@@ -0,0 +1,147 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// EditConfig sets values of the configuration file with the writer of its
+// language, which keeps the rest of the file and a backup of it. The values
+// are given as KEY=VALUE assignments, where KEY is a field of
+// diag.ConfigKeys or its key in the file; without assignments, they are
+// chosen in a menu. The problems that the new values have are printed.
+func EditConfig(opts Options, assignments []string) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	language := strings.ToLower(opts.Language)
+	if language == diag.RESTLanguage {
+		return i18n.Errorf("%s has no config file to edit", language)
+	}
+	reporter := opts.Reporter
+	if reporter == nil {
+		reporter = report.LogReporter{}
+	}
+	parsed := loadConfigFile(language, opts)
+	if opts.OAuthType == "" {
+		var err error
+		if opts.OAuthType, err = detectOAuthType(language, opts, parsed, reporter); err != nil {
+			return err
+		}
+	}
+	c, err := readConfigFile(language, opts, parsed, reporter)
+	if err == nil && opts.Keyring {
+		_, err = c.LoadKeyring()
+	}
+	if err != nil {
+		return err
+	}
+	if c.FromEnv() {
+		return i18n.Errorf("The configuration is read from the environment variables, so there is no config file to edit.")
+	}
+
+	// The assignments are checked before the file is changed.
+	values := make(map[string]string)
+	var fields []string
+	for _, a := range assignments {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 {
+			return i18n.Errorf("%s is not a KEY=VALUE assignment", a)
+		}
+		field, err := c.ConfigField(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return err
+		}
+		if _, ok := values[field]; !ok {
+			fields = append(fields, field)
+		}
+		values[field] = strings.TrimSpace(kv[1])
+	}
+	edit := func(field, value string) error {
+		if c.EditValue(field, false) == value {
+			reporter.Print(i18n.Sprintf("%s is already set to this value.", field))
+			return nil
+		}
+		if _, err := c.ReplaceConfig(field, value); err != nil {
+			return err
+		}
+		reporter.Print(i18n.Sprintf("%s was written to the config file.", field))
+		return nil
+	}
+
+	if len(assignments) == 0 {
+		p := opts.Prompter
+		if p == nil {
+			p = prompt.NewTerminal(os.Stdin, os.Stdout)
+		}
+		if fields, err = editMenu(&c, p, opts.HidePII, edit); err != nil {
+			return err
+		}
+	} else {
+		for _, field := range fields {
+			if err := edit(field, values[field]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range c.Lint(opts.CustomerID) {
+		if diag.Contains(fields, f.Key) {
+			reporter.Print(i18n.Sprintf("%s: %s", f.Severity, f.Message))
+		}
+	}
+	return nil
+}
+
+// editMenu asks for the field to change and its new value with p until the
+// user is done, and returns the fields that were edited.
+func editMenu(c *diag.ConfigFile, p prompt.Prompter, hidePII bool, edit func(field, value string) error) ([]string, error) {
+	var edited []string
+	for {
+		fields := c.EditableFields()
+		options := make([]string, len(fields)+1)
+		for i, field := range fields {
+			options[i] = i18n.Sprintf("%s: %s", field, c.EditValue(field, hidePII))
+		}
+		options[len(fields)] = i18n.T("Done")
+		i, err := p.Select(i18n.Sprintf("Choose the value of %s to change:", c.GetFilepath()), options)
+		if err == prompt.ErrNoInput || err == io.EOF || (err == nil && i == len(fields)) {
+			return edited, nil
+		}
+		if err != nil {
+			return edited, err
+		}
+		value, err := p.ReadLine(i18n.Sprintf("New value of %s (empty to keep it) >> ", fields[i]))
+		if err != nil && err != prompt.ErrNoInput && err != io.EOF {
+			return edited, err
+		}
+		if value == "" {
+			continue
+		}
+		if err := edit(fields[i], value); err != nil {
+			return edited, err
+		}
+		if !diag.Contains(edited, fields[i]) {
+			edited = append(edited, fields[i])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestEditConfig(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	dir, err := ioutil.TempDir("", "edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := "# Credentials\ndeveloper_token: AbCdEfGhIjKlMnOpQrStUv\nclient_id: id.apps.googleusercontent.com\n" +
+		"client_secret: secret\nrefresh_token: 1//token\n"
+
+	tests := []struct {
+		desc        string
+		assignments []string
+		stdin       string
+		want        string
+		wantErr     string
+		wantMsg     string
+	}{
+		{
+			desc:        "field and key of the file",
+			assignments: []string{"logincustomerid=1234567890", "refresh_token = 1//new"},
+			want: "# Credentials\ndeveloper_token: AbCdEfGhIjKlMnOpQrStUv\nclient_id: id.apps.googleusercontent.com\n" +
+				"client_secret: secret\nrefresh_token: 1//new\nlogin_customer_id: \"1234567890\"\n",
+		},
+		{
+			desc:        "invalid value",
+			assignments: []string{"DevToken=Ab Cd"},
+			want: "# Credentials\ndeveloper_token: Ab Cd\nclient_id: id.apps.googleusercontent.com\n" +
+				"client_secret: secret\nrefresh_token: 1//token\n",
+			wantMsg: "WARNING: Dev token contains whitespace",
+		},
+		{
+			desc:        "unknown key",
+			assignments: []string{"user_agent=doctor"},
+			want:        config,
+			wantErr:     "user_agent is not a key of the config file",
+		},
+		{
+			desc:        "not an assignment",
+			assignments: []string{"refresh_token"},
+			want:        config,
+			wantErr:     "is not a KEY=VALUE assignment",
+		},
+		{
+			desc:  "menu",
+			stdin: "2\nnew-secret\n1\n\n",
+			want: "# Credentials\ndeveloper_token: AbCdEfGhIjKlMnOpQrStUv\nclient_id: id.apps.googleusercontent.com\n" +
+				"client_secret: new-secret\nrefresh_token: 1//token\n",
+			wantMsg: "ClientSecret was written to the config file.",
+		},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, "google-ads.yaml")
+		if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		out := &fakeReporter{}
+		opts := Options{Language: "python", OAuthType: diag.InstalledApp, ConfigPath: path, Reporter: out,
+			Prompter: prompt.NewTerminal(strings.NewReader(tt.stdin), ioutil.Discard)}
+
+		err := EditConfig(opts, tt.assignments)
+
+		if !strings.Contains(errstring(err), tt.wantErr) || (tt.wantErr == "" && err != nil) {
+			t.Errorf("[%s] EditConfig() error: %v, want: %s", tt.desc, err, tt.wantErr)
+		}
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("[%s] EditConfig() wrote:\n%s\nwant:\n%s", tt.desc, got, tt.want)
+		}
+		if msgs := strings.Join(out.msgs, "\n"); !strings.Contains(msgs, tt.wantMsg) {
+			t.Errorf("[%s] EditConfig() printed: %s\nwant substring: %s", tt.desc, msgs, tt.wantMsg)
+		}
+	}
+}
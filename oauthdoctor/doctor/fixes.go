@@ -0,0 +1,42 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"os"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// offerFixes offers the fixes of the checks that did not pass, see
+// report.Offer. The checks are not run again, so their results stay the
+// ones of the problems that were found.
+func offerFixes(checks []report.Check, opts Options, out report.Reporter) {
+	p := opts.Prompter
+	if p == nil {
+		p = prompt.NewTerminal(os.Stdin, os.Stdout)
+	}
+	for _, c := range checks {
+		if c.Status == report.Pass {
+			continue
+		}
+		for _, r := range c.Remediations {
+			if _, err := report.Offer(r, p, out, opts.AutoFix); err != nil {
+				out.Print(i18n.Sprintf("ERROR: The fix was not applied: %s", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestOfferFixes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+
+	tests := []struct {
+		desc    string
+		autoFix bool
+		stdin   string
+		want    string
+		wantMsg string
+	}{
+		{
+			desc:    "Confirmed",
+			stdin:   "y\n",
+			want:    "GoodDevToken",
+			wantMsg: "Fix: Set developer_token to the value of developer_tokn on line 1",
+		},
+		{
+			desc:    "Declined",
+			stdin:   "n\n",
+			wantMsg: "Fix: Set developer_token to the value of developer_tokn on line 1",
+		},
+		{
+			desc:    "Applied without asking",
+			autoFix: true,
+			want:    "GoodDevToken",
+			wantMsg: "Applying the fix: Set developer_token",
+		},
+	}
+
+	for _, tt := range tests {
+		config := "developer_tokn: GoodDevToken\n" +
+			"client_id: 0123456789-GoodClientID.apps.googleusercontent.com\n" +
+			"client_secret: GoodClientSecret\n" +
+			"refresh_token: 1/PG1Ap6P-Good_Refresh_Token\n"
+		if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{
+			Language:   "python",
+			OAuthType:  diag.InstalledApp,
+			ConfigPath: path,
+			AutoFix:    tt.autoFix,
+			Prompter:   prompt.NewTerminal(strings.NewReader(tt.stdin), ioutil.Discard),
+		}
+		reporter := &fakeReporter{}
+		var cfg diag.ConfigFile
+		chk, err := configTask("python", opts, loadConfigFile("python", opts), http.DefaultClient, &cfg).run(context.Background(), reporter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chk.Status != report.Fail || len(chk.Remediations) != 1 {
+			t.Fatalf("[%s] configTask() got %s with %d fixes, want FAIL with 1 fix", tt.desc, chk.Status, len(chk.Remediations))
+		}
+
+		offerFixes([]report.Check{chk}, opts, reporter)
+
+		if cfg.DevToken != tt.want {
+			t.Errorf("[%s] offerFixes() got developer token %q, want: %q", tt.desc, cfg.DevToken, tt.want)
+		}
+		if !strings.Contains(strings.Join(reporter.msgs, "\n"), tt.wantMsg) {
+			t.Errorf("[%s] offerFixes() printed:\n%s\nwant substring: %s", tt.desc, strings.Join(reporter.msgs, "\n"), tt.wantMsg)
+		}
+	}
+}
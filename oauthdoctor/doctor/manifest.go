@@ -0,0 +1,174 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// CheckInfo describes a check of the diagnosis, so tools that wrap the
+// doctor can find out what it does without running it.
+type CheckInfo struct {
+	// ID is the ID of the check in the report.
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Inputs are the fields of Options and of the configuration file that
+	// the check uses.
+	Inputs []string `json:"inputs"`
+	// EnabledBy is the field of Options that adds the check, or empty when
+	// the check always runs.
+	EnabledBy string `json:"enabledBy,omitempty"`
+	// Network is true when the check sends requests over the network.
+	Network bool `json:"network"`
+	// Interactive is true when the check may ask the user for input.
+	Interactive bool `json:"interactive"`
+}
+
+// Checks returns the checks of the diagnosis in the order they run.
+func Checks() []CheckInfo {
+	endpoint := []string{"Endpoint", "ConfigPath"}
+	return []CheckInfo{
+		{
+			ID:          report.SysInfoCheck,
+			Name:        i18n.T("System information"),
+			Description: i18n.T("Prints the operating system and its version, locale, time zone, proxy variables, DNS servers, VPN interfaces and IPv4 addresses of this machine, and warns when its memory, free disk space or open files limit is low."),
+			Inputs:      []string{},
+			EnabledBy:   "SysInfo",
+		},
+		{
+			ID:          report.BuildEnvCheck,
+			Name:        i18n.T("Build environment"),
+			Description: i18n.T("Prints the compiler and module of this binary and checks the version and module mode of the Go toolchain on the PATH. Prebuilt release binaries skip it."),
+			Inputs:      []string{},
+			EnabledBy:   "SysInfo",
+		},
+		{
+			ID:          report.DNSCheck,
+			Name:        i18n.T("DNS resolution"),
+			Description: i18n.T("Resolves the host name of the Google Ads API endpoint."),
+			Inputs:      endpoint,
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.ConnectivityCheck,
+			Name:        i18n.T("Connectivity"),
+			Description: i18n.T("Sends an HTTPS request for the REST discovery document to the Google Ads API endpoint through the proxy of the environment."),
+			Inputs:      endpoint,
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.TCPCheck,
+			Name:        i18n.T("TCP connection"),
+			Description: i18n.T("Opens a TCP connection to the Google Ads API endpoint, without TLS or proxy, to compare with the connectivity check."),
+			Inputs:      endpoint,
+			EnabledBy:   "TCPCheck",
+			Network:     true,
+		},
+		{
+			ID:          report.TLSCheck,
+			Name:        i18n.T("TLS connection"),
+			Description: i18n.T("Verifies the TLS certificate of the Google Ads API endpoint."),
+			Inputs:      endpoint,
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.HTTP2Check,
+			Name:        i18n.T("HTTP/2 support"),
+			Description: i18n.T("Checks that the connection to the endpoint supports HTTP/2, which gRPC requires."),
+			Inputs:      endpoint,
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.TransportCheck,
+			Name:        i18n.T("Transport"),
+			Description: i18n.T("Checks that the proxy lets through the REST or gRPC requests of the client library."),
+			Inputs:      []string{"Language", "ConfigPath", "ConfigFormat", "Endpoint"},
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.APIVersionsCheck,
+			Name:        i18n.T("API versions"),
+			Description: i18n.T("Finds the versions of the Google Ads API that the endpoint serves, and checks that the version of the client library is one of them."),
+			Inputs:      []string{"Endpoint", "ConfigPath", "APIVersion"},
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.AllowListCheck,
+			Name:        i18n.T("Allow-list"),
+			Description: i18n.T("Connects to all the hosts that the client libraries need on their HTTPS port, and prints the allow-list of a firewall or proxy."),
+			Inputs:      endpoint,
+			EnabledBy:   "SysInfo",
+			Network:     true,
+		},
+		{
+			ID:          report.NetPerfCheck,
+			Name:        i18n.T("Network latency"),
+			Description: i18n.T("Measures the connect, TLS handshake and first byte latencies to the endpoint."),
+			Inputs:      endpoint,
+			EnabledBy:   "NetPerf",
+			Network:     true,
+		},
+		{
+			ID:          report.ConfigCheck,
+			Name:        i18n.T("Configuration file"),
+			Description: i18n.T("Parses the client library configuration file and validates its values."),
+			Inputs:      []string{"Language", "OAuthType", "ConfigPath", "ConfigFormat", "Credentials", "CustomerID"},
+		},
+		{
+			ID:          report.PluginCheckPrefix + "*",
+			Name:        i18n.T("Custom checks"),
+			Description: i18n.T("Runs the plugins, which report their own check IDs prefixed with plugin:."),
+			Inputs:      []string{"Plugins", "Language", "OAuthType", "ConfigPath", "CustomerID"},
+			EnabledBy:   "Plugins",
+		},
+		{
+			ID:          report.OAuthCheck,
+			Name:        i18n.T("OAuth flow and API access"),
+			Description: i18n.T("Gets an access token with the credentials and calls the Google Ads API; asks for a customer ID and offers to fix the credentials when it fails."),
+			Inputs:      []string{"Language", "OAuthType", "ConfigPath", "CustomerID", "Endpoint", "AuthURL", "TokenURL", "Scopes", "RequestTimeout"},
+			Network:     true,
+			Interactive: true,
+		},
+		{
+			ID:          report.TokenCheck,
+			Name:        i18n.T("Refresh token health"),
+			Description: i18n.T("Estimates the age of the refresh token from the configuration file and its backups, counts the refresh tokens of the OAuth client that were replaced, and explains why a refresh token that was expired or revoked stopped working. Service accounts skip it."),
+			Inputs:      []string{"OAuthType", "ConfigPath"},
+		},
+		{
+			ID:          report.MutateCheck,
+			Name:        i18n.T("Mutate validation"),
+			Description: i18n.T("Validates the creation of a campaign budget in the customer account with validate_only and partial_failure, so nothing is changed, to check that the credentials and the developer token can write. Explains read-only access, temporary errors and rejected operations."),
+			Inputs:      []string{"CustomerID", "Endpoint", "RequestTimeout"},
+			EnabledBy:   "Mutate",
+			Network:     true,
+		},
+		{
+			ID:          report.CustomerCheckPrefix + "*",
+			Name:        i18n.T("Access to customer accounts"),
+			Description: i18n.T("Gets each customer account of the list with the credentials that passed the OAuth check, and reports whether access is denied, the account is not linked to the login customer or not active."),
+			Inputs:      []string{"CustomerIDs", "Endpoint", "RequestTimeout"},
+			EnabledBy:   "CustomerIDs",
+			Network:     true,
+		},
+	}
+}
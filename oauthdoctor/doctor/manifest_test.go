@@ -0,0 +1,41 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestChecks(t *testing.T) {
+	ids := make(map[string]bool)
+	for _, c := range Checks() {
+		if ids[c.ID] {
+			t.Errorf("Checks() lists %s twice", c.ID)
+		}
+		ids[c.ID] = true
+		if c.Name == "" || c.Description == "" || c.Inputs == nil {
+			t.Errorf("Checks() has an incomplete entry: %+v", c)
+		}
+	}
+
+	for _, id := range []string{report.AllowListCheck, report.BuildEnvCheck, report.ConfigCheck, report.ConnectivityCheck, report.DNSCheck, report.HTTP2Check, report.MutateCheck,
+		report.NetPerfCheck, report.OAuthCheck, report.SysInfoCheck, report.TLSCheck, report.TokenCheck,
+		report.TransportCheck, report.APIVersionsCheck} {
+		if !ids[id] {
+			t.Errorf("Checks() does not list %s", id)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"os"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// detectOAuthType returns the OAuth type of the credentials in the
+// configuration, for when opts.OAuthType is empty. Without a configuration
+// file, diag.RESTLanguage uses user credentials.
+func detectOAuthType(language string, opts Options, parsed parsedConfig, out report.Reporter) (string, error) {
+	var found string
+	if language == diag.RESTLanguage {
+		if found = diag.CredentialsOAuthType(opts.reader().RESTConfigFile("", opts.Credentials).ConfigKeys); !diag.ThreeLegged(found) {
+			found = diag.InstalledApp
+		}
+	} else {
+		if parsed.err != nil {
+			return "", i18n.Errorf("Cannot detect the OAuth type: %s", parsed.err)
+		}
+		if found = diag.DetectOAuthType(parsed.file.ConfigKeys); found == "" {
+			return "", i18n.Errorf("Cannot detect the OAuth type from the credentials in the configuration. "+
+				"Specify one of %s", strings.Join(OAuthTypes, ", "))
+		}
+	}
+	out.Print(i18n.Sprintf("OAuth type: %s (detected from the credentials in the configuration)\n", found))
+	return found, nil
+}
+
+// matchOAuthType compares opts.OAuthType with the credentials in the
+// configuration file. When a service account is selected for the credentials
+// of a user, or the other way around, it explains the difference and offers
+// to diagnose the flow of the credentials instead. It returns the OAuth type
+// to diagnose. Errors reading the file are left for the configuration check
+// to report.
+func matchOAuthType(language string, opts Options, parsed parsedConfig, out report.Reporter) (string, error) {
+	if language == diag.RESTLanguage || parsed.err != nil {
+		return opts.OAuthType, nil
+	}
+	found := diag.CredentialsOAuthType(parsed.file.ConfigKeys)
+	if found == "" || diag.ThreeLegged(found) == diag.ThreeLegged(opts.OAuthType) {
+		return opts.OAuthType, nil
+	}
+
+	if diag.ThreeLegged(found) {
+		out.Print(i18n.Sprintf("WARNING: The OAuth type is %s, but the configuration has the credentials of "+
+			"a user (client ID, client secret and refresh token) and no %s.", opts.OAuthType, diag.PrivateKeyPath))
+	} else {
+		out.Print(i18n.Sprintf("WARNING: The OAuth type is %s, but the configuration has the credentials of "+
+			"a service account (%s) and no client ID or refresh token.", opts.OAuthType, diag.PrivateKeyPath))
+	}
+	out.Print(i18n.T("A service account (2-legged OAuth) authenticates as itself with its JSON key file, " +
+		"without a user. The installed app and web flows (3-legged OAuth) act on behalf of a user who " +
+		"authorized the OAuth client; the authorization is saved as a refresh token. " +
+		"Read https://developers.google.com/google-ads/api/docs/oauth/overview"))
+
+	p := opts.Prompter
+	if p == nil {
+		p = prompt.NewTerminal(os.Stdin, os.Stdout)
+	}
+	yes, err := p.Confirm(i18n.Sprintf("Diagnose the %s flow instead? Enter Y for Yes [Anything else is No] >> ", found))
+	if err != nil {
+		return opts.OAuthType, err
+	}
+	if !yes {
+		return opts.OAuthType, nil
+	}
+	out.Print(i18n.Sprintf("Diagnosing the %s flow.\n", found))
+	return found, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestMatchOAuthType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	serviceAccount := filepath.Join(dir, "google-ads.yaml")
+	config := "developer_token: GoodDevToken\n" +
+		"json_key_file_path: " + filepath.Join("..", "diag", "testdata", "service_account.json") + "\n"
+	if err := ioutil.WriteFile(serviceAccount, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	user := filepath.Join("..", "diag", "testdata", "python_config")
+	yes := func() prompt.Prompter {
+		return prompt.NewTerminal(strings.NewReader("y\n"), ioutil.Discard)
+	}
+
+	tests := []struct {
+		desc       string
+		oauthType  string
+		configPath string
+		prompter   prompt.Prompter
+		want       string
+		wantMsg    string
+	}{
+		{
+			desc:       "Matching credentials",
+			oauthType:  diag.Web,
+			configPath: user,
+			prompter:   yes(),
+			want:       diag.Web,
+		},
+		{
+			desc:       "User credentials for a service account",
+			oauthType:  diag.ServiceAccount,
+			configPath: user,
+			prompter:   yes(),
+			want:       diag.InstalledApp,
+			wantMsg:    "has the credentials of a user",
+		},
+		{
+			desc:       "Switching is declined",
+			oauthType:  diag.ServiceAccount,
+			configPath: user,
+			prompter:   prompt.NonInteractive{},
+			want:       diag.ServiceAccount,
+			wantMsg:    "2-legged OAuth",
+		},
+		{
+			desc:       "Service account credentials for a user",
+			oauthType:  diag.InstalledApp,
+			configPath: serviceAccount,
+			prompter:   yes(),
+			want:       diag.ServiceAccount,
+			wantMsg:    "has the credentials of a service account",
+		},
+		{
+			desc:       "Missing config file",
+			oauthType:  diag.ServiceAccount,
+			configPath: filepath.Join(dir, "does_not_exist"),
+			want:       diag.ServiceAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		reporter := &fakeReporter{}
+		opts := Options{
+			Language:   "python",
+			OAuthType:  tt.oauthType,
+			ConfigPath: tt.configPath,
+			Prompter:   tt.prompter,
+		}
+		got, err := matchOAuthType("python", opts, loadConfigFile("python", opts), reporter)
+		if err != nil {
+			t.Errorf("[%s] matchOAuthType() error: %s", tt.desc, err)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] matchOAuthType() = %s, want: %s", tt.desc, got, tt.want)
+		}
+		msgs := strings.Join(reporter.msgs, "\n")
+		if tt.wantMsg == "" && msgs != "" {
+			t.Errorf("[%s] matchOAuthType() printed %q, want nothing", tt.desc, msgs)
+		}
+		if !strings.Contains(msgs, tt.wantMsg) {
+			t.Errorf("[%s] matchOAuthType() printed %q, want substring: %s", tt.desc, msgs, tt.wantMsg)
+		}
+	}
+}
+
+func TestDetectOAuthType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	delegated := filepath.Join(dir, "google-ads.yaml")
+	config := "developer_token: GoodDevToken\nimpersonated_email: ads@example.com\n"
+	if err := ioutil.WriteFile(delegated, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	both := filepath.Join(dir, "both.yaml")
+	config = "client_id: GoodClientID\njson_key_file_path: " +
+		filepath.Join("..", "diag", "testdata", "service_account.json") + "\n"
+	if err := ioutil.WriteFile(both, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc       string
+		language   string
+		configPath string
+		want       string
+		errstr     string
+	}{
+		{
+			desc:       "User credentials",
+			language:   "python",
+			configPath: filepath.Join("..", "diag", "testdata", "python_config2"),
+			want:       diag.InstalledApp,
+			errstr:     "nil",
+		},
+		{
+			desc:       "Delegated account",
+			language:   "python",
+			configPath: delegated,
+			want:       diag.ServiceAccount,
+			errstr:     "nil",
+		},
+		{
+			desc:       "Delegated account with user credentials",
+			language:   "python",
+			configPath: filepath.Join("..", "diag", "testdata", "python_config"),
+			want:       diag.InstalledApp,
+			errstr:     "nil",
+		},
+		{
+			desc:       "Both kinds of credentials",
+			language:   "python",
+			configPath: both,
+			errstr:     "Cannot detect the OAuth type from the credentials",
+		},
+		{
+			desc:       "Missing config file",
+			language:   "python",
+			configPath: filepath.Join(dir, "does_not_exist"),
+			errstr:     "Cannot detect the OAuth type",
+		},
+		{
+			desc:     "REST interface",
+			language: diag.RESTLanguage,
+			want:     diag.InstalledApp,
+			errstr:   "nil",
+		},
+	}
+
+	for _, tt := range tests {
+		opts := Options{Language: tt.language, ConfigPath: tt.configPath}
+		got, err := detectOAuthType(tt.language, opts, loadConfigFile(tt.language, opts), &fakeReporter{})
+		if !strings.Contains(errstring(err), tt.errstr) {
+			t.Errorf("[%s] detectOAuthType() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] detectOAuthType() = %s, want: %s", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+// This file contains the protocol of the plugins that add custom checks to
+// the diagnosis.
+//
+// A plugin is an executable. For each diagnosis, it is started with the
+// pluginRequest in JSON on stdin and must write a pluginResponse in JSON to
+// stdout, then exit with status 0. For example, the request
+//
+//	{"protocol": 1, "language": "python", "oauthType": "installed_app",
+//	 "configPath": "/home/me/google-ads.yaml", "endpoint": "https://googleads.googleapis.com",
+//	 "clientId": "123-abc.apps.googleusercontent.com", "loginCustomerId": "1234567890"}
+//
+// may be answered with
+//
+//	{"id": "oauth-client", "name": "Internal OAuth client", "status": "FAIL",
+//	 "code": "WRONG_CLIENT", "message": "Use the OAuth client of the ads-reporting project",
+//	 "output": ["The client ID belongs to project 123"]}
+//
+// The status is one of PASS, WARN, FAIL and SKIP. Secrets, such as the client
+// secret, the refresh token and the developer token, are never sent to
+// plugins.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// PluginProtocol is the version of the plugin protocol. It is incremented
+// when a field changes meaning or is removed.
+const PluginProtocol = 1
+
+// pluginTimeout is how long a plugin may run.
+const pluginTimeout = 30 * time.Second
+
+// pluginRequest describes the configuration under diagnosis to a plugin.
+type pluginRequest struct {
+	Protocol         int    `json:"protocol"`
+	Language         string `json:"language"`
+	OAuthType        string `json:"oauthType"`
+	ConfigPath       string `json:"configPath,omitempty"`
+	Endpoint         string `json:"endpoint"`
+	CustomerID       string `json:"customerId,omitempty"`
+	ClientID         string `json:"clientId,omitempty"`
+	LoginCustomerID  string `json:"loginCustomerId,omitempty"`
+	ClientEmail      string `json:"clientEmail,omitempty"`
+	DelegatedAccount string `json:"delegatedAccount,omitempty"`
+}
+
+// pluginResponse is the result of the check of a plugin.
+type pluginResponse struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Status  report.Status `json:"status"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Output  []string      `json:"output"`
+}
+
+// newPluginRequest returns the request sent to the plugins for the parsed
+// configuration cfg.
+func newPluginRequest(language string, opts Options, cfg diag.ConfigFile, endpoint string) pluginRequest {
+	req := pluginRequest{
+		Protocol:         PluginProtocol,
+		Language:         language,
+		OAuthType:        opts.OAuthType,
+		Endpoint:         endpoint,
+		CustomerID:       strings.Replace(opts.CustomerID, "-", "", -1),
+		ClientID:         cfg.ConfigKeys.ClientID,
+		LoginCustomerID:  cfg.LoginCustomerID,
+		ClientEmail:      cfg.ClientEmail,
+		DelegatedAccount: cfg.DelegatedAccount,
+	}
+	if language != diag.RESTLanguage {
+		req.ConfigPath = cfg.GetFilepath()
+	}
+	if req.Endpoint == "" {
+		req.Endpoint = diag.DefaultEndpoint
+	}
+	return req
+}
+
+// pluginTask runs the plugin at path with req. A plugin that fails or
+// returns an invalid response fails its check, so the other checks still
+// run.
+func pluginTask(path string, req pluginRequest) task {
+	return task{
+		run: func(ctx context.Context, out report.Reporter) (report.Check, error) {
+			name := filepath.Base(path)
+			chk := report.Check{
+				ID:     report.PluginCheckPrefix + name,
+				Name:   name,
+				Status: report.Fail,
+			}
+			out.Print(i18n.Sprintf("Running plugin %s...", path))
+
+			resp, err := runPlugin(ctx, path, req)
+			if err != nil {
+				chk.Message = i18n.Sprintf("Plugin %s failed: %s", path, err)
+				out.Print(i18n.Sprintf("ERROR: %s", chk.Message))
+				return chk, nil
+			}
+			for _, line := range resp.Output {
+				out.Print(line)
+			}
+			if resp.ID != "" {
+				chk.ID = report.PluginCheckPrefix + resp.ID
+			}
+			if resp.Name != "" {
+				chk.Name = resp.Name
+			}
+			chk.Status, chk.Code, chk.Message = resp.Status, resp.Code, resp.Message
+			return chk, nil
+		},
+	}
+}
+
+// runPlugin starts the plugin at path, writes req to its stdin and decodes
+// its response.
+func runPlugin(ctx context.Context, path string, req pluginRequest) (*pluginResponse, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, i18n.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	resp := &pluginResponse{}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return nil, i18n.Errorf("invalid response: %s", err)
+	}
+	switch resp.Status {
+	case report.Pass, report.Warn, report.Fail, report.Skip:
+	default:
+		return nil, i18n.Errorf("invalid status %q", resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestPluginTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test plugins are shell scripts")
+	}
+	dir, err := ioutil.TempDir("", "oauthdoctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc       string
+		script     string
+		wantID     string
+		wantStatus report.Status
+		wantMsg    string
+		wantOutput string
+	}{
+		{
+			desc: "Check passes",
+			script: `grep -q '"clientId":"0123456789-GoodClientID' || exit 1
+echo '{"id": "oauth-client", "name": "Internal OAuth client", "status": "PASS", "output": ["The client is allowed"]}'`,
+			wantID:     "plugin:oauth-client",
+			wantStatus: report.Pass,
+			wantOutput: "The client is allowed",
+		},
+		{
+			desc:       "Check fails",
+			script:     `echo '{"id": "proxy", "status": "FAIL", "code": "NOT_ALLOWED", "message": "Not allowed"}'`,
+			wantID:     "plugin:proxy",
+			wantStatus: report.Fail,
+			wantMsg:    "Not allowed",
+		},
+		{
+			desc:       "Plugin exits with an error",
+			script:     "echo 'proxy unreachable' >&2; exit 3",
+			wantID:     "plugin:plugin2",
+			wantStatus: report.Fail,
+			wantMsg:    "exit status 3: proxy unreachable",
+		},
+		{
+			desc:       "Invalid status",
+			script:     `echo '{"id": "proxy", "status": "OK"}'`,
+			wantID:     "plugin:plugin3",
+			wantStatus: report.Fail,
+			wantMsg:    `invalid status "OK"`,
+		},
+	}
+
+	req := newPluginRequest("python", Options{OAuthType: diag.InstalledApp}, diag.ConfigFile{
+		ConfigKeys: diag.ConfigKeys{
+			ClientID:     "0123456789-GoodClientID.apps.googleusercontent.com",
+			ClientSecret: "GoodClientSecret",
+		},
+	}, "")
+	for i, tt := range tests {
+		path := filepath.Join(dir, "plugin"+string(rune('0'+i)))
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+tt.script+"\n"), 0700); err != nil {
+			t.Fatalf("Error writing plugin: %s", err)
+		}
+
+		reporter := &fakeReporter{}
+		chk, err := pluginTask(path, req).run(context.Background(), reporter)
+		if err != nil {
+			t.Fatalf("[%s] pluginTask() error: %s", tt.desc, err)
+		}
+		if chk.ID != tt.wantID || chk.Status != tt.wantStatus || !strings.Contains(chk.Message, tt.wantMsg) {
+			t.Errorf("[%s] pluginTask() = %+v, want ID %s, status %s, message %s", tt.desc, chk, tt.wantID,
+				tt.wantStatus, tt.wantMsg)
+		}
+		if got := strings.Join(reporter.msgs, "\n"); !strings.Contains(got, tt.wantOutput) {
+			t.Errorf("[%s] pluginTask() printed %s, want: %s", tt.desc, got, tt.wantOutput)
+		}
+	}
+}
+
+func TestPluginRequestHasNoSecrets(t *testing.T) {
+	req := newPluginRequest("python", Options{OAuthType: diag.InstalledApp}, diag.ConfigFile{
+		ConfigKeys: diag.ConfigKeys{
+			ClientSecret: "GoodClientSecret",
+			DevToken:     "GoodDevToken",
+			RefreshToken: "1/PG1Ap6P-Good_Refresh_Token",
+		},
+	}, "")
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %s", err)
+	}
+	for _, secret := range []string{"GoodClientSecret", "GoodDevToken", "Good_Refresh_Token"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("newPluginRequest() contains %s: %s", secret, b)
+		}
+	}
+	if req.Endpoint != diag.DefaultEndpoint {
+		t.Errorf("newPluginRequest() endpoint = %s, want: %s", req.Endpoint, diag.DefaultEndpoint)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// progress numbers the steps of a diagnosis and times them, so users can
+// tell which step hangs when a network call stalls.
+type progress struct {
+	reporter report.Reporter
+	// now returns the current time. It is replaced in tests.
+	now   func() time.Time
+	steps []step
+	start time.Time
+}
+
+// step is a finished or running step of a diagnosis.
+type step struct {
+	name     string
+	duration time.Duration
+}
+
+func newProgress(reporter report.Reporter) *progress {
+	return &progress{reporter: reporter, now: time.Now}
+}
+
+// begin finishes the running step, if any, and prints the header of the
+// next one.
+func (p *progress) begin(name string) {
+	p.finish()
+	p.steps = append(p.steps, step{name: name})
+	p.start = p.now()
+	p.reporter.Print(i18n.Sprintf("[Step %d] %s...", len(p.steps), name))
+}
+
+// finish prints the elapsed time of the running step.
+func (p *progress) finish() {
+	if p.start.IsZero() {
+		return
+	}
+	s := &p.steps[len(p.steps)-1]
+	s.duration = p.now().Sub(p.start)
+	p.start = time.Time{}
+	p.reporter.Print(i18n.Sprintf("[Step %d] finished in %s\n", len(p.steps), s.duration.Round(time.Millisecond)))
+}
+
+// summary finishes the running step and returns the time taken by each
+// step.
+func (p *progress) summary() string {
+	p.finish()
+	var total time.Duration
+	lines := []string{i18n.T("Time taken by each step:")}
+	for i, s := range p.steps {
+		lines = append(lines, i18n.Sprintf("\t%d. %s: %s", i+1, s.name, s.duration.Round(time.Millisecond)))
+		total += s.duration
+	}
+	lines = append(lines, i18n.Sprintf("\tTotal: %s", total.Round(time.Millisecond)))
+	return strings.Join(lines, "\n")
+}
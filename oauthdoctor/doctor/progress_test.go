@@ -0,0 +1,41 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	reporter := &fakeReporter{}
+	p := newProgress(reporter)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	p.begin("Parse")
+	now = now.Add(1500 * time.Millisecond)
+	p.begin("Call the API")
+	now = now.Add(2 * time.Second)
+
+	want := "Time taken by each step:\n\t1. Parse: 1.5s\n\t2. Call the API: 2s\n\tTotal: 3.5s"
+	if got := p.summary(); got != want {
+		t.Errorf("summary() = %q, want: %q", got, want)
+	}
+	wantMsgs := "[Step 1] Parse...,[Step 1] finished in 1.5s\n,[Step 2] Call the API...,[Step 2] finished in 2s\n"
+	if got := strings.Join(reporter.msgs, ","); got != wantMsgs {
+		t.Errorf("progress printed %q, want: %q", got, wantMsgs)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// sampleQuery is the query of the sample code, which any account can run.
+const sampleQuery = "SELECT customer.id, customer.descriptive_name FROM customer LIMIT 1"
+
+// samples are the templates of the sample code of each language. They use
+// the Google Ads API version that the doctor calls. An empty ConfigPath means
+// the default configuration file of the client library.
+var samples = map[string]string{
+	"java": `import com.google.ads.googleads.lib.GoogleAdsClient;
+import com.google.ads.googleads.v8.services.GoogleAdsRow;
+import com.google.ads.googleads.v8.services.GoogleAdsServiceClient;
+import java.io.File;
+
+public class FirstCall {
+  public static void main(String[] args) throws Exception {
+    GoogleAdsClient client =
+        GoogleAdsClient.newBuilder().fromPropertiesFile({{if .ConfigPath}}new File({{quote .ConfigPath}}){{end}}).build();
+    try (GoogleAdsServiceClient service = client.getVersion8().createGoogleAdsServiceClient()) {
+      String query = {{quote .Query}};
+      for (GoogleAdsRow row : service.search({{quote .CustomerID}}, query).iterateAll()) {
+        System.out.printf("Customer %d: %s%n", row.getCustomer().getId(), row.getCustomer().getDescriptiveName());
+      }
+    }
+  }
+}
+`,
+	"dotnet": `using Google.Ads.GoogleAds.Lib;
+using Google.Ads.GoogleAds.V8.Services;
+using System;
+
+public class FirstCall
+{
+    public static void Main()
+    {
+        // The settings are read from the GoogleAdsApi section of the App.config
+        // file of the application{{if .ConfigPath}}: copy it from {{.ConfigPath}}{{end}}.
+        GoogleAdsClient client = new GoogleAdsClient();
+        GoogleAdsServiceClient service = client.GetService(Services.V8.GoogleAdsService);
+        string query = {{quote .Query}};
+        foreach (GoogleAdsRow row in service.Search({{quote .CustomerID}}, query))
+        {
+            Console.WriteLine($"Customer {row.Customer.Id}: {row.Customer.DescriptiveName}");
+        }
+    }
+}
+`,
+	"php": `<?php
+require __DIR__ . '/vendor/autoload.php';
+
+use Google\Ads\GoogleAds\Lib\OAuth2TokenBuilder;
+use Google\Ads\GoogleAds\Lib\V8\GoogleAdsClientBuilder;
+
+$oAuth2Credential = (new OAuth2TokenBuilder())->fromFile({{if .ConfigPath}}{{quote .ConfigPath}}{{end}})->build();
+$client = (new GoogleAdsClientBuilder())
+    ->fromFile({{if .ConfigPath}}{{quote .ConfigPath}}{{end}})
+    ->withOAuth2Credential($oAuth2Credential)
+    ->build();
+$query = {{quote .Query}};
+$response = $client->getGoogleAdsServiceClient()->search({{quote .CustomerID}}, $query);
+foreach ($response->iterateAllElements() as $row) {
+    printf("Customer %d: %s%s", $row->getCustomer()->getId(), $row->getCustomer()->getDescriptiveName(), PHP_EOL);
+}
+`,
+	"python": `from google.ads.googleads.client import GoogleAdsClient
+
+client = GoogleAdsClient.load_from_storage({{if .ConfigPath}}{{quote .ConfigPath}}, {{end}}version="v8")
+ga_service = client.get_service("GoogleAdsService")
+query = {{quote .Query}}
+for row in ga_service.search(customer_id={{quote .CustomerID}}, query=query):
+    print(f"Customer {row.customer.id}: {row.customer.descriptive_name}")
+`,
+	"ruby": `require 'google/ads/google_ads'
+
+client = Google::Ads::GoogleAds::GoogleAdsClient.new{{if .ConfigPath}}({{quote .ConfigPath}}){{end}}
+query = {{quote .Query}}
+client.service.google_ads.search(customer_id: {{quote .CustomerID}}, query: query).each do |row|
+  puts "Customer #{row.customer.id}: #{row.customer.descriptive_name}"
+end
+`,
+	"nodejs": `require('dotenv').config({{if .ConfigPath}}{ path: {{quote .ConfigPath}} }{{end}});
+const { GoogleAdsApi } = require('google-ads-api');
+
+const client = new GoogleAdsApi({
+  client_id: process.env.GOOGLE_ADS_CLIENT_ID,
+  client_secret: process.env.GOOGLE_ADS_CLIENT_SECRET,
+  developer_token: process.env.GOOGLE_ADS_DEVELOPER_TOKEN,
+});
+const customer = client.Customer({
+  customer_id: {{quote .CustomerID}},
+  login_customer_id: process.env.GOOGLE_ADS_LOGIN_CUSTOMER_ID,
+  refresh_token: process.env.GOOGLE_ADS_REFRESH_TOKEN,
+});
+customer.query({{quote .Query}}).then((rows) => {
+  rows.forEach((row) => console.log('Customer ' + row.customer.id + ': ' + row.customer.descriptive_name));
+});
+`,
+}
+
+// Sample returns minimal code that makes a first Google Ads API call with the
+// client library of language, reading the configuration file at configPath
+// (or the default file when it is empty) and querying the account
+// customerID. There is no sample for diag.RESTLanguage, whose request is
+// printed as curl commands.
+func Sample(language, configPath, customerID string) (string, error) {
+	language = strings.ToLower(language)
+	text, ok := samples[language]
+	if !ok {
+		if language == diag.RESTLanguage {
+			return "", i18n.Errorf("There is no sample code for %s: use the curl commands above", language)
+		}
+		return "", i18n.Errorf("There is no sample code for %s", language)
+	}
+	tmpl, err := template.New(language).Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, struct {
+		ConfigPath string
+		CustomerID string
+		Query      string
+	}{configPath, strings.ReplaceAll(customerID, "-", ""), sampleQuery})
+	return b.String(), err
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	for _, language := range []string{"java", "dotnet", "php", "python", "ruby", "nodejs"} {
+		got, err := Sample(language, "/home/me/config file", "123-456-7890")
+		if err != nil {
+			t.Errorf("Sample(%s) error: %s", language, err)
+			continue
+		}
+		for _, want := range []string{"/home/me/config file", "1234567890", sampleQuery} {
+			if !strings.Contains(got, want) {
+				t.Errorf("Sample(%s) does not contain %q:\n%s", language, want, got)
+			}
+		}
+	}
+
+	got, err := Sample("python", "", "1234567890")
+	if err != nil || !strings.Contains(got, `load_from_storage(version="v8")`) {
+		t.Errorf("Sample(python) with the default config file = %s, %v", got, err)
+	}
+
+	if _, err := Sample("rest", "", "1234567890"); err == nil {
+		t.Errorf("Sample(rest) returned no error")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+// es contains the Spanish translations.
+var es = map[string]string{
+	"%s is empty.\n":                                                     "%s está vacío.\n",
+	"%s needs to be updated. Value: %s\n":                                "Es necesario actualizar %s. Valor: %s\n",
+	"******************* (hidden)":                                       "******************* (oculto)",
+	"<empty>":                                                            "<vacío>",
+	"A HTTP Status (%s) is returned while calling %s":                    "Se devolvió un estado HTTP (%s) al llamar a %s",
+	"Attempting to regenerate refresh token...":                          "Intentando volver a generar el token de actualización...",
+	"Auth code received":                                                 "Código de autorización recibido",
+	"Backing up config file %s to %s...":                                 "Creando una copia de seguridad del archivo de configuración %s en %s...",
+	"Cannot find config file (%s): %s\n":                                 "No se encuentra el archivo de configuración (%s): %s\n",
+	"Cannot parse %s: %s":                                                "No se puede analizar %s: %s",
+	"Cannot parse key-value pair from this line: %s":                     "No se puede analizar el par clave-valor de esta línea: %s",
+	"Client library language: %s\n":                                      "Lenguaje de la biblioteca cliente: %s\n",
+	"ClientID does not end with apps.googleusercontent.com. Value: %s\n": "ClientID no termina en apps.googleusercontent.com. Valor: %s\n",
+	"Config file validation failed: %s\n":                                "Error en la validación del archivo de configuración: %s\n",
+	"Config keys and values:":                                            "Claves y valores de configuración:",
+	"Connect to endpoint error: %s":                                      "Error al conectar con el extremo: %s",
+	"Connected to %s\n":                                                  "Conectado a %s\n",
+	"Copy the code here to continue:":                                    "Copie aquí el código para continuar:",
+	"Creating a new config file %s...":                                   "Creando un nuevo archivo de configuración %s...",
+	"Dev token is invalid. Value: %s\n":                                  "El token de desarrollador no es válido. Valor: %s\n",
+	"ERROR: Authentication succeeded, but the Google Ads account %s is not active. This is usually caused by a policy or billing suspension, not by your credentials.\nPlease sign in to the Google Ads UI (https://ads.google.com) and check the account's Billing and Policy manager pages.": "ERROR: La autenticación se realizó correctamente, pero la cuenta de Google Ads %s no está activa. Normalmente esto se debe a una suspensión por políticas o por facturación, no a sus credenciales.\nInicie sesión en la interfaz de Google Ads (https://ads.google.com) y revise las páginas de Facturación y del Administrador de políticas de la cuenta.",
+	"ERROR: Cannot rename config file from (%s) to (%s): %s":                        "ERROR: No se puede cambiar el nombre del archivo de configuración de (%s) a (%s): %s",
+	"ERROR: Cannot write to temp config file (%s): %s":                              "ERROR: No se puede escribir en el archivo de configuración temporal (%s): %s",
+	"ERROR: OAuth test failed.":                                                     "ERROR: La prueba de OAuth falló.",
+	"ERROR: PrintIPV4: %v\n":                                                        "ERROR: PrintIPV4: %v\n",
+	"ERROR: Problem creating temp file: %s":                                         "ERROR: Problema al crear el archivo temporal: %s",
+	"ERROR: Problem opening config file: %s":                                        "ERROR: Problema al abrir el archivo de configuración: %s",
+	"ERROR: The login email may not have access to the given account.":              "ERROR: Es posible que el correo electrónico de acceso no tenga acceso a la cuenta indicada.",
+	"ERROR: Your client ID and/or client secret may be invalid.":                    "ERROR: Es posible que su ID de cliente o su secreto de cliente no sean válidos.",
+	"ERROR: Your credentials are invalid but we cannot determine the exact error. ": "ERROR: Sus credenciales no son válidas, pero no podemos determinar el error exacto. ",
+	"ERROR: Your credentials are not permitted to access to a manager account.\nPlease create your credentials with a Google Ads account with manager access.": "ERROR: Sus credenciales no tienen permiso para acceder a una cuenta de administrador.\nCree sus credenciales con una cuenta de Google Ads que tenga acceso de administrador.",
+	"ERROR: Your customer ID is invalid.":                              "ERROR: Su ID de cliente no es válido.",
+	"ERROR: Your developer token is missing in the configuration file": "ERROR: Falta el token de desarrollador en el archivo de configuración",
+	"ERROR: Your refresh token may be invalid.":                        "ERROR: Es posible que su token de actualización no sea válido.",
+	"Enter Code >> ": "Introduzca el código >> ",
+	"Enter Y for Yes [Anything else is No] >> ":                            "Introduzca Y para Sí [cualquier otra cosa es No] >> ",
+	"Error finding user's home directory: %s":                              "Error al buscar el directorio principal del usuario: %s",
+	"Error printing HTTP request: %s":                                      "Error al imprimir la solicitud HTTP: %s",
+	"Follow this guide to setup your OAuth2 client ID and client secret: ": "Siga esta guía para configurar su ID de cliente y su secreto de cliente de OAuth2: ",
+	"Google Ads API client library config file: %s\n":                      "Archivo de configuración de la biblioteca cliente de la API de Google Ads: %s\n",
+	"Host: %s\nCPUs: %d\nOS: %s\nArch: %s\nPageSize: %d bytes\n":           "Host: %s\nCPU: %d\nSO: %s\nArquitectura: %s\nTamaño de página: %d bytes\n",
+	"JSON response error: ":                                                "Error en la respuesta JSON: ",
+	"LoginCustomerID cannot have dashes. Value: %s\n":                      "LoginCustomerID no puede contener guiones. Valor: %s\n",
+	"Making a HTTP Request to Google Ads API:\n%v\n":                       "Realizando una solicitud HTTP a la API de Google Ads:\n%v\n",
+	"New Client ID >> ":                                                    "Nuevo ID de cliente >> ",
+	"New Client Secret >> ":                                                "Nuevo secreto de cliente >> ",
+	"New Developer Token >> ":                                              "Nuevo token de desarrollador >> ",
+	"OAuth code received by the HTTP server handler: ":                     "Código de OAuth recibido por el controlador del servidor HTTP: ",
+	"OAuth type not supported: %s":                                         "Tipo de OAuth no admitido: %s",
+	"Please enter a Google Ads account ID:":                                "Introduzca un ID de cuenta de Google Ads:",
+	"Please enter a new Developer Token here and it will replace the one in your client library configuration file": "Introduzca aquí un nuevo token de desarrollador; reemplazará al del archivo de configuración de su biblioteca cliente",
+	"Please follow this guide to retrieve your developer token: ":                                                   "Siga esta guía para obtener su token de desarrollador: ",
+	"Please provide --language": "Indique --language",
+	"Please verify the path of JSON key file and impersonate email (or delegated email).": "Verifique la ruta del archivo de clave JSON y el correo electrónico suplantado (o delegado).",
+	"Please verify your developer token, client ID and client secret.":                    "Verifique su token de desarrollador, su ID de cliente y su secreto de cliente.",
+	"Please verify your developer token, client ID, client secret and refresh token.":     "Verifique su token de desarrollador, su ID de cliente, su secreto de cliente y su token de actualización.",
+	"Press <Enter> to continue after you enable Google Ads API":                           "Pulse <Intro> para continuar después de habilitar la API de Google Ads",
+	"PrivateKeyPath in the config file is empty":                                          "PrivateKeyPath está vacío en el archivo de configuración",
+	"Refresh token is NOT replaced":                                                       "El token de actualización NO se ha reemplazado",
+	"Running HTTP server in the background at port 8080...":                               "Ejecutando el servidor HTTP en segundo plano en el puerto 8080...",
+	"SUCCESS: OAuth test passed with given config file settings.":                         "SUCCESS: La prueba de OAuth se superó con la configuración del archivo indicado.",
+	"Service account JSON keys and values:":                                               "Claves y valores del JSON de la cuenta de servicio:",
+	"Visit the URL for the auth dialog:\n%s\n":                                            "Visite la URL del cuadro de diálogo de autorización:\n%s\n",
+	"You are running Windows, so to properly copy and paste the URL into the command prompt:\n1) Ensure that 'Quick Edit' mode is ON for your Command Prompt\n2) Hold down the shift key\n3) Highlight the URL\n4) Right click on the highlighted area\n": "Está usando Windows; para copiar y pegar correctamente la URL en el símbolo del sistema:\n1) Asegúrese de que el modo 'Edición rápida' esté ACTIVADO en el símbolo del sistema\n2) Mantenga pulsada la tecla Mayús\n3) Seleccione la URL\n4) Haga clic con el botón derecho en el área seleccionada\n",
+	"You specified %s. Supported languages are %s\n": "Ha especificado %s. Los lenguajes admitidos son %s\n",
+	"You will need to enter the URL http://localhost:8080 as a valid redirect URI in your Google APIs Console's project (https://console.developers.google.com/apis/library). Please follow this guide (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) for further instructions.": "Debe introducir la URL http://localhost:8080 como URI de redirección válido en el proyecto de la consola de API de Google (https://console.developers.google.com/apis/library). Siga esta guía (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) para obtener más instrucciones.",
+	"could not parse version (%s): %s":                         "no se pudo analizar la versión (%s): %s",
+	"minimum required Go version is %d.%d: you are running %s": "la versión mínima requerida de Go es %d.%d: está ejecutando %s",
+	"the given version is too short: %s":                       "la versión indicada es demasiado corta: %s",
+}
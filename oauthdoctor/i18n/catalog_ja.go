@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+// ja contains the Japanese translations.
+var ja = map[string]string{
+	"%s is empty.\n":                                                     "%s が空です。\n",
+	"%s needs to be updated. Value: %s\n":                                "%s を更新する必要があります。値: %s\n",
+	"******************* (hidden)":                                       "******************* (非表示)",
+	"<empty>":                                                            "<空>",
+	"A HTTP Status (%s) is returned while calling %s":                    "%[2]s の呼び出し中に HTTP ステータス (%[1]s) が返されました",
+	"Attempting to regenerate refresh token...":                          "更新トークンを再生成しています...",
+	"Auth code received":                                                 "認証コードを受信しました",
+	"Backing up config file %s to %s...":                                 "構成ファイル %s を %s にバックアップしています...",
+	"Cannot find config file (%s): %s\n":                                 "構成ファイル (%s) が見つかりません: %s\n",
+	"Cannot parse %s: %s":                                                "%s を解析できません: %s",
+	"Cannot parse key-value pair from this line: %s":                     "この行からキーと値のペアを解析できません: %s",
+	"Client library language: %s\n":                                      "クライアント ライブラリの言語: %s\n",
+	"ClientID does not end with apps.googleusercontent.com. Value: %s\n": "ClientID の末尾が apps.googleusercontent.com ではありません。値: %s\n",
+	"Config file validation failed: %s\n":                                "構成ファイルの検証に失敗しました: %s\n",
+	"Config keys and values:":                                            "構成のキーと値:",
+	"Connect to endpoint error: %s":                                      "エンドポイントへの接続エラー: %s",
+	"Connected to %s\n":                                                  "%s に接続しました\n",
+	"Copy the code here to continue:":                                    "続行するには、ここにコードを貼り付けてください:",
+	"Creating a new config file %s...":                                   "新しい構成ファイル %s を作成しています...",
+	"Dev token is invalid. Value: %s\n":                                  "開発者トークンが無効です。値: %s\n",
+	"ERROR: Authentication succeeded, but the Google Ads account %s is not active. This is usually caused by a policy or billing suspension, not by your credentials.\nPlease sign in to the Google Ads UI (https://ads.google.com) and check the account's Billing and Policy manager pages.": "ERROR: 認証には成功しましたが、Google 広告アカウント %s は有効ではありません。通常、これは認証情報ではなく、ポリシーまたはお支払いによる停止が原因です。\nGoogle 広告の管理画面 (https://ads.google.com) にログインし、アカウントの「お支払い」ページと「ポリシー マネージャ」ページを確認してください。",
+	"ERROR: Cannot rename config file from (%s) to (%s): %s":                        "ERROR: 構成ファイルの名前を (%s) から (%s) に変更できません: %s",
+	"ERROR: Cannot write to temp config file (%s): %s":                              "ERROR: 一時構成ファイル (%s) に書き込めません: %s",
+	"ERROR: OAuth test failed.":                                                     "ERROR: OAuth テストに失敗しました。",
+	"ERROR: PrintIPV4: %v\n":                                                        "ERROR: PrintIPV4: %v\n",
+	"ERROR: Problem creating temp file: %s":                                         "ERROR: 一時ファイルの作成中に問題が発生しました: %s",
+	"ERROR: Problem opening config file: %s":                                        "ERROR: 構成ファイルを開く際に問題が発生しました: %s",
+	"ERROR: The login email may not have access to the given account.":              "ERROR: ログインに使用したメールアドレスに、指定されたアカウントへのアクセス権がない可能性があります。",
+	"ERROR: Your client ID and/or client secret may be invalid.":                    "ERROR: クライアント ID またはクライアント シークレットが無効な可能性があります。",
+	"ERROR: Your credentials are invalid but we cannot determine the exact error. ": "ERROR: 認証情報が無効ですが、正確なエラーを特定できません。",
+	"ERROR: Your credentials are not permitted to access to a manager account.\nPlease create your credentials with a Google Ads account with manager access.": "ERROR: 認証情報にはクライアント センター（MCC）アカウントへのアクセス権がありません。\nクライアント センターへのアクセス権を持つ Google 広告アカウントで認証情報を作成してください。",
+	"ERROR: Your customer ID is invalid.":                              "ERROR: お客様 ID が無効です。",
+	"ERROR: Your developer token is missing in the configuration file": "ERROR: 構成ファイルに開発者トークンがありません",
+	"ERROR: Your refresh token may be invalid.":                        "ERROR: 更新トークンが無効な可能性があります。",
+	"Enter Code >> ": "コードを入力 >> ",
+	"Enter Y for Yes [Anything else is No] >> ":                            "「はい」の場合は Y を入力してください [それ以外は「いいえ」] >> ",
+	"Error finding user's home directory: %s":                              "ユーザーのホーム ディレクトリが見つかりません: %s",
+	"Error printing HTTP request: %s":                                      "HTTP リクエストの出力中にエラーが発生しました: %s",
+	"Follow this guide to setup your OAuth2 client ID and client secret: ": "こちらのガイドに沿って OAuth2 のクライアント ID とクライアント シークレットを設定してください: ",
+	"Google Ads API client library config file: %s\n":                      "Google Ads API クライアント ライブラリの構成ファイル: %s\n",
+	"Host: %s\nCPUs: %d\nOS: %s\nArch: %s\nPageSize: %d bytes\n":           "ホスト: %s\nCPU: %d\nOS: %s\nアーキテクチャ: %s\nページサイズ: %d バイト\n",
+	"JSON response error: ":                                                "JSON レスポンスのエラー: ",
+	"LoginCustomerID cannot have dashes. Value: %s\n":                      "LoginCustomerID にハイフンを含めることはできません。値: %s\n",
+	"Making a HTTP Request to Google Ads API:\n%v\n":                       "Google Ads API に HTTP リクエストを送信しています:\n%v\n",
+	"New Client ID >> ":                                                    "新しいクライアント ID >> ",
+	"New Client Secret >> ":                                                "新しいクライアント シークレット >> ",
+	"New Developer Token >> ":                                              "新しい開発者トークン >> ",
+	"OAuth code received by the HTTP server handler: ":                     "HTTP サーバーのハンドラが OAuth コードを受信しました: ",
+	"OAuth type not supported: %s":                                         "サポートされていない OAuth タイプです: %s",
+	"Please enter a Google Ads account ID:":                                "Google 広告のアカウント ID を入力してください:",
+	"Please enter a new Developer Token here and it will replace the one in your client library configuration file": "新しい開発者トークンをここに入力してください。クライアント ライブラリの構成ファイル内のトークンが置き換えられます",
+	"Please follow this guide to retrieve your developer token: ":                                                   "こちらのガイドに沿って開発者トークンを取得してください: ",
+	"Please provide --language": "--language を指定してください",
+	"Please verify the path of JSON key file and impersonate email (or delegated email).": "JSON キーファイルのパスと、なりすますメールアドレス（または委任先のメールアドレス）を確認してください。",
+	"Please verify your developer token, client ID and client secret.":                    "開発者トークン、クライアント ID、クライアント シークレットを確認してください。",
+	"Please verify your developer token, client ID, client secret and refresh token.":     "開発者トークン、クライアント ID、クライアント シークレット、更新トークンを確認してください。",
+	"Press <Enter> to continue after you enable Google Ads API":                           "Google Ads API を有効にしたら <Enter> キーを押して続行してください",
+	"PrivateKeyPath in the config file is empty":                                          "構成ファイルの PrivateKeyPath が空です",
+	"Refresh token is NOT replaced":                                                       "更新トークンは置き換えられていません",
+	"Running HTTP server in the background at port 8080...":                               "ポート 8080 でバックグラウンドの HTTP サーバーを実行しています...",
+	"SUCCESS: OAuth test passed with given config file settings.":                         "SUCCESS: 指定された構成ファイルの設定で OAuth テストに合格しました。",
+	"Service account JSON keys and values:":                                               "サービス アカウント JSON のキーと値:",
+	"Visit the URL for the auth dialog:\n%s\n":                                            "認証ダイアログの URL にアクセスしてください:\n%s\n",
+	"You are running Windows, so to properly copy and paste the URL into the command prompt:\n1) Ensure that 'Quick Edit' mode is ON for your Command Prompt\n2) Hold down the shift key\n3) Highlight the URL\n4) Right click on the highlighted area\n": "Windows を使用しているため、コマンド プロンプトで URL を正しくコピーして貼り付けるには:\n1) コマンド プロンプトの「簡易編集モード」がオンになっていることを確認します\n2) Shift キーを押したままにします\n3) URL を選択します\n4) 選択した範囲を右クリックします\n",
+	"You specified %s. Supported languages are %s\n": "%s が指定されました。サポートされている言語は %s です\n",
+	"You will need to enter the URL http://localhost:8080 as a valid redirect URI in your Google APIs Console's project (https://console.developers.google.com/apis/library). Please follow this guide (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) for further instructions.": "Google API Console のプロジェクト (https://console.developers.google.com/apis/library) で、URL http://localhost:8080 を有効なリダイレクト URI として登録する必要があります。詳しくはこちらのガイド (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) をご覧ください。",
+	"could not parse version (%s): %s":                         "バージョン (%s) を解析できませんでした: %s",
+	"minimum required Go version is %d.%d: you are running %s": "必要な Go の最小バージョンは %d.%d です: 実行中のバージョンは %s です",
+	"the given version is too short: %s":                       "指定されたバージョンが短すぎます: %s",
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+// zh contains the Simplified Chinese translations.
+var zh = map[string]string{
+	"%s is empty.\n":                                                     "%s 为空。\n",
+	"%s needs to be updated. Value: %s\n":                                "需要更新 %s。值：%s\n",
+	"******************* (hidden)":                                       "******************* (已隐藏)",
+	"<empty>":                                                            "<空>",
+	"A HTTP Status (%s) is returned while calling %s":                    "调用 %[2]s 时返回了 HTTP 状态 (%[1]s)",
+	"Attempting to regenerate refresh token...":                          "正在尝试重新生成刷新令牌...",
+	"Auth code received":                                                 "已收到授权代码",
+	"Backing up config file %s to %s...":                                 "正在将配置文件 %s 备份到 %s...",
+	"Cannot find config file (%s): %s\n":                                 "找不到配置文件 (%s)：%s\n",
+	"Cannot parse %s: %s":                                                "无法解析 %s：%s",
+	"Cannot parse key-value pair from this line: %s":                     "无法从此行解析键值对：%s",
+	"Client library language: %s\n":                                      "客户端库语言：%s\n",
+	"ClientID does not end with apps.googleusercontent.com. Value: %s\n": "ClientID 不是以 apps.googleusercontent.com 结尾。值：%s\n",
+	"Config file validation failed: %s\n":                                "配置文件验证失败：%s\n",
+	"Config keys and values:":                                            "配置键和值：",
+	"Connect to endpoint error: %s":                                      "连接到端点时出错：%s",
+	"Connected to %s\n":                                                  "已连接到 %s\n",
+	"Copy the code here to continue:":                                    "请将代码复制到此处以继续：",
+	"Creating a new config file %s...":                                   "正在创建新的配置文件 %s...",
+	"Dev token is invalid. Value: %s\n":                                  "开发者令牌无效。值：%s\n",
+	"ERROR: Authentication succeeded, but the Google Ads account %s is not active. This is usually caused by a policy or billing suspension, not by your credentials.\nPlease sign in to the Google Ads UI (https://ads.google.com) and check the account's Billing and Policy manager pages.": "ERROR: 身份验证成功，但 Google Ads 账号 %s 未处于活跃状态。这通常是由政策或结算暂停造成的，而不是您的凭据问题。\n请登录 Google Ads 界面 (https://ads.google.com)，查看该账号的“结算”和“政策管理器”页面。",
+	"ERROR: Cannot rename config file from (%s) to (%s): %s":                        "ERROR: 无法将配置文件从 (%s) 重命名为 (%s)：%s",
+	"ERROR: Cannot write to temp config file (%s): %s":                              "ERROR: 无法写入临时配置文件 (%s)：%s",
+	"ERROR: OAuth test failed.":                                                     "ERROR: OAuth 测试失败。",
+	"ERROR: PrintIPV4: %v\n":                                                        "ERROR: PrintIPV4: %v\n",
+	"ERROR: Problem creating temp file: %s":                                         "ERROR: 创建临时文件时出现问题：%s",
+	"ERROR: Problem opening config file: %s":                                        "ERROR: 打开配置文件时出现问题：%s",
+	"ERROR: The login email may not have access to the given account.":              "ERROR: 登录电子邮件可能无权访问指定的账号。",
+	"ERROR: Your client ID and/or client secret may be invalid.":                    "ERROR: 您的客户端 ID 和/或客户端密钥可能无效。",
+	"ERROR: Your credentials are invalid but we cannot determine the exact error. ": "ERROR: 您的凭据无效，但我们无法确定具体错误。",
+	"ERROR: Your credentials are not permitted to access to a manager account.\nPlease create your credentials with a Google Ads account with manager access.": "ERROR: 您的凭据无权访问经理账号。\n请使用具有经理访问权限的 Google Ads 账号创建凭据。",
+	"ERROR: Your customer ID is invalid.":                              "ERROR: 您的客户 ID 无效。",
+	"ERROR: Your developer token is missing in the configuration file": "ERROR: 配置文件中缺少开发者令牌",
+	"ERROR: Your refresh token may be invalid.":                        "ERROR: 您的刷新令牌可能无效。",
+	"Enter Code >> ": "输入代码 >> ",
+	"Enter Y for Yes [Anything else is No] >> ":                            "输入 Y 表示“是”[其他任何输入表示“否”] >> ",
+	"Error finding user's home directory: %s":                              "查找用户主目录时出错：%s",
+	"Error printing HTTP request: %s":                                      "打印 HTTP 请求时出错：%s",
+	"Follow this guide to setup your OAuth2 client ID and client secret: ": "请按照本指南设置您的 OAuth2 客户端 ID 和客户端密钥：",
+	"Google Ads API client library config file: %s\n":                      "Google Ads API 客户端库配置文件：%s\n",
+	"Host: %s\nCPUs: %d\nOS: %s\nArch: %s\nPageSize: %d bytes\n":           "主机：%s\nCPU：%d\n操作系统：%s\n架构：%s\n页面大小：%d 字节\n",
+	"JSON response error: ":                                                "JSON 响应错误：",
+	"LoginCustomerID cannot have dashes. Value: %s\n":                      "LoginCustomerID 不能包含短划线。值：%s\n",
+	"Making a HTTP Request to Google Ads API:\n%v\n":                       "正在向 Google Ads API 发送 HTTP 请求：\n%v\n",
+	"New Client ID >> ":                                                    "新的客户端 ID >> ",
+	"New Client Secret >> ":                                                "新的客户端密钥 >> ",
+	"New Developer Token >> ":                                              "新的开发者令牌 >> ",
+	"OAuth code received by the HTTP server handler: ":                     "HTTP 服务器处理程序收到的 OAuth 代码：",
+	"OAuth type not supported: %s":                                         "不支持的 OAuth 类型：%s",
+	"Please enter a Google Ads account ID:":                                "请输入 Google Ads 账号 ID：",
+	"Please enter a new Developer Token here and it will replace the one in your client library configuration file": "请在此处输入新的开发者令牌，它将替换您的客户端库配置文件中的令牌",
+	"Please follow this guide to retrieve your developer token: ":                                                   "请按照本指南获取您的开发者令牌：",
+	"Please provide --language": "请提供 --language",
+	"Please verify the path of JSON key file and impersonate email (or delegated email).": "请验证 JSON 密钥文件的路径和模拟电子邮件（或委派电子邮件）。",
+	"Please verify your developer token, client ID and client secret.":                    "请验证您的开发者令牌、客户端 ID 和客户端密钥。",
+	"Please verify your developer token, client ID, client secret and refresh token.":     "请验证您的开发者令牌、客户端 ID、客户端密钥和刷新令牌。",
+	"Press <Enter> to continue after you enable Google Ads API":                           "启用 Google Ads API 后，请按 <Enter> 键继续",
+	"PrivateKeyPath in the config file is empty":                                          "配置文件中的 PrivateKeyPath 为空",
+	"Refresh token is NOT replaced":                                                       "刷新令牌未被替换",
+	"Running HTTP server in the background at port 8080...":                               "正在后台的 8080 端口运行 HTTP 服务器...",
+	"SUCCESS: OAuth test passed with given config file settings.":                         "SUCCESS: 使用给定的配置文件设置通过了 OAuth 测试。",
+	"Service account JSON keys and values:":                                               "服务账号 JSON 键和值：",
+	"Visit the URL for the auth dialog:\n%s\n":                                            "请访问授权对话框的网址：\n%s\n",
+	"You are running Windows, so to properly copy and paste the URL into the command prompt:\n1) Ensure that 'Quick Edit' mode is ON for your Command Prompt\n2) Hold down the shift key\n3) Highlight the URL\n4) Right click on the highlighted area\n": "您正在使用 Windows，要在命令提示符中正确复制和粘贴网址：\n1) 确保命令提示符的“快速编辑”模式已开启\n2) 按住 Shift 键\n3) 选中网址\n4) 右键点击选中的区域\n",
+	"You specified %s. Supported languages are %s\n": "您指定了 %s。支持的语言为 %s\n",
+	"You will need to enter the URL http://localhost:8080 as a valid redirect URI in your Google APIs Console's project (https://console.developers.google.com/apis/library). Please follow this guide (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) for further instructions.": "您需要在 Google API 控制台项目 (https://console.developers.google.com/apis/library) 中将网址 http://localhost:8080 添加为有效的重定向 URI。如需更多说明，请参阅本指南 (https://developers.google.com/google-ads/api/docs/oauth/cloud-project)。",
+	"could not parse version (%s): %s":                         "无法解析版本 (%s)：%s",
+	"minimum required Go version is %d.%d: you are running %s": "所需的最低 Go 版本为 %d.%d：您正在运行 %s",
+	"the given version is too short: %s":                       "给定的版本过短：%s",
+}
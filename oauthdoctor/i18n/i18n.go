@@ -0,0 +1,85 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n translates the user-facing messages of oauthdoctor. Messages
+// are looked up by their English text, so a message without a translation
+// is displayed in English.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// English is the language of the message source strings.
+const English = "en"
+
+// catalogs maps a language code to its translations keyed by English text.
+var catalogs = map[string]map[string]string{
+	"es": es,
+	"ja": ja,
+	"zh": zh,
+}
+
+var current = English
+
+// Languages returns a sorted slice of the supported output languages.
+func Languages() []string {
+	langs := []string{English}
+	for k := range catalogs {
+		langs = append(langs, k)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// SetLanguage changes the language of all subsequent messages. An empty
+// string selects English.
+func SetLanguage(lang string) error {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = English
+	}
+	if _, ok := catalogs[lang]; !ok && lang != English {
+		return fmt.Errorf("output language not supported: %s. Supported languages are %s",
+			lang, strings.Join(Languages(), ","))
+	}
+	current = lang
+	return nil
+}
+
+// Language returns the current output language.
+func Language() string {
+	return current
+}
+
+// T returns the translation of msg in the current language. It returns msg
+// unchanged when there is no translation.
+func T(msg string) string {
+	if t, ok := catalogs[current][msg]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf translates format and then formats it with the given arguments.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
+
+// Errorf translates format and returns an error formatted with the given
+// arguments.
+func Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(T(format), a...)
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSetLanguage(t *testing.T) {
+	defer SetLanguage(English)
+
+	tests := []struct {
+		desc    string
+		lang    string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc: "Empty string selects English",
+			lang: "",
+			want: English,
+		},
+		{
+			desc: "Language code is case insensitive",
+			lang: "JA",
+			want: "ja",
+		},
+		{
+			desc:    "Unsupported language",
+			lang:    "xx",
+			want:    "ja",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		err := SetLanguage(tt.lang)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("[%s] SetLanguage(%s) error: %v, want error: %v", tt.desc, tt.lang, err, tt.wantErr)
+		}
+		if got := Language(); got != tt.want {
+			t.Errorf("[%s] got: %s, want: %s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	defer SetLanguage(English)
+
+	tests := []struct {
+		desc   string
+		lang   string
+		format string
+		want   string
+	}{
+		{
+			desc:   "English is returned unchanged",
+			lang:   English,
+			format: "Connected to %s\n",
+			want:   "Connected to host\n",
+		},
+		{
+			desc:   "Translation is used",
+			lang:   "es",
+			format: "Connected to %s\n",
+			want:   "Conectado a host\n",
+		},
+		{
+			desc:   "Missing translation falls back to English",
+			lang:   "es",
+			format: "No translation for %s",
+			want:   "No translation for host",
+		},
+	}
+
+	for _, tt := range tests {
+		SetLanguage(tt.lang)
+		if got := Sprintf(tt.format, "host"); got != tt.want {
+			t.Errorf("[%s] got: %q, want: %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+var verbRegex = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// TestCatalogs verifies that every catalog translates the same messages and
+// that each translation keeps the number of formatting verbs.
+func TestCatalogs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for other, otherCatalog := range catalogs {
+			for k := range otherCatalog {
+				if _, ok := catalog[k]; !ok {
+					t.Errorf("catalog %s is missing %q which is translated in %s", lang, k, other)
+				}
+			}
+		}
+
+		for k, v := range catalog {
+			if got, want := len(verbRegex.FindAllString(v, -1)), len(verbRegex.FindAllString(k, -1)); got != want {
+				t.Errorf("catalog %s: %q has %d formatting verbs, want %d", lang, v, got, want)
+			}
+		}
+	}
+}
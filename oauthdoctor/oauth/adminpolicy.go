@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// The policies that block the OAuth grant, in the codes of the consent page
+// and of the token endpoint.
+const (
+	// adminPolicyEnforced is the error of a Google Workspace organization
+	// whose admin restricted the access of third-party apps.
+	adminPolicyEnforced = "admin_policy_enforced"
+	// appBlocked stands for the "This app is blocked" page, which Google
+	// shows to the accounts of the Advanced Protection Program for apps that
+	// are not verified. The page has no error code.
+	appBlocked = "app_blocked"
+	// invalidRapt is in the description of the invalid_grant error of a
+	// refresh token that the reauthentication policy (session control) of a
+	// Google Workspace or Cloud Identity organization expired.
+	invalidRapt = "invalid_rapt"
+)
+
+// blockedPagePhrases are texts of the pages that block the OAuth grant,
+// which users paste instead of a code, and the codes they stand for.
+var blockedPagePhrases = []struct {
+	phrase, code string
+}{
+	{"blocked by your administrator", adminPolicyEnforced},
+	{"has not been approved by your administrator", adminPolicyEnforced},
+	{"This app is blocked", appBlocked},
+}
+
+// blockedPageCode returns the code of the page that blocks the OAuth grant
+// in input, or an empty string.
+func blockedPageCode(input string) string {
+	for _, p := range blockedPagePhrases {
+		if strings.Contains(input, p.phrase) {
+			return p.code
+		}
+	}
+	return ""
+}
+
+// adminPolicy returns the policy that blocked the OAuth grant of err, or an
+// empty string.
+func (c *Config) adminPolicy(err error) string {
+	if cErr, ok := err.(*consentError); ok && (cErr.code == adminPolicyEnforced || cErr.code == appBlocked) {
+		return cErr.code
+	}
+	if c.tokenErr != nil {
+		if c.tokenErr.Code == adminPolicyEnforced {
+			return adminPolicyEnforced
+		}
+		if c.tokenErr.Code == "invalid_grant" && strings.Contains(c.tokenErr.Description, invalidRapt) {
+			return invalidRapt
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), adminPolicyEnforced) {
+		return adminPolicyEnforced
+	}
+	return ""
+}
+
+// adminPolicyHelp explains the policy that blocked the OAuth grant. The
+// credentials are valid, so only an admin of the organization, or another
+// Google account, can fix it.
+func adminPolicyHelp(policy string) string {
+	switch policy {
+	case appBlocked:
+		return i18n.T("ERROR: Google blocked the access of your OAuth client (\"This app is blocked\"). The Google " +
+			"account you signed in with is enrolled in the Advanced Protection Program, which only lets verified " +
+			"apps access the data of the account. This is not caused by your credentials." +
+			"\nSubmit the app of your Google Cloud project for verification on the OAuth consent screen page, sign " +
+			"in with a Google account that is not enrolled, or use a service account.")
+	case invalidRapt:
+		return i18n.T("ERROR: Your refresh token expired because the admin of your Google Workspace or Cloud " +
+			"Identity organization requires to sign in again periodically (session control, invalid_rapt). " +
+			"This is not caused by your client ID or client secret, and a new refresh token only works until " +
+			"the next reauthentication." +
+			"\nAsk your admin to exempt your OAuth client from the reauthentication policy by marking it as " +
+			"Trusted in the Admin console (Security > Access and data control > API controls > Manage " +
+			"third-party app access), or use a service account, which the policy does not apply to.")
+	}
+	return i18n.T("ERROR: The admin of the Google Workspace organization of the Google account you signed in " +
+		"with blocked your OAuth client or the Google Ads API scope (admin_policy_enforced). This is not caused " +
+		"by your credentials, and only an admin of the organization can allow the access." +
+		"\nAsk your admin to mark your OAuth client as Trusted in the Admin console (Security > Access and data " +
+		"control > API controls > Manage third-party app access), giving them the client ID from your " +
+		"configuration file, or sign in with a Google account outside of the organization.")
+}
@@ -0,0 +1,60 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+// maxEnableChecks is how many times the user is asked to enable the Google
+// Ads API before the OAuth flow is retried anyway.
+const maxEnableChecks = 3
+
+// apiEnabled tells whether the Google Ads API is enabled in a project.
+var apiEnabled = diag.GoogleAdsAPIEnabled
+
+// waitForAPIEnabled waits until the user enabled the Google Ads API in the
+// project. When the project is known, the Service Usage API confirms it
+// after the user presses Enter, so the OAuth flow is not retried while the
+// API is still disabled. Without Application Default Credentials, the flow
+// is retried as before.
+func (c *Config) waitForAPIEnabled(ctx context.Context, project string) {
+	for i := 0; i < maxEnableChecks; i++ {
+		c.print(i18n.T("Press <Enter> to continue after you enable Google Ads API"))
+		if _, err := c.prompter().ReadLine(""); err != nil || project == "" {
+			return
+		}
+		// The OAuth2 library sends the requests of the Application Default
+		// Credentials with the client of the context.
+		checkCtx, cancel := context.WithTimeout(context.WithValue(ctx, oauth2.HTTPClient, c.httpClient()), 10*time.Second)
+		enabled, err := apiEnabled(checkCtx, project)
+		cancel()
+		switch {
+		case err != nil:
+			c.print(i18n.Sprintf("Cannot check whether Google Ads API is enabled in project %s: %s", project, err))
+			return
+		case enabled:
+			c.print(i18n.Sprintf("Google Ads API is enabled in project %s. It can take a few minutes before "+
+				"the requests succeed.", project))
+			return
+		}
+		c.print(i18n.Sprintf("Google Ads API is still disabled in project %s. Enable it at %s", project,
+			diag.EnableAPIURL(project)))
+	}
+}
@@ -0,0 +1,73 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestWaitForAPIEnabled(t *testing.T) {
+	defer func(orig func(context.Context, string) (bool, error)) { apiEnabled = orig }(apiEnabled)
+
+	tests := []struct {
+		desc      string
+		project   string
+		states    []bool
+		err       error
+		wantCalls int
+		want      string
+	}{
+		{
+			desc:      "Enabled after the second check",
+			project:   "123456789",
+			states:    []bool{false, true},
+			wantCalls: 2,
+			want:      "Google Ads API is enabled in project 123456789",
+		},
+		{
+			desc:      "Still disabled",
+			project:   "123456789",
+			states:    []bool{false, false, false},
+			wantCalls: maxEnableChecks,
+			want:      "still disabled in project 123456789. Enable it at https://console.cloud.google.com/apis/library/googleads.googleapis.com?project=123456789",
+		},
+		{
+			desc:      "No Application Default Credentials",
+			project:   "123456789",
+			err:       fmt.Errorf("no Application Default Credentials"),
+			wantCalls: 1,
+			want:      "Cannot check whether Google Ads API is enabled in project 123456789",
+		},
+		{
+			desc: "Unknown project",
+		},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		apiEnabled = func(ctx context.Context, project string) (bool, error) {
+			calls++
+			if tt.err != nil {
+				return false, tt.err
+			}
+			return tt.states[calls-1], nil
+		}
+		var got strings.Builder
+		log.SetOutput(&got)
+		c := Config{Prompter: prompt.NewTerminal(strings.NewReader(strings.Repeat("\n", maxEnableChecks)), ioutil.Discard)}
+
+		c.waitForAPIEnabled(context.Background(), tt.project)
+
+		if calls != tt.wantCalls {
+			t.Errorf("[%s] waitForAPIEnabled() checked %d times, want: %d", tt.desc, calls, tt.wantCalls)
+		}
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] got: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
+		}
+	}
+}
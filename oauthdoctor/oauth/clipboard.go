@@ -0,0 +1,51 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// readClipboard returns the text of the system clipboard.
+var readClipboard = diag.ReadClipboard
+
+// readInput reads a value at the prompt label. With c.Clipboard, an empty
+// answer reads the value from the clipboard instead, since pasting a long
+// code is error prone in some terminals, e.g. on Windows. The clipboard is
+// read again until it holds a single line other than the consent URL, or a
+// value is entered.
+func (c *Config) readInput(label string) (string, error) {
+	if c.Clipboard {
+		c.print(i18n.T("Copy the value, then press Enter without typing it to read it from the clipboard."))
+	}
+	for {
+		v, err := c.prompter().ReadLine(label)
+		if err != nil || v != "" || !c.Clipboard {
+			return v, err
+		}
+		text, err := readClipboard()
+		switch {
+		case err != nil:
+			c.print(i18n.Sprintf("WARNING: Cannot read the clipboard: %s. Paste the value instead.", err))
+		case text == "" || strings.ContainsAny(text, "\r\n") || text == c.copied:
+			c.print(i18n.T("The clipboard does not hold the value. Copy it and press Enter again, or paste it."))
+		default:
+			c.print(i18n.Sprintf("Read %s from the clipboard.", diag.Redact(text)))
+			return text, nil
+		}
+	}
+}
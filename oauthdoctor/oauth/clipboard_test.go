@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestReadInput(t *testing.T) {
+	clipboard := readClipboard
+	defer func() { readClipboard = clipboard }()
+
+	const consentURL = "https://accounts.google.com/o/oauth2/auth?client_id=id"
+	tests := []struct {
+		desc      string
+		clipboard bool
+		input     string
+		contents  []string
+		want      string
+		wantOut   string
+	}{
+		{
+			desc:    "Typed value",
+			input:   "4/0AbCd\n",
+			want:    "4/0AbCd",
+			wantOut: "",
+		},
+		{
+			desc:      "Typed value with clipboard",
+			clipboard: true,
+			input:     "4/0AbCd\n",
+			contents:  []string{"other"},
+			want:      "4/0AbCd",
+			wantOut:   "press Enter without typing it",
+		},
+		{
+			desc:      "Read from the clipboard",
+			clipboard: true,
+			input:     "\n",
+			contents:  []string{"4/0AbCdEfGhIjKlMn"},
+			want:      "4/0AbCdEfGhIjKlMn",
+			wantOut:   "Read 4/0A...KlMn from the clipboard",
+		},
+		{
+			desc:      "Clipboard still holds the consent URL",
+			clipboard: true,
+			input:     "\n\n",
+			contents:  []string{consentURL, "4/0AbCdEfGhIjKlMn"},
+			want:      "4/0AbCdEfGhIjKlMn",
+			wantOut:   "The clipboard does not hold the value",
+		},
+		{
+			desc:      "Clipboard cannot be read",
+			clipboard: true,
+			input:     "\n4/0AbCd\n",
+			want:      "4/0AbCd",
+			wantOut:   "Cannot read the clipboard: no clipboard command",
+		},
+	}
+
+	for _, tt := range tests {
+		contents := tt.contents
+		readClipboard = func() (string, error) {
+			if len(contents) == 0 {
+				return "", fmt.Errorf("no clipboard command")
+			}
+			text := contents[0]
+			contents = contents[1:]
+			return text, nil
+		}
+		var out strings.Builder
+		log.SetOutput(&out)
+
+		c := Config{
+			Clipboard: tt.clipboard,
+			Prompter:  prompt.NewTerminal(strings.NewReader(tt.input), ioutil.Discard),
+			copied:    consentURL,
+		}
+		got, err := c.readInput("Enter Code >> ")
+		if err != nil {
+			t.Fatalf("[%s] readInput() error: %s", tt.desc, err)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] readInput() got: %s, want: %s", tt.desc, got, tt.want)
+		}
+		if !strings.Contains(out.String(), tt.wantOut) || (tt.wantOut == "" && out.Len() > 0) {
+			t.Errorf("[%s] readInput() printed: %s\nwant substring: %s", tt.desc, out.String(), tt.wantOut)
+		}
+	}
+	log.SetOutput(ioutil.Discard)
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"regexp"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// consentError is an error of the consent page, e.g. access_denied, which
+// the web flow receives in the OAuth redirect and the user of the installed
+// app flow sees in the browser instead of a code.
+type consentError struct {
+	code string
+}
+
+func (e *consentError) Error() string {
+	return i18n.Sprintf("The consent page returned the error %s", e.code)
+}
+
+// consentErrorRegex matches the error of the consent page in the text that
+// the user copies from the browser, e.g. "Error 403: access_denied" or the
+// URL of a redirect with error=access_denied.
+var consentErrorRegex = regexp.MustCompile(`(?:Error \d{3}: |error=)([a-z_]+)`)
+
+// parseConsentError returns the error of the consent page in the input of
+// the user instead of a code, or nil.
+func parseConsentError(input string) error {
+	if code := blockedPageCode(input); code != "" {
+		return &consentError{code}
+	}
+	if m := consentErrorRegex.FindStringSubmatch(input); m != nil {
+		return &consentError{m[1]}
+	}
+	return nil
+}
+
+// consentHelp explains the error of the consent page, which is caused by the
+// configuration of the OAuth consent screen of the Google Cloud project, or
+// by the user.
+func consentHelp(code string) string {
+	switch code {
+	case "access_denied":
+		return i18n.Sprintf("ERROR: Access to the Google Ads API scope was denied (%s). Either you clicked "+
+			"Cancel on the consent page, or the publishing status of the OAuth consent screen of your Google "+
+			"Cloud project is Testing and the Google account you signed in with is not one of its test users "+
+			"(\"The developer hasn't given you access to this app\"): add it under OAuth consent screen > Test "+
+			"users, or publish the app. If Google shows \"Google hasn't verified this app\", the app is not "+
+			"blocked: click Advanced, then Go to the app, since the Google Ads API scope is sensitive and "+
+			"unverified apps get this warning. An unverified app is limited to 100 users; submit it for "+
+			"verification to lift the limit.", code)
+	case "org_internal":
+		return i18n.Sprintf("ERROR: The user type of the OAuth consent screen of your Google Cloud project "+
+			"is Internal (%s), so only the accounts of its Google Workspace organization can sign in, and the "+
+			"account you signed in with is not one of them, e.g. a gmail.com account. Sign in with an account "+
+			"of the organization, or change the user type to External on the OAuth consent screen page.", code)
+	}
+	return i18n.Sprintf("ERROR: The consent page returned the error %s. Check the OAuth consent screen of "+
+		"your Google Cloud project and the Google account you signed in with.", code)
+}
@@ -0,0 +1,155 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestParseConsentError(t *testing.T) {
+	tests := []struct {
+		desc  string
+		input string
+		want  string
+	}{
+		{
+			desc:  "Error page of the browser",
+			input: "Error 403: access_denied",
+			want:  "access_denied",
+		},
+		{
+			desc:  "Redirect URL",
+			input: "http://localhost/?error=org_internal&state=state",
+			want:  "org_internal",
+		},
+		{
+			desc:  "Blocked by the admin",
+			input: "Access blocked: Your institution's admin needs to review My App. This app is blocked by your administrator",
+			want:  "admin_policy_enforced",
+		},
+		{
+			desc:  "Advanced Protection",
+			input: "This app is blocked. This app tried to access sensitive info in your Google Account.",
+			want:  "app_blocked",
+		},
+		{
+			desc:  "Code",
+			input: "4/0AX4XfWh-Good_Code",
+		},
+	}
+
+	for _, tt := range tests {
+		var got string
+		if err := parseConsentError(tt.input); err != nil {
+			got = err.(*consentError).code
+		}
+		if got != tt.want {
+			t.Errorf("[%s] parseConsentError(%q) = %q, want: %q", tt.desc, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGenAuthCodeConsentError(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	c := Config{Prompter: prompt.NewTerminal(strings.NewReader("Error 403: access_denied\n"), ioutil.Discard)}
+	_, err := c.genAuthCode()
+	if got := errorNames[c.decodeError(err)]; got != "CONSENT_DENIED" {
+		t.Fatalf("genAuthCode() error: %v, decoded as %s, want: CONSENT_DENIED", err, got)
+	}
+
+	var out strings.Builder
+	log.SetOutput(&out)
+	c.diagnose(context.Background(), err)
+	if !strings.Contains(out.String(), "OAuth consent screen > Test users") {
+		t.Errorf("diagnose() got: %s\nwant the test users of the consent screen", out.String())
+	}
+}
+
+func TestServerHandlerConsentError(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	authCode, denied := make(chan string, 1), make(chan string, 1)
+	c := Config{}
+	handler := c.serverHandler(authCode, denied)
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/?error=org_internal&state=state", nil))
+
+	select {
+	case code := <-denied:
+		if code != "org_internal" {
+			t.Errorf("serverHandler() sent %s, want: org_internal", code)
+		}
+	default:
+		t.Errorf("serverHandler() did not send the error of the consent page")
+	}
+	if len(authCode) != 0 {
+		t.Errorf("serverHandler() sent an auth code")
+	}
+	if !strings.Contains(w.Body.String(), "org_internal") {
+		t.Errorf("serverHandler() page: %s", w.Body.String())
+	}
+	if got := consentHelp("org_internal"); !strings.Contains(got, fmt.Sprintf("is Internal (%s)", "org_internal")) {
+		t.Errorf("consentHelp() = %s", got)
+	}
+}
+
+func TestDiagnoseAdminPolicy(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	tests := []struct {
+		desc     string
+		err      error
+		tokenErr *tokenError
+		want     string
+	}{
+		{
+			desc: "Consent page of a Workspace organization",
+			err:  &consentError{"admin_policy_enforced"},
+			want: "Manage third-party app access",
+		},
+		{
+			desc: "Advanced Protection",
+			err:  &consentError{"app_blocked"},
+			want: "Advanced Protection Program",
+		},
+		{
+			desc:     "Token endpoint",
+			err:      fmt.Errorf("oauth2: cannot fetch token: 400 Bad Request"),
+			tokenErr: &tokenError{Code: "admin_policy_enforced"},
+			want:     "only an admin of the organization can allow the access",
+		},
+		{
+			desc:     "Reauthentication policy",
+			err:      fmt.Errorf("oauth2: cannot fetch token: 400 Bad Request"),
+			tokenErr: &tokenError{Code: "invalid_grant", Description: "reauth related error (invalid_rapt)"},
+			want:     "session control",
+		},
+	}
+
+	for _, tt := range tests {
+		c := Config{tokenErr: tt.tokenErr}
+		if got := errorNames[c.decodeError(tt.err)]; got != "ADMIN_POLICY_ENFORCED" {
+			t.Errorf("[%s] decodeError() = %s, want: ADMIN_POLICY_ENFORCED", tt.desc, got)
+		}
+
+		var out strings.Builder
+		log.SetOutput(&out)
+		c.diagnose(context.Background(), tt.err)
+		if !strings.Contains(out.String(), tt.want) {
+			t.Errorf("[%s] diagnose() got: %s\nwant substring: %s", tt.desc, out.String(), tt.want)
+		}
+		if strings.Contains(out.String(), "credentials are invalid") {
+			t.Errorf("[%s] diagnose() got the generic message: %s", tt.desc, out.String())
+		}
+	}
+}
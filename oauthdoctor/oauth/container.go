@@ -0,0 +1,39 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains the guidance printed when the doctor runs in a
+// container, where the browser of the user cannot be opened.
+
+import (
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// detectContainer returns the container runtime that the doctor runs in, or
+// an empty string.
+var detectContainer = diag.Container
+
+// containerSuggestions returns how to complete the web flow, whose redirect
+// to a local HTTP server at the given port cannot reach a container unless
+// the port is published.
+func containerSuggestions(runtime string, port int) string {
+	return i18n.Sprintf("The doctor runs in a %[1]s container. The OAuth redirect to http://localhost:%[2]d "+
+		"only reaches it when the browser runs on the same machine and port %[2]d is published, e.g. "+
+		"docker run -p %[2]d:%[2]d. Otherwise, you can:\n"+
+		"  - generate a refresh token on your computer with oauthdoctor mint-token, and pass it to the container, "+
+		"e.g. in GOOGLE_ADS_REFRESH_TOKEN or a secret file read with GOOGLE_ADS_REFRESH_TOKEN_FILE;\n"+
+		"  - use the installed_app OAuth type, where you copy the authorization code from the browser "+
+		"instead of being redirected.\n", runtime, port)
+}
@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestShowAuthURLInContainer(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	tests := []struct {
+		desc      string
+		container string
+		headless  string
+		copied    bool
+		want      string
+	}{
+		{
+			desc:   "Desktop",
+			copied: true,
+			want:   "copied to your clipboard",
+		},
+		{
+			desc:      "Docker",
+			container: "docker",
+			want:      "runs in a docker container, which cannot open a browser",
+		},
+		{
+			desc:     "SSH session",
+			headless: diag.SSHSession,
+			want:     "This is an SSH session",
+		},
+	}
+
+	for _, tt := range tests {
+		detectContainer = func() string { return tt.container }
+		detectHeadless = func() string { return tt.headless }
+		copied := false
+		copyToClipboard = func(string) error {
+			copied = true
+			return nil
+		}
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		c := Config{}
+		c.showAuthURL("https://accounts.google.com/o/oauth2/auth")
+
+		if copied != tt.copied {
+			t.Errorf("[%s] showAuthURL() copied the URL: %t, want: %t", tt.desc, copied, tt.copied)
+		}
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] showAuthURL() got: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
+		}
+	}
+}
+
+func TestContainerSuggestions(t *testing.T) {
+	got := containerSuggestions("docker", 8080)
+	for _, want := range []string{"docker run -p 8080:8080", "oauthdoctor mint-token", "installed_app"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("containerSuggestions(docker, 8080) got=%s\nwant substring=%s", got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"strings"
+
+	"github.com/fatih/structs"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+// CurlCommand returns shell commands that reproduce the Google Ads API
+// request of the diagnosis with curl, so it can be tested outside the doctor
+// and shared with support. The credentials are passed in the environment
+// variables of diag.RESTLanguage, whose exports are printed with placeholders
+// instead of the values when hidePII is set. A service account cannot get an
+// access token with curl, so the token is a placeholder for it.
+func (c *Config) CurlCommand(hidePII bool) (string, error) {
+	customerURL, err := c.customerURL()
+	if err != nil {
+		return "", err
+	}
+
+	env := structs.Map(diag.Languages[diag.RESTLanguage].Cfg.ConfigKeys)
+	values := structs.Map(c.ConfigFile.ConfigKeys)
+	fields := []string{diag.DevToken, diag.ClientID, diag.ClientSecret, diag.RefreshToken}
+	if c.OAuthType == diag.ServiceAccount {
+		fields = fields[:1]
+	}
+	if c.ConfigFile.LoginCustomerID != "" {
+		fields = append(fields, "LoginCustomerID")
+	}
+
+	var lines []string
+	for _, f := range fields {
+		v := values[f].(string)
+		if hidePII && diag.IsPII(f) {
+			v = "<" + f + ">"
+		}
+		lines = append(lines, "export "+env[f].(string)+"="+shellQuote(v))
+	}
+	if c.OAuthType == diag.ServiceAccount {
+		lines = append(lines, "ACCESS_TOKEN='<access token of the service account>'")
+	} else {
+		lines = append(lines,
+			"ACCESS_TOKEN=$(curl -s -X POST "+shellQuote(c.endpoint().TokenURL)+" \\",
+			"  --data-urlencode \"client_id=$"+env[diag.ClientID].(string)+"\" \\",
+			"  --data-urlencode \"client_secret=$"+env[diag.ClientSecret].(string)+"\" \\",
+			"  --data-urlencode \"refresh_token=$"+env[diag.RefreshToken].(string)+"\" \\",
+			"  --data-urlencode \"grant_type=refresh_token\" \\",
+			"  | sed -n 's/.*\"access_token\": *\"\\([^\"]*\\)\".*/\\1/p')")
+	}
+	lines = append(lines,
+		"curl -s "+shellQuote(customerURL)+" \\",
+		"  -H \"Authorization: Bearer $ACCESS_TOKEN\" \\",
+		"  -H \"developer-token: $"+env[diag.DevToken].(string)+"\"")
+	if c.ConfigFile.LoginCustomerID != "" {
+		lines[len(lines)-1] += " \\"
+		lines = append(lines, "  -H \"login-customer-id: $"+env["LoginCustomerID"].(string)+"\"")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
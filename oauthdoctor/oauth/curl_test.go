@@ -0,0 +1,79 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestCurlCommand(t *testing.T) {
+	cfg := diag.ConfigFile{
+		Lang: diag.RESTLanguage,
+		ConfigKeys: diag.ConfigKeys{
+			ClientID:        "ClientID",
+			ClientSecret:    "Client'Secret",
+			DevToken:        "DevToken",
+			RefreshToken:    "RefreshToken",
+			LoginCustomerID: "1112223333",
+		},
+	}
+
+	tests := []struct {
+		desc      string
+		oauthType string
+		hidePII   bool
+		want      []string
+		notWant   []string
+	}{
+		{
+			desc: "Values are exported",
+			want: []string{
+				"export GOOGLE_ADS_CLIENT_SECRET='Client'\\''Secret'\n",
+				"export GOOGLE_ADS_LOGIN_CUSTOMER_ID='1112223333'\n",
+				"ACCESS_TOKEN=$(curl -s -X POST 'https://oauth.example.com/token' \\\n",
+				"curl -s 'https://googleads.googleapis.com/" + DefaultAPIVersion + "/customers/1234567890' \\\n",
+				"  -H \"developer-token: $GOOGLE_ADS_DEVELOPER_TOKEN\" \\\n" +
+					"  -H \"login-customer-id: $GOOGLE_ADS_LOGIN_CUSTOMER_ID\"",
+			},
+		},
+		{
+			desc:    "Secrets are hidden",
+			hidePII: true,
+			want: []string{
+				"export GOOGLE_ADS_CLIENT_SECRET='<ClientSecret>'\n",
+				"export GOOGLE_ADS_LOGIN_CUSTOMER_ID='1112223333'\n",
+			},
+			notWant: []string{"DevToken'", "RefreshToken'"},
+		},
+
+		{
+			desc:      "Service account",
+			oauthType: diag.ServiceAccount,
+			want: []string{
+				"export GOOGLE_ADS_DEVELOPER_TOKEN='DevToken'\nexport GOOGLE_ADS_LOGIN_CUSTOMER_ID='1112223333'\n" +
+					"ACCESS_TOKEN='<access token of the service account>'\n",
+			},
+			notWant: []string{"GOOGLE_ADS_CLIENT_SECRET", "oauth.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		c := Config{ConfigFile: cfg, CustomerID: "1234567890", OAuthType: tt.oauthType,
+			Endpoints: Endpoints{TokenURL: "https://oauth.example.com/token"}}
+		got, err := c.CurlCommand(tt.hidePII)
+		if err != nil {
+			t.Fatalf("[%s] CurlCommand() error: %s", tt.desc, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("[%s] CurlCommand() got:\n%s\nwant: %s", tt.desc, got, w)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(got, w) {
+				t.Errorf("[%s] CurlCommand() got:\n%s\nshould not contain: %s", tt.desc, got, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains functions that verify the access of the credentials to
+// other customer accounts, once the OAuth flow succeeded.
+
+import (
+	"context"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// These are the codes of the customer checks, in addition to the ones of
+// errorNames.
+const (
+	// AccessDenied means the user who authorized the credentials has no
+	// access to the account.
+	AccessDenied = "ACCESS_DENIED"
+	// NotLinked means the account is not a client of the login customer.
+	NotLinked = "NOT_LINKED"
+	// CustomerNotFound means the account does not exist or was deleted.
+	CustomerNotFound = "CUSTOMER_NOT_FOUND"
+)
+
+// CheckCustomer gets the customer account cid with the credentials that
+// passed the OAuth flow simulation, e.g. to audit the accounts of an agency.
+// An error is returned when the simulation did not pass. The config can be
+// copied to check several accounts at the same time.
+func (c *Config) CheckCustomer(ctx context.Context, cid string) (report.Check, error) {
+	if c.client == nil {
+		return report.Check{}, i18n.Errorf("The customer accounts can only be checked after the OAuth test passed")
+	}
+	cid = strings.ReplaceAll(strings.TrimSpace(cid), "-", "")
+	chk := report.Check{
+		ID:     report.CustomerCheckPrefix + cid,
+		Name:   i18n.Sprintf("Access to customer %s", report.FormatCustomerID(cid)),
+		Status: report.Pass,
+	}
+
+	cc := *c
+	cc.CustomerID = cid
+	cc.tokenErr = nil
+	_, err := cc.fetchAccount(ctx, c.client)
+	if err == nil {
+		return chk, nil
+	}
+	if ctx.Err() != nil {
+		return chk, ctx.Err()
+	}
+
+	chk.Status = report.Fail
+	errstr := err.Error()
+	switch {
+	case strings.Contains(errstr, "USER_PERMISSION_DENIED") && c.ConfigFile.LoginCustomerID != "":
+		chk.Code = NotLinked
+		chk.Message = i18n.Sprintf("The account is not a client of the login customer %s",
+			report.FormatCustomerID(c.ConfigFile.LoginCustomerID))
+	case strings.Contains(errstr, "USER_PERMISSION_DENIED"):
+		chk.Code = AccessDenied
+		chk.Message = i18n.T("The user who authorized the credentials has no access to the account")
+	case strings.Contains(errstr, CustomerNotFound):
+		chk.Code = CustomerNotFound
+		chk.Message = i18n.T("The account does not exist")
+	default:
+		chk.Code = cc.KnowledgeBase.name(cc.decodeError(err))
+		chk.Message = errstr
+		switch chk.Code {
+		case errorNames[CustomerNotActive]:
+			chk.Message = i18n.T("The account is not active")
+		case errorNames[CustomerNotEnabled]:
+			chk.Message = i18n.T("The account is cancelled or not enabled")
+		case errorNames[DevTokenNotApproved]:
+			chk.Message = i18n.T("The account is a production account, and the developer token only has test access")
+		case errorNames[DevTokenProhibited]:
+			chk.Message = i18n.T("The developer token is bound to another Google Cloud project than the one of the OAuth client")
+		case errorNames[AccessNotPermittedForManagerAccount]:
+			// Manager accounts are reachable, but cannot be tested.
+			chk.Status = report.Warn
+			chk.Message = i18n.T("The account is a manager account")
+		}
+	}
+	return chk, nil
+}
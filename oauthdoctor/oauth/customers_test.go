@@ -0,0 +1,72 @@
+package oauth
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestCheckCustomer(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, "/v8/customers/") {
+		case "1111111111":
+			w.Write([]byte(`{"resourceName": "customers/1111111111", "id": "1111111111"}`))
+		case "2222222222":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"status": "PERMISSION_DENIED", "details": [{"errors": [{"errorCode": {"authorizationError": "USER_PERMISSION_DENIED"}}]}]}}`))
+		case "3333333333":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"status": "PERMISSION_DENIED", "details": [{"errors": [{"errorCode": {"authorizationError": "CUSTOMER_NOT_ACTIVE"}}]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"status": "NOT_FOUND", "details": [{"errors": [{"errorCode": {"requestError": "CUSTOMER_NOT_FOUND"}}]}]}}`))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc       string
+		cid        string
+		loginID    string
+		wantStatus report.Status
+		wantCode   string
+	}{
+		{desc: "Access", cid: "111-111-1111", wantStatus: report.Pass},
+		{desc: "Access denied", cid: "2222222222", wantStatus: report.Fail, wantCode: AccessDenied},
+		{desc: "Not linked to the login customer", cid: "2222222222", loginID: "9999999999", wantStatus: report.Fail, wantCode: NotLinked},
+		{desc: "Inactive account", cid: "3333333333", wantStatus: report.Fail, wantCode: "CUSTOMER_NOT_ACTIVE"},
+		{desc: "Unknown account", cid: "4444444444", wantStatus: report.Fail, wantCode: CustomerNotFound},
+	}
+
+	for _, tt := range tests {
+		c := Config{
+			ConfigFile: diag.ConfigFile{ConfigKeys: diag.ConfigKeys{LoginCustomerID: tt.loginID}},
+			CustomerID: "1234567890",
+			Endpoint:   ts.URL,
+			client:     ts.Client(),
+		}
+		chk, err := c.CheckCustomer(context.Background(), tt.cid)
+		if err != nil {
+			t.Fatalf("[%s] CheckCustomer() error: %s", tt.desc, err)
+		}
+		if chk.Status != tt.wantStatus || chk.Code != tt.wantCode {
+			t.Errorf("[%s] CheckCustomer() = %s %s, want: %s %s", tt.desc, chk.Status, chk.Code, tt.wantStatus, tt.wantCode)
+		}
+		if c.CustomerID != "1234567890" {
+			t.Errorf("[%s] CheckCustomer() changed the customer ID of the config to %s", tt.desc, c.CustomerID)
+		}
+	}
+
+	if _, err := (&Config{}).CheckCustomer(context.Background(), "1111111111"); err == nil {
+		t.Errorf("CheckCustomer() without a successful OAuth test returned no error")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// These are the URLs of the Google services called by the OAuth flows.
+const (
+	defaultRevokeURL    = "https://oauth2.googleapis.com/revoke"
+	defaultTokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	// defaultSTSURL is the token endpoint of workload identity federation.
+	defaultSTSURL = "https://sts.googleapis.com/v1/token"
+)
+
+// Endpoints override the URLs of the Google services that the OAuth flows
+// call, e.g. to go through a proxy or to use an emulator. The URLs of Google
+// are used for the empty ones.
+type Endpoints struct {
+	// AuthURL is the URL of the OAuth2 consent page.
+	AuthURL string
+	// TokenURL is the URL of the OAuth2 token endpoint of all flows,
+	// including the token exchange of workload identity federation unless
+	// STSURL is set.
+	TokenURL string
+	// STSURL is the token endpoint of workload identity federation. When
+	// empty, the one of the credentials is used, then the one of Google.
+	STSURL string
+	// TokenInfoURL is the endpoint that tells who a token was issued to and
+	// with which scopes.
+	TokenInfoURL string
+	// RevokeURL is the OAuth2 token revocation endpoint.
+	RevokeURL string
+}
+
+// endpoint returns the OAuth2 endpoint of the user flows, with the
+// authorization and token URLs overridden by c.Endpoints.
+func (c *Config) endpoint() oauth2.Endpoint {
+	e := google.Endpoint
+	if c.Endpoints.AuthURL != "" {
+		e.AuthURL = c.Endpoints.AuthURL
+	}
+	if c.Endpoints.TokenURL != "" {
+		e.TokenURL = c.Endpoints.TokenURL
+	}
+	return e
+}
+
+// jwtTokenURL returns the token endpoint of the service account flow.
+func (c *Config) jwtTokenURL() string {
+	if c.Endpoints.TokenURL != "" {
+		return c.Endpoints.TokenURL
+	}
+	return google.JWTTokenURL
+}
+
+// stsURL returns the token endpoint of the token exchange of acct.
+func (c *Config) stsURL(acct *externalAccount) string {
+	switch {
+	case c.Endpoints.STSURL != "":
+		return c.Endpoints.STSURL
+	case c.Endpoints.TokenURL != "":
+		return c.Endpoints.TokenURL
+	case acct.TokenURL != "":
+		return acct.TokenURL
+	}
+	return defaultSTSURL
+}
+
+// tokenInfoURL returns the tokeninfo endpoint.
+func (c *Config) tokenInfoURL() string {
+	if c.Endpoints.TokenInfoURL != "" {
+		return c.Endpoints.TokenInfoURL
+	}
+	return defaultTokenInfoURL
+}
+
+// revokeURL returns the token revocation endpoint.
+func (c *Config) revokeURL() string {
+	if c.Endpoints.RevokeURL != "" {
+		return c.Endpoints.RevokeURL
+	}
+	return defaultRevokeURL
+}
+
+// httpClient returns the HTTP client of the config, or http.DefaultClient.
+func (c *Config) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// transport returns the transport of the HTTP client of the config.
+func (c *Config) transport() http.RoundTripper {
+	if t := c.httpClient().Transport; t != nil {
+		return t
+	}
+	return http.DefaultTransport
+}
+
+// clientWith returns a copy of the HTTP client of the config, e.g. with its
+// timeout, that sends the requests with t.
+func (c *Config) clientWith(t http.RoundTripper) *http.Client {
+	client := *c.httpClient()
+	client.Transport = t
+	return &client
+}
@@ -0,0 +1,547 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+const (
+	tokenExchangeGrant = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType    = "urn:ietf:params:oauth:token-type:access_token"
+	awsTokenType       = "urn:ietf:params:aws:token-type:aws4_request"
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	// awsCallerIdentityURL is the AWS request that an AWS credential source
+	// signs, when the credentials do not set another one.
+	awsCallerIdentityURL = "https://sts.{region}.amazonaws.com?Action=GetCallerIdentity&Version=2011-06-15"
+)
+
+var (
+	// audienceRegex matches the resource name of a workload (or workforce)
+	// identity pool provider.
+	audienceRegex = regexp.MustCompile(`^//iam\.googleapis\.com/(projects/([^/]+)/)?locations/[^/]+/` +
+		`(workloadIdentityPools|workforcePools)/[^/]+/providers/[^/]+$`)
+	// impersonationRegex matches the service account of an impersonation URL.
+	impersonationRegex = regexp.MustCompile(`/serviceAccounts/([^/:]+):generateAccessToken$`)
+)
+
+// externalAccount is the JSON file of workload identity federation
+// credentials.
+type externalAccount struct {
+	Type             string           `json:"type"`
+	Audience         string           `json:"audience"`
+	SubjectTokenType string           `json:"subject_token_type"`
+	TokenURL         string           `json:"token_url"`
+	ImpersonationURL string           `json:"service_account_impersonation_url"`
+	CredentialSource credentialSource `json:"credential_source"`
+}
+
+// credentialSource tells where the workload gets the token of its identity
+// provider: a file, a URL or AWS.
+type credentialSource struct {
+	File    string            `json:"file"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Format  struct {
+		Type      string `json:"type"`
+		FieldName string `json:"subject_token_field_name"`
+	} `json:"format"`
+	EnvironmentID   string          `json:"environment_id"`
+	RegionURL       string          `json:"region_url"`
+	VerificationURL string          `json:"regional_cred_verification_url"`
+	SessionTokenURL string          `json:"imdsv2_session_token_url"`
+	Executable      json.RawMessage `json:"executable"`
+}
+
+// awsCredentials are the AWS security credentials of the workload.
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+}
+
+// externalAccountError is a failure of workload identity federation, whose
+// message tells how to fix it.
+type externalAccountError struct {
+	msg string
+}
+
+func (e *externalAccountError) Error() string {
+	return e.msg
+}
+
+// federationError returns an externalAccountError with a translated message.
+func federationError(format string, a ...interface{}) error {
+	return &externalAccountError{i18n.Sprintf(format, a...)}
+}
+
+// readExternalAccount reads the workload identity federation credentials in
+// path.
+func readExternalAccount(path string) (*externalAccount, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	acct := &externalAccount{}
+	if err := json.Unmarshal(input, acct); err != nil {
+		return nil, err
+	}
+	return acct, nil
+}
+
+// simulateExternalAccountFlow exchanges the token of the credential source
+// of workload identity federation for an access token of the impersonated
+// service account and gets the account info. It returns the error of the
+// attempt.
+func (c *Config) simulateExternalAccountFlow(ctx context.Context) error {
+	token, err := c.federatedToken(ctx)
+	if err != nil {
+		c.print(i18n.Sprintf("ERROR: %s", err))
+		c.print(i18n.T("ERROR: OAuth test failed."))
+		return err
+	}
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+
+	accountInfo, err := c.getAccount(ctx, client)
+	if err == nil {
+		if c.Verbose {
+			c.print(accountInfo.String())
+		}
+		c.print(i18n.T("SUCCESS: OAuth test passed with given config file settings."))
+	} else {
+		c.diagnose(ctx, err)
+		if c.Verbose {
+			c.print(err.Error())
+		}
+		c.print(i18n.T("ERROR: OAuth test failed."))
+	}
+	return err
+}
+
+// federatedToken validates the workload identity federation credentials of
+// the config and returns the access token they get for the Google Ads API.
+func (c *Config) federatedToken(ctx context.Context) (string, error) {
+	acct, err := readExternalAccount(c.ConfigFile.PrivateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	if err := acct.validate(); err != nil {
+		return "", err
+	}
+	if c.ConfigFile.DelegatedAccount != "" {
+		c.print(i18n.Sprintf("WARNING: Workload identity federation cannot use domain-wide delegation, "+
+			"so the delegated account %s is ignored.", c.ConfigFile.DelegatedAccount))
+	}
+
+	subjectToken, err := c.subjectToken(ctx, acct)
+	if err != nil {
+		return "", err
+	}
+	c.print(i18n.T("The credential source returned a subject token."))
+
+	scope := cloudPlatformScope
+	if acct.ImpersonationURL == "" {
+		c.print(i18n.T("WARNING: The credentials do not impersonate a service account " +
+			"(service_account_impersonation_url), but the Google Ads API only accepts the " +
+			"access tokens of service accounts and users."))
+		scope = strings.Join(c.scopes(), " ")
+	}
+	token, err := c.exchangeToken(ctx, acct, subjectToken, scope)
+	if err != nil {
+		return "", err
+	}
+	c.print(i18n.T("The security token service exchanged the subject token for a federated token."))
+	if acct.ImpersonationURL == "" {
+		return token, nil
+	}
+	return c.impersonate(ctx, acct, token)
+}
+
+// validate checks the fields of the credentials that can be checked without
+// sending any request.
+func (a *externalAccount) validate() error {
+	m := audienceRegex.FindStringSubmatch(a.Audience)
+	if m == nil {
+		return federationError("The audience %q is not the resource name of a workload identity pool provider, "+
+			"e.g. //iam.googleapis.com/projects/PROJECT_NUMBER/locations/global/workloadIdentityPools/POOL_ID/providers/PROVIDER_ID. "+
+			"Download the credential configuration again from the pool in the Google Cloud console.", a.Audience)
+	}
+	if m[2] != "" && strings.Trim(m[2], "0123456789") != "" {
+		return federationError("The audience %q names the project %s by its ID, but it must use the project number.",
+			a.Audience, m[2])
+	}
+
+	src := a.CredentialSource
+	switch {
+	case len(src.Executable) > 0:
+		return federationError("The credential source runs an executable, which this tool does not run. " +
+			"Check it with your client library and GOOGLE_EXTERNAL_ACCOUNT_ALLOW_EXECUTABLES=1.")
+	case src.EnvironmentID != "":
+		if src.EnvironmentID != "aws1" {
+			return federationError("The environment_id %s of the credential source is not supported; it must be aws1.",
+				src.EnvironmentID)
+		}
+		if a.SubjectTokenType != awsTokenType {
+			return federationError("The subject_token_type %q does not match the AWS credential source; it must be %s.",
+				a.SubjectTokenType, awsTokenType)
+		}
+	case src.File != "" || src.URL != "":
+		if a.SubjectTokenType == awsTokenType {
+			return federationError("The subject_token_type %q is for AWS, but the credential source is a file or a URL.",
+				a.SubjectTokenType)
+		}
+		if src.Format.Type != "" && src.Format.Type != "text" && src.Format.Type != "json" {
+			return federationError("The format %q of the credential source must be text or json.", src.Format.Type)
+		}
+		if src.Format.Type == "json" && src.Format.FieldName == "" {
+			return federationError("The credential source has a json format, but no subject_token_field_name.")
+		}
+	default:
+		return federationError("The credentials have no credential_source with a file, a url or an " +
+			"environment_id. Download the credential configuration again from the pool in the Google Cloud console.")
+	}
+	if a.ImpersonationURL != "" && !impersonationRegex.MatchString(a.ImpersonationURL) {
+		return federationError("The service_account_impersonation_url %s does not name a service account, "+
+			"e.g. https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/EMAIL:generateAccessToken.",
+			a.ImpersonationURL)
+	}
+	return nil
+}
+
+// subjectToken gets the token of the identity provider from the credential
+// source.
+func (c *Config) subjectToken(ctx context.Context, acct *externalAccount) (string, error) {
+	src := acct.CredentialSource
+	var content []byte
+	var err error
+	switch {
+	case src.EnvironmentID != "":
+		return c.awsSubjectToken(ctx, acct)
+	case src.File != "":
+		content, err = ioutil.ReadFile(src.File)
+		if os.IsNotExist(err) {
+			return "", federationError("The credential source file %s does not exist. The workload platform "+
+				"writes it, e.g. as a projected service account token in Kubernetes; check that it is mounted "+
+				"at this path where the client library runs.", src.File)
+		}
+		if err != nil {
+			return "", federationError("The credential source file %s cannot be read: %s", src.File, err)
+		}
+	default:
+		content, err = c.send(ctx, "GET", src.URL, src.Headers, nil)
+		if err != nil {
+			return "", federationError("The credential source URL %s cannot be reached: %s. Metadata servers "+
+				"can only be reached from the workload, e.g. from an Azure VM.", src.URL, err)
+		}
+	}
+
+	token := strings.TrimSpace(string(content))
+	if src.Format.Type == "json" {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return "", federationError("The credential source is not JSON: %s", err)
+		}
+		v, ok := fields[src.Format.FieldName].(string)
+		if !ok {
+			return "", federationError("The credential source has no %s field.", src.Format.FieldName)
+		}
+		token = v
+	}
+	if token == "" {
+		return "", federationError("The credential source returned an empty subject token.")
+	}
+	return token, nil
+}
+
+// send sends a request to u through a tokenTracer and returns the body of the
+// response, which is also returned with the error of an unsuccessful status.
+func (c *Config) send(ctx context.Context, method, u string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := c.clientWith(&tokenTracer{c: c, base: c.transport()})
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return content, i18n.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
+
+// exchangeToken exchanges the subject token for a federated access token at
+// the security token service.
+func (c *Config) exchangeToken(ctx context.Context, acct *externalAccount, subjectToken, scope string) (string, error) {
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrant},
+		"audience":             {acct.Audience},
+		"scope":                {scope},
+		"requested_token_type": {accessTokenType},
+		"subject_token_type":   {acct.SubjectTokenType},
+		"subject_token":        {subjectToken},
+	}
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	content, err := c.send(ctx, "POST", c.stsURL(acct), headers, []byte(form.Encode()))
+	if err != nil {
+		if c.tokenErr != nil {
+			return "", stsError(acct, c.tokenErr)
+		}
+		return "", federationError("The token exchange failed: %s", err)
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(content, &resp); err != nil || resp.AccessToken == "" {
+		return "", federationError("The security token service returned no access token.")
+	}
+	return resp.AccessToken, nil
+}
+
+// stsError maps an error of the security token service to the fix of its
+// usual cause.
+func stsError(acct *externalAccount, te *tokenError) error {
+	desc := strings.ToLower(te.Description)
+	switch {
+	case te.Code == "invalid_target":
+		return federationError("The workload identity pool or provider of the audience %s does not exist or is "+
+			"disabled (%s). Check the pool and its provider in the Google Cloud console.", acct.Audience, te)
+	case strings.Contains(desc, "expired"):
+		return federationError("The subject token of the credential source has expired (%s). "+
+			"Check that the workload platform refreshes it.", te)
+	case strings.Contains(desc, "attribute condition"):
+		return federationError("The attribute condition of the provider rejected the subject token (%s). "+
+			"Check the condition and the claims of the token.", te)
+	case strings.Contains(desc, "audience"):
+		return federationError("The audience of the subject token is not allowed by the provider (%s). "+
+			"Add it to the allowed audiences of the provider.", te)
+	case strings.Contains(desc, "issuer"):
+		return federationError("The issuer of the subject token does not match the provider (%s). "+
+			"Check the issuer URL of the provider.", te)
+	case strings.Contains(desc, "subject_token_type"):
+		return federationError("The subject_token_type %s does not match the provider (%s).",
+			acct.SubjectTokenType, te)
+	case strings.Contains(desc, "signature") || strings.Contains(desc, "jwk"):
+		return federationError("The signature of the subject token cannot be verified with the keys of the "+
+			"issuer (%s). Check the JWKS of the provider.", te)
+	}
+	return federationError("The security token service rejected the subject token: %s", te)
+}
+
+// impersonate exchanges the federated token for an access token of the
+// service account of the impersonation URL.
+func (c *Config) impersonate(ctx context.Context, acct *externalAccount, token string) (string, error) {
+	email := impersonationRegex.FindStringSubmatch(acct.ImpersonationURL)[1]
+	body, err := json.Marshal(map[string]interface{}{"scope": c.scopes(), "lifetime": "3600s"})
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token, "Content-Type": "application/json"}
+	content, err := c.send(ctx, "POST", acct.ImpersonationURL, headers, body)
+	if err != nil {
+		var apiErr struct {
+			Error struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		json.Unmarshal(content, &apiErr)
+		switch {
+		case strings.Contains(apiErr.Error.Message, "iamcredentials.googleapis.com"):
+			return "", federationError("The IAM Service Account Credentials API is not enabled in the project of "+
+				"the workload identity pool. Enable iamcredentials.googleapis.com (%s).", apiErr.Error.Message)
+		case apiErr.Error.Code == http.StatusNotFound:
+			return "", federationError("The service account %s does not exist.", email)
+		case apiErr.Error.Status == "PERMISSION_DENIED":
+			return "", federationError("The federated identity cannot impersonate %s. Grant the principal of "+
+				"the workload identity pool the Workload Identity User role (roles/iam.workloadIdentityUser) "+
+				"on the service account.", email)
+		}
+		return "", federationError("The impersonation of %s failed: %s", email, err)
+	}
+	var resp struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(content, &resp); err != nil || resp.AccessToken == "" {
+		return "", federationError("The impersonation of %s returned no access token.", email)
+	}
+	c.print(i18n.Sprintf("The federated identity impersonated %s.", email))
+	return resp.AccessToken, nil
+}
+
+// awsSubjectToken returns the subject token of an AWS credential source: a
+// signed GetCallerIdentity request that the security token service sends to
+// AWS. The region and the credentials are read from the environment like the
+// client libraries do, else from the metadata server.
+func (c *Config) awsSubjectToken(ctx context.Context, acct *externalAccount) (string, error) {
+	src := acct.CredentialSource
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		Token:           os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	fromEnv := creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+
+	var headers map[string]string
+	if src.SessionTokenURL != "" && (region == "" || !fromEnv) {
+		session, err := c.send(ctx, "PUT", src.SessionTokenURL,
+			map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "300"}, nil)
+		if err != nil {
+			return "", federationError("The AWS session token cannot be got from %s: %s. The metadata server "+
+				"can only be reached from an AWS workload.", src.SessionTokenURL, err)
+		}
+		headers = map[string]string{"X-aws-ec2-metadata-token": string(session)}
+	}
+
+	if region == "" {
+		if src.RegionURL == "" {
+			return "", federationError("The AWS region is unknown: set AWS_REGION or the region_url of the credential source.")
+		}
+		zone, err := c.send(ctx, "GET", src.RegionURL, headers, nil)
+		if err != nil {
+			return "", federationError("The AWS region cannot be got from %s: %s. Set AWS_REGION outside of AWS.",
+				src.RegionURL, err)
+		}
+		// The metadata server returns the availability zone, e.g. us-east-1b.
+		if region = strings.TrimSpace(string(zone)); region != "" {
+			region = region[:len(region)-1]
+		}
+	}
+
+	if !fromEnv {
+		if src.URL == "" {
+			return "", federationError("The AWS credentials are unknown: set AWS_ACCESS_KEY_ID and " +
+				"AWS_SECRET_ACCESS_KEY, or the url of the credential source.")
+		}
+		role, err := c.send(ctx, "GET", src.URL, headers, nil)
+		if err != nil {
+			return "", federationError("The AWS role cannot be got from %s: %s. Check that an IAM role is "+
+				"attached to the instance.", src.URL, err)
+		}
+		roleURL := strings.TrimSuffix(src.URL, "/") + "/" + strings.TrimSpace(string(role))
+		content, err := c.send(ctx, "GET", roleURL, headers, nil)
+		if err == nil {
+			err = json.Unmarshal(content, &creds)
+		}
+		if err != nil {
+			return "", federationError("The AWS credentials of the role %s cannot be got: %s", role, err)
+		}
+	}
+
+	verifyURL := src.VerificationURL
+	if verifyURL == "" {
+		verifyURL = awsCallerIdentityURL
+	}
+	verifyURL = strings.Replace(verifyURL, "{region}", region, -1)
+	return signAWSRequest(verifyURL, region, acct.Audience, creds, time.Now().UTC())
+}
+
+// signAWSRequest signs a POST request to u with AWS Signature Version 4 and
+// returns it in the URL-encoded JSON form of a subject token.
+func signAWSRequest(u, region, audience string, creds awsCredentials, now time.Time) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	headers := map[string]string{
+		"host":                         parsed.Host,
+		"x-amz-date":                   amzDate,
+		"x-goog-cloud-target-resource": audience,
+	}
+	if creds.Token != "" {
+		headers["x-amz-security-token"] = creds.Token
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders string
+	for _, k := range names {
+		canonicalHeaders += k + ":" + headers[k] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(nil)
+	request := strings.Join([]string{"POST", path, parsed.Query().Encode(), canonicalHeaders, signedHeaders,
+		hex.EncodeToString(payloadHash[:])}, "\n")
+	requestHash := sha256.Sum256([]byte(request))
+	scope := date + "/" + region + "/sts/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, s := range []string{date, region, "sts", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	headers["authorization"] = "AWS4-HMAC-SHA256 Credential=" + creds.AccessKeyID + "/" + scope +
+		", SignedHeaders=" + signedHeaders + ", Signature=" + hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	type header struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	var list []header
+	for _, k := range append(names, "authorization") {
+		list = append(list, header{http.CanonicalHeaderKey(k), headers[k]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	token, err := json.Marshal(map[string]interface{}{"url": u, "method": "POST", "headers": list})
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(string(token)), nil
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+const testAudience = "//iam.googleapis.com/projects/123456/locations/global/workloadIdentityPools/pool/providers/provider"
+
+// setupFakeFederationServer fakes the security token service, a URL
+// credential source, the IAM credentials API and the Google Ads API.
+func setupFakeFederationServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sts", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch {
+		case strings.Contains(r.Form.Get("audience"), "deleted"):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_target","error_description":"The target service indicated by the \"audience\" parameters is invalid."}`))
+		case r.Form.Get("subject_token") == "expired":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"The token has expired."}`))
+		case strings.Contains(r.Form.Get("subject_token"), "GetCallerIdentity"):
+			w.Write([]byte(`{"access_token":"federated","token_type":"Bearer"}`))
+		case r.Form.Get("subject_token") == "subject":
+			w.Write([]byte(`{"access_token":"federated","token_type":"Bearer"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Unexpected subject token"}`))
+		}
+	})
+	mux.HandleFunc("/subject", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "True" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"subject"}`))
+	})
+	mux.HandleFunc("/iam/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer federated":
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.Contains(r.URL.Path, "denied@"):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"Permission 'iam.serviceAccounts.getAccessToken' denied","status":"PERMISSION_DENIED"}}`))
+		default:
+			w.Write([]byte(`{"accessToken":"sa-token","expireTime":"2030-01-01T00:00:00Z"}`))
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"resourceName": "customers/1234567890", "id": "1234567890"}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestSimulateExternalAccountFlow(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	ts := setupFakeFederationServer()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "external_account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("subject\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expiredFile := filepath.Join(dir, "expired")
+	if err := ioutil.WriteFile(expiredFile, []byte("expired"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	impersonation := ts.URL + "/iam/v1/projects/-/serviceAccounts/%s:generateAccessToken"
+
+	tests := []struct {
+		desc     string
+		audience string
+		source   interface{}
+		email    string
+		want     string
+		wantCode string
+	}{
+		{
+			desc:   "file source",
+			source: map[string]string{"file": tokenFile},
+			email:  "ads@project.iam.gserviceaccount.com",
+			want:   "OAuth test passed",
+		},
+		{
+			desc: "URL source with JSON format",
+			source: map[string]interface{}{
+				"url":     ts.URL + "/subject",
+				"headers": map[string]string{"Metadata": "True"},
+				"format":  map[string]string{"type": "json", "subject_token_field_name": "access_token"},
+			},
+			email: "ads@project.iam.gserviceaccount.com",
+			want:  "OAuth test passed",
+		},
+		{
+			desc:     "missing file",
+			source:   map[string]string{"file": filepath.Join(dir, "missing")},
+			email:    "ads@project.iam.gserviceaccount.com",
+			want:     "does not exist",
+			wantCode: "FEDERATION_FAILED",
+		},
+		{
+			desc:     "expired subject token",
+			source:   map[string]string{"file": expiredFile},
+			email:    "ads@project.iam.gserviceaccount.com",
+			want:     "has expired",
+			wantCode: "FEDERATION_FAILED",
+		},
+		{
+			desc:     "deleted pool",
+			audience: strings.Replace(testAudience, "pool/", "deleted/", 1),
+			source:   map[string]string{"file": tokenFile},
+			email:    "ads@project.iam.gserviceaccount.com",
+			want:     "does not exist or is disabled",
+			wantCode: "FEDERATION_FAILED",
+		},
+		{
+			desc:     "project ID in audience",
+			audience: strings.Replace(testAudience, "123456", "my-project", 1),
+			source:   map[string]string{"file": tokenFile},
+			want:     "must use the project number",
+			wantCode: "FEDERATION_FAILED",
+		},
+		{
+			desc:     "impersonation denied",
+			source:   map[string]string{"file": tokenFile},
+			email:    "denied@project.iam.gserviceaccount.com",
+			want:     "roles/iam.workloadIdentityUser",
+			wantCode: "FEDERATION_FAILED",
+		},
+		{
+			desc:     "no credential source",
+			source:   map[string]string{},
+			want:     "no credential_source",
+			wantCode: "FEDERATION_FAILED",
+		},
+	}
+
+	for i, tt := range tests {
+		if tt.audience == "" {
+			tt.audience = testAudience
+		}
+		creds := map[string]interface{}{
+			"type":               diag.ExternalAccount,
+			"audience":           tt.audience,
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"credential_source":  tt.source,
+		}
+		if tt.email != "" {
+			creds["service_account_impersonation_url"] = fmt.Sprintf(impersonation, tt.email)
+		}
+		content, err := json.Marshal(creds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("creds%d.json", i))
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		c := Config{
+			ConfigFile: diag.ConfigFile{
+				OAuthType:          diag.ServiceAccount,
+				ConfigKeys:         diag.ConfigKeys{PrivateKeyPath: path},
+				ServiceAccountInfo: diag.ServiceAccountInfo{Type: diag.ExternalAccount},
+			},
+			OAuthType: diag.ServiceAccount,
+			Endpoint:  ts.URL,
+			Endpoints: Endpoints{STSURL: ts.URL + "/sts"},
+			FailFast:  true,
+		}
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		chk := c.SimulateOAuthFlow(context.Background())
+
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] got: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
+		}
+		if chk.Code != tt.wantCode {
+			t.Errorf("[%s] got code: %s, want: %s", tt.desc, chk.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestSignAWSRequest(t *testing.T) {
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", Token: "session"}
+	now := time.Date(2020, 8, 11, 6, 55, 22, 0, time.UTC)
+	u := strings.Replace(awsCallerIdentityURL, "{region}", "us-east-1", 1)
+
+	token, err := signAWSRequest(u, "us-east-1", testAudience, creds, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := url.QueryUnescape(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		URL     string
+		Method  string
+		Headers []struct{ Key, Value string }
+	}
+	if err := json.Unmarshal([]byte(decoded), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != u || got.Method != "POST" {
+		t.Errorf("signAWSRequest() got request %s %s, want POST %s", got.Method, got.URL, u)
+	}
+	var keys []string
+	headers := make(map[string]string)
+	for _, h := range got.Headers {
+		keys = append(keys, h.Key)
+		headers[h.Key] = h.Value
+	}
+	wantKeys := "Authorization,Host,X-Amz-Date,X-Amz-Security-Token,X-Goog-Cloud-Target-Resource"
+	if strings.Join(keys, ",") != wantKeys {
+		t.Errorf("signAWSRequest() got headers %v, want %s", keys, wantKeys)
+	}
+	wantAuth := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20200811/us-east-1/sts/aws4_request, " +
+		"SignedHeaders=host;x-amz-date;x-amz-security-token;x-goog-cloud-target-resource, Signature="
+	if !strings.HasPrefix(headers["Authorization"], wantAuth) {
+		t.Errorf("signAWSRequest() got Authorization %s, want prefix %s", headers["Authorization"], wantAuth)
+	}
+	if headers["X-Amz-Date"] != "20200811T065522Z" || headers["Host"] != "sts.us-east-1.amazonaws.com" {
+		t.Errorf("signAWSRequest() got headers %v", headers)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains the firewall suggestions printed when the OAuth
+// redirect does not reach the local HTTP server.
+
+import (
+	"os"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// firewallSuggestions returns the operating system specific commands to
+// check and allow loopback connections to the given port.
+func firewallSuggestions(goos string, port int) string {
+	switch goos {
+	case "windows":
+		return i18n.Sprintf("Windows Firewall may be blocking the connection. To check for rules on port %[1]d, "+
+			"run in an administrator Command Prompt:\n"+
+			"  netsh advfirewall firewall show rule name=all dir=in | findstr %[1]d\n"+
+			"To allow loopback connections to port %[1]d, run:\n"+
+			"  netsh advfirewall firewall add rule name=\"Google Ads Doctor\" dir=in action=allow "+
+			"protocol=TCP localport=%[1]d remoteip=127.0.0.1\n", port)
+	case "darwin":
+		return i18n.Sprintf("The macOS application firewall may be blocking the connection. If you were "+
+			"prompted to allow incoming connections for this program, choose Allow. To check the firewall, run:\n"+
+			"  /usr/libexec/ApplicationFirewall/socketfilterfw --getglobalstate\n"+
+			"To allow this program, run:\n"+
+			"  sudo /usr/libexec/ApplicationFirewall/socketfilterfw --add %[1]s\n"+
+			"  sudo /usr/libexec/ApplicationFirewall/socketfilterfw --unblockapp %[1]s\n", executable())
+	case "linux":
+		return i18n.Sprintf("A local firewall may be blocking the connection. To check for rules on port %[1]d, run:\n"+
+			"  sudo iptables -L INPUT -n -v | grep -E 'lo|%[1]d'\n"+
+			"  sudo nft list ruleset | grep -E 'lo|%[1]d'\n"+
+			"To allow loopback connections to port %[1]d, run one of:\n"+
+			"  sudo iptables -I INPUT -i lo -p tcp --dport %[1]d -j ACCEPT\n"+
+			"  sudo nft add rule inet filter input iif lo tcp dport %[1]d accept\n", port)
+	default:
+		return i18n.Sprintf("Please make sure that your firewall allows loopback connections to port %d.\n", port)
+	}
+}
+
+// executable returns the path of this program for firewall rules.
+func executable() string {
+	if path, err := os.Executable(); err == nil {
+		return path
+	}
+	return os.Args[0]
+}
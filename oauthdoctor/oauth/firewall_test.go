@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFirewallSuggestions(t *testing.T) {
+	tests := []struct {
+		goos string
+		want []string
+	}{
+		{
+			goos: "windows",
+			want: []string{"netsh advfirewall", "localport=8080"},
+		},
+		{
+			goos: "darwin",
+			want: []string{"socketfilterfw --getglobalstate", "--unblockapp"},
+		},
+		{
+			goos: "linux",
+			want: []string{"iptables -I INPUT -i lo -p tcp --dport 8080", "nft add rule"},
+		},
+		{
+			goos: "plan9",
+			want: []string{"port 8080"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := firewallSuggestions(tt.goos, 8080)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("firewallSuggestions(%s, 8080) got=%s\nwant substring=%s", tt.goos, got, want)
+			}
+		}
+	}
+}
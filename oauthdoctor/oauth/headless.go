@@ -0,0 +1,63 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains the guidance printed in headless sessions, e.g. over
+// SSH, where the browser of the user runs on another machine.
+
+import (
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// detectHeadless returns the kind of headless session the doctor runs in,
+// or an empty string, see diag.Headless.
+var detectHeadless = diag.Headless
+
+// headlessNotice explains that the consent page must be opened on another
+// machine in the given kind of headless session.
+func headlessNotice(kind string) string {
+	switch kind {
+	case diag.SSHSession:
+		return i18n.T("This is an SSH session, so the URL cannot be opened in a browser or copied to " +
+			"the clipboard of this machine. Copy the URL and open it in a browser on your computer.")
+	case diag.ServiceSession:
+		return i18n.T("The doctor runs in a Windows service session, which has no desktop. Copy the URL " +
+			"and open it in a browser on another machine.")
+	default:
+		return i18n.T("This machine has no display, so the URL cannot be opened in a browser here. Copy " +
+			"the URL and open it in a browser on another machine.")
+	}
+}
+
+// headlessSuggestions returns how to complete the web flow, whose redirect
+// to a local HTTP server at the given port does not reach this machine from
+// the browser of another machine. Over SSH, the port can be forwarded to
+// the computer of the user.
+func headlessSuggestions(kind string, port int) string {
+	if kind == diag.SSHSession {
+		server := diag.SSHServer()
+		if server == "" {
+			server = "HOST"
+		}
+		return i18n.Sprintf("The OAuth redirect to http://localhost:%[1]d is sent by the browser of your "+
+			"computer, not this machine. Before you open the URL, forward the port from your computer with:\n"+
+			"  ssh -L %[1]d:localhost:%[1]d %[2]s\n"+
+			"Otherwise, generate a refresh token on your computer with oauthdoctor mint-token, or use the "+
+			"installed_app OAuth type, where you paste the authorization code here.\n", port, server)
+	}
+	return i18n.Sprintf("The OAuth redirect to http://localhost:%d cannot reach this machine from a browser "+
+		"on another machine. Generate a refresh token on a machine with a browser with oauthdoctor mint-token, "+
+		"or use the installed_app OAuth type, where you paste the authorization code here.\n", port)
+}
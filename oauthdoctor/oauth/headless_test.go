@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestHeadlessSuggestions(t *testing.T) {
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{
+			kind: diag.SSHSession,
+			want: []string{"ssh -L 8080:localhost:8080", "oauthdoctor mint-token"},
+		},
+		{
+			kind: diag.NoDisplay,
+			want: []string{"cannot reach this machine", "installed_app"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := headlessSuggestions(tt.kind, 8080)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("headlessSuggestions(%s, 8080) got=%s\nwant substring=%s", tt.kind, got, want)
+			}
+		}
+	}
+}
@@ -17,20 +17,22 @@
 package oauth
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/http/httputil"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 // This is a list of error codes (not comprehensive) returned by Google OAuth2
@@ -44,199 +46,383 @@ const (
 	MissingDevToken
 	Unauthenticated
 	Unauthorized
+	CustomerNotActive
+	DeadlineExceeded
+	InsufficientScope
+	RedirectURIMismatch
+	CustomerNotEnabled
+	DevTokenNotApproved
+	DevTokenProhibited
+	FederationFailed
+	UntrustedCertificate
+	ConsentDenied
+	AdminPolicyEnforced
 	UnknownError
 
 	GoogleAdsApiScope = "https://www.googleapis.com/auth/adwords"
 )
 
+// errorNames are the names of the built-in error codes used in check results.
+// The errors added by a loaded knowledge base are named by their entries.
+var errorNames = map[int32]string{
+	AccessNotPermittedForManagerAccount: "ACCESS_NOT_PERMITTED_FOR_MANAGER_ACCOUNT",
+	GoogleAdsAPIDisabled:                "GOOGLE_ADS_API_DISABLED",
+	InvalidClientInfo:                   "INVALID_CLIENT_INFO",
+	InvalidRefreshToken:                 "INVALID_REFRESH_TOKEN",
+	InvalidCustomerID:                   "INVALID_CUSTOMER_ID",
+	MissingDevToken:                     "MISSING_DEV_TOKEN",
+	Unauthenticated:                     "UNAUTHENTICATED",
+	Unauthorized:                        "UNAUTHORIZED",
+	CustomerNotActive:                   "CUSTOMER_NOT_ACTIVE",
+	DeadlineExceeded:                    "DEADLINE_EXCEEDED",
+	InsufficientScope:                   "INSUFFICIENT_SCOPE",
+	RedirectURIMismatch:                 "REDIRECT_URI_MISMATCH",
+	CustomerNotEnabled:                  "CUSTOMER_NOT_ENABLED",
+	DevTokenNotApproved:                 "DEVELOPER_TOKEN_NOT_APPROVED",
+	DevTokenProhibited:                  "DEVELOPER_TOKEN_PROHIBITED",
+	FederationFailed:                    "FEDERATION_FAILED",
+	UntrustedCertificate:                "UNTRUSTED_CERTIFICATE",
+	ConsentDenied:                       "CONSENT_DENIED",
+	AdminPolicyEnforced:                 "ADMIN_POLICY_ENFORCED",
+	UnknownError:                        "UNKNOWN_ERROR",
+}
+
 // Config is a required configuration for diagnosing the OAuth2 flow based on
 // the client library configuration.
 type Config struct {
 	ConfigFile diag.ConfigFile
 	CustomerID string
+	// Endpoint is the Google Ads API endpoint, see diag.ParseEndpoint. When
+	// empty, diag.DefaultEndpoint is used.
+	Endpoint string
+	// APIVersion is the Google Ads API version of the requests, e.g. v17,
+	// which is the newest the endpoint serves. When empty,
+	// DefaultAPIVersion is used.
+	APIVersion string
 	OAuthType  string
 	Verbose    bool
+	// Scopes are OAuth2 scopes requested in addition to GoogleAdsApiScope,
+	// e.g. "email" to show who authorized the refresh token.
+	Scopes []string
+	// RequestTimeout is the deadline of the Google Ads API request, like the
+	// one a client library sets on its calls. Zero means no deadline.
+	RequestTimeout time.Duration
+	// Endpoints override the URLs of the OAuth2 and token services.
+	Endpoints Endpoints
+	// KnowledgeBase recognizes and explains the errors. When nil, the
+	// built-in knowledge base is used.
+	KnowledgeBase KnowledgeBase
+	// HTTPClient sends the HTTP requests of the OAuth flows and the Google
+	// Ads API, e.g. through a proxy, or to record or replay them. When nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+	// TraceToken prints the requests to the OAuth2 token endpoint and their
+	// responses, with the secrets redacted.
+	TraceToken bool
+	// FailFast reports the first error of the OAuth flow without asking the
+	// user to fix it and without retrying.
+	FailFast bool
+	// AutoFix applies the fixes that need no input without asking, see
+	// report.Offer.
+	AutoFix bool
+	// Clipboard reads the auth code and the new developer token from the
+	// clipboard when the user presses Enter without typing them.
+	Clipboard bool
+	// QRCode also prints the URL of the consent page as a QR code, to open
+	// it on a phone. The web flow then reads the address of the redirect
+	// from the user instead of waiting for it.
+	QRCode bool
+	// Prompter asks the user for input. When nil, the input is read from
+	// stdin.
+	Prompter prompt.Prompter
+	// Reporter receives the output of the diagnosis. When nil, the output is
+	// printed with the standard logger.
+	Reporter report.Reporter
+
+	// tokenErr is the OAuth2 error of the last token request, if it failed.
+	tokenErr *tokenError
+	// client is the authorized HTTP client that last got the customer
+	// account, which CheckCustomer uses.
+	client *http.Client
+	// account is the customer account that was last got, if its JSON could
+	// be parsed.
+	account *customerAccount
+	// copied is the text that the doctor copied to the clipboard, which is
+	// not read back as a value.
+	copied string
+	// requestID is the ID of the last request to the Google Ads API, which
+	// the API support needs to look up a failed call.
+	requestID string
+}
+
+// customerAccount holds the fields of the customer resource that tell what
+// kind of account it is.
+type customerAccount struct {
+	Manager     bool `json:"manager"`
+	TestAccount bool `json:"testAccount"`
 }
 
 // ConfigWriter allows replacement of key by a given value in a configuration.
 type ConfigWriter interface {
-	ReplaceConfig(k, v string) string
+	ReplaceConfig(k, v string) (string, error)
 }
 
 var (
-	appVersion string
+	// terminal asks for input on stdin when the config has no prompter.
+	terminal prompt.Prompter = prompt.NewTerminal(os.Stdin, os.Stdout)
+)
 
-	stdinSanitizer = strings.NewReplacer("\n", "")
+// print sends msg to the reporter of the config.
+func (c *Config) print(msg string) {
+	c.reporter().Print(msg)
+}
 
-	readStdin = func() string {
-		reader := bufio.NewReader(os.Stdin)
-		str, err := reader.ReadString('\n')
-		if err != nil {
-			log.Printf("Error reading input (%s) from command line: %s", str, err)
-		}
+// reporter returns the reporter of the config.
+func (c *Config) reporter() report.Reporter {
+	if c.Reporter == nil {
+		return report.LogReporter{}
+	}
+	return c.Reporter
+}
 
-		return strings.TrimSpace(stdinSanitizer.Replace(str))
+// prompter returns the prompter of the config.
+func (c *Config) prompter() prompt.Prompter {
+	if c.Prompter == nil {
+		return terminal
 	}
-)
+	return c.Prompter
+}
 
 // SimulateOAuthFlow simulates the OAuth2 flows supported by the Google Ads API
-// client libraries.
-func (c *Config) SimulateOAuthFlow() {
+// client libraries and returns the result as a check. Cancelling ctx stops
+// the HTTP requests and the local HTTP server of the web flow.
+func (c *Config) SimulateOAuthFlow(ctx context.Context) report.Check {
+	ctx = c.tokenContext(ctx)
+	var err error
 	switch c.OAuthType {
 	case diag.Web:
-		c.simulateWebFlow()
+		err = c.simulateWebFlow(ctx)
 	case diag.InstalledApp:
-		c.simulateAppFlow()
+		err = c.simulateAppFlow(ctx)
 	case diag.ServiceAccount:
-		c.simulateServiceAccFlow()
+		if c.ConfigFile.IsExternalAccount() {
+			err = c.simulateExternalAccountFlow(ctx)
+		} else {
+			err = c.simulateServiceAccFlow(ctx)
+		}
+	}
+	chk := c.result(err)
+	if chk.Message != "" && err == nil {
+		c.print(chk.Message)
 	}
+	return chk
 }
 
-// decodeError checks the JSON response in the error and determines the error
-// code.
-func (c *Config) decodeError(err error) int32 {
-	errstr := err.Error()
-
-	if strings.Contains(errstr, "invalid_client") {
-		// Client ID and/or secret is invalid
-		return InvalidClientInfo
-	}
-	if strings.Contains(errstr, "unauthorized_client") {
-		// The given refresh token may not be generated with the given client ID
-		// and secret
-		return Unauthorized
+// result converts the final error of an OAuth flow simulation into a check
+// result.
+func (c *Config) result(err error) report.Check {
+	chk := report.Check{
+		ID:        report.OAuthCheck,
+		Name:      i18n.T("OAuth flow and API access"),
+		Status:    report.Pass,
+		RequestID: c.requestID,
 	}
-	if strings.Contains(errstr, "invalid_grant") {
-		// Refresh token is not valid for any users
-		return InvalidRefreshToken
-	}
-	if strings.Contains(errstr, "refresh token is not set") {
-		return InvalidRefreshToken
+	if err != nil {
+		chk.Status = report.Fail
+		code := c.decodeError(err)
+		chk.Code = c.KnowledgeBase.name(code)
+		chk.Message = err.Error()
+		chk.Explanation = c.message(c.KnowledgeBase.entry(code), err)
+		if c.KnowledgeBase.entry(code).Severity == "warning" {
+			chk.Status = report.Warn
+		}
+	} else {
+		chk.Message = c.accountKind()
 	}
-	if strings.Contains(errstr, "USER_PERMISSION_DENIED") {
-		// User doesn't have permission to access Google Ads account
-		return InvalidRefreshToken
+	return chk
+}
+
+// decodeError determines the error code from the type of the error, then from
+// the error of the token endpoint and the text of the error with the
+// knowledge base.
+func (c *Config) decodeError(err error) int32 {
+	// Workload identity federation errors were already diagnosed.
+	if _, ok := err.(*externalAccountError); ok {
+		return FederationFailed
 	}
-	if strings.Contains(errstr, "\"PERMISSION_DENIED\"") {
-		return GoogleAdsAPIDisabled
+	// A policy of the organization or of the account blocked the grant.
+	if c.adminPolicy(err) != "" {
+		return AdminPolicyEnforced
 	}
-	if strings.Contains(errstr, "UNAUTHENTICATED") {
-		return Unauthenticated
+	// The consent page returned an error instead of a code.
+	if _, ok := err.(*consentError); ok {
+		return ConsentDenied
 	}
-	if strings.Contains(errstr, "CANNOT_BE_EXECUTED_BY_MANAGER_ACCOUNT") {
-		// Request cannot be executed by a manager account
-		return AccessNotPermittedForManagerAccount
+	if diag.IsUnknownAuthority(err) {
+		// A proxy intercepts HTTPS traffic, so no request can succeed
+		return UntrustedCertificate
 	}
-	if strings.Contains(errstr, "DEVELOPER_TOKEN_PARAMETER_MISSING") {
-		return MissingDevToken
+
+	// The error returned by the token endpoint is more reliable than the
+	// text of the error wrapped by the OAuth2 library.
+	if c.tokenErr != nil {
+		for _, e := range c.KnowledgeBase.entries() {
+			if containsString(e.TokenErrors, c.tokenErr.Code) {
+				return e.code
+			}
+		}
 	}
-	if strings.Contains(errstr, "INVALID_CUSTOMER_ID") {
-		return InvalidCustomerID
+
+	errstr := err.Error()
+	for _, e := range c.KnowledgeBase.entries() {
+		if e.matches(errstr) {
+			return e.code
+		}
 	}
 	return UnknownError
 }
 
 // diagnose handles the error by guiding the user to take appropriate
 // actions to fix the OAuth2 error based on the error code.
-func (c *Config) diagnose(err error) {
+func (c *Config) diagnose(ctx context.Context, err error) {
 	// Print the given message from JSON response if there's any
 	var parsedMsg map[string]interface{}
 	if err := json.Unmarshal([]byte(err.Error()), &parsedMsg); err == nil {
-		errMsg := parsedMsg["error"].(map[string]interface{})["message"]
-		log.Print("JSON response error: " + errMsg.(string))
-	}
-
-	switch c.decodeError(err) {
-	case AccessNotPermittedForManagerAccount:
-		log.Print("ERROR: Your credentials are not permitted to access to a manager account." +
-			"\nPlease create your credentials with a Google Ads account with manager access.")
-	case GoogleAdsAPIDisabled:
-		log.Print("Press <Enter> to continue after you enable Google Ads API")
-		readStdin()
-	case InvalidClientInfo:
-		log.Print("ERROR: Your client ID and/or client secret may be invalid.")
-		replaceCloudCredentials(&c.ConfigFile)
-	case InvalidRefreshToken, Unauthorized:
-		log.Print("ERROR: Your refresh token may be invalid.")
-	case MissingDevToken:
-		log.Print("ERROR: Your developer token is missing in the configuration file")
-		replaceDevToken(&c.ConfigFile)
-	case Unauthenticated:
-		log.Print("ERROR: The login email may not have access to the given account.")
-	case InvalidCustomerID:
-		log.Print("ERROR: You customer ID is invalid.")
-	default:
-		var helperText string
-		switch c.ConfigFile.OAuthType {
-		case diag.ServiceAccount:
-			helperText = "Please verify the path of JSON key file and impersonate email (or delegated email)."
-		case diag.Web:
-			helperText = "Please verify your developer token, client ID and client secret."
-		case diag.InstalledApp:
-			helperText = "Please verify your developer token, client ID, client secret and refresh token."
+		apiErr, _ := parsedMsg["error"].(map[string]interface{})
+		if errMsg, ok := apiErr["message"].(string); ok {
+			c.print(i18n.T("JSON response error: ") + errMsg)
 		}
-		log.Print("ERROR: Your credentials are invalid but we cannot determine the exact error. " + helperText)
 	}
-}
 
-var (
-	getClientID = func() string {
-		fmt.Print("New Client ID >> ")
-		return readStdin()
+	if c.tokenErr != nil {
+		c.print(i18n.Sprintf("OAuth2 token endpoint error: %s", c.tokenErr))
 	}
 
-	getClientSecret = func() string {
-		fmt.Print("New Client Secret >> ")
-		return readStdin()
+	e := c.KnowledgeBase.entry(c.decodeError(err))
+	if e.Message != "" {
+		c.print(c.message(e, err))
 	}
-)
+	if e.Link != "" {
+		c.print(i18n.Sprintf("More information: %s", e.Link))
+	}
+	c.remedy(ctx, e.Remediation, err)
+}
+
+// timeoutGuidance explains how to change the client-side timeout of the
+// client library in c.ConfigFile.Lang.
+func (c *Config) timeoutGuidance() string {
+	switch c.ConfigFile.Lang {
+	case "java":
+		return i18n.T("Java: increase the total timeout in the retry settings of the service client, " +
+			"e.g. GoogleAdsServiceSettings.newBuilder().searchSettings().setRetrySettings(...).")
+	case "dotnet":
+		return i18n.T(".NET: increase the Timeout setting (in milliseconds) of GoogleAdsConfig, " +
+			"e.g. <add key=\"Timeout\" value=\"600000\"/> in App.config.")
+	case "php":
+		return i18n.T("PHP: pass a larger 'timeoutMillis' in the call options of the service method, " +
+			"or increase the total timeout in the retry settings.")
+	case "python":
+		return i18n.T("Python: pass a larger timeout argument (in seconds) to the service method, " +
+			"e.g. googleads_service.search(request=request, timeout=600).")
+	case "ruby":
+		return i18n.T("Ruby: increase the timeout in the call options of the service method, " +
+			"e.g. options: { timeout: 600 }.")
+	case "nodejs":
+		return i18n.T("Node.js: increase the timeout (in milliseconds) in the call options of the " +
+			"service method, e.g. { timeout: 600000 }.")
+	}
+	return i18n.T("Increase the timeout of the API calls in your client library.")
+}
 
 // replaceCloudCredentials prompts the user to create a new client ID and
 // secret and to then enter them at the prompt. The values entered will
 // replace the existing values in the client library configuration file.
-func replaceCloudCredentials(c ConfigWriter) {
-	log.Print("Follow this guide to setup your OAuth2 client ID and client secret: " +
+func (c *Config) replaceCloudCredentials(w ConfigWriter) error {
+	c.print(i18n.T("Follow this guide to setup your OAuth2 client ID and client secret: ") +
 		"https://developers.google.com/adwords/api/docs/guides/first-api-call#set_up_oauth2_authentication")
 
-	clientID := getClientID()
-	clientSecret := getClientSecret()
+	clientID, err := c.prompter().ReadLine(i18n.T("New Client ID >> "))
+	if err != nil {
+		return err
+	}
+	clientSecret, err := c.prompter().ReadLine(i18n.T("New Client Secret >> "))
+	if err != nil {
+		return err
+	}
 
-	c.ReplaceConfig(diag.ClientID, clientID)
-	c.ReplaceConfig(diag.ClientSecret, clientSecret)
+	if _, err := w.ReplaceConfig(diag.ClientID, clientID); err != nil {
+		return err
+	}
+	_, err = w.ReplaceConfig(diag.ClientSecret, clientSecret)
+	return err
 }
 
 // replaceDevToken guides the user to retrieve their developer token and
 // enter it at the prompt. The entered value will replace the existing
 // developer token in the client library configuration file.
-var replaceDevToken = func(c ConfigWriter) {
-	log.Print("Please follow this guide to retrieve your developer token: " +
+var replaceDevToken = func(c *Config, w ConfigWriter) error {
+	c.print(i18n.T("Please follow this guide to retrieve your developer token: ") +
 		"https://developers.google.com/adwords/api/docs/guides/signup#step-2")
-	log.Print("Pleae enter a new Developer Token here and it will replace " +
-		"the one in your client library configuration file")
+	c.print(i18n.T("Please enter a new Developer Token here and it will replace " +
+		"the one in your client library configuration file"))
 
-	fmt.Print("New Developer Token >> ")
-	devToken := readStdin()
+	devToken, err := c.readInput(i18n.T("New Developer Token >> "))
+	if err != nil {
+		return err
+	}
 
-	c.ReplaceConfig(diag.DevToken, devToken)
+	_, err = w.ReplaceConfig(diag.DevToken, devToken)
+	return err
 }
 
-// replaceRefreshToken asks the user if they want to replace the refresh
-// token in the configuration file with the newly generated value.
-func replaceRefreshToken(c ConfigWriter, refreshToken string) {
-	log.Print("Would you like to replace your refresh token in the " +
-		"client library config file with the new one generated?")
-
-	fmt.Print("Enter Y for Yes [Anything else is No] >> ")
-	answer := readStdin()
+// replaceRefreshToken offers to replace the refresh token in the
+// configuration file with the newly generated value.
+func (c *Config) replaceRefreshToken(w ConfigWriter, refreshToken string) error {
+	applied, err := report.Offer(&refreshTokenFix{w: w, token: refreshToken}, c.prompter(), c.reporter(), c.AutoFix)
+	if err == nil && !applied {
+		c.print(i18n.T("Refresh token is NOT replaced"))
+	}
+	return err
+}
 
-	if answer == "Y" {
-		c.ReplaceConfig(diag.RefreshToken, refreshToken)
-	} else {
-		log.Print("Refresh token is NOT replaced")
+// copyToClipboard writes text to the system clipboard.
+var copyToClipboard = diag.CopyToClipboard
+
+// showAuthURL prints the URL of the consent page and copies it to the
+// clipboard, so users do not need to select it in the terminal. In a
+// container or a headless session, there is no clipboard of the user to
+// copy it to.
+func (c *Config) showAuthURL(url string) {
+	c.print(i18n.Sprintf("Visit the URL for the auth dialog:\n%s\n", url))
+	if c.QRCode {
+		c.showQRCode(url)
+	}
+	if rt := detectContainer(); rt != "" {
+		c.print(i18n.Sprintf("The doctor runs in a %s container, which cannot open a browser or use your "+
+			"clipboard. Copy the URL and open it in a browser on your computer.", rt))
+		return
+	}
+	if kind := detectHeadless(); kind != "" {
+		c.print(headlessNotice(kind))
+		return
+	}
+	if err := copyToClipboard(url); err == nil {
+		c.copied = url
+		c.print(i18n.T("The URL has been copied to your clipboard."))
 	}
 }
 
-var oauthEndpoint = google.Endpoint
+// scopes returns the OAuth2 scopes to request: GoogleAdsApiScope followed by
+// c.Scopes without duplicates.
+func (c *Config) scopes() []string {
+	scopes := []string{GoogleAdsApiScope}
+	for _, s := range c.Scopes {
+		if s = strings.TrimSpace(s); s != "" && !diag.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
 
 // oauth2Conf creates a corresponding OAuth2 config struct based on the
 // given configuration details. This is only applicable when a refresh token
@@ -246,45 +432,124 @@ func (c *Config) oauth2Conf(redirectURL string) *oauth2.Config {
 		ClientID:     c.ConfigFile.ConfigKeys.ClientID,
 		ClientSecret: c.ConfigFile.ClientSecret,
 		RedirectURL:  redirectURL,
-		Scopes:       []string{GoogleAdsApiScope},
-		Endpoint:     oauthEndpoint,
+		Scopes:       c.scopes(),
+		Endpoint:     c.endpoint(),
 	}
 }
 
 // Given the auth code returned after the authentication and authorization
 // step, oauth2Client creates a HTTP client with an authorized access token.
-func (c *Config) oauth2Client(code string) (*http.Client, string) {
+// It also returns the refresh token issued with the access token.
+func (c *Config) oauth2Client(ctx context.Context, code string) (*http.Client, string, error) {
 	conf := c.oauth2Conf(InstalledAppRedirectURL)
 	// Handle the exchange code to initiate a transport.
-	token, err := conf.Exchange(oauth2.NoContext, code)
+	token, err := conf.Exchange(ctx, code)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", err
 	}
-	return conf.Client(oauth2.NoContext, token), token.RefreshToken
+	return conf.Client(ctx, token), token.RefreshToken, nil
 }
 
-var apiURL = "https://googleads.googleapis.com/v8/customers/"
+// DefaultAPIVersion is the Google Ads API version of the requests when the
+// versions that the endpoint serves are unknown.
+const DefaultAPIVersion = "v8"
 
-// getAccount makes a HTTP request to Google Ads API customer account
-// endpoint and parses the JSON response.
-func (c *Config) getAccount(client *http.Client) (*bytes.Buffer, error) {
-	req, err := http.NewRequest("GET", apiURL+c.CustomerID, nil)
+// apiVersion returns the Google Ads API version of the requests.
+func (c *Config) apiVersion() string {
+	if c.APIVersion == "" {
+		return DefaultAPIVersion
+	}
+	return c.APIVersion
+}
+
+// customerURL returns the REST URL of the customer account in c.Endpoint.
+func (c *Config) customerURL() (string, error) {
+	u, err := diag.ParseEndpoint(c.Endpoint)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return u.String() + "/" + c.apiVersion() + "/customers/" + c.CustomerID, nil
+}
+
+// getAccount makes a HTTP request to Google Ads API customer account
+// endpoint and parses the JSON response. When the customer is a manager
+// account, the user may choose one of its client accounts to retry with.
+func (c *Config) getAccount(ctx context.Context, client *http.Client) (*bytes.Buffer, error) {
+	c.printOAuthClient(ctx, client)
+
+	accountInfo, err := c.fetchAccount(ctx, client)
+	if err != nil && !c.FailFast && c.decodeError(err) == AccessNotPermittedForManagerAccount {
+		accountInfo, err = c.retryClientAccount(ctx, client, err)
+	}
+	if err == nil {
+		c.client = client
+		c.account = nil
+		var account customerAccount
+		if json.Unmarshal(accountInfo.Bytes(), &account) == nil {
+			c.account = &account
+		}
+	}
+	return accountInfo, err
+}
+
+// accountKind tells whether the customer account is a test account, and
+// what it means for the developer token. It returns an empty string when the
+// account is not known.
+func (c *Config) accountKind() string {
+	if c.account == nil {
+		return ""
+	}
+	cid := report.FormatCustomerID(c.CustomerID)
+	var msg string
+	if c.account.TestAccount {
+		msg = i18n.Sprintf("Account %s is a test account: it serves no ads, and developer tokens of any "+
+			"access level can use it.", cid)
+	} else {
+		// A developer token with test access gets DEVELOPER_TOKEN_NOT_APPROVED
+		// from production accounts.
+		msg = i18n.Sprintf("Account %s is a production account, so your developer token is approved "+
+			"for production use.", cid)
+	}
+	if c.account.Manager {
+		msg += " " + i18n.T("It is a manager account.")
 	}
+	return msg
+}
 
+// setAPIHeaders sets the headers of the Google Ads API requests.
+func (c *Config) setAPIHeaders(req *http.Request) {
 	req.Header.Set("user-agent", userAgent())
 	req.Header.Set("developer-token", c.ConfigFile.DevToken)
 	if c.ConfigFile.LoginCustomerID != "" {
 		req.Header.Set("login-customer-id", c.ConfigFile.LoginCustomerID)
 	}
+}
+
+// fetchAccount gets the customer account c.CustomerID from the Google Ads
+// API.
+func (c *Config) fetchAccount(ctx context.Context, client *http.Client) (*bytes.Buffer, error) {
+	customerURL, err := c.customerURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", customerURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+	c.setAPIHeaders(req)
 
 	if c.Verbose {
 		dump, err := httputil.DumpRequestOut(req, true)
 		if err != nil {
-			log.Printf("Error printing HTTP request: %s", err)
+			c.print(i18n.Sprintf("Error printing HTTP request: %s", err))
 		}
-		log.Printf("Making a HTTP Request to Google Ads API:\n%v\n", c.sanitizeOutput(string(dump)))
+		c.print(i18n.Sprintf("Making a HTTP Request to Google Ads API:\n%v\n", c.sanitizeOutput(string(dump))))
 	}
 
 	resp, err := client.Do(req)
@@ -292,6 +557,7 @@ func (c *Config) getAccount(client *http.Client) (*bytes.Buffer, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	c.requestID = resp.Header.Get("request-id")
 
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(resp.Body)
@@ -300,7 +566,11 @@ func (c *Config) getAccount(client *http.Client) (*bytes.Buffer, error) {
 	json.Unmarshal(buf.Bytes(), &jsonBody)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("A HTTP Status (%s) is returned while calling %s", resp.Status, apiURL+c.CustomerID)
+		// Return the JSON error body, if any, so it can be diagnosed.
+		if jsonBody["error"] != nil {
+			return nil, fmt.Errorf("%s", buf.String())
+		}
+		return nil, i18n.Errorf("A HTTP Status (%s) is returned while calling %s", resp.Status, customerURL)
 	}
 
 	if jsonBody["error"] != nil {
@@ -310,29 +580,21 @@ func (c *Config) getAccount(client *http.Client) (*bytes.Buffer, error) {
 	return buf, nil
 }
 
-// userAgent returns a User-Agent HTTP header for this tool.
-func userAgent() string {
-	ua := "google-ads-doctor/"
-	if appVersion != "" {
-		ua += appVersion
-	} else {
-		ua += "source"
-	}
-	return ua
-}
-
 func (c *Config) sanitizeOutput(s string) string {
 	return strings.ReplaceAll(s, c.ConfigFile.DevToken, "REDACTED")
 }
 
-// ReadCustomerID retrieves the CID from stdin.
-func ReadCustomerID() string {
+// ReadCustomerID asks the user for a CID until one is entered.
+func (c *Config) ReadCustomerID() (string, error) {
 	for {
-		log.Print("Please enter a Google Ads account ID:")
-		customerID := readStdin()
+		c.print(i18n.T("Please enter a Google Ads account ID:"))
+		customerID, err := c.prompter().ReadLine("")
+		if err != nil {
+			return "", err
+		}
 
 		if customerID != "" {
-			return strings.ReplaceAll(customerID, "-", "")
+			return strings.ReplaceAll(customerID, "-", ""), nil
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package oauth
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -9,17 +10,21 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+	"golang.org/x/oauth2/google"
 )
 
 type FakeConfig struct {
 	cfgFile diag.ConfigFile
 }
 
-func (c *FakeConfig) ReplaceConfig(k, v string) string {
+func (c *FakeConfig) ReplaceConfig(k, v string) (string, error) {
 	c.cfgFile.SetConfigKeys(k, v)
-	return ""
+	return "", nil
 }
 
 func TestDiagnose(t *testing.T) {
@@ -27,10 +32,10 @@ func TestDiagnose(t *testing.T) {
 	defer enableStdio()
 
 	origFn := replaceDevToken
-	replaceDevToken = func(c ConfigWriter) {}
+	replaceDevToken = func(c *Config, w ConfigWriter) error { return nil }
 	defer func() { replaceDevToken = origFn }()
 
-	c := Config{}
+	c := Config{Prompter: prompt.NonInteractive{}}
 
 	tests := []struct {
 		desc     string
@@ -62,6 +67,31 @@ func TestDiagnose(t *testing.T) {
 			filepath: "testdata/permission_denied.json",
 			want:     "refresh token may be invalid",
 		},
+		{
+			desc:     "Check CustomerNotActive",
+			filepath: "testdata/customer_not_active.json",
+			want:     "is not active",
+		},
+		{
+			desc:     "Check CustomerNotEnabled",
+			filepath: "testdata/customer_not_enabled.json",
+			want:     "is cancelled or not enabled",
+		},
+		{
+			desc:     "Check DevTokenNotApproved",
+			filepath: "testdata/dev_token_not_approved.json",
+			want:     "developer token only has test access",
+		},
+		{
+			desc:     "Check DevTokenProhibited",
+			filepath: "testdata/dev_token_prohibited.json",
+			want:     "The project of your OAuth client is 123456789",
+		},
+		{
+			desc:     "Check DeadlineExceeded",
+			filepath: "testdata/deadline_exceeded.json",
+			want:     "did not complete before its deadline",
+		},
 		{
 			desc:     "Check undetermined error",
 			filepath: "testdata/undetermined_error.json",
@@ -78,7 +108,7 @@ func TestDiagnose(t *testing.T) {
 			t.Fatalf("Problem opening test file: %s", err)
 		}
 
-		c.diagnose(fmt.Errorf(string(content)))
+		c.diagnose(context.Background(), fmt.Errorf(string(content)))
 
 		if !strings.Contains(got.String(), tt.want) {
 			t.Errorf("[%s] got: (%s). Should have text (%s).", tt.desc, got.String(), tt.want)
@@ -89,13 +119,6 @@ func TestDiagnose(t *testing.T) {
 func TestReplaceCloudCredentials(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
-	originalReadID := getClientID
-	originalReadSecret := getClientSecret
-	defer func() {
-		getClientID = originalReadID
-		getClientSecret = originalReadSecret
-	}()
-
 	test := struct {
 		desc         string
 		c            FakeConfig
@@ -115,15 +138,13 @@ func TestReplaceCloudCredentials(t *testing.T) {
 		clientSecret: "newSecret",
 	}
 
-	getClientID = func() string {
-		return test.clientID
+	c := Config{
+		Prompter: prompt.NewTerminal(strings.NewReader(test.clientID+"\n"+test.clientSecret+"\n"), ioutil.Discard),
 	}
-	getClientSecret = func() string {
-		return test.clientSecret
+	if err := c.replaceCloudCredentials(&test.c); err != nil {
+		t.Errorf("[%s] replaceCloudCredentials() returned error: %s", test.desc, err)
 	}
 
-	replaceCloudCredentials(&test.c)
-
 	if test.c.cfgFile.ConfigKeys.ClientID != test.clientID || test.c.cfgFile.ClientSecret != test.clientSecret {
 		t.Errorf("[%s] got: (ClientID=%s, ClientSecret=%s), want: (ClientID=%s, ClientSecret=%s)",
 			test.desc, test.c.cfgFile.ConfigKeys.ClientID, test.c.cfgFile.ClientSecret, test.clientID, test.clientSecret)
@@ -139,11 +160,18 @@ func disableStdio(t *testing.T) func() {
 	if err != nil {
 		t.Fatalf("Unable to create /dev/null: %s", err)
 	}
-	stdin := readStdin
+	clipboard := copyToClipboard
+	copyToClipboard = func(string) error { return fmt.Errorf("clipboard disabled") }
+	container := detectContainer
+	detectContainer = func() string { return "" }
+	headless := detectHeadless
+	detectHeadless = func() string { return "" }
 
 	enableStdio := func() {
 		os.Stdout = stdout
-		readStdin = stdin
+		copyToClipboard = clipboard
+		detectContainer = container
+		detectHeadless = headless
 	}
 
 	return enableStdio
@@ -169,11 +197,10 @@ func TestReplaceDevToken(t *testing.T) {
 		want: "newDevToken",
 	}
 
-	readStdin = func() string {
-		return test.want
+	c := Config{
+		Prompter: prompt.NewTerminal(strings.NewReader(test.want+"\n"), ioutil.Discard),
 	}
-
-	replaceDevToken(&test.c)
+	replaceDevToken(&c, &test.c)
 
 	if test.want != test.c.cfgFile.DevToken {
 		t.Errorf("[%s] got: %s, want: %s", test.desc, test.c.cfgFile.DevToken, test.want)
@@ -220,11 +247,10 @@ func TestReplaceRefreshToken(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		readStdin = func() string {
-			return test.stdin
+		c := Config{
+			Prompter: prompt.NewTerminal(strings.NewReader(test.stdin+"\n"), ioutil.Discard),
 		}
-
-		replaceRefreshToken(&test.c, test.input)
+		c.replaceRefreshToken(&test.c, test.input)
 
 		if test.c.cfgFile.RefreshToken != test.want {
 			t.Errorf("[%s] got: %s, want: %s", test.desc, test.c.cfgFile.RefreshToken, test.want)
@@ -234,10 +260,11 @@ func TestReplaceRefreshToken(t *testing.T) {
 
 func TestGetAccount(t *testing.T) {
 	tests := []struct {
-		desc string
-		c    Config
-		ts   *httptest.Server
-		want string
+		desc          string
+		c             Config
+		ts            *httptest.Server
+		want          string
+		wantRequestID string
 	}{
 		{
 			desc: "developer-token is in HTTP header",
@@ -253,6 +280,22 @@ func TestGetAccount(t *testing.T) {
 			})),
 			want: "devToken",
 		},
+		{
+			desc: "Customer is fetched from the endpoint",
+			c:    Config{CustomerID: "1234567890"},
+			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.URL.Path))
+			})),
+			want: "/v8/customers/1234567890",
+		},
+		{
+			desc: "Customer is fetched with the API version",
+			c:    Config{CustomerID: "1234567890", APIVersion: "v17"},
+			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.URL.Path))
+			})),
+			want: "/v17/customers/1234567890",
+		},
 		{
 			desc: "login-customer-id is in HTTP header",
 			c: Config{
@@ -283,13 +326,24 @@ func TestGetAccount(t *testing.T) {
 			})),
 			want: "This is an error",
 		},
+		{
+			desc: "Error (JSON) is returned with a HTTP error status",
+			c:    Config{},
+			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("request-id", "abc123")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error": {"status": "PERMISSION_DENIED"}}`))
+			})),
+			want:          `{"error": {"status": "PERMISSION_DENIED"}}`,
+			wantRequestID: "abc123",
+		},
 	}
 
 	for _, tt := range tests {
-		apiURL = tt.ts.URL
+		tt.c.Endpoint = tt.ts.URL
 		defer tt.ts.Close()
 
-		buf, err := tt.c.getAccount(tt.ts.Client())
+		buf, err := tt.c.getAccount(context.Background(), tt.ts.Client())
 		if err != nil && errstring(err) != tt.want {
 			t.Errorf("[%s] got: %s, want: %s", tt.desc, errstring(err), tt.want)
 		}
@@ -297,75 +351,266 @@ func TestGetAccount(t *testing.T) {
 		if buf != nil && buf.String() != tt.want {
 			t.Errorf("[%s] got: %s, want: %s", tt.desc, buf.String(), tt.want)
 		}
+		if tt.c.requestID != tt.wantRequestID {
+			t.Errorf("[%s] got request ID: %s, want: %s", tt.desc, tt.c.requestID, tt.wantRequestID)
+		}
 	}
 }
 
-func TestUserAgent(t *testing.T) {
+func TestGetAccountCancelled(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+
+	c := Config{CustomerID: "1234567890", Endpoint: ts.URL}
+	if _, err := c.getAccount(ctx, ts.Client()); !strings.Contains(errstring(err), context.Canceled.Error()) {
+		t.Errorf("getAccount() error: %s, want: %s", errstring(err), context.Canceled)
+	}
+}
+
+func TestReadCustomerID(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
 	tests := []struct {
-		desc  string
-		input string
-		want  string
+		desc     string
+		prompter prompt.Prompter
+		want     string
+		errstr   string
 	}{
 		{
-			desc:  "Display with version",
-			input: "1.0.3",
-			want:  "google-ads-doctor/1.0.3",
+			desc:     "Return a valid customer ID",
+			prompter: prompt.NewTerminal(strings.NewReader("123-456-7890\n"), ioutil.Discard),
+			want:     "1234567890",
+			errstr:   "nil",
+		},
+		{
+			desc:     "Return original string",
+			prompter: prompt.NewTerminal(strings.NewReader("abc\n"), ioutil.Discard),
+			want:     "abc",
+			errstr:   "nil",
+		},
+		{
+			desc:     "Ask again after an empty line",
+			prompter: prompt.NewTerminal(strings.NewReader("\n1234567890\n"), ioutil.Discard),
+			want:     "1234567890",
+			errstr:   "nil",
 		},
 		{
-			desc:  "Display without version",
-			input: "",
-			want:  "google-ads-doctor/source",
+			desc:     "No input",
+			prompter: prompt.NonInteractive{},
+			errstr:   prompt.ErrNoInput.Error(),
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.desc, func(t *testing.T) {
-			appVersion = tt.input
-			got := userAgent()
+	for _, test := range tests {
+		c := Config{Prompter: test.prompter}
+		got, err := c.ReadCustomerID()
+		if got != test.want {
+			t.Errorf("[%s] got: %s, want: %s\n", test.desc, got, test.want)
+		}
+		if errstring(err) != test.errstr {
+			t.Errorf("[%s] error: %s, want: %s\n", test.desc, errstring(err), test.errstr)
+		}
+	}
+}
 
-			if got != tt.want {
-				t.Errorf("got: %s, want: %s\n", got, tt.want)
-			}
-		})
+func errstring(err error) string {
+	if err != nil {
+		return err.Error()
 	}
+	return "nil"
 }
 
-func TestReadCustomerID(t *testing.T) {
+func TestGetAccountRequestTimeout(t *testing.T) {
 	enableStdio := disableStdio(t)
 	defer enableStdio()
 
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	c := Config{CustomerID: "1234567890", Endpoint: ts.URL, RequestTimeout: 10 * time.Millisecond}
+	_, err := c.getAccount(context.Background(), ts.Client())
+	if err == nil {
+		t.Fatal("getAccount() error: nil, want: a deadline error")
+	}
+	if got := errorNames[c.decodeError(err)]; got != "DEADLINE_EXCEEDED" {
+		t.Errorf("decodeError(%s) = %s, want: DEADLINE_EXCEEDED", err, got)
+	}
+}
+
+func TestResult(t *testing.T) {
 	tests := []struct {
-		desc  string
-		stdin string
-		want  string
+		desc       string
+		err        error
+		wantStatus report.Status
+		wantCode   string
 	}{
 		{
-			desc:  "Return a valid customer ID",
-			stdin: "123-456-7890",
-			want:  "1234567890",
+			desc:       "No error passes",
+			err:        nil,
+			wantStatus: report.Pass,
+		},
+		{
+			desc:       "Error code is named",
+			err:        fmt.Errorf(`{"error": {"status": "UNAUTHENTICATED"}}`),
+			wantStatus: report.Fail,
+			wantCode:   "UNAUTHENTICATED",
+		},
+		{
+			desc:       "Insufficient scope is not an API enablement error",
+			err:        fmt.Errorf(`{"error": {"message": "Request had insufficient authentication scopes.", "status": "PERMISSION_DENIED"}}`),
+			wantStatus: report.Fail,
+			wantCode:   "INSUFFICIENT_SCOPE",
+		},
+		{
+			desc:       "Cancelled account is not an API enablement error",
+			err:        fmt.Errorf(`{"error": {"status": "PERMISSION_DENIED", "details": [{"errors": [{"errorCode": {"customerError": "CUSTOMER_NOT_ENABLED"}}]}]}}`),
+			wantStatus: report.Fail,
+			wantCode:   "CUSTOMER_NOT_ENABLED",
 		},
 		{
-			desc:  "Return original string",
-			stdin: "abc",
-			want:  "abc",
+			desc:       "Client timeout is a deadline error",
+			err:        fmt.Errorf("Get https://googleads.googleapis.com: net/http: request canceled (Client.Timeout exceeded while awaiting headers)"),
+			wantStatus: report.Fail,
+			wantCode:   "DEADLINE_EXCEEDED",
+		},
+		{
+			desc:       "Intercepted HTTPS traffic is not a credentials error",
+			err:        fmt.Errorf("oauth2: cannot fetch token: Post https://oauth2.googleapis.com/token: x509: certificate signed by unknown authority"),
+			wantStatus: report.Fail,
+			wantCode:   "UNTRUSTED_CERTIFICATE",
 		},
 	}
 
-	for _, test := range tests {
-		readStdin = func() string {
-			return test.stdin
+	for _, tt := range tests {
+		c := Config{}
+		got := c.result(tt.err)
+		if got.Status != tt.wantStatus || got.Code != tt.wantCode {
+			t.Errorf("[%s] got: (%s, %s), want: (%s, %s)", tt.desc, got.Status, got.Code, tt.wantStatus, tt.wantCode)
 		}
+	}
+}
 
-		got := ReadCustomerID()
-		if got != test.want {
-			t.Errorf("[%s] got: %s, want: %s\n", test.desc, got, test.want)
+func TestAccountKind(t *testing.T) {
+	tests := []struct {
+		desc    string
+		account string
+		want    string
+	}{
+		{
+			desc:    "Test manager account",
+			account: `{"resourceName": "customers/1234567890", "manager": true, "testAccount": true}`,
+			want:    "Account 123-456-7890 is a test account: it serves no ads, and developer tokens of any access level can use it. It is a manager account.",
+		},
+		{
+			desc:    "Production account",
+			account: `{"resourceName": "customers/1234567890"}`,
+			want:    "Account 123-456-7890 is a production account, so your developer token is approved for production use.",
+		},
+		{
+			desc:    "Unknown account",
+			account: `not JSON`,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.account))
+		}))
+		defer ts.Close()
+
+		c := Config{CustomerID: "1234567890", Endpoint: ts.URL}
+		if _, err := c.getAccount(context.Background(), ts.Client()); err != nil {
+			t.Fatalf("[%s] getAccount() error: %s", tt.desc, err)
+		}
+		if got := c.result(nil).Message; got != tt.want {
+			t.Errorf("[%s] result() message: %q, want: %q", tt.desc, got, tt.want)
 		}
 	}
 }
 
-func errstring(err error) string {
-	if err != nil {
-		return err.Error()
+func TestEndpointOverrides(t *testing.T) {
+	c := Config{}
+	if got := c.endpoint(); got != google.Endpoint {
+		t.Errorf("endpoint() got: %v, want: %v", got, google.Endpoint)
 	}
-	return "nil"
+	if got := c.jwtTokenURL(); got != google.JWTTokenURL {
+		t.Errorf("jwtTokenURL() got: %s, want: %s", got, google.JWTTokenURL)
+	}
+	acct := &externalAccount{}
+	if got := c.stsURL(acct); got != defaultSTSURL {
+		t.Errorf("stsURL() got: %s, want: %s", got, defaultSTSURL)
+	}
+	if got := c.tokenInfoURL(); got != defaultTokenInfoURL {
+		t.Errorf("tokenInfoURL() got: %s, want: %s", got, defaultTokenInfoURL)
+	}
+	if got := c.revokeURL(); got != defaultRevokeURL {
+		t.Errorf("revokeURL() got: %s, want: %s", got, defaultRevokeURL)
+	}
+
+	e := Endpoints{AuthURL: "http://localhost:9000/auth", TokenURL: "http://localhost:9000/token"}
+	c = Config{Endpoints: e}
+	if got := c.endpoint(); got.AuthURL != e.AuthURL || got.TokenURL != e.TokenURL {
+		t.Errorf("endpoint() got: %v, want: %s and %s", got, e.AuthURL, e.TokenURL)
+	}
+	if got := c.jwtTokenURL(); got != e.TokenURL {
+		t.Errorf("jwtTokenURL() got: %s, want: %s", got, e.TokenURL)
+	}
+	if got := c.stsURL(acct); got != e.TokenURL {
+		t.Errorf("stsURL() got: %s, want: %s", got, e.TokenURL)
+	}
+
+	c.Endpoints.STSURL = "http://localhost:9000/sts"
+	if got := c.stsURL(acct); got != c.Endpoints.STSURL {
+		t.Errorf("stsURL() got: %s, want: %s", got, c.Endpoints.STSURL)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Proxy")
+		w.Write([]byte(`{"scope": "https://www.googleapis.com/auth/adwords"}`))
+	}))
+	defer ts.Close()
+
+	c := Config{
+		Endpoints:  Endpoints{TokenInfoURL: ts.URL},
+		HTTPClient: &http.Client{Transport: headerTransport{"X-Proxy", "yes"}, Timeout: time.Minute},
+	}
+	if _, err := c.getTokenInfo(context.Background(), "token"); err != nil {
+		t.Fatalf("getTokenInfo() error: %s", err)
+	}
+	if got != "yes" {
+		t.Errorf("getTokenInfo() sent X-Proxy: %q, want: yes", got)
+	}
+	traced := c.clientWith(&tokenTracer{c: &c, base: c.transport()})
+	if traced.Timeout != time.Minute || c.HTTPClient.Transport != (headerTransport{"X-Proxy", "yes"}) {
+		t.Errorf("clientWith() got timeout %s, want: 1m0s and the client of the config unchanged", traced.Timeout)
+	}
+}
+
+// headerTransport sets a header on the requests.
+type headerTransport struct {
+	key, value string
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set(t.key, t.value)
+	return http.DefaultTransport.RoundTrip(req)
 }
@@ -21,109 +21,149 @@ package oauth
 
 import (
 	"bytes"
-	"fmt"
-	"log"
+	"context"
 	"runtime"
 
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 	"golang.org/x/oauth2"
 )
 
 const (
 	// InstalledAppRedirectURL is the redirect URL for the web flow.
 	InstalledAppRedirectURL = "urn:ietf:wg:oauth:2.0:oob"
+	// WebRedirectURL is the redirect URL of the web flow, which must be an
+	// authorized redirect URI of the OAuth client.
+	WebRedirectURL = "http://localhost:8080"
 )
 
 // This function simulates the installed app flow to see if it succeeds
 // or fails. If it fails, it will try to examine the error and prompt user
-// to fix it. Then it retries to connect again and prints the result of the
-// 2nd attempt.
-func (c *Config) simulateAppFlow() {
+// to fix it. Then it retries to connect again until all the errors are
+// fixed, see fixAndRetry, unless c.FailFast is set. It returns the error of
+// the last attempt.
+func (c *Config) simulateAppFlow(ctx context.Context) error {
 	var refreshToken string
 
-	accountInfo, err := c.connectWithRefreshToken()
-	if err != nil {
-		if c.Verbose {
-			log.Print(err)
+	accountInfo, err := c.connectWithRefreshToken(ctx)
+	err = c.fixAndRetry(ctx, err, func(err error) error {
+		info, token, rErr := c.reconnect(ctx, err)
+		accountInfo = info
+		if token != "" {
+			// The next attempts, e.g. after a new developer token, use
+			// the new refresh token.
+			refreshToken = token
+			c.ConfigFile.RefreshToken = token
 		}
-		c.diagnose(err)
-		accountInfo, refreshToken, err = c.reconnect(err)
-	}
+		return rErr
+	})
 
 	if err == nil {
 		if c.Verbose {
-			log.Print(accountInfo)
+			c.print(accountInfo.String())
 		}
-		log.Println("SUCCESS: OAuth test passed with given config file settings.")
+		c.print(i18n.T("SUCCESS: OAuth test passed with given config file settings."))
 
 		if refreshToken != "" {
-			replaceRefreshToken(&c.ConfigFile, refreshToken)
+			if rErr := c.replaceRefreshToken(&c.ConfigFile, refreshToken); rErr != nil {
+				c.print(rErr.Error())
+			}
 		}
 	} else {
 		if c.Verbose {
-			log.Println(err)
+			c.print(err.Error())
 		}
-		log.Println("ERROR: OAuth test failed.")
+		c.print(i18n.T("ERROR: OAuth test failed."))
 	}
+	return err
 }
 
 // This function connects with OAuth2 based on the given error and then
 // sends a HTTP request to Google Ads API to get account info.
-func (c *Config) reconnect(err error) (*bytes.Buffer, string, error) {
-	switch c.decodeError(err) {
+func (c *Config) reconnect(ctx context.Context, err error) (*bytes.Buffer, string, error) {
+	code := c.decodeError(err)
+	if c.cannotFix(code, err) {
+		// A new refresh token would not help.
+		return nil, "", err
+	}
+	switch code {
 	case GoogleAdsAPIDisabled:
-		accountInfo, oErr := c.connectWithRefreshToken()
+		accountInfo, oErr := c.connectWithRefreshToken(ctx)
 		return accountInfo, "", oErr
 	case InvalidCustomerID:
-		c.CustomerID = ReadCustomerID()
-		accountInfo, oErr := c.connectWithRefreshToken()
+		cid, rErr := c.ReadCustomerID()
+		if rErr != nil {
+			return nil, "", rErr
+		}
+		c.CustomerID = cid
+		accountInfo, oErr := c.connectWithRefreshToken(ctx)
 		return accountInfo, "", oErr
 	case InvalidClientInfo:
-		accountInfo, oErr := c.connectWithRefreshToken()
+		accountInfo, oErr := c.connectWithRefreshToken(ctx)
 		return accountInfo, "", oErr
 	case AccessNotPermittedForManagerAccount:
-		log.Print("Attempting to regenerate refresh token...")
-		return c.connectWithNoRefreshToken()
-	case InvalidRefreshToken:
-		log.Print("Attempting to regenerate refresh token...")
-		return c.connectWithNoRefreshToken()
+		c.print(i18n.T("Attempting to regenerate refresh token..."))
+		return c.connectWithNoRefreshToken(ctx)
+	case InvalidRefreshToken, InsufficientScope, Unauthenticated, Unauthorized:
+		c.print(i18n.T("Attempting to regenerate refresh token..."))
+		return c.connectWithNoRefreshToken(ctx)
 	case MissingDevToken:
-		accountInfo, oErr := c.connectWithRefreshToken()
+		accountInfo, oErr := c.connectWithRefreshToken(ctx)
 		return accountInfo, "", oErr
 	default:
-		log.Print("Attempting to regenerate refresh token...")
-		return c.connectWithNoRefreshToken()
+		return nil, "", err
 	}
 }
 
 // This function simulates the auth code generation step during the OAuth2
-// authentication and authorization step.
-func (c *Config) genAuthCode() string {
+// authentication and authorization step. The options are added to the URL of
+// the consent page.
+func (c *Config) genAuthCode(opts ...oauth2.AuthCodeOption) (string, error) {
 	conf := c.oauth2Conf(InstalledAppRedirectURL)
 
 	// Redirect the user to Google's consent page to ask for permission
 	// for the scopes specified above.
-	url := conf.AuthCodeURL("state", oauth2.AccessTypeOffline)
-	log.Printf("Visit the URL for the auth dialog:\n%s\n", url)
+	url := conf.AuthCodeURL("state", append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, opts...)...)
+	c.showAuthURL(url)
 
-	log.Print(genAuthCodePrompt(runtime.GOOS))
-	fmt.Print("Enter Code >> ")
+	shell := ""
+	if runtime.GOOS == "windows" {
+		shell = diag.WindowsShell()
+	}
+	c.print(genAuthCodePrompt(runtime.GOOS, shell))
 
-	return readStdin()
+	code, err := c.readInput(i18n.T("Enter Code >> "))
+	if err != nil {
+		return "", err
+	}
+	// The browser shows an error instead of a code, which users paste.
+	if cErr := parseConsentError(code); cErr != nil {
+		return "", cErr
+	}
+	return code, nil
 }
 
-// genAuthCodePrompt returns the operating specific command prompt.
-func genAuthCodePrompt(goos string) string {
+// genAuthCodePrompt returns the operating specific command prompt. On
+// Windows, shell selects the instructions for PowerShell or the Command
+// Prompt.
+func genAuthCodePrompt(goos, shell string) string {
 	var msg string
 
-	if goos == "windows" {
-		msg += "You are running Windows, so to properly copy and paste the URL "
-		msg += "into the command prompt:\n"
-		msg += "1) Ensure that 'Quick Edit' mode is ON for your Command Prompt\n"
-		msg += "2) Hold down the shift key\n"
-		msg += "3) Highlight the URL\n"
-		msg += "4) Right click on the highlighted area\n"
+	switch {
+	case goos == "windows" && shell == diag.PowerShell:
+		msg += i18n.T("You are running Windows PowerShell, so to properly copy and paste the URL:\n" +
+			"1) Highlight the URL with the mouse\n" +
+			"2) Press Enter or right click to copy it\n" +
+			"3) Paste the code below with a right click or Ctrl+V\n")
+	case goos == "windows":
+		msg += i18n.T("You are running Windows, so to properly copy and paste the URL " +
+			"into the command prompt:\n" +
+			"1) Ensure that 'Quick Edit' mode is ON for your Command Prompt\n" +
+			"2) Hold down the shift key\n" +
+			"3) Highlight the URL\n" +
+			"4) Right click on the highlighted area\n")
 	}
-	msg += "Copy the code here to continue:"
+	msg += i18n.T("Copy the code here to continue:")
 	return msg
 }
 
@@ -131,23 +171,29 @@ func genAuthCodePrompt(goos string) string {
 // for the refresh token. And then, it gets the account info. This function
 // is used based on the assumption of missing/incorrect refresh token in the
 // client library config file.
-func (c *Config) connectWithNoRefreshToken() (*bytes.Buffer, string, error) {
-	code := c.genAuthCode()
-	client, refreshToken := c.oauth2Client(code)
-	accountInfo, err := c.getAccount(client)
+func (c *Config) connectWithNoRefreshToken(ctx context.Context) (*bytes.Buffer, string, error) {
+	code, err := c.genAuthCode()
+	if err != nil {
+		return nil, "", err
+	}
+	client, refreshToken, err := c.oauth2Client(ctx, code)
+	if err != nil {
+		return nil, "", err
+	}
+	accountInfo, err := c.getAccount(ctx, client)
 	return accountInfo, refreshToken, err
 }
 
 // With refresh token given from client lib config file, it directly connects
 // with OAuth and get the account info.
-func (c *Config) connectWithRefreshToken() (*bytes.Buffer, error) {
+func (c *Config) connectWithRefreshToken(ctx context.Context) (*bytes.Buffer, error) {
 	conf := &oauth2.Config{
 		ClientID:     c.ConfigFile.ConfigKeys.ClientID,
 		ClientSecret: c.ConfigFile.ClientSecret,
-		Endpoint:     oauthEndpoint,
+		Endpoint:     c.endpoint(),
 	}
 	token := &oauth2.Token{RefreshToken: c.ConfigFile.RefreshToken}
-	client := conf.Client(oauth2.NoContext, token)
+	client := conf.Client(ctx, token)
 
-	return c.getAccount(client)
+	return c.getAccount(ctx, client)
 }
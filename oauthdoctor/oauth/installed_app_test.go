@@ -1,6 +1,8 @@
 package oauth
 
 import (
+	"context"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -8,10 +10,12 @@ import (
 	"testing"
 
 	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
-	"golang.org/x/oauth2"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
 )
 
-func setupFakeOAuthServer() (*httptest.Server, func()) {
+// setupFakeOAuthServer fakes the OAuth2 endpoints and returns them with a
+// function that stops the server.
+func setupFakeOAuthServer() (Endpoints, func()) {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("code=fakeauthcode"))
@@ -25,21 +29,28 @@ func setupFakeOAuthServer() (*httptest.Server, func()) {
 			"token_type":"bearer"}`))
 	})
 
+	mux.HandleFunc("/tokeninfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"aud":"1234567890-fakeclient.apps.googleusercontent.com",
+			"scope":"https://www.googleapis.com/auth/adwords",
+			"expires_in":"3599"}`))
+	})
+
 	server := httptest.NewServer(mux)
 
-	// overriding the endpoint for OAuth2 library
-	oauthEndpoint = oauth2.Endpoint{
-		AuthURL:  server.URL + "/auth",
-		TokenURL: server.URL + "/token",
+	endpoints := Endpoints{
+		AuthURL:      server.URL + "/auth",
+		TokenURL:     server.URL + "/token",
+		TokenInfoURL: server.URL + "/tokeninfo",
 	}
-
-	return server, func() {
+	return endpoints, func() {
 		server.Close()
 	}
 }
 
 func TestAppFlow(t *testing.T) {
-	_, close := setupFakeOAuthServer()
+	endpoints, close := setupFakeOAuthServer()
 	defer close()
 
 	enableStdio := disableStdio(t)
@@ -67,15 +78,30 @@ func TestAppFlow(t *testing.T) {
 		},
 		{
 			desc: "OAuth retry succeeds",
-			c:    Config{},
+			c: Config{
+				Prompter: prompt.NewTerminal(strings.NewReader("fakeauthcode\nN\n"), ioutil.Discard),
+			},
 			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Write([]byte(`{"resourceName": "customers/1234567890", "id": "1234567890"}`))
 			})),
 			want: "OAuth test passed",
 		},
+		{
+			desc: "OAuth is not retried with FailFast",
+			c: Config{
+				FailFast: true,
+				Prompter: prompt.NewTerminal(strings.NewReader("fakeauthcode\nN\n"), ioutil.Discard),
+			},
+			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"resourceName": "customers/1234567890", "id": "1234567890"}`))
+			})),
+			want: "OAuth test failed",
+		},
 		{
 			desc: "OAuth fails",
-			c:    Config{},
+			c: Config{
+				Prompter: prompt.NewTerminal(strings.NewReader("fakeauthcode\n"), ioutil.Discard),
+			},
 			ts: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusInternalServerError)
 			})),
@@ -84,13 +110,14 @@ func TestAppFlow(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		apiURL = tt.ts.URL
+		tt.c.Endpoint = tt.ts.URL
+		tt.c.Endpoints = endpoints
 		defer tt.ts.Close()
 
 		var got strings.Builder
 		log.SetOutput(&got)
 
-		tt.c.simulateAppFlow()
+		tt.c.simulateAppFlow(context.Background())
 
 		if !strings.Contains(got.String(), tt.want) {
 			t.Errorf("[%s] got: %s, want: %s", tt.desc, got.String(), tt.want)
@@ -102,11 +129,18 @@ func TestGenAuthCode(t *testing.T) {
 	var tests = []struct {
 		desc  string
 		input string
+		shell string
 		want  string
 	}{
 		{
 			input: "windows",
-			want:  "You are running Windows",
+			shell: diag.CommandPrompt,
+			want:  "You are running Windows, so",
+		},
+		{
+			input: "windows",
+			shell: diag.PowerShell,
+			want:  "You are running Windows PowerShell",
 		},
 		{
 			input: "linux",
@@ -115,9 +149,27 @@ func TestGenAuthCode(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		got := genAuthCodePrompt(tt.input)
+		got := genAuthCodePrompt(tt.input, tt.shell)
 		if !strings.HasPrefix(got, tt.want) {
-			t.Errorf("genAutCodePrompt(%s) got=%s\nwant=%s", tt.input, got, tt.want)
+			t.Errorf("genAutCodePrompt(%s, %s) got=%s\nwant=%s", tt.input, tt.shell, got, tt.want)
 		}
 	}
 }
+
+func TestOAuth2ClientExchangeFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Bad Request"}`))
+	}))
+	defer server.Close()
+
+	c := Config{Endpoints: Endpoints{AuthURL: server.URL + "/auth", TokenURL: server.URL + "/token"}}
+	client, refreshToken, err := c.oauth2Client(context.Background(), "badauthcode")
+	if !strings.Contains(errstring(err), "invalid_grant") {
+		t.Errorf("oauth2Client() error: %s, want: invalid_grant", errstring(err))
+	}
+	if client != nil || refreshToken != "" {
+		t.Errorf("oauth2Client() = (%v, %q), want: (nil, \"\")", client, refreshToken)
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// The automated actions of the knowledge base entries, which run after the
+// explanation is printed.
+const (
+	// remedyCloudCredentials asks for a new client ID and client secret.
+	remedyCloudCredentials = "replace_cloud_credentials"
+	// remedyDevToken asks for a new developer token.
+	remedyDevToken = "replace_dev_token"
+	// remedyEnableAPI links to the page that enables the Google Ads API in
+	// the project of the error, and waits until it is enabled.
+	remedyEnableAPI = "enable_api"
+	// remedyTokenProject names the project of the error.
+	remedyTokenProject = "name_project"
+	// remedyInterception looks for a proxy that intercepts HTTPS traffic.
+	remedyInterception = "interception_help"
+	// remedyTimeout explains the timeout of the client library.
+	remedyTimeout = "timeout_guidance"
+	// remedyConsent explains the error of the consent page.
+	remedyConsent = "consent_help"
+	// remedyAdminPolicy explains the policy that blocked the OAuth grant.
+	remedyAdminPolicy = "admin_policy_help"
+	// remedyVerify lists the settings of the OAuth type to verify.
+	remedyVerify = "verify_credentials"
+)
+
+// remedies are the valid Remediation values.
+var remedies = []string{remedyCloudCredentials, remedyDevToken, remedyEnableAPI, remedyTokenProject,
+	remedyInterception, remedyTimeout, remedyConsent, remedyAdminPolicy, remedyVerify}
+
+// The values of Entry.Arg, which is formatted into the message.
+const (
+	argCustomerID = "customer_id"
+	argProject    = "project"
+	argScope      = "scope"
+)
+
+// Entry is an error of the knowledge base: how to recognize it, how to
+// explain it and what to do about it. A published knowledge base is a JSON
+// array of entries, e.g.
+//
+//	[{"code": "RESOURCE_EXHAUSTED", "patterns": ["RESOURCE_EXHAUSTED"],
+//	  "message": "ERROR: The developer token exceeded its quota.",
+//	  "link": "https://developers.google.com/google-ads/api/docs/best-practices/quotas",
+//	  "unfixable": true}]
+type Entry struct {
+	// Code is the error code in the check results, e.g. CUSTOMER_NOT_ACTIVE.
+	Code string `json:"code"`
+	// TokenErrors are the error codes of the OAuth2 token endpoint, e.g.
+	// invalid_client, which are checked before the patterns.
+	TokenErrors []string `json:"token_errors,omitempty"`
+	// Patterns are regular expressions matched against the text of the
+	// error. Errors without patterns are recognized by their type.
+	Patterns []string `json:"patterns,omitempty"`
+	// Message is the explanation printed by the diagnosis. It is a format
+	// with one %s verb when Arg is set.
+	Message string `json:"message,omitempty"`
+	// Arg is the value of the %s verb of Message: customer_id, project (of
+	// the Google Cloud project in the error) or scope.
+	Arg string `json:"arg,omitempty"`
+	// Link is a page with more information.
+	Link string `json:"link,omitempty"`
+	// Severity is "error", the default, or "warning" when the OAuth check
+	// only warns about the error.
+	Severity string `json:"severity,omitempty"`
+	// Remediation is the automated action, e.g. replace_dev_token.
+	Remediation string `json:"remediation,omitempty"`
+	// Unfixable is true for the errors that the credentials do not cause, so
+	// retrying the OAuth flow will not help.
+	Unfixable bool `json:"unfixable,omitempty"`
+
+	code    int32
+	regexps []*regexp.Regexp
+}
+
+// KnowledgeBase is the knowledge base of the errors that a diagnosis
+// recognizes. The patterns are matched in order. A nil KnowledgeBase is the
+// built-in one.
+type KnowledgeBase []Entry
+
+// builtinKnowledgeBase is the built-in knowledge base, which is never
+// changed. An error that also contains a more general pattern, e.g.
+// "PERMISSION_DENIED", comes first.
+var builtinKnowledgeBase = mustCompile(KnowledgeBase{
+	{
+		Code:        errorNames[InvalidClientInfo],
+		TokenErrors: []string{"invalid_client"},
+		Patterns:    []string{"invalid_client"},
+		Message:     "ERROR: Your client ID and/or client secret may be invalid.",
+		Remediation: remedyCloudCredentials,
+	},
+	{
+		// The given refresh token may not be generated with the given
+		// client ID and secret.
+		Code:        errorNames[Unauthorized],
+		TokenErrors: []string{"unauthorized_client"},
+		Patterns:    []string{"unauthorized_client"},
+		Message:     "ERROR: Your refresh token may be invalid.",
+	},
+	{
+		// The refresh token is not valid for any user, or the user has no
+		// access to the Google Ads account.
+		Code:        errorNames[InvalidRefreshToken],
+		TokenErrors: []string{"invalid_grant"},
+		Patterns:    []string{"invalid_grant", "refresh token is not set", "USER_PERMISSION_DENIED"},
+		Message:     "ERROR: Your refresh token may be invalid.",
+	},
+	{
+		// The account is suspended for policy or billing reasons.
+		Code:     errorNames[CustomerNotActive],
+		Patterns: []string{"CUSTOMER_NOT_ACTIVE"},
+		Message: "ERROR: Authentication succeeded, but the Google Ads account %s " +
+			"is not active. This is usually caused by a policy or billing suspension, not by your credentials." +
+			"\nPlease sign in to the Google Ads UI (https://ads.google.com) and check the account's " +
+			"Billing and Policy manager pages.",
+		Arg:       argCustomerID,
+		Unfixable: true,
+	},
+	{
+		// The account is cancelled, or its setup was never completed.
+		Code:     errorNames[CustomerNotEnabled],
+		Patterns: []string{"CUSTOMER_NOT_ENABLED"},
+		Message: "ERROR: Authentication succeeded, but the Google Ads account %s is cancelled " +
+			"or not enabled (CUSTOMER_NOT_ENABLED). This is not caused by your credentials." +
+			"\nReactivate the account in the Google Ads UI (https://ads.google.com), or test with another " +
+			"account, e.g. a test account: https://developers.google.com/google-ads/api/docs/first-call/test-accounts",
+		Arg:       argCustomerID,
+		Unfixable: true,
+	},
+	{
+		// A developer token with test access called a production account.
+		Code:     errorNames[DevTokenNotApproved],
+		Patterns: []string{"DEVELOPER_TOKEN_NOT_APPROVED"},
+		Message: "ERROR: Your developer token only has test access, so it can only be used with " +
+			"test accounts, and account %s is a production account. This is not caused by your OAuth credentials." +
+			"\nApply for Basic access in the API Center of your manager account, or test with a test account: " +
+			"https://developers.google.com/google-ads/api/docs/first-call/test-accounts",
+		Arg:       argCustomerID,
+		Unfixable: true,
+	},
+	{
+		// The developer token is bound to another Google Cloud project.
+		Code:     errorNames[DevTokenProhibited],
+		Patterns: []string{"DEVELOPER_TOKEN_PROHIBITED"},
+		Message: "ERROR: Your developer token cannot be used with the Google Cloud project of your OAuth client " +
+			"(DEVELOPER_TOKEN_PROHIBITED). A developer token can only be used with the project of the first OAuth " +
+			"client that called the Google Ads API with it. This is not caused by an invalid client ID, client " +
+			"secret or refresh token.\nEither use an OAuth client of the project your developer token is bound " +
+			"to, or apply for a new developer token in the API Center of another Google Ads manager account for " +
+			"the project you use now.",
+		Remediation: remedyTokenProject,
+		Unfixable:   true,
+	},
+	{
+		// The token was not authorized for the Google Ads API scope.
+		Code:        errorNames[InsufficientScope],
+		TokenErrors: []string{"invalid_scope"},
+		Patterns:    []string{"ACCESS_TOKEN_SCOPE_INSUFFICIENT", "insufficient authentication scopes"},
+		Message: "ERROR: Your credentials were not authorized for the Google Ads API scope (%s). " +
+			"Generate a new refresh token that includes this scope.",
+		Arg: argScope,
+	},
+	{
+		// The status of the error is PERMISSION_DENIED, so it comes before
+		// GOOGLE_ADS_API_DISABLED.
+		Code:     errorNames[AccessNotPermittedForManagerAccount],
+		Patterns: []string{"CANNOT_BE_EXECUTED_BY_MANAGER_ACCOUNT"},
+		Message: "ERROR: Your credentials are not permitted to access to a manager account." +
+			"\nPlease create your credentials with a Google Ads account with manager access.",
+	},
+	{
+		Code:        errorNames[GoogleAdsAPIDisabled],
+		Patterns:    []string{`"PERMISSION_DENIED"`},
+		Message:     "ERROR: Google Ads API is not enabled in the Google Cloud project of your OAuth client.",
+		Remediation: remedyEnableAPI,
+	},
+	{
+		Code:     errorNames[Unauthenticated],
+		Patterns: []string{"UNAUTHENTICATED"},
+		Message:  "ERROR: The login email may not have access to the given account.",
+	},
+	{
+		Code:        errorNames[MissingDevToken],
+		Patterns:    []string{"DEVELOPER_TOKEN_PARAMETER_MISSING"},
+		Message:     "ERROR: Your developer token is missing in the configuration file",
+		Remediation: remedyDevToken,
+	},
+	{
+		Code:     errorNames[InvalidCustomerID],
+		Patterns: []string{"INVALID_CUSTOMER_ID"},
+		Message:  "ERROR: Your customer ID is invalid.",
+	},
+	{
+		// The request did not complete before the deadline.
+		Code:     errorNames[DeadlineExceeded],
+		Patterns: []string{"DEADLINE_EXCEEDED", regexp.QuoteMeta(context.DeadlineExceeded.Error()), `Client\.Timeout exceeded`},
+		Message: "ERROR: The request to Google Ads API did not complete before its deadline " +
+			"(DEADLINE_EXCEEDED). This is caused by a slow network or a slow request, not by your credentials.",
+		Remediation: remedyTimeout,
+		Unfixable:   true,
+	},
+	{
+		Code:        errorNames[RedirectURIMismatch],
+		TokenErrors: []string{"redirect_uri_mismatch"},
+		Message: "ERROR: The redirect URI is not registered for your OAuth client. Add it to the " +
+			"authorized redirect URIs of the client in the Google Cloud console, or use a client of the right type.",
+		Unfixable: true,
+	},
+	{
+		// A proxy intercepts HTTPS traffic, so no request can succeed.
+		Code: errorNames[UntrustedCertificate],
+		Message: "ERROR: The certificate of a Google server is not trusted by this machine. " +
+			"This is caused by the network, not by your credentials.",
+		Remediation: remedyInterception,
+		Unfixable:   true,
+	},
+	{
+		Code:        errorNames[ConsentDenied],
+		Remediation: remedyConsent,
+		Unfixable:   true,
+	},
+	{
+		Code:        errorNames[AdminPolicyEnforced],
+		Remediation: remedyAdminPolicy,
+		Unfixable:   true,
+	},
+	{
+		// Workload identity federation errors are diagnosed by the flow.
+		Code: errorNames[FederationFailed],
+	},
+	{
+		Code:        errorNames[UnknownError],
+		Message:     "ERROR: Your credentials are invalid but we cannot determine the exact error. ",
+		Remediation: remedyVerify,
+	},
+})
+
+// mustCompile compiles the built-in knowledge base, whose entries have the
+// error codes of errorNames.
+func mustCompile(kb KnowledgeBase) KnowledgeBase {
+	for i := range kb {
+		if err := kb[i].compile(); err != nil {
+			panic(err)
+		}
+		for code, name := range errorNames {
+			if name == kb[i].Code {
+				kb[i].code = code
+			}
+		}
+	}
+	return kb
+}
+
+// compile validates the entry and compiles its patterns.
+func (e *Entry) compile() error {
+	if e.Code == "" {
+		return i18n.Errorf("A knowledge base entry has no code")
+	}
+	if e.Remediation != "" && !containsString(remedies, e.Remediation) {
+		return i18n.Errorf("%s: unknown remediation %q. Values: %s", e.Code, e.Remediation, strings.Join(remedies, ", "))
+	}
+	if e.Arg != "" && e.Arg != argCustomerID && e.Arg != argProject && e.Arg != argScope {
+		return i18n.Errorf("%s: unknown arg %q. Values: %s, %s, %s", e.Code, e.Arg, argCustomerID, argProject, argScope)
+	}
+	if e.Severity != "" && e.Severity != "error" && e.Severity != "warning" {
+		return i18n.Errorf("%s: unknown severity %q. Values: error, warning", e.Code, e.Severity)
+	}
+	e.regexps = nil
+	for _, p := range e.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return i18n.Errorf("%s: invalid pattern %q: %s", e.Code, p, err)
+		}
+		e.regexps = append(e.regexps, re)
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true when one of the patterns of e matches errstr.
+func (e *Entry) matches(errstr string) bool {
+	for _, re := range e.regexps {
+		if re.MatchString(errstr) {
+			return true
+		}
+	}
+	return false
+}
+
+// entries returns the entries of kb, which are those of the built-in
+// knowledge base when kb is nil.
+func (kb KnowledgeBase) entries() KnowledgeBase {
+	if kb == nil {
+		return builtinKnowledgeBase
+	}
+	return kb
+}
+
+// entry returns the entry of code, or the one of UnknownError.
+func (kb KnowledgeBase) entry(code int32) *Entry {
+	entries := kb.entries()
+	var unknown *Entry
+	for i := range entries {
+		switch entries[i].code {
+		case code:
+			return &entries[i]
+		case UnknownError:
+			unknown = &entries[i]
+		}
+	}
+	return unknown
+}
+
+// name returns the name of the error code in check results, which is the
+// code of its entry for the errors added by a loaded knowledge base.
+func (kb KnowledgeBase) name(code int32) string {
+	for _, e := range kb.entries() {
+		if e.code == code {
+			return e.Code
+		}
+	}
+	return errorNames[code]
+}
+
+// commonErrorsURL is the page of the common errors of the Google Ads API,
+// which Link returns for the entries without a link.
+const commonErrorsURL = "https://developers.google.com/google-ads/api/docs/common-errors"
+
+// Link returns the page with more information about the error code of a
+// check result, or "" when the code is not in the knowledge base.
+func (kb KnowledgeBase) Link(code string) string {
+	for _, e := range kb.entries() {
+		if e.Code != code {
+			continue
+		}
+		if e.Link != "" {
+			return e.Link
+		}
+		return commonErrorsURL
+	}
+	return ""
+}
+
+// LoadKnowledgeBase returns the built-in knowledge base with the entries of
+// the JSON file at src, a path or an https URL of a published knowledge
+// base, which is downloaded with client. An entry replaces the built-in
+// entry of its code, and new codes are matched before the built-in ones.
+// The built-in knowledge base is not changed.
+func LoadKnowledgeBase(ctx context.Context, client *http.Client, src string) (KnowledgeBase, error) {
+	content, err := readKnowledgeBase(ctx, client, src)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, i18n.Errorf("Cannot parse the knowledge base %s: %s", src, err)
+	}
+	for i := range entries {
+		if err := entries[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	kb := append(KnowledgeBase(nil), builtinKnowledgeBase...)
+	var next int32
+	for _, e := range kb {
+		if e.code >= next {
+			next = e.code + 1
+		}
+	}
+	var added KnowledgeBase
+	for _, e := range entries {
+		replaced := false
+		for i := range kb {
+			if kb[i].Code == e.Code {
+				e.code = kb[i].code
+				kb[i] = e
+				replaced = true
+			}
+		}
+		for i := range added {
+			if added[i].Code == e.Code {
+				e.code = added[i].code
+				added[i] = e
+				replaced = true
+			}
+		}
+		if !replaced {
+			e.code = next
+			next++
+			added = append(added, e)
+		}
+	}
+	return append(added, kb...), nil
+}
+
+// readKnowledgeBase reads the knowledge base file or downloads it with
+// client.
+func readKnowledgeBase(ctx context.Context, client *http.Client, src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") {
+		return ioutil.ReadFile(src)
+	}
+	req, err := http.NewRequest("GET", src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, i18n.Errorf("Cannot download the knowledge base %s: %s", src, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// message formats the explanation of e for err.
+func (c *Config) message(e *Entry, err error) string {
+	switch e.Arg {
+	case argCustomerID:
+		return i18n.Sprintf(e.Message, c.CustomerID)
+	case argProject:
+		return i18n.Sprintf(e.Message, diag.DisabledAPIProject(err.Error()))
+	case argScope:
+		return i18n.Sprintf(e.Message, GoogleAdsApiScope)
+	}
+	return i18n.T(e.Message)
+}
+
+// remedy runs the automated action named remediation for err.
+func (c *Config) remedy(ctx context.Context, remediation string, err error) {
+	switch remediation {
+	case remedyCloudCredentials:
+		c.offer(&cloudCredentialsFix{c: c, w: &c.ConfigFile})
+	case remedyDevToken:
+		c.offer(&devTokenFix{c: c, w: &c.ConfigFile})
+	case remedyEnableAPI:
+		project := diag.DisabledAPIProject(err.Error())
+		if project != "" {
+			c.print(i18n.Sprintf("The project of your OAuth client is %s. Enable Google Ads API at %s",
+				project, diag.EnableAPIURL(project)))
+		}
+		if !c.FailFast {
+			c.waitForAPIEnabled(ctx, project)
+		}
+	case remedyTokenProject:
+		if project := diag.DisabledAPIProject(err.Error()); project != "" {
+			c.print(i18n.Sprintf("The project of your OAuth client is %s, which your developer token is "+
+				"not bound to.", project))
+		}
+	case remedyInterception:
+		if endpoint, err := diag.ParseEndpoint(c.Endpoint); err == nil {
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			c.print(diag.InterceptionHelp(ctx, endpoint))
+			cancel()
+		}
+	case remedyTimeout:
+		c.print(c.timeoutGuidance())
+	case remedyConsent:
+		if cErr, ok := err.(*consentError); ok {
+			c.print(consentHelp(cErr.code))
+		}
+	case remedyAdminPolicy:
+		c.print(adminPolicyHelp(c.adminPolicy(err)))
+	case remedyVerify:
+		switch c.ConfigFile.OAuthType {
+		case diag.ServiceAccount:
+			c.print(i18n.T("Please verify the path of JSON key file and impersonate email (or delegated email)."))
+		case diag.Web:
+			c.print(i18n.T("Please verify your developer token, client ID and client secret."))
+		case diag.InstalledApp:
+			c.print(i18n.T("Please verify your developer token, client ID, client secret and refresh token."))
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestKnowledgeBase(t *testing.T) {
+	var kb KnowledgeBase
+	for code, name := range errorNames {
+		e := kb.entry(code)
+		if e.Code != name {
+			t.Errorf("%s has no knowledge base entry", name)
+		}
+		if e.Message == "" && e.Remediation == "" && code != FederationFailed {
+			t.Errorf("%s is not explained", name)
+		}
+	}
+}
+
+func TestLoadKnowledgeBase(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	content := `[
+		{"code": "RESOURCE_EXHAUSTED", "patterns": ["RESOURCE_EXHAUSTED"],
+		 "message": "ERROR: The developer token exceeded its quota.",
+		 "link": "https://developers.google.com/google-ads/api/docs/best-practices/quotas",
+		 "severity": "warning", "unfixable": true},
+		{"code": "INVALID_CUSTOMER_ID", "patterns": ["INVALID_CUSTOMER_ID"],
+		 "message": "ERROR: %s is not a customer ID.", "arg": "customer_id"}
+	]`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	kb, err := LoadKnowledgeBase(context.Background(), ts.Client(), ts.URL)
+	if err != nil {
+		t.Fatalf("LoadKnowledgeBase() error: %s", err)
+	}
+
+	tests := []struct {
+		desc       string
+		err        error
+		wantCode   string
+		wantStatus report.Status
+		// wantExplanation is the explanation of the check result.
+		wantExplanation string
+		want            string
+	}{
+		{
+			desc:            "New error",
+			err:             fmt.Errorf(`{"error": {"code": 429, "status": "RESOURCE_EXHAUSTED"}}`),
+			wantCode:        "RESOURCE_EXHAUSTED",
+			wantStatus:      report.Warn,
+			wantExplanation: "ERROR: The developer token exceeded its quota.",
+			want:            "More information: https://developers.google.com/google-ads/api/docs/best-practices/quotas",
+		},
+		{
+			desc:            "Replaced error",
+			err:             fmt.Errorf(`{"error": {"code": 400, "status": "INVALID_CUSTOMER_ID"}}`),
+			wantCode:        "INVALID_CUSTOMER_ID",
+			wantStatus:      report.Fail,
+			wantExplanation: "ERROR: 1234567890 is not a customer ID.",
+			want:            "ERROR: 1234567890 is not a customer ID.",
+		},
+		{
+			desc:       "Built-in error",
+			err:        fmt.Errorf(`{"error": {"code": 403, "status": "CUSTOMER_NOT_ACTIVE"}}`),
+			wantCode:   "CUSTOMER_NOT_ACTIVE",
+			wantStatus: report.Fail,
+			want:       "account 1234567890 is not active",
+		},
+	}
+
+	for _, tt := range tests {
+		c := Config{CustomerID: "1234567890", FailFast: true, KnowledgeBase: kb}
+		chk := c.result(tt.err)
+		if chk.Code != tt.wantCode || chk.Status != tt.wantStatus {
+			t.Errorf("[%s] result() got %s %s, want: %s %s", tt.desc, chk.Status, chk.Code, tt.wantStatus, tt.wantCode)
+		}
+		if tt.wantExplanation != "" && chk.Explanation != tt.wantExplanation {
+			t.Errorf("[%s] result() explanation: %q, want: %q", tt.desc, chk.Explanation, tt.wantExplanation)
+		}
+
+		var got strings.Builder
+		log.SetOutput(&got)
+		c.diagnose(context.Background(), tt.err)
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] diagnose() got: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
+		}
+	}
+	c := Config{KnowledgeBase: kb}
+	if code := c.decodeError(fmt.Errorf("RESOURCE_EXHAUSTED")); !kb.unfixable(code) {
+		t.Errorf("unfixable(RESOURCE_EXHAUSTED) = false, want: true")
+	}
+
+	links := map[string]string{
+		"RESOURCE_EXHAUSTED":  "https://developers.google.com/google-ads/api/docs/best-practices/quotas",
+		"CUSTOMER_NOT_ACTIVE": commonErrorsURL,
+		"NOT_AN_ERROR":        "",
+	}
+	for code, want := range links {
+		if got := kb.Link(code); got != want {
+			t.Errorf("Link(%s) = %q, want: %q", code, got, want)
+		}
+	}
+
+	// Loading the knowledge base again returns the same entries, and the
+	// built-in knowledge base is not changed.
+	again, err := LoadKnowledgeBase(context.Background(), ts.Client(), ts.URL)
+	if err != nil {
+		t.Fatalf("LoadKnowledgeBase() again error: %s", err)
+	}
+	if len(again) != len(kb) {
+		t.Errorf("LoadKnowledgeBase() again got %d entries, want: %d", len(again), len(kb))
+	}
+	var builtin KnowledgeBase
+	if got := builtin.Link("RESOURCE_EXHAUSTED"); got != "" {
+		t.Errorf("Link(RESOURCE_EXHAUSTED) of the built-in knowledge base = %q, want: \"\"", got)
+	}
+	if got := (&Config{}).result(fmt.Errorf("RESOURCE_EXHAUSTED")).Code; got != "UNKNOWN_ERROR" {
+		t.Errorf("result() with the built-in knowledge base got code %s, want: UNKNOWN_ERROR", got)
+	}
+}
+
+func TestLoadKnowledgeBaseErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "knowledge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc    string
+		content string
+		want    string
+	}{
+		{
+			desc:    "Not JSON",
+			content: "code: QUOTA",
+			want:    "Cannot parse the knowledge base",
+		},
+		{
+			desc:    "Unknown remediation",
+			content: `[{"code": "QUOTA", "remediation": "retry_later"}]`,
+			want:    `unknown remediation "retry_later"`,
+		},
+		{
+			desc:    "Invalid pattern",
+			content: `[{"code": "QUOTA", "patterns": ["RESOURCE_(EXHAUSTED"]}]`,
+			want:    "invalid pattern",
+		},
+		{
+			desc:    "No code",
+			content: `[{"message": "ERROR: Quota"}]`,
+			want:    "has no code",
+		},
+	}
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("kb%d.json", i))
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		kb, err := LoadKnowledgeBase(context.Background(), http.DefaultClient, path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("[%s] LoadKnowledgeBase() error: %v, want: %s", tt.desc, err, tt.want)
+		}
+		if kb != nil {
+			t.Errorf("[%s] LoadKnowledgeBase() returned %d entries with the error", tt.desc, len(kb))
+		}
+	}
+}
+
+func TestLoadKnowledgeBaseClient(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"code": "RESOURCE_EXHAUSTED", "patterns": ["RESOURCE_EXHAUSTED"]}]`))
+	}))
+	defer ts.Close()
+
+	// The knowledge base is downloaded with the given client, which trusts
+	// the certificate of the server, not with http.DefaultClient.
+	if _, err := LoadKnowledgeBase(context.Background(), http.DefaultClient, ts.URL); err == nil {
+		t.Fatalf("LoadKnowledgeBase() with http.DefaultClient error: nil, want an error")
+	}
+	if _, err := LoadKnowledgeBase(context.Background(), ts.Client(), ts.URL); err != nil {
+		t.Errorf("LoadKnowledgeBase() with the client of the server error: %s", err)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains functions that find the client accounts of a manager
+// account, so the API call can be retried against one of them.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// maxClientAccounts is the maximum number of client accounts offered to the
+// user.
+const maxClientAccounts = 20
+
+// clientAccountsQuery selects the client accounts under a manager account.
+// The manager accounts in the hierarchy are excluded since they cannot be
+// tested either.
+var clientAccountsQuery = fmt.Sprintf("SELECT customer_client.id, customer_client.descriptive_name "+
+	"FROM customer_client WHERE customer_client.manager = false LIMIT %d", maxClientAccounts)
+
+// clientAccount is a client account under a manager account.
+type clientAccount struct {
+	ID   string `json:"id"`
+	Name string `json:"descriptiveName"`
+}
+
+// searchResponse is the response of GoogleAdsService.Search to
+// clientAccountsQuery.
+type searchResponse struct {
+	Results []struct {
+		CustomerClient clientAccount `json:"customerClient"`
+	} `json:"results"`
+}
+
+// retryClientAccount is called when c.CustomerID is a manager account. It
+// lists the client accounts under the manager account and, if the user
+// chooses one, retries the API call against it through the manager account.
+// The error of the manager account is returned when no client account is
+// chosen.
+func (c *Config) retryClientAccount(ctx context.Context, client *http.Client, managerErr error) (*bytes.Buffer, error) {
+	accounts, err := c.clientAccounts(ctx, client)
+	if err != nil {
+		c.print(i18n.Sprintf("Cannot list the client accounts of manager account %s: %s",
+			report.FormatCustomerID(c.CustomerID), err))
+		return nil, managerErr
+	}
+	if len(accounts) == 0 {
+		c.print(i18n.Sprintf("Manager account %s has no client accounts.", report.FormatCustomerID(c.CustomerID)))
+		return nil, managerErr
+	}
+
+	var options []string
+	for _, a := range accounts {
+		options = append(options, fmt.Sprintf("%s %s", report.FormatCustomerID(a.ID), a.Name))
+	}
+	i, err := c.prompter().Select(i18n.Sprintf("%s is a manager account. Choose a client account to test instead:",
+		report.FormatCustomerID(c.CustomerID)), options)
+	if err != nil {
+		return nil, managerErr
+	}
+
+	// The client account is accessed through the manager account, unless
+	// the configuration already sets the manager account to log in with.
+	if c.ConfigFile.LoginCustomerID == "" {
+		c.offer(&report.SetValue{Config: &c.ConfigFile, Key: "LoginCustomerID", Value: c.CustomerID,
+			Description: i18n.Sprintf("Set %s to %s in your configuration to access the client accounts of the manager account.",
+				c.ConfigFile.GetConfigKeysInLang("LoginCustomerID"), c.CustomerID)})
+		c.ConfigFile.LoginCustomerID = c.CustomerID
+	}
+	c.CustomerID = accounts[i].ID
+	return c.fetchAccount(ctx, client)
+}
+
+// clientAccounts returns the client accounts under the manager account
+// c.CustomerID.
+func (c *Config) clientAccounts(ctx context.Context, client *http.Client) ([]clientAccount, error) {
+	customerURL, err := c.customerURL()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{"query": clientAccountsQuery})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", customerURL+"/googleAds:search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	c.setAPIHeaders(req)
+	if c.ConfigFile.LoginCustomerID == "" {
+		req.Header.Set("login-customer-id", c.CustomerID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, i18n.Errorf("A HTTP Status (%s) is returned while calling %s", resp.Status, req.URL)
+	}
+
+	var sr searchResponse
+	if err := json.Unmarshal(buf.Bytes(), &sr); err != nil {
+		return nil, err
+	}
+	var accounts []clientAccount
+	for _, r := range sr.Results {
+		accounts = append(accounts, r.CustomerClient)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+const managerError = `{"error": {"code": 403, "status": "PERMISSION_DENIED", "details": [{"errors": [{"errorCode": {"authorizationError": "CANNOT_BE_EXECUTED_BY_MANAGER_ACCOUNT"}}]}]}}`
+
+func TestRetryClientAccount(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	tests := []struct {
+		desc        string
+		prompter    prompt.Prompter
+		clients     string
+		want        string
+		wantLoginID string
+		errstr      string
+	}{
+		{
+			desc:        "The chosen client account is tested through the manager account",
+			prompter:    prompt.NewTerminal(strings.NewReader("2\n"), ioutil.Discard),
+			clients:     `{"results": [{"customerClient": {"id": "2222222222", "descriptiveName": "A"}}, {"customerClient": {"id": "3333333333", "descriptiveName": "B"}}]}`,
+			want:        "customers/3333333333 login=1111111111",
+			wantLoginID: "1111111111",
+		},
+		{
+			desc:     "No client account",
+			prompter: prompt.NewTerminal(strings.NewReader("1\n"), ioutil.Discard),
+			clients:  `{}`,
+			errstr:   "CANNOT_BE_EXECUTED_BY_MANAGER_ACCOUNT",
+		},
+		{
+			desc:     "No client account is chosen in non-interactive mode",
+			prompter: prompt.NonInteractive{},
+			clients:  `{"results": [{"customerClient": {"id": "2222222222", "descriptiveName": "A"}}]}`,
+			errstr:   "CANNOT_BE_EXECUTED_BY_MANAGER_ACCOUNT",
+		},
+	}
+
+	for _, tt := range tests {
+		var query string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/googleAds:search"):
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				query = body["query"]
+				w.Write([]byte(tt.clients))
+			case strings.HasSuffix(r.URL.Path, "/customers/1111111111"):
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(managerError))
+			default:
+				w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/v8/") + " login=" + r.Header.Get("login-customer-id")))
+			}
+		}))
+		defer ts.Close()
+
+		c := Config{CustomerID: "1111111111", Endpoint: ts.URL, Prompter: tt.prompter}
+		buf, err := c.getAccount(context.Background(), ts.Client())
+
+		if !strings.Contains(errstring(err), tt.errstr) || (tt.errstr == "" && err != nil) {
+			t.Errorf("[%s] getAccount() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+		if buf != nil && buf.String() != tt.want {
+			t.Errorf("[%s] getAccount() = %s, want: %s", tt.desc, buf.String(), tt.want)
+		}
+		if c.ConfigFile.LoginCustomerID != tt.wantLoginID {
+			t.Errorf("[%s] LoginCustomerID = %q, want: %q", tt.desc, c.ConfigFile.LoginCustomerID, tt.wantLoginID)
+		}
+		if !strings.Contains(query, "FROM customer_client") {
+			t.Errorf("[%s] The client accounts were searched with %q", tt.desc, query)
+		}
+	}
+}
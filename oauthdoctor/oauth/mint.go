@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"context"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+// MintRefreshToken runs the consent flow of the OAuth client in the
+// configuration file and returns the new refresh token, like the
+// generate_user_credentials examples of the client libraries. The Google Ads
+// API is not called. Service accounts do not use refresh tokens.
+func (c *Config) MintRefreshToken(ctx context.Context) (string, error) {
+	ctx = c.tokenContext(ctx)
+
+	// Google only returns a refresh token the first time a user consents,
+	// unless the consent page is shown again.
+	var code string
+	var err error
+	switch c.OAuthType {
+	case diag.InstalledApp:
+		code, err = c.genAuthCode(oauth2.ApprovalForce)
+	case diag.Web:
+		code, err = c.webAuthCode(ctx, oauth2.ApprovalForce)
+	default:
+		return "", i18n.Errorf("The %s OAuth type does not use refresh tokens", c.OAuthType)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_, refreshToken, err := c.oauth2Client(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	if refreshToken == "" {
+		return "", i18n.Errorf("The OAuth2 token endpoint did not return a refresh token")
+	}
+	return refreshToken, nil
+}
+
+// SaveRefreshToken asks the user if they want to replace the refresh token in
+// the configuration file with refreshToken, and replaces it if so.
+func (c *Config) SaveRefreshToken(refreshToken string) error {
+	return c.replaceRefreshToken(&c.ConfigFile, refreshToken)
+}
@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestMintRefreshToken(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+	log.SetOutput(ioutil.Discard)
+
+	tests := []struct {
+		desc      string
+		oauthType string
+		response  string
+		want      string
+		wantErr   string
+	}{
+		{
+			desc:      "Refresh token returned",
+			oauthType: diag.InstalledApp,
+			response:  `{"access_token":"fakeaccesstoken","refresh_token":"fakerefreshtoken","token_type":"bearer"}`,
+			want:      "fakerefreshtoken",
+		},
+		{
+			desc:      "No refresh token returned",
+			oauthType: diag.InstalledApp,
+			response:  `{"access_token":"fakeaccesstoken","token_type":"bearer"}`,
+			wantErr:   "did not return a refresh token",
+		},
+		{
+			desc:      "Service account",
+			oauthType: diag.ServiceAccount,
+			wantErr:   "does not use refresh tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		var gotCode string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			gotCode = r.Form.Get("code")
+			w.Header().Add("Content-Type", "application/json")
+			w.Write([]byte(tt.response))
+		}))
+
+		c := Config{
+			OAuthType: tt.oauthType,
+			Endpoints: Endpoints{TokenURL: server.URL + "/token"},
+			Prompter:  prompt.NewTerminal(strings.NewReader("fakeauthcode\n"), ioutil.Discard),
+		}
+		got, err := c.MintRefreshToken(context.Background())
+		server.Close()
+
+		if !strings.Contains(errstring(err), tt.wantErr) || (tt.wantErr == "" && err != nil) {
+			t.Errorf("[%s] MintRefreshToken() error: %s, want: %s", tt.desc, errstring(err), tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] MintRefreshToken() = %q, want: %q", tt.desc, got, tt.want)
+		}
+		if tt.oauthType == diag.InstalledApp && gotCode != "fakeauthcode" {
+			t.Errorf("[%s] MintRefreshToken() exchanged code %q, want: fakeauthcode", tt.desc, gotCode)
+		}
+	}
+}
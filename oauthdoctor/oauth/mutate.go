@@ -0,0 +1,244 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains the validation of a mutate call, which checks that the
+// credentials can also write to the customer account once the OAuth flow
+// succeeded. A successful read does not mean that writes work.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// These are the codes of the mutate check, in addition to the ones of
+// errorNames.
+const (
+	// ReadOnlyAccess means the user who authorized the credentials can read
+	// the account but not change it.
+	ReadOnlyAccess = "READ_ONLY_ACCESS"
+	// TransientError means the mutate failed with an error that goes away
+	// when the call is retried later.
+	TransientError = "TRANSIENT_ERROR"
+	// InvalidOperation means the API accepted the call, but rejected the
+	// operation itself, which is reported as a partial failure.
+	InvalidOperation = "INVALID_OPERATION"
+)
+
+// validationBudget is the operation of the mutate validation: a shared
+// campaign budget, which any account that is not a manager account can
+// have. With validateOnly, the budget is not created.
+var validationBudget = map[string]interface{}{
+	"name":             "google-ads-doctor validation",
+	"amountMicros":     "10000000",
+	"deliveryMethod":   "STANDARD",
+	"explicitlyShared": true,
+}
+
+// transientErrors are the error codes of mutates that go away when the call
+// is retried with an exponential backoff.
+var transientErrors = []string{"CONCURRENT_MODIFICATION", "RESOURCE_TEMPORARILY_EXHAUSTED",
+	"RESOURCE_TEMPORARILY_UNAVAILABLE", "TRANSIENT_ERROR", "INTERNAL_ERROR"}
+
+// googleAdsError is an error of a GoogleAdsFailure, e.g.
+// {"errorCode": {"quotaError": "RESOURCE_TEMPORARILY_EXHAUSTED"}}.
+type googleAdsError struct {
+	ErrorCode map[string]string `json:"errorCode"`
+	Message   string            `json:"message"`
+}
+
+// code returns the error code, e.g. quotaError.RESOURCE_TEMPORARILY_EXHAUSTED.
+func (e googleAdsError) code() string {
+	var codes []string
+	for kind, code := range e.ErrorCode {
+		codes = append(codes, kind+"."+code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ",")
+}
+
+// apiStatus is the status of a failed call, or of the failed operations of a
+// call with partialFailure.
+type apiStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	Details []struct {
+		Errors []googleAdsError `json:"errors"`
+	} `json:"details"`
+}
+
+// errors returns the Google Ads errors of the status.
+func (s *apiStatus) errors() []googleAdsError {
+	var errs []googleAdsError
+	for _, d := range s.Details {
+		errs = append(errs, d.Errors...)
+	}
+	return errs
+}
+
+// mutateResponse is the response of a mutate call with partialFailure.
+type mutateResponse struct {
+	PartialFailureError *apiStatus `json:"partialFailureError"`
+	Error               *apiStatus `json:"error"`
+}
+
+// CheckMutate validates the creation of a campaign budget in the customer
+// account with the credentials that passed the OAuth flow simulation. The
+// call has validateOnly, so nothing is created, and partialFailure, so an
+// invalid operation is told apart from a call that cannot write at all. An
+// error is returned when the simulation did not pass.
+func (c *Config) CheckMutate(ctx context.Context) (report.Check, error) {
+	if c.client == nil {
+		return report.Check{}, i18n.Errorf("The mutate call can only be validated after the OAuth test passed")
+	}
+	chk := report.Check{
+		ID:     report.MutateCheck,
+		Name:   i18n.T("Mutate validation"),
+		Status: report.Pass,
+	}
+	if c.account != nil && c.account.Manager {
+		chk.Status = report.Skip
+		chk.Message = i18n.T("manager accounts have no campaign budgets")
+		return chk, nil
+	}
+
+	resp, err := c.validateMutate(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return chk, ctx.Err()
+		}
+		chk.Status = report.Fail
+		chk.Code = c.KnowledgeBase.name(c.decodeError(err))
+		chk.Message = err.Error()
+		c.print(i18n.Sprintf("ERROR: The mutate call cannot be validated: %s", err))
+		return chk, nil
+	}
+
+	status, partial := resp.Error, false
+	if status == nil {
+		status, partial = resp.PartialFailureError, true
+	}
+	if status == nil {
+		c.print(i18n.Sprintf("SUCCESS: A mutate call was validated in account %s, so the credentials and the "+
+			"developer token can also change the account.", report.FormatCustomerID(c.CustomerID)))
+		return chk, nil
+	}
+
+	var codes []string
+	for _, e := range status.errors() {
+		codes = append(codes, e.code())
+	}
+	chk.Message = strings.Join(codes, ", ")
+	if chk.Message == "" {
+		chk.Message = status.Message
+	}
+	switch all := strings.Join(codes, ","); {
+	case containsAny(all, transientErrors):
+		chk.Status = report.Warn
+		chk.Code = TransientError
+		c.print(i18n.Sprintf("WARNING: The mutate call failed with a temporary error (%s). Mutates can fail "+
+			"like this under load, so retry them with an exponential backoff. Read "+
+			"https://developers.google.com/google-ads/api/docs/best-practices/error-types", chk.Message))
+	case partial:
+		// The call was authorized, so the write path works.
+		chk.Status = report.Warn
+		chk.Code = InvalidOperation
+		c.print(i18n.Sprintf("WARNING: The mutate call was authorized, but its test operation was rejected as a "+
+			"partial failure (%s). The credentials can write to the account; check the operations of your "+
+			"application for the same error.", chk.Message))
+	case strings.Contains(all, "ACTION_NOT_PERMITTED"):
+		chk.Status = report.Fail
+		chk.Code = ReadOnlyAccess
+		c.print(i18n.Sprintf("ERROR: The user who authorized the credentials can read account %s but not change "+
+			"it. Ask an admin of the account to give the user Standard or Admin access.",
+			report.FormatCustomerID(c.CustomerID)))
+	default:
+		chk.Status = report.Fail
+		raw, _ := json.Marshal(resp)
+		chk.Code = c.KnowledgeBase.name(c.decodeError(rawError(raw)))
+		c.print(i18n.Sprintf("ERROR: The mutate call failed: %s", status.Message))
+	}
+	return chk, nil
+}
+
+// validateMutate sends the mutate of validationBudget and returns its
+// response. An error is returned when the call fails without a JSON error.
+func (c *Config) validateMutate(ctx context.Context) (*mutateResponse, error) {
+	customerURL, err := c.customerURL()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"operations":     []interface{}{map[string]interface{}{"create": validationBudget}},
+		"partialFailure": true,
+		"validateOnly":   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", customerURL+"/campaignBudgets:mutate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	c.setAPIHeaders(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Body)
+	if c.Verbose {
+		c.print(i18n.Sprintf("Response of the mutate validation:\n%s", buf.String()))
+	}
+	var mr mutateResponse
+	if err := json.Unmarshal(buf.Bytes(), &mr); err != nil || (resp.StatusCode != http.StatusOK && mr.Error == nil) {
+		return nil, i18n.Errorf("A HTTP Status (%s) is returned while calling %s", resp.Status, req.URL)
+	}
+	return &mr, nil
+}
+
+// rawError is the JSON of an API error, which decodeError reads.
+type rawError []byte
+
+func (e rawError) Error() string {
+	return string(e)
+}
+
+// containsAny returns true when s contains one of substrs.
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
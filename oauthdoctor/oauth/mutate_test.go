@@ -0,0 +1,96 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestCheckMutate(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Operations     []interface{} `json:"operations"`
+			PartialFailure bool          `json:"partialFailure"`
+			ValidateOnly   bool          `json:"validateOnly"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != "POST" || !body.ValidateOnly || !body.PartialFailure || len(body.Operations) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v8/customers/"), "/campaignBudgets:mutate") {
+		case "1111111111":
+			w.Write([]byte(`{}`))
+		case "2222222222":
+			w.Write([]byte(`{"partialFailureError": {"code": 3, "message": "Multiple errors in 'details'.", "details": [{"errors": [{"errorCode": {"campaignBudgetError": "MONEY_AMOUNT_TOO_LARGE"}}]}]}}`))
+		case "3333333333":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"code": 429, "status": "RESOURCE_EXHAUSTED", "details": [{"errors": [{"errorCode": {"quotaError": "RESOURCE_TEMPORARILY_EXHAUSTED"}}]}]}}`))
+		case "4444444444":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "status": "PERMISSION_DENIED", "details": [{"errors": [{"errorCode": {"authorizationError": "ACTION_NOT_PERMITTED"}}]}]}}`))
+		case "5555555555":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"code": 403, "status": "PERMISSION_DENIED", "message": "The developer token is not approved.", "details": [{"errors": [{"errorCode": {"authorizationError": "DEVELOPER_TOKEN_NOT_APPROVED"}}]}]}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>Bad Gateway</html>"))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc       string
+		cid        string
+		manager    bool
+		wantStatus report.Status
+		wantCode   string
+		wantMsg    string
+	}{
+		{desc: "Write access", cid: "1111111111", wantStatus: report.Pass},
+		{desc: "Partial failure", cid: "2222222222", wantStatus: report.Warn, wantCode: InvalidOperation,
+			wantMsg: "campaignBudgetError.MONEY_AMOUNT_TOO_LARGE"},
+		{desc: "Temporary error", cid: "3333333333", wantStatus: report.Warn, wantCode: TransientError,
+			wantMsg: "quotaError.RESOURCE_TEMPORARILY_EXHAUSTED"},
+		{desc: "Read-only access", cid: "4444444444", wantStatus: report.Fail, wantCode: ReadOnlyAccess,
+			wantMsg: "authorizationError.ACTION_NOT_PERMITTED"},
+		{desc: "Developer token with test access", cid: "5555555555", wantStatus: report.Fail,
+			wantCode: "DEVELOPER_TOKEN_NOT_APPROVED", wantMsg: "authorizationError.DEVELOPER_TOKEN_NOT_APPROVED"},
+		{desc: "Not JSON", cid: "6666666666", wantStatus: report.Fail, wantCode: "UNKNOWN_ERROR",
+			wantMsg: "502 Bad Gateway"},
+		{desc: "Manager account", cid: "7777777777", manager: true, wantStatus: report.Skip,
+			wantMsg: "manager accounts have no campaign budgets"},
+	}
+
+	for _, tt := range tests {
+		c := Config{
+			CustomerID: tt.cid,
+			Endpoint:   ts.URL,
+			client:     ts.Client(),
+			account:    &customerAccount{Manager: tt.manager},
+		}
+		chk, err := c.CheckMutate(context.Background())
+		if err != nil {
+			t.Fatalf("[%s] CheckMutate() error: %s", tt.desc, err)
+		}
+		if chk.ID != report.MutateCheck || chk.Status != tt.wantStatus || chk.Code != tt.wantCode ||
+			!strings.Contains(chk.Message, tt.wantMsg) {
+			t.Errorf("[%s] CheckMutate() got %s %s %s %q, want %s %s %s %q", tt.desc, chk.ID, chk.Status, chk.Code,
+				chk.Message, report.MutateCheck, tt.wantStatus, tt.wantCode, tt.wantMsg)
+		}
+	}
+
+	c := Config{CustomerID: "1111111111", Endpoint: ts.URL}
+	if _, err := c.CheckMutate(context.Background()); err == nil {
+		t.Error("CheckMutate() before the OAuth test got no error")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/qr"
+)
+
+// showQRCode prints u as a QR code, so the consent page can be opened on a
+// phone when this machine has no browser.
+func (c *Config) showQRCode(u string) {
+	code, err := qr.Encode(u)
+	if err != nil {
+		c.print(i18n.Sprintf("WARNING: The URL cannot be shown as a QR code: %s", err))
+		return
+	}
+	c.print(i18n.T("Scan this QR code with the camera of your phone to open the URL:") + "\n" + code.String())
+}
+
+// pastedAuthCode reads the auth code of the web flow from the address that
+// the phone was redirected to. The redirect to localhost cannot reach the
+// HTTP server of the doctor from a phone, and the device flow does not
+// support the Google Ads API scope, so the user pastes the address instead.
+func (c *Config) pastedAuthCode() (string, error) {
+	c.print(i18n.Sprintf("After you allow access, your phone opens %s, which fails to load since the doctor "+
+		"does not run on your phone. Copy the address of that page from the browser of your phone and paste "+
+		"it here, or only the value of its code parameter.", WebRedirectURL))
+	input, err := c.readInput(i18n.T("Enter the address or the code >> "))
+	if err != nil {
+		return "", err
+	}
+	return redirectCode(input)
+}
+
+// redirectCode returns the auth code of the address of an OAuth redirect, or
+// the input when it is the code itself.
+func redirectCode(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	u, err := url.Parse(input)
+	if err != nil || u.RawQuery == "" {
+		if cErr := parseConsentError(input); cErr != nil {
+			return "", cErr
+		}
+		return input, nil
+	}
+	q := u.Query()
+	if e := q.Get("error"); e != "" {
+		return "", &consentError{e}
+	}
+	if code := q.Get("code"); code != "" {
+		return code, nil
+	}
+	return "", i18n.Errorf("The address has no code parameter: %s", input)
+}
@@ -0,0 +1,80 @@
+package oauth
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestRedirectCode(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			desc:  "Address of the redirect",
+			input: "http://localhost:8080/?state=state&code=4/0AbCd&scope=https://www.googleapis.com/auth/adwords\n",
+			want:  "4/0AbCd",
+		},
+		{
+			desc:  "Code only",
+			input: " 4/0AbCd ",
+			want:  "4/0AbCd",
+		},
+		{
+			desc:    "Consent denied",
+			input:   "http://localhost:8080/?error=access_denied&state=state",
+			wantErr: "access_denied",
+		},
+		{
+			desc:    "No code",
+			input:   "http://localhost:8080/?state=state",
+			wantErr: "has no code parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := redirectCode(tt.input)
+		if got != tt.want {
+			t.Errorf("[%s] redirectCode() got: %q, want: %q", tt.desc, got, tt.want)
+		}
+		if !strings.Contains(errstring(err), tt.wantErr) || (tt.wantErr == "" && err != nil) {
+			t.Errorf("[%s] redirectCode() error: %s, want: %s", tt.desc, errstring(err), tt.wantErr)
+		}
+	}
+}
+
+func TestWebAuthCodeWithQRCode(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	var got strings.Builder
+	log.SetOutput(&got)
+	defer log.SetOutput(ioutil.Discard)
+
+	c := Config{
+		QRCode:   true,
+		Prompter: prompt.NewTerminal(strings.NewReader("http://localhost:8080/?state=state&code=4/0AbCd\n"), ioutil.Discard),
+	}
+	code, err := c.webAuthCode(context.Background())
+	if err != nil {
+		t.Fatalf("webAuthCode() error: %s", err)
+	}
+	if code != "4/0AbCd" {
+		t.Errorf("webAuthCode() got: %s, want: 4/0AbCd", code)
+	}
+	for _, want := range []string{"Scan this QR code", "█ ▄▄▄▄▄ █", "paste it here"} {
+		if !strings.Contains(got.String(), want) {
+			t.Errorf("webAuthCode() printed: %s\nwant substring: %s", got.String(), want)
+		}
+	}
+	if strings.Contains(got.String(), "Running HTTP server") {
+		t.Errorf("webAuthCode() started the HTTP server, want the address to be pasted")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+// offer offers the fix r with report.Offer, unless c.FailFast is set. It
+// returns true when the fix was applied.
+func (c *Config) offer(r report.Remediation) bool {
+	if c.FailFast {
+		return false
+	}
+	applied, err := report.Offer(r, c.prompter(), c.reporter(), c.AutoFix)
+	if err != nil {
+		c.print(err.Error())
+	}
+	return applied
+}
+
+// cloudCredentialsFix asks for a new client ID and client secret.
+type cloudCredentialsFix struct {
+	c *Config
+	w ConfigWriter
+}
+
+func (f *cloudCredentialsFix) Describe() string {
+	return i18n.T("Enter a new client ID and client secret, which replace the ones of the config file.")
+}
+
+func (f *cloudCredentialsFix) CanAutoApply() bool {
+	return false
+}
+
+func (f *cloudCredentialsFix) Apply(p prompt.Prompter) error {
+	return f.c.replaceCloudCredentials(f.w)
+}
+
+// devTokenFix asks for a new developer token.
+type devTokenFix struct {
+	c *Config
+	w ConfigWriter
+}
+
+func (f *devTokenFix) Describe() string {
+	return i18n.T("Enter a new developer token, which replaces the one of the config file.")
+}
+
+func (f *devTokenFix) CanAutoApply() bool {
+	return false
+}
+
+func (f *devTokenFix) Apply(p prompt.Prompter) error {
+	return replaceDevToken(f.c, f.w)
+}
+
+// refreshTokenFix saves the refresh token generated by the doctor.
+type refreshTokenFix struct {
+	w     ConfigWriter
+	token string
+}
+
+func (f *refreshTokenFix) Describe() string {
+	return i18n.T("Replace the refresh token of the config file with the new one generated.")
+}
+
+func (f *refreshTokenFix) CanAutoApply() bool {
+	return true
+}
+
+func (f *refreshTokenFix) Apply(p prompt.Prompter) error {
+	_, err := f.w.ReplaceConfig(diag.RefreshToken, f.token)
+	return err
+}
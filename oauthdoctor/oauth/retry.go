@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+// maxAttempts is how many times an OAuth flow is tried in all. Each failed
+// attempt fixes one error, so that several wrong settings, e.g. a client ID
+// and a developer token, are fixed in one run.
+const maxAttempts = 4
+
+// unfixable returns true for the errors that the credentials do not cause,
+// so retrying the OAuth flow will not help.
+func (kb KnowledgeBase) unfixable(code int32) bool {
+	return kb.entry(code).Unfixable
+}
+
+// networkFailures are the texts of the errors of connections that failed
+// before a response, which the OAuth2 library wraps without keeping the
+// error.
+var networkFailures = []string{"connection refused", "connection reset", "no such host", "network is unreachable",
+	"i/o timeout"}
+
+// networkError returns true when err is a failed connection, which new
+// credentials cannot fix.
+func networkError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	for _, s := range networkFailures {
+		if strings.Contains(err.Error(), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cannotFix returns true when no fix of the credentials applies to err with
+// the given code: the unfixable errors, the unknown ones and the failed
+// connections.
+func (c *Config) cannotFix(code int32, err error) bool {
+	return c.KnowledgeBase.unfixable(code) || code == UnknownError || networkError(err)
+}
+
+// fixAndRetry diagnoses err, asking the user to fix it, and calls retry with
+// it until the OAuth flow succeeds or maxAttempts attempts were made. Each
+// error is fixed once: when it comes back after its fix, the fix did not
+// help and the flow is not retried again. It returns the error of the last
+// attempt, or the error being fixed when the fix cannot ask for input.
+func (c *Config) fixAndRetry(ctx context.Context, err error, retry func(error) error) error {
+	fixed := make(map[int32]bool)
+	var fixes []string
+	var diagnosed error
+	for attempt := 1; err != nil; attempt++ {
+		if c.Verbose {
+			c.print(err.Error())
+		}
+		// The user cannot be asked to fix the error.
+		if err == prompt.ErrNoInput && diagnosed != nil {
+			return diagnosed
+		}
+		if err == prompt.ErrNoInput || ctx.Err() != nil {
+			return err
+		}
+		code := c.decodeError(err)
+		if fixed[code] {
+			c.print(i18n.Sprintf("ERROR: %s is returned again after it was fixed, so the OAuth flow is not "+
+				"retried.", c.KnowledgeBase.name(code)))
+			return err
+		}
+		c.diagnose(ctx, err)
+		if c.FailFast || c.cannotFix(code, err) || attempt == maxAttempts {
+			return err
+		}
+		diagnosed = err
+		fixed[code] = true
+		fixes = append(fixes, c.KnowledgeBase.name(code))
+		err = retry(err)
+	}
+	if len(fixes) > 1 {
+		c.print(i18n.Sprintf("The OAuth flow passed after %d fixes: %s.", len(fixes), strings.Join(fixes, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+const missingDevTokenError = `{"error": {"code": 400, "status": "INVALID_ARGUMENT", "details": [{"errors": [{"errorCode": {"authenticationError": "DEVELOPER_TOKEN_PARAMETER_MISSING"}}]}]}}`
+
+func TestAppFlowFixesSeveralErrors(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Add("Content-Type", "application/json")
+		if r.Form.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token": "fakeaccesstoken", "refresh_token": "newrefreshtoken", "token_type": "bearer"}`))
+	})
+	mux.HandleFunc("/tokeninfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"scope": "https://www.googleapis.com/auth/adwords", "expires_in": "3599"}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("developer-token") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(missingDevTokenError))
+			return
+		}
+		w.Write([]byte(`{"resourceName": "customers/1234567890", "id": "1234567890"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		desc    string
+		stdin   string
+		failing string
+		want    string
+	}{
+		{
+			desc:  "Refresh token and developer token fixed",
+			stdin: "fakeauthcode\nY\nGoodDevToken\nN\n",
+			want:  "The OAuth flow passed after 2 fixes: INVALID_REFRESH_TOKEN, MISSING_DEV_TOKEN.",
+		},
+		{
+			desc:  "Developer token still missing",
+			stdin: "fakeauthcode\nY\n\n",
+			want:  "MISSING_DEV_TOKEN is returned again after it was fixed",
+		},
+	}
+
+	for _, tt := range tests {
+		c := Config{
+			ConfigFile: diag.ConfigFile{Lang: diag.RESTLanguage, ConfigKeys: diag.ConfigKeys{RefreshToken: "revoked"}},
+			Endpoint:   ts.URL,
+			Endpoints:  Endpoints{AuthURL: ts.URL + "/auth", TokenURL: ts.URL + "/token", TokenInfoURL: ts.URL + "/tokeninfo"},
+			Prompter:   prompt.NewTerminal(strings.NewReader(tt.stdin), ioutil.Discard),
+		}
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		c.simulateAppFlow(context.Background())
+
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] got: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
+		}
+	}
+}
+
+func TestAppFlowWithoutInput(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`))
+	}))
+	defer ts.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		desc     string
+		tokenURL string
+		want     string
+		// noRetry is true when no new refresh token is asked for.
+		noRetry bool
+	}{
+		{
+			desc:     "Revoked refresh token",
+			tokenURL: ts.URL + "/token",
+			want:     "invalid_grant",
+		},
+		{
+			desc:     "Unreachable token endpoint",
+			tokenURL: closed.URL + "/token",
+			want:     "connection refused",
+			noRetry:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		c := Config{
+			ConfigFile: diag.ConfigFile{Lang: diag.RESTLanguage, ConfigKeys: diag.ConfigKeys{RefreshToken: "revoked"}},
+			Endpoint:   ts.URL,
+			Endpoints:  Endpoints{AuthURL: ts.URL + "/auth", TokenURL: tt.tokenURL},
+			Prompter:   prompt.NonInteractive{},
+		}
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		err := c.simulateAppFlow(context.Background())
+		if err == prompt.ErrNoInput || !strings.Contains(errstring(err), tt.want) {
+			t.Errorf("[%s] simulateAppFlow() error: %s, want: %s", tt.desc, errstring(err), tt.want)
+		}
+		if tt.noRetry && strings.Contains(got.String(), "regenerate refresh token") {
+			t.Errorf("[%s] simulateAppFlow() regenerated the refresh token:\n%s", tt.desc, got.String())
+		}
+	}
+}
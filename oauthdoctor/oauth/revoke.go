@@ -0,0 +1,90 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains functions that revoke the refresh token of a
+// configuration.
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+// RevokeRefreshToken shows who the refresh token in the configuration file
+// was issued to, asks the user for confirmation and revokes it. Revoking a
+// token removes the access the user granted to the OAuth client, so the
+// other tokens of the grant stop working too. It returns false when the user
+// declined.
+func (c *Config) RevokeRefreshToken(ctx context.Context) (bool, error) {
+	refreshToken := c.ConfigFile.RefreshToken
+	if refreshToken == "" {
+		return false, i18n.Errorf("There is no refresh token to revoke in the configuration")
+	}
+
+	// The details of the token tell whether it was generated under the
+	// wrong account, which is a common reason to revoke it.
+	conf := &oauth2.Config{
+		ClientID:     c.ConfigFile.ConfigKeys.ClientID,
+		ClientSecret: c.ConfigFile.ClientSecret,
+		Endpoint:     c.endpoint(),
+	}
+	ctx = c.tokenContext(ctx)
+	c.printOAuthClient(ctx, conf.Client(ctx, &oauth2.Token{RefreshToken: refreshToken}))
+
+	c.print(i18n.T("Would you like to revoke the refresh token? Applications using it will no longer " +
+		"be able to call the Google Ads API."))
+	yes, err := c.prompter().Confirm(i18n.T("Enter Y for Yes [Anything else is No] >> "))
+	if err != nil {
+		return false, err
+	}
+	if !yes {
+		c.print(i18n.T("Refresh token is NOT revoked"))
+		return false, nil
+	}
+
+	if err := c.revoke(ctx, refreshToken); err != nil {
+		return false, err
+	}
+	c.print(i18n.T("SUCCESS: The refresh token was revoked."))
+	return true, nil
+}
+
+// revoke sends token to the revocation endpoint.
+func (c *Config) revoke(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequest("POST", c.revokeURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := c.clientWith(&tokenTracer{c: c, base: c.transport()})
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if c.tokenErr != nil {
+			return i18n.Errorf("Cannot revoke the refresh token: %s", c.tokenErr)
+		}
+		return i18n.Errorf("Cannot revoke the refresh token: HTTP status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+func TestRevokeRefreshToken(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	tests := []struct {
+		desc         string
+		refreshToken string
+		input        string
+		status       int
+		want         bool
+		wantRevoked  string
+		wantErr      string
+	}{
+		{
+			desc:         "Confirmed",
+			refreshToken: "fakerefreshtoken",
+			input:        "Y\n",
+			status:       http.StatusOK,
+			want:         true,
+			wantRevoked:  "fakerefreshtoken",
+		},
+		{
+			desc:         "Declined",
+			refreshToken: "fakerefreshtoken",
+			input:        "N\n",
+			status:       http.StatusOK,
+		},
+		{
+			desc:         "Already revoked",
+			refreshToken: "fakerefreshtoken",
+			input:        "Y\n",
+			status:       http.StatusBadRequest,
+			wantRevoked:  "fakerefreshtoken",
+			wantErr:      "invalid_token: Token expired or revoked",
+		},
+		{
+			desc:    "No refresh token",
+			wantErr: "no refresh token",
+		},
+	}
+
+	for _, tt := range tests {
+		var gotRevoked string
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"fakeaccesstoken","token_type":"bearer"}`))
+		})
+		mux.HandleFunc("/tokeninfo", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"aud":"1234567890-fakeclient.apps.googleusercontent.com"}`))
+		})
+		mux.HandleFunc("/revoke", func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			gotRevoked = r.Form.Get("token")
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(tt.status)
+			if tt.status != http.StatusOK {
+				w.Write([]byte(`{"error":"invalid_token","error_description":"Token expired or revoked"}`))
+			}
+		})
+		server := httptest.NewServer(mux)
+
+		c := Config{
+			ConfigFile: diag.ConfigFile{ConfigKeys: diag.ConfigKeys{RefreshToken: tt.refreshToken}},
+			OAuthType:  diag.InstalledApp,
+			Endpoints: Endpoints{
+				TokenURL:     server.URL + "/token",
+				TokenInfoURL: server.URL + "/tokeninfo",
+				RevokeURL:    server.URL + "/revoke",
+			},
+			Prompter: prompt.NewTerminal(strings.NewReader(tt.input), ioutil.Discard),
+		}
+		got, err := c.RevokeRefreshToken(context.Background())
+		server.Close()
+
+		if !strings.Contains(errstring(err), tt.wantErr) || (tt.wantErr == "" && err != nil) {
+			t.Errorf("[%s] RevokeRefreshToken() error: %s, want: %s", tt.desc, errstring(err), tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("[%s] RevokeRefreshToken() = %t, want: %t", tt.desc, got, tt.want)
+		}
+		if gotRevoked != tt.wantRevoked {
+			t.Errorf("[%s] RevokeRefreshToken() revoked %q, want: %q", tt.desc, gotRevoked, tt.wantRevoked)
+		}
+	}
+}
@@ -1,36 +1,35 @@
 package oauth
 
 import (
-	"log"
-
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+	"context"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 	"golang.org/x/oauth2/jwt"
 )
 
-var tokenURL = google.JWTTokenURL
-
-func (c *Config) simulateServiceAccFlow() {
+// simulateServiceAccFlow connects with a service account and gets the
+// account info. It returns the error of the attempt.
+func (c *Config) simulateServiceAccFlow(ctx context.Context) error {
 	conf := &jwt.Config{
 		Email:      c.ConfigFile.ClientEmail,
 		PrivateKey: []byte(c.ConfigFile.PrivateKey),
-		Scopes:     []string{GoogleAdsApiScope},
-		TokenURL:   tokenURL,
+		Scopes:     c.scopes(),
+		TokenURL:   c.jwtTokenURL(),
 		Subject:    c.ConfigFile.DelegatedAccount,
 	}
-	client := conf.Client(oauth2.NoContext)
+	client := conf.Client(ctx)
 
-	accountInfo, err := c.getAccount(client)
+	accountInfo, err := c.getAccount(ctx, client)
 	if err == nil {
 		if c.Verbose {
-			log.Print(accountInfo.String())
+			c.print(accountInfo.String())
 		}
-		log.Println("SUCCESS: OAuth test passed with given config file settings.")
+		c.print(i18n.T("SUCCESS: OAuth test passed with given config file settings."))
 	} else {
-		c.diagnose(err)
+		c.diagnose(ctx, err)
 		if c.Verbose {
-			log.Println(err)
+			c.print(err.Error())
 		}
-		log.Println("ERROR: OAuth test failed.")
+		c.print(i18n.T("ERROR: OAuth test failed."))
 	}
+	return err
 }
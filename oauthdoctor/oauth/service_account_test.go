@@ -1,9 +1,13 @@
 package oauth
 
 import (
+	"context"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -42,8 +46,7 @@ ckD3dVK4nva0qzdpfKOX/QHX/21Y0ivELswnmZ488IYrwWJpJta0ZvD1/SpvAgJb
 -----END PRIVATE KEY-----`
 
 func TestSimulateServiceAccFlow(t *testing.T) {
-	s, close := setupFakeOAuthServer()
-	tokenURL = s.URL + "/token" // overriding the code to use this fake tokenURL
+	endpoints, close := setupFakeOAuthServer()
 	defer close()
 
 	enableStdio := disableStdio(t)
@@ -86,16 +89,56 @@ func TestSimulateServiceAccFlow(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		apiURL = tt.ts.URL
+		tt.c.Endpoint = tt.ts.URL
+		tt.c.Endpoints = endpoints
 		defer tt.ts.Close()
 
 		var got strings.Builder
 		log.SetOutput(&got)
 
-		tt.c.simulateServiceAccFlow()
+		tt.c.simulateServiceAccFlow(context.Background())
 
 		if !strings.Contains(got.String(), tt.want) {
 			t.Errorf("\n[%s]\ngot: %s\nwant substring: %s", tt.desc, got.String(), tt.want)
 		}
 	}
 }
+
+func TestSimulateServiceAccFlowInlineKey(t *testing.T) {
+	endpoints, close := setupFakeOAuthServer()
+	defer close()
+
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resourceName": "customers/1234567890", "id": "1234567890"}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "inline_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "google-ads.yaml")
+	config := "developer_token: GoodDevToken\n" +
+		"private_key: |\n  " + strings.Replace(fakePrivateKey, "\n", "\n  ", -1) + "\n" +
+		"client_email: sa@project.iam.gserviceaccount.com\n" +
+		"impersonated_email: admin@example.com\n"
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := diag.ParseConfigFile("python", path, diag.ServiceAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{ConfigFile: cfg, OAuthType: diag.ServiceAccount, Endpoint: ts.URL, Endpoints: endpoints}
+	var got strings.Builder
+	log.SetOutput(&got)
+
+	if err := c.simulateServiceAccFlow(context.Background()); err != nil {
+		t.Errorf("simulateServiceAccFlow() error: %s\n%s", err, got.String())
+	}
+}
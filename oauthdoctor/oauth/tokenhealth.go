@@ -0,0 +1,135 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains the check of the age of the refresh token and of the
+// causes of a refresh token that stops working without notice.
+
+import (
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+const (
+	// maxRefreshTokens is the number of refresh tokens that Google keeps for
+	// each user and OAuth client. Issuing another one revokes the oldest
+	// without notice.
+	maxRefreshTokens = 100
+	// manyRefreshTokens is the number of replaced refresh tokens of the
+	// OAuth client above which tokens are likely generated too often.
+	manyRefreshTokens = 10
+	// testingTokenLifetime is how long a refresh token lasts when the
+	// publishing status of the OAuth consent screen is Testing.
+	testingTokenLifetime = 7 * 24 * time.Hour
+	// idleTokenLifetime is how long a refresh token lasts without being used.
+	idleTokenLifetime = 183 * 24 * time.Hour
+)
+
+// RefreshTokenHealth reports how old the refresh token of the configuration
+// appears to be, and explains the causes of a refresh token that stops
+// working without notice when the OAuth check failed because the token was
+// expired or revoked. oauthCheck is the result of SimulateOAuthFlow.
+func (c *Config) RefreshTokenHealth(oauthCheck report.Check) report.Check {
+	chk := report.Check{
+		ID:     report.TokenCheck,
+		Name:   i18n.T("Refresh token health"),
+		Status: report.Pass,
+	}
+	if !diag.ThreeLegged(c.OAuthType) || c.ConfigFile.RefreshToken == "" {
+		chk.Status = report.Skip
+		chk.Message = i18n.T("no refresh token")
+		return chk
+	}
+
+	// The age is a lower bound, so it only makes some causes more likely.
+	h := c.ConfigFile.RefreshTokenHistory()
+	var age time.Duration
+	var notes []string
+	switch {
+	case !h.FromFile:
+		c.print(i18n.T("The age of the refresh token cannot be determined, since it is not read from a " +
+			"configuration file."))
+		notes = append(notes, i18n.T("age unknown"))
+	case h.Since.IsZero():
+		c.print(i18n.Sprintf("The age of the refresh token cannot be determined, since no backup of %s "+
+			"has it.", c.ConfigFile.GetFilepath()))
+		notes = append(notes, i18n.T("age unknown"))
+	default:
+		age = time.Since(h.Since)
+		days := int(age.Hours() / 24)
+		c.print(i18n.Sprintf("The refresh token has been in %s since %s, so it is at least %d days old.",
+			c.ConfigFile.GetFilepath(), h.Since.Format("2006-01-02"), days))
+		notes = append(notes, i18n.Sprintf("at least %d days old", days))
+	}
+	if h.Previous > 0 {
+		c.print(i18n.Sprintf("The backups of the configuration file have %d other refresh tokens of the same "+
+			"OAuth client: %s", h.Previous, strings.Join(h.Backups, ", ")))
+		notes = append(notes, i18n.Sprintf("%d replaced refresh tokens", h.Previous))
+	}
+
+	revoked := oauthCheck.Code == errorNames[InvalidRefreshToken] && c.tokenErr != nil &&
+		strings.Contains(c.tokenErr.Description, "expired or revoked")
+	if revoked {
+		chk.Status = report.Warn
+		c.print(i18n.T("WARNING: The refresh token has been expired or revoked. Google does not tell why; " +
+			"these are the causes, from the most likely:"))
+		for _, cause := range revocationCauses(age, h.Since.IsZero()) {
+			c.print("- " + cause)
+		}
+	} else if h.Previous >= manyRefreshTokens {
+		chk.Status = report.Warn
+		c.print(i18n.Sprintf("WARNING: The refresh token of this OAuth client was replaced %d times. Google "+
+			"keeps at most %d refresh tokens for each user and OAuth client, and revokes the oldest without "+
+			"notice when another one is issued.", h.Previous, maxRefreshTokens))
+	}
+	if revoked || h.Previous >= manyRefreshTokens {
+		c.print(i18n.T("Generate a refresh token once for each user, store it, e.g. in a secret manager, and " +
+			"share it between the machines and deployments of your application instead of generating new ones. " +
+			"Read https://developers.google.com/identity/protocols/oauth2#expiration"))
+	}
+	chk.Message = strings.Join(notes, ", ")
+	return chk
+}
+
+// revocationCauses returns the causes of an expired or revoked refresh
+// token, from the most likely given that the token is at least age old.
+// unknown is true when the age cannot be determined.
+func revocationCauses(age time.Duration, unknown bool) []string {
+	idle := i18n.T("The refresh token was not used for 6 months.")
+	testing := i18n.T("The publishing status of the OAuth consent screen is Testing, so the refresh tokens " +
+		"expire after 7 days. Publish the app: https://console.cloud.google.com/apis/credentials/consent")
+	limit := i18n.Sprintf("More than %d refresh tokens were issued for this user and OAuth client, e.g. one for "+
+		"each machine, deployment or test run, so the oldest ones were revoked.", maxRefreshTokens)
+	removed := i18n.T("The user removed the access of the application at https://myaccount.google.com/permissions.")
+
+	var causes []string
+	if !unknown && age >= idleTokenLifetime {
+		causes = append(causes, idle)
+	}
+	if unknown || age >= testingTokenLifetime {
+		causes = append(causes, testing)
+	}
+	causes = append(causes, limit)
+	if !unknown && age < testingTokenLifetime {
+		causes = append(causes, testing)
+	}
+	if unknown || age < idleTokenLifetime {
+		causes = append(causes, idle)
+	}
+	return append(causes, removed)
+}
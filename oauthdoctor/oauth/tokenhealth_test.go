@@ -0,0 +1,169 @@
+package oauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
+)
+
+func TestRefreshTokenHealth(t *testing.T) {
+	enableStdio := disableStdio(t)
+	defer enableStdio()
+
+	dir, err := ioutil.TempDir("", "tokenhealth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	clientID := "0123456789-client.apps.googleusercontent.com"
+	config := func(token string) []byte {
+		return []byte("developer_token: GoodDevToken\nclient_id: " + clientID +
+			"\nclient_secret: GoodClientSecret\nrefresh_token: " + token + "\n")
+	}
+	path := filepath.Join(dir, "google-ads.yaml")
+	if err := ioutil.WriteFile(path, config("1/current"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A backup with the same token tells that the token is 30 days old.
+	aged := path + "_" + time.Now().Add(-30*24*time.Hour).Format("2006-01-02_15-04-05")
+	cfg, err := diag.ParseConfigFile("python", path, diag.InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := report.Check{Status: report.Fail, Code: "INVALID_REFRESH_TOKEN"}
+	expired := &tokenError{Code: "invalid_grant", Description: "Token has been expired or revoked."}
+	tests := []struct {
+		desc       string
+		oauthType  string
+		aged       bool
+		backups    int
+		oauthCheck report.Check
+		tokenErr   *tokenError
+		want       report.Status
+		wantMsg    string
+		wantOut    []string
+	}{
+		{
+			desc:      "Service account",
+			oauthType: diag.ServiceAccount,
+			want:      report.Skip,
+			wantMsg:   "no refresh token",
+		},
+		{
+			desc:       "Token without backups",
+			oauthType:  diag.InstalledApp,
+			oauthCheck: revoked,
+			tokenErr:   expired,
+			want:       report.Warn,
+			wantMsg:    "age unknown",
+			wantOut: []string{"since no backup of " + path + " has it",
+				"- The publishing status of the OAuth consent screen is Testing"},
+		},
+		{
+			desc:       "Working token",
+			oauthType:  diag.InstalledApp,
+			aged:       true,
+			oauthCheck: report.Check{Status: report.Pass},
+			want:       report.Pass,
+			wantMsg:    "at least 30 days old",
+			wantOut:    []string{"so it is at least 30 days old"},
+		},
+		{
+			desc:       "Expired or revoked token",
+			oauthType:  diag.InstalledApp,
+			aged:       true,
+			oauthCheck: revoked,
+			tokenErr:   expired,
+			want:       report.Warn,
+			wantMsg:    "at least 30 days old",
+			wantOut: []string{"WARNING: The refresh token has been expired or revoked.",
+				"- The publishing status of the OAuth consent screen is Testing",
+				"- More than 100 refresh tokens were issued", "Generate a refresh token once for each user"},
+		},
+		{
+			desc:       "Malformed token",
+			oauthType:  diag.InstalledApp,
+			aged:       true,
+			oauthCheck: revoked,
+			tokenErr:   &tokenError{Code: "invalid_grant", Description: "Bad Request"},
+			want:       report.Pass,
+			wantMsg:    "at least 30 days old",
+		},
+		{
+			desc:       "Token replaced often",
+			oauthType:  diag.InstalledApp,
+			aged:       true,
+			backups:    manyRefreshTokens,
+			oauthCheck: report.Check{Status: report.Pass},
+			want:       report.Warn,
+			wantMsg:    "at least 30 days old, 10 replaced refresh tokens",
+			wantOut:    []string{"WARNING: The refresh token of this OAuth client was replaced 10 times."},
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.aged {
+			if err := ioutil.WriteFile(aged, config("1/current"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < tt.backups; i++ {
+			backup := fmt.Sprintf("%s_2024-01-%02d_10-00-00", path, i+1)
+			if err := ioutil.WriteFile(backup, config(fmt.Sprintf("1/old%d", i)), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		c := Config{ConfigFile: cfg, OAuthType: tt.oauthType, tokenErr: tt.tokenErr}
+		var out strings.Builder
+		log.SetOutput(&out)
+
+		got := c.RefreshTokenHealth(tt.oauthCheck)
+
+		if got.ID != report.TokenCheck || got.Status != tt.want || got.Message != tt.wantMsg {
+			t.Errorf("[%s] RefreshTokenHealth() got %s %s %q, want %s %s %q", tt.desc, got.ID, got.Status, got.Message,
+				report.TokenCheck, tt.want, tt.wantMsg)
+		}
+		for _, want := range tt.wantOut {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("[%s] RefreshTokenHealth() printed: %s\nwant substring: %s", tt.desc, out.String(), want)
+			}
+		}
+	}
+}
+
+func TestRevocationCauses(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		desc    string
+		age     time.Duration
+		unknown bool
+		want    []string
+	}{
+		{desc: "Unknown age", unknown: true, want: []string{"Testing", "More than 100", "6 months", "removed"}},
+		{desc: "New token", age: day, want: []string{"More than 100", "Testing", "6 months", "removed"}},
+		{desc: "Week old token", age: 8 * day, want: []string{"Testing", "More than 100", "6 months", "removed"}},
+		{desc: "Old token", age: 200 * day, want: []string{"6 months", "Testing", "More than 100", "removed"}},
+	}
+
+	for _, tt := range tests {
+		got := revocationCauses(tt.age, tt.unknown)
+		if len(got) != len(tt.want) {
+			t.Errorf("[%s] revocationCauses() got: %q", tt.desc, got)
+			continue
+		}
+		for i := range got {
+			if !strings.Contains(got[i], tt.want[i]) {
+				t.Errorf("[%s] revocationCauses()[%d] got: %s, want substring: %s", tt.desc, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains functions that look up the details of an access token,
+// such as the OAuth client it was issued to.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+// tokenInfo is the response of the OAuth2 tokeninfo endpoint.
+type tokenInfo struct {
+	// Audience is the client ID the token was issued to.
+	Audience        string `json:"aud"`
+	AuthorizedParty string `json:"azp"`
+	Scope           string `json:"scope"`
+	Email           string `json:"email"`
+	ExpiresIn       string `json:"expires_in"`
+}
+
+// getTokenInfo retrieves the details of the given access token.
+func (c *Config) getTokenInfo(ctx context.Context, accessToken string) (*tokenInfo, error) {
+	req, err := http.NewRequest("GET", c.tokenInfoURL()+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.httpClient()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tokeninfo returned HTTP status %s", resp.Status)
+	}
+
+	ti := &tokenInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(ti); err != nil {
+		return nil, err
+	}
+	return ti, nil
+}
+
+// accessToken returns the access token used by an OAuth2 HTTP client.
+func accessToken(client *http.Client) (string, error) {
+	t, ok := client.Transport.(*oauth2.Transport)
+	if !ok {
+		return "", fmt.Errorf("not an OAuth2 client")
+	}
+	token, err := t.Source.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// projectNumber returns the Google Cloud project number that prefixes an
+// OAuth client ID, or an empty string if the client ID has no such prefix.
+func projectNumber(clientID string) string {
+	idx := strings.Index(clientID, "-")
+	if idx <= 0 {
+		return ""
+	}
+	for _, r := range clientID[:idx] {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return clientID[:idx]
+}
+
+// printOAuthClient shows which OAuth client the access token of the given
+// HTTP client was issued to, so users can tell when they are authorizing
+// an application from the wrong Google Cloud project. It does nothing when
+// the token details cannot be retrieved.
+func (c *Config) printOAuthClient(ctx context.Context, client *http.Client) {
+	token, err := accessToken(client)
+	if err != nil {
+		return
+	}
+	ti, err := c.getTokenInfo(ctx, token)
+	if err != nil {
+		if c.Verbose {
+			c.print(i18n.Sprintf("Cannot retrieve the access token details: %s", err))
+		}
+		return
+	}
+
+	clientID := ti.Audience
+	if clientID == "" {
+		clientID = ti.AuthorizedParty
+	}
+	c.print(i18n.Sprintf("The access token was issued to OAuth client %s", clientID))
+
+	if pn := projectNumber(clientID); pn != "" {
+		c.print(i18n.Sprintf("The consent screen shows the application name configured in Google Cloud "+
+			"project %[1]s. Make sure this is your project: "+
+			"https://console.cloud.google.com/apis/credentials/consent?project=%[1]s", pn))
+	}
+
+	if configured := c.ConfigFile.ConfigKeys.ClientID; configured != "" && configured != clientID {
+		c.print(i18n.Sprintf("WARNING: The client ID in your configuration file (%s) does not match "+
+			"the OAuth client of the access token (%s).", configured, clientID))
+	}
+
+	c.verifyScopes(ti)
+}
+
+// verifyScopes prints the scopes granted to the access token and warns about
+// the requested scopes it lacks. A refresh token without GoogleAdsApiScope,
+// e.g. one generated for another Google API, cannot call the Google Ads API.
+func (c *Config) verifyScopes(ti *tokenInfo) {
+	if ti.Scope == "" {
+		return
+	}
+	c.print(i18n.Sprintf("The access token is authorized for these scopes: %s", ti.Scope))
+	if ti.Email != "" {
+		c.print(i18n.Sprintf("The access token was authorized by %s", ti.Email))
+	}
+
+	var extra []string
+	for _, s := range missingScopes(ti.Scope, c.scopes()) {
+		if s == GoogleAdsApiScope {
+			c.print(i18n.Sprintf("ERROR: The access token does not include the Google Ads API scope (%s). "+
+				"Generate a new refresh token that includes this scope.", GoogleAdsApiScope))
+		} else {
+			extra = append(extra, s)
+		}
+	}
+	if len(extra) > 0 {
+		c.print(i18n.Sprintf("WARNING: The access token does not include the requested scopes %s. "+
+			"Generate a new refresh token if your application needs them.", strings.Join(extra, ", ")))
+	}
+}
+
+// missingScopes returns the scopes in want that are not in granted, a space
+// separated list of scopes as returned by tokeninfo.
+func missingScopes(granted string, want []string) []string {
+	have := strings.Fields(granted)
+	var missing []string
+	for _, s := range want {
+		if !diag.Contains(have, s) && !diag.Contains(have, scopeAliases[s]) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// scopeAliases are the full names that tokeninfo returns for the short
+// OpenID Connect scopes.
+var scopeAliases = map[string]string{
+	"email":   "https://www.googleapis.com/auth/userinfo.email",
+	"profile": "https://www.googleapis.com/auth/userinfo.profile",
+}
@@ -0,0 +1,136 @@
+package oauth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"golang.org/x/oauth2"
+)
+
+func TestProjectNumber(t *testing.T) {
+	tests := []struct {
+		clientID string
+		want     string
+	}{
+		{
+			clientID: "1234567890-abcdef.apps.googleusercontent.com",
+			want:     "1234567890",
+		},
+		{
+			clientID: "abc-def.apps.googleusercontent.com",
+			want:     "",
+		},
+		{
+			clientID: "noprefix",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := projectNumber(tt.clientID); got != tt.want {
+			t.Errorf("projectNumber(%s) got: %s, want: %s", tt.clientID, got, tt.want)
+		}
+	}
+}
+
+func TestPrintOAuthClient(t *testing.T) {
+	endpoints, close := setupFakeOAuthServer()
+	defer close()
+
+	tests := []struct {
+		desc     string
+		clientID string
+		want     string
+	}{
+		{
+			desc:     "Client ID matches",
+			clientID: "1234567890-fakeclient.apps.googleusercontent.com",
+			want:     "project 1234567890",
+		},
+		{
+			desc:     "Client ID does not match",
+			clientID: "999-otherclient.apps.googleusercontent.com",
+			want:     "does not match",
+		},
+	}
+
+	for _, tt := range tests {
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		c := Config{
+			ConfigFile: diag.ConfigFile{
+				ConfigKeys: diag.ConfigKeys{
+					ClientID: tt.clientID,
+				},
+			},
+			Endpoints: endpoints,
+		}
+		client := oauth2.NewClient(oauth2.NoContext, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "fake"}))
+		c.printOAuthClient(context.Background(), client)
+
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] got: %s, want substring: %s", tt.desc, got.String(), tt.want)
+		}
+	}
+
+	var got strings.Builder
+	log.SetOutput(&got)
+	(&Config{}).printOAuthClient(context.Background(), http.DefaultClient)
+	if got.Len() != 0 {
+		t.Errorf("[Not an OAuth2 client] got: %s, want no output", got.String())
+	}
+}
+
+func TestVerifyScopes(t *testing.T) {
+	tests := []struct {
+		desc    string
+		scopes  []string
+		granted string
+		want    string
+		notWant string
+	}{
+		{
+			desc:    "Google Ads API scope granted",
+			granted: GoogleAdsApiScope,
+			want:    "authorized for these scopes: " + GoogleAdsApiScope,
+			notWant: "ERROR",
+		},
+		{
+			desc:    "Google Ads API scope missing",
+			granted: "https://www.googleapis.com/auth/drive",
+			want:    "does not include the Google Ads API scope",
+		},
+		{
+			desc:    "Short scope names match full names",
+			scopes:  []string{"email"},
+			granted: GoogleAdsApiScope + " https://www.googleapis.com/auth/userinfo.email",
+			notWant: "WARNING",
+		},
+		{
+			desc:    "Requested scope missing",
+			scopes:  []string{"email", GoogleAdsApiScope},
+			granted: GoogleAdsApiScope,
+			want:    "does not include the requested scopes email.",
+		},
+	}
+
+	for _, tt := range tests {
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		c := Config{Scopes: tt.scopes}
+		c.verifyScopes(&tokenInfo{Scope: tt.granted})
+
+		if !strings.Contains(got.String(), tt.want) {
+			t.Errorf("[%s] got: %s, want substring: %s", tt.desc, got.String(), tt.want)
+		}
+		if tt.notWant != "" && strings.Contains(got.String(), tt.notWant) {
+			t.Errorf("[%s] got: %s, want no substring: %s", tt.desc, got.String(), tt.notWant)
+		}
+	}
+}
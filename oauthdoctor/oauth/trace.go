@@ -0,0 +1,158 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+// This file contains an HTTP transport that traces the requests to the
+// OAuth2 token endpoint and records the OAuth2 errors they return.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"golang.org/x/oauth2"
+)
+
+// secretParams are the token request parameters and response fields that
+// are redacted from the trace.
+var secretParams = []string{"client_secret", "refresh_token", "token", "code", "code_verifier", "assertion",
+	"access_token", "id_token", "subject_token"}
+
+// tokenError is the error returned by the OAuth2 token endpoint, as defined
+// by RFC 6749 section 5.2.
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *tokenError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// tokenTracer is an HTTP transport that records the error of each token
+// request in its config, and prints the redacted requests and responses when
+// the config traces token requests. Other requests are passed through.
+type tokenTracer struct {
+	c    *Config
+	base http.RoundTripper
+}
+
+// tokenContext returns a context that makes the OAuth2 library send its token
+// requests through a tokenTracer.
+func (c *Config) tokenContext(ctx context.Context) context.Context {
+	client := c.clientWith(&tokenTracer{c: c, base: c.transport()})
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+func (t *tokenTracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Token requests are form POSTs; the API requests also use this
+	// transport since the OAuth2 client wraps it.
+	if req.Method != "POST" || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return t.base.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if t.c.TraceToken {
+			t.c.print(i18n.Sprintf("Token request: %s %s\n%s", req.Method, req.URL, redactForm(string(body))))
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if t.c.TraceToken {
+			t.c.print(i18n.Sprintf("Token request failed: %s", err))
+		}
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.c.tokenErr = nil
+	if resp.StatusCode != http.StatusOK {
+		te := &tokenError{}
+		if json.Unmarshal(body, te) == nil && te.Code != "" {
+			t.c.tokenErr = te
+		}
+	}
+	if t.c.TraceToken {
+		t.c.print(i18n.Sprintf("Token response: %s\n%s", resp.Status, redactJSON(body)))
+		if t.c.tokenErr != nil {
+			t.c.print(i18n.Sprintf("OAuth2 error: %s", t.c.tokenErr))
+		}
+	}
+	return resp, nil
+}
+
+// redactForm returns the parameters of a URL-encoded form, one per line,
+// with the values of secretParams redacted.
+func redactForm(body string) string {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return i18n.T("<body cannot be parsed>")
+	}
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		v := strings.Join(values[k], ",")
+		if diag.Contains(secretParams, k) {
+			v = "REDACTED"
+		}
+		lines = append(lines, "\t"+k+"="+v)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactJSON returns the JSON object in body with the values of
+// secretParams redacted.
+func redactJSON(body []byte) string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return string(body)
+	}
+	for k := range fields {
+		if diag.Contains(secretParams, k) {
+			fields[k] = "REDACTED"
+		}
+	}
+	redacted, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"golang.org/x/oauth2"
+)
+
+func TestRedactForm(t *testing.T) {
+	got := redactForm("grant_type=refresh_token&client_id=id&client_secret=secret&refresh_token=1%2Ftoken")
+	want := "\tclient_id=id\n\tclient_secret=REDACTED\n\tgrant_type=refresh_token\n\trefresh_token=REDACTED"
+	if got != want {
+		t.Errorf("redactForm() got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTokenTracer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		desc    string
+		trace   bool
+		want    []string
+		notWant string
+	}{
+		{
+			desc:    "Error is recorded without tracing",
+			trace:   false,
+			notWant: "Token request",
+		},
+		{
+			desc:    "Trace is redacted",
+			trace:   true,
+			want:    []string{"refresh_token=REDACTED", "400 Bad Request", "OAuth2 error: invalid_grant: Token has been expired or revoked."},
+			notWant: "secretvalue",
+		},
+	}
+
+	for _, tt := range tests {
+		var got strings.Builder
+		log.SetOutput(&got)
+
+		c := Config{
+			ConfigFile: diag.ConfigFile{
+				ConfigKeys: diag.ConfigKeys{
+					ClientSecret: "secretvalue",
+					RefreshToken: "secretvalue",
+				},
+			},
+			TraceToken: tt.trace,
+		}
+		conf := &oauth2.Config{
+			ClientID:     "id",
+			ClientSecret: c.ConfigFile.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: ts.URL},
+		}
+		ctx := c.tokenContext(context.Background())
+		_, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: c.ConfigFile.RefreshToken}).Token()
+		if err == nil {
+			t.Fatalf("[%s] Token() error: nil, want: invalid_grant", tt.desc)
+		}
+
+		if c.tokenErr == nil || c.tokenErr.Code != "invalid_grant" {
+			t.Errorf("[%s] tokenErr: %v, want: invalid_grant", tt.desc, c.tokenErr)
+		}
+		if got := c.decodeError(err); got != InvalidRefreshToken {
+			t.Errorf("[%s] decodeError() got: %s, want: %s", tt.desc, errorNames[got], errorNames[InvalidRefreshToken])
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got.String(), want) {
+				t.Errorf("[%s] got: %s, want substring: %s", tt.desc, got.String(), want)
+			}
+		}
+		if strings.Contains(got.String(), tt.notWant) {
+			t.Errorf("[%s] got: %s, want no substring: %s", tt.desc, got.String(), tt.notWant)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// The release builds set these with -ldflags, e.g.
+// -X github.com/googleads/google-ads-doctor/oauthdoctor/oauth.appVersion=1.0.4.
+// Other builds take them from the module and version control information
+// that Go records in the binary.
+var (
+	appVersion string
+	gitCommit  string
+	buildDate  string
+)
+
+// readBuildInfo is replaced in tests.
+var readBuildInfo = debug.ReadBuildInfo
+
+// BuildInfo identifies the build of this binary, so that a report can be
+// traced back to the code that produced it.
+type BuildInfo struct {
+	// Version is the release version, the module version of go install, or
+	// "source".
+	Version string
+	// Commit is the git commit the binary was built from, if known.
+	Commit string
+	// Date is when the commit was made or the release was built, if known.
+	Date string
+}
+
+// Build returns the build metadata of this binary.
+func Build() BuildInfo {
+	b := BuildInfo{Version: appVersion, Commit: gitCommit, Date: buildDate}
+	if info, ok := readBuildInfo(); ok {
+		if b.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			b.Version = info.Main.Version
+		}
+		// Go 1.18 and later record the VCS settings.
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && b.Commit == "":
+				b.Commit = s.Value
+			case s.Key == "vcs.time" && b.Date == "":
+				b.Date = s.Value
+			}
+		}
+	}
+	if b.Version == "" {
+		b.Version = "source"
+	}
+	return b
+}
+
+// String returns the version followed by the commit and date, if known.
+func (b BuildInfo) String() string {
+	s := b.Version
+	if b.Commit != "" {
+		s += fmt.Sprintf(" (commit %s", b.Commit)
+		if b.Date != "" {
+			s += ", " + b.Date
+		}
+		s += ")"
+	}
+	return s
+}
+
+// shortCommit returns the abbreviated commit of the build.
+func (b BuildInfo) shortCommit() string {
+	if len(b.Commit) > 12 {
+		return b.Commit[:12]
+	}
+	return b.Commit
+}
+
+// Release returns true for the prebuilt release binaries, whose version is
+// set at build time.
+func Release() bool {
+	return appVersion != ""
+}
+
+// userAgent returns a User-Agent HTTP header for this tool.
+func userAgent() string {
+	b := Build()
+	ua := "google-ads-doctor/" + b.Version
+	if c := b.shortCommit(); c != "" {
+		ua += " (" + c + ")"
+	}
+	return ua
+}
@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+// setBuild sets the ldflags variables and the build information of the
+// binary, and returns a function that restores them.
+func setBuild(version, commit, date string, info *debug.BuildInfo) func() {
+	v, c, d, r := appVersion, gitCommit, buildDate, readBuildInfo
+	appVersion, gitCommit, buildDate = version, commit, date
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return info, info != nil }
+	return func() { appVersion, gitCommit, buildDate, readBuildInfo = v, c, d, r }
+}
+
+func TestBuild(t *testing.T) {
+	vcs := &debug.BuildInfo{
+		Main: debug.Module{Path: "github.com/googleads/google-ads-doctor", Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "4f5d6f6a1b2c3d4e5f60718293a4b5c6d7e8f901"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+		},
+	}
+	tests := []struct {
+		desc                  string
+		version, commit, date string
+		info                  *debug.BuildInfo
+		want                  BuildInfo
+		wantUserAgent         string
+	}{
+		{
+			desc:          "release with ldflags",
+			version:       "1.0.3",
+			commit:        "abc1234",
+			date:          "2024-06-01",
+			info:          vcs,
+			want:          BuildInfo{Version: "1.0.3", Commit: "abc1234", Date: "2024-06-01"},
+			wantUserAgent: "google-ads-doctor/1.0.3 (abc1234)",
+		},
+		{
+			desc:          "source with version control information",
+			info:          vcs,
+			want:          BuildInfo{Version: "source", Commit: "4f5d6f6a1b2c3d4e5f60718293a4b5c6d7e8f901", Date: "2024-05-01T10:00:00Z"},
+			wantUserAgent: "google-ads-doctor/source (4f5d6f6a1b2c)",
+		},
+		{
+			desc:          "go install of a module version",
+			info:          &debug.BuildInfo{Main: debug.Module{Path: "github.com/googleads/google-ads-doctor", Version: "v1.0.4"}},
+			want:          BuildInfo{Version: "v1.0.4"},
+			wantUserAgent: "google-ads-doctor/v1.0.4",
+		},
+		{
+			desc:          "no build information",
+			want:          BuildInfo{Version: "source"},
+			wantUserAgent: "google-ads-doctor/source",
+		},
+	}
+
+	for _, tt := range tests {
+		restore := setBuild(tt.version, tt.commit, tt.date, tt.info)
+		if got := Build(); got != tt.want {
+			t.Errorf("[%s] Build() got: %+v, want: %+v", tt.desc, got, tt.want)
+		}
+		if got := userAgent(); got != tt.wantUserAgent {
+			t.Errorf("[%s] userAgent() got: %s, want: %s", tt.desc, got, tt.wantUserAgent)
+		}
+		restore()
+	}
+}
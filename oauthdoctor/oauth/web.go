@@ -20,92 +20,187 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
+	"runtime"
+	"strconv"
+	"time"
 
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 	"golang.org/x/oauth2"
 )
 
-var authCode = make(chan string)
+// callbackPort is the local port of the redirect URI used by the web flow.
+const callbackPort = 8080
+
+// callbackTimeout is how long to wait for the OAuth redirect to reach the
+// local HTTP server.
+var callbackTimeout = 5 * time.Minute
 
 // simulateWebFlow simulates the web flow to see if it succeeds
 // or fails. If it fails, it will try to examine the error and prompt user
-// to fix it. Then it retries to connect again and prints the result of the
-// 2nd attempt.
-func (c *Config) simulateWebFlow() {
-	// Can only register the handle once
-	http.HandleFunc("/", serverHandler)
-
-	accountInfo, err := c.connectWebFlow()
-
-	if err != nil {
-		if c.Verbose {
-			log.Print(err)
-		}
-		c.diagnose(err)
-		accountInfo, err = c.connectWebFlow()
-	}
-
-	close(authCode)
+// to fix it. Then it retries to connect again until all the errors are
+// fixed, see fixAndRetry. It returns the error of the last attempt.
+func (c *Config) simulateWebFlow(ctx context.Context) error {
+	accountInfo, err := c.connectWebFlow(ctx)
+	err = c.fixAndRetry(ctx, err, func(error) error {
+		var rErr error
+		accountInfo, rErr = c.connectWebFlow(ctx)
+		return rErr
+	})
 
 	if err == nil {
 		if c.Verbose {
-			log.Print(accountInfo.String())
+			c.print(accountInfo.String())
 		}
-		log.Println("SUCCESS: OAuth test passed with given config file settings.")
+		c.print(i18n.T("SUCCESS: OAuth test passed with given config file settings."))
 	} else {
 		if c.Verbose {
-			log.Println(err)
+			c.print(err.Error())
 		}
-		log.Println("ERROR: OAuth test failed.")
+		c.print(i18n.T("ERROR: OAuth test failed."))
+	}
+	return err
+}
+
+// connectWebFlow connects with web flow OAuth2 and gets the account info with
+// the new credentials.
+func (c *Config) connectWebFlow(ctx context.Context) (*bytes.Buffer, error) {
+	code, err := c.webAuthCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, _, err := c.oauth2Client(ctx, code)
+	if err != nil {
+		return nil, err
 	}
+	return c.getAccount(ctx, client)
 }
 
-// connectWebFlow connects with web flow OAuth2 and starts a web server in the
-// background. The parent process interacts with users on the command line,
-// while the background process is waiting for the auth code returned
-// after the authentication and authorization step. Once the auth code is
-// received in the background process, the command line will continue the
-// simulation process.
-func (c *Config) connectWebFlow() (*bytes.Buffer, error) {
-	log.Print("You will need to enter the URL http://localhost:8080 as a valid " +
+// webAuthCode starts a web server in the background and returns the auth
+// code of the OAuth redirect. The parent process interacts with users on the
+// command line, while the background process is waiting for the auth code
+// returned after the authentication and authorization step. The options are
+// added to the URL of the consent page.
+func (c *Config) webAuthCode(ctx context.Context, opts ...oauth2.AuthCodeOption) (string, error) {
+	c.print(i18n.T("You will need to enter the URL http://localhost:8080 as a valid " +
 		"redirect URI in your Google APIs Console's project (https://console.developers.google.com/apis/library). " +
 		"Please follow this guide (https://developers.google.com/google-ads/api/docs/oauth/cloud-project) " +
-		"for further instructions.")
-	conf := c.oauth2Conf("http://localhost:8080")
+		"for further instructions."))
+	if rt := detectContainer(); rt != "" {
+		c.print(containerSuggestions(rt, callbackPort))
+	} else if kind := detectHeadless(); kind != "" {
+		c.print(headlessSuggestions(kind, callbackPort))
+	}
+	conf := c.oauth2Conf(WebRedirectURL)
 
 	// Redirect user to Google's consent page to ask for permission
 	// for the scopes specified above.
-	url := conf.AuthCodeURL("state", oauth2.AccessTypeOffline)
-	log.Printf("Visit the URL for the auth dialog:\n%s\n", url)
-
-	srv := runServer()
+	url := conf.AuthCodeURL("state", append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, opts...)...)
+	c.showAuthURL(url)
+	if c.QRCode {
+		return c.pastedAuthCode()
+	}
 
-	code := <-authCode
+	authCode := make(chan string, 1)
+	denied := make(chan string, 1)
+	srv, srvErr := c.runServer(authCode, denied)
+	defer srv.Shutdown(context.Background())
+
+	select {
+	case code := <-authCode:
+		return code, nil
+	case code := <-denied:
+		return "", &consentError{code}
+	case err := <-srvErr:
+		return "", i18n.Errorf("Cannot start the HTTP server at port %d: %s", callbackPort, err)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(callbackTimeout):
+		c.diagnoseCallbackTimeout(callbackPort)
+		return "", i18n.Errorf("Timed out waiting for the OAuth redirect at port %d", callbackPort)
+	}
+}
 
-	srv.Shutdown(context.Background())
+// runServer starts a HTTP server as a background process, which sends the
+// auth code of the OAuth redirect to authCode, or its error to denied. The
+// returned channel receives an error if the server cannot listen on
+// callbackPort.
+func (c *Config) runServer(authCode, denied chan<- string) (*http.Server, <-chan error) {
+	c.print(i18n.T("Running HTTP server in the background at port 8080..."))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.serverHandler(authCode, denied))
+	srv := &http.Server{Addr: ":" + strconv.Itoa(callbackPort), Handler: mux}
+	errc := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			errc <- err
+		}
+	}()
+	return srv, errc
+}
 
-	client, _ := c.oauth2Client(code)
-	return c.getAccount(client)
+// diagnoseCallbackTimeout explains why the OAuth redirect may not have
+// reached the local HTTP server. When the server is listening, the redirect
+// is most likely blocked by a firewall, so OS specific suggestions are
+// printed, or by the network of the container or the remote machine the
+// doctor runs in.
+func (c *Config) diagnoseCallbackTimeout(port int) {
+	if !listening(port) {
+		c.print(i18n.Sprintf("ERROR: The HTTP server is not accepting connections at port %d.", port))
+		return
+	}
+	if rt := detectContainer(); rt != "" {
+		c.print(i18n.Sprintf("ERROR: The HTTP server is listening at port %d, but the OAuth redirect never arrived.", port))
+		c.print(containerSuggestions(rt, port))
+		return
+	}
+	if kind := detectHeadless(); kind != "" {
+		c.print(i18n.Sprintf("ERROR: The HTTP server is listening at port %d, but the OAuth redirect never arrived.", port))
+		c.print(headlessSuggestions(kind, port))
+		return
+	}
+	c.print(i18n.Sprintf("ERROR: The HTTP server is listening at port %d, but the OAuth redirect never arrived. "+
+		"Make sure that the browser runs on this machine and that loopback connections are allowed by your firewall.", port))
+	c.print(firewallSuggestions(runtime.GOOS, port))
 }
 
-// runServer starts a HTTP server as a background process.
-func runServer() *http.Server {
-	log.Print("Running HTTP server in the background at port 8080...")
-	srv := &http.Server{Addr: ":8080"}
-	go srv.ListenAndServe()
-	return srv
+// listening returns true when a TCP connection to the given local port
+// succeeds.
+func listening(port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), 3*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
 }
 
-// serverHandler handles all the HTTP home page requests. It parses the auth
-// code and sends it to the channel, so the parent process can continue the
-// simulation at the command line.
-func serverHandler(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
+// serverHandler returns the handler of all the HTTP home page requests. It
+// parses the auth code, or the error of the consent page, and sends it to the
+// channel, so the parent process can continue the simulation at the command
+// line.
+func (c *Config) serverHandler(authCode, denied chan<- string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Query().Get("code")
+
+		if e := r.URL.Query().Get("error"); e != "" {
+			select {
+			case denied <- e:
+			default:
+			}
+			fmt.Fprint(w, i18n.Sprintf("The consent page returned the error %s. See the doctor for help.", e))
+			return
+		}
 
-	if code != "" {
-		authCode <- code
-		log.Print("OAuth code received by the HTTP server handler: " + code)
-		fmt.Fprintf(w, "Auth code received")
+		if code != "" {
+			select {
+			case authCode <- code:
+				c.print(i18n.T("OAuth code received by the HTTP server handler: ") + code)
+			default:
+				// A code was already received
+			}
+			fmt.Fprint(w, i18n.T("Auth code received"))
+		}
 	}
 }
@@ -14,106 +14,645 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/doctor"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
 	"github.com/googleads/google-ads-doctor/oauthdoctor/oauth"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/report"
 )
 
 var (
-	oauthTypes = []string{diag.InstalledApp, diag.Web, diag.ServiceAccount}
-	language   = flag.String("language", "", "Required: The programming language of Google Ads API client library")
-	oauthType  = flag.String("oauthtype", "Required: The OAuth2 type for Google Ads API.", fmt.Sprintf("Values: %s", strings.Join(oauthTypes, ", ")))
-	configPath = flag.String("configpath", "", "Optional: An absolute file path for Google Ads API configuration file")
-	customerId = flag.String("customerid", "", "Optional: A customer ID. Providing this value avoids prompting for a customer ID during execution.")
-	hidePII    = flag.Bool("hidepii", true, "Optional: Suppress output of Personally Identifiable Information")
-	sysinfo    = flag.Bool("sysinfo", false, "Optional: Print system information.")
-	verbose    = flag.Bool("verbose", false, "Optional: Print out debugging info, such as JSON response")
+	language       = flag.String("language", "", "Required: The programming language of Google Ads API client library")
+	oauthType      = flag.String("oauthtype", "", fmt.Sprintf("Optional: The OAuth2 type for Google Ads API. Detected from the credentials in the config file by default. Values: %s", strings.Join(doctor.OAuthTypes, ", ")))
+	configPath     = flag.String("configpath", "", "Optional: An absolute file path for Google Ads API configuration file")
+	configFormat   = flag.String("configformat", "", "Optional: The format of the configuration when it is not the native file of the client library. Values: "+diag.JSONFormat+", "+diag.EnvFormat+" (environment variables only). Detected for dotnet: App.config, then appsettings.json, then environment variables.")
+	decryptCmd     = flag.String("decrypt-cmd", "", "Optional: A shell command that decrypts the config file, e.g. encrypted with SOPS, age or ansible-vault. The file is piped to its stdin and the plaintext read from its stdout, so it is never written to disk, and the config file is not changed. Example: \"sops -d --input-type yaml --output-type yaml /dev/stdin\"")
+	devToken       = flag.String("devtoken", "", "Optional: With -language rest, the developer token. Default: $GOOGLE_ADS_DEVELOPER_TOKEN")
+	clientID       = flag.String("clientid", "", "Optional: With -language rest, the OAuth2 client ID. Default: $GOOGLE_ADS_CLIENT_ID")
+	clientSecret   = flag.String("clientsecret", "", "Optional: With -language rest, the OAuth2 client secret. Default: $GOOGLE_ADS_CLIENT_SECRET")
+	refreshToken   = flag.String("refreshtoken", "", "Optional: With -language rest, the OAuth2 refresh token. Default: $GOOGLE_ADS_REFRESH_TOKEN")
+	loginCID       = flag.String("logincustomerid", "", "Optional: With -language rest, the login customer ID. Default: $GOOGLE_ADS_LOGIN_CUSTOMER_ID")
+	credFiles      = flag.String("credential-files", "", "Optional: Comma-separated NAME=PATH pairs of files that hold the values of environment variables, e.g. GOOGLE_ADS_REFRESH_TOKEN=/run/secrets/google_ads_refresh_token. Same as setting NAME_FILE=PATH, which is also read, as are the systemd credentials in $CREDENTIALS_DIRECTORY.")
+	clientSecrets  = flag.String("import-client-secrets", "", "Optional: The JSON file of an OAuth client downloaded from the Google Cloud console (client_secret_*.json). Its client ID and client secret are written to the config file before the diagnosis, and its type (desktop or web) sets -oauthtype, which must match when given.")
+	useKeyring     = flag.Bool("keyring", false, "Optional: Read the client secret and refresh token from the OS credential store when the config file does not set them, and save new ones there.")
+	migrateKeyring = flag.Bool("migratekeyring", false, "Optional: Move the client secret and refresh token from the config file to the OS credential store.")
+	customerId     = flag.String("customerid", "", "Optional: A customer ID. Providing this value avoids prompting for a customer ID during execution.")
+	customerIDs    = flag.String("cids", "", "Optional: A CSV file with customer IDs in the first column. After the OAuth test passes, the access of the credentials to each account is checked.")
+	apiVersion     = flag.String("api-version", "", "Optional: With -sysinfo, the Google Ads API version that your client library uses, e.g. v17, to check that it is still served.")
+	hidePII        = flag.Bool("hidepii", true, "Optional: Suppress output of Personally Identifiable Information")
+	sysinfo        = flag.Bool("sysinfo", false, "Optional: Print system information.")
+	tcpCheck       = flag.Bool("tcpcheck", false, "Optional: With -sysinfo, also open a plain TCP connection to the endpoint, as older versions did, to compare with the HTTPS connectivity check.")
+	netperf        = flag.Bool("netperf", false, "Optional: Measure the network latency to the Google Ads API endpoint.")
+	mutate         = flag.Bool("mutate", false, "Optional: After the OAuth test passes, validate a mutate call in the account (validate_only, nothing is changed) to check that the credentials and the developer token can also write.")
+	authEndpoint   = flag.String("auth-endpoint", "", "Optional: The URL of the OAuth2 consent page, e.g. a proxy or an emulator.")
+	tokenEndpoint  = flag.String("token-endpoint", "", "Optional: The URL of the OAuth2 token endpoint, e.g. a proxy or an emulator.")
+	scopes         = flag.String("scopes", "", "Optional: Comma-separated OAuth2 scopes to request and verify in addition to the Google Ads API scope, e.g. email,profile")
+	checkTimeout   = flag.Duration("check-timeout", 30*time.Second, "Optional: The deadline of each check that does not ask for input, e.g. the network checks, so that a blocked network call cannot stall the diagnosis. A check that does not complete in time is reported as TIMEOUT. 0 means no limit.")
+	reqTimeout     = flag.Duration("requesttimeout", 0, "Optional: The deadline of the Google Ads API request, e.g. 30s, to reproduce DEADLINE_EXCEEDED errors of your client library. There is no limit by default.")
+	knowledgeBase  = flag.String("knowledge-base", "", "Optional: A JSON file, or the URL of a published one, with entries of the error knowledge base that replace the built-in entries of their codes or add new errors. See the README for the format.")
+	plugins        = flag.String("plugins", "", "Optional: Comma-separated paths of executables that add custom checks. See doctor/plugin.go for the protocol.")
+	showVersion    = flag.Bool("version", false, "Optional: Print the version, git commit and build date of the doctor and exit.")
+	undo           = flag.Bool("undo", false, "Optional: Restore the config files changed by the last run of the doctor that changed them from the backups it made, and exit.")
+	listChecks     = flag.Bool("list-checks", false, "Optional: Print the checks of the diagnosis in JSON and exit.")
+	noColor        = flag.Bool("no-color", false, "Optional: Do not color the errors, warnings and successes. Colors are only used when the output is a terminal.")
+	quiet          = flag.Bool("quiet", false, "Optional: Only print warnings, errors and the summary, e.g. in scheduled jobs.")
+	logFormat      = flag.String("log-format", textLog, "Optional: The format of the output. Values: "+textLog+", "+jsonLog+" (one JSON object per line with the time, level and message, and the check, status, code, duration and request_id of the check results, e.g. for log pipelines).")
+	logFile        = flag.String("log-file", "", "Optional: Also write the messages and the check results to this file as JSON lines, like -log-format "+jsonLog+".")
+	reportFile     = flag.String("report", "", "Optional: Save the report of the diagnosis to this file: the check results with their error codes and links to fix them, the system information, the changes of the config file and the HTTP requests, with secrets redacted.")
+	reportFormat   = flag.String("format", report.JSONFormat, "Optional: The format of -report. Values: "+report.JSONFormat+", "+report.HTMLFormat+" (a single page with a collapsible section per check, e.g. to share with non-technical stakeholders).")
+	verbose        = flag.Bool("verbose", false, "Optional: Print out debugging info, such as JSON response")
+	caCert         = flag.String("cacert", "", "Optional: A PEM file of root certificates to trust in addition to those of this machine, e.g. of a corporate proxy that intercepts HTTPS traffic. Used by all the connections of the doctor.")
+	clientCert     = flag.String("client-cert", "", "Optional: A PEM client certificate presented in all the TLS connections of the doctor, e.g. to an egress proxy that requires one. Requires -client-key.")
+	clientKey      = flag.String("client-key", "", "Optional: The PEM private key of -client-cert.")
+	endpoint       = flag.String("endpoint", "", "Optional: The Google Ads API endpoint, e.g. a testing proxy. Overrides the endpoint in the config file. Default: "+diag.DefaultEndpoint)
+	timeout        = flag.Duration("timeout", 0, "Optional: Stop the diagnosis after the given duration, e.g. 2m. There is no limit by default.")
+	clipboard      = flag.Bool("clipboard", false, "Optional: When asked for the authorization code or a new developer token, press Enter without typing it to read it from the clipboard, e.g. in Windows terminals where pasting is error prone. The consent URL is copied to the clipboard in any case.")
+	qrCode         = flag.Bool("qr", false, "Optional: Also print the URL of the consent page as a QR code, to open it on your phone when this machine has no browser, e.g. over SSH. With the web OAuth type, you then paste the address that your phone was redirected to instead of waiting for the redirect.")
+	autoFix        = flag.Bool("autofix", false, "Optional: Apply the fixes that need no input without asking, e.g. setting a misspelled key or removing the dashes of the login customer ID. The other fixes are still offered.")
+	failFast       = flag.Bool("failfast", false, "Optional: Stop at the first failed check instead of asking to fix it, and exit with status 3 when a check failed, e.g. as a preflight check in a deployment pipeline.")
+	nonInteractive = flag.Bool("noninteractive", false, "Optional: Never prompt for input, e.g. when running in CI. Questions are answered with no and the config file is not changed.")
+	traceToken     = flag.Bool("tracetoken", false, "Optional: Print the requests to the OAuth2 token endpoint and their responses, with secrets redacted.")
+	record         = flag.String("record", "", "Optional: Save the HTTP traffic of the diagnosis, with secrets redacted, to this file.")
+	replayFile     = flag.String("replay", "", "Optional: Run the diagnosis against the HTTP traffic saved with -record instead of the network.")
+	templateDiff   = flag.Bool("template-diff", false, "Optional: Compare the config file with the one the client library expects and print the differences, e.g. misspelled keys, keys in the wrong section and missing keys, instead of running the diagnosis.")
+	emitSample     = flag.Bool("emit-sample", false, "Optional: When all the checks pass, print sample code of a first API call with your client library, your config file and customer ID.")
+	shareOutcome   = flag.Bool("share-outcome", false, "Optional: After the diagnosis, show an anonymous summary (language, OAuth type, OS, and the status and error code of each check) and send it to the maintainers if you confirm. No identifiers are sent.")
+	outcomeURL     = flag.String("outcome-url", defaultOutcomeURL, "Optional: The collection endpoint of -share-outcome.")
+	outputLang     = flag.String("lang", i18n.English, fmt.Sprintf("Optional: The language of the output messages. Values: %s", strings.Join(i18n.Languages(), ", ")))
 )
 
+// setValues are the KEY=VALUE assignments of -set.
+var setValues assignments
+
+func init() {
+	flag.Var(&setValues, "set", "Optional: Set a value of the config file, e.g. -set refresh_token=1//0abc, and exit. KEY is a key of the config file or a field such as RefreshToken. Repeat it to set several values.")
+}
+
+// assignments are the values of a flag that can be repeated.
+type assignments []string
+
+func (a *assignments) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *assignments) Set(v string) error {
+	*a = append(*a, v)
+	return nil
+}
+
+// defaultOutcomeURL is the collection endpoint of -share-outcome. Release
+// builds set it with -ldflags "-X main.defaultOutcomeURL=...".
+var defaultOutcomeURL string
+
+// Exit codes of the doctor.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	// exitFailed means that a check failed, and is only used with -failfast
+	// so that existing scripts keep working.
+	exitFailed = 3
+)
+
+// Formats of -log-format.
+const (
+	textLog = "text"
+	jsonLog = "json"
+)
+
+// stdout receives the output of the doctor that is not a message of the
+// diagnosis, e.g. the summary. With -log-format json, it is the structured
+// logger, so that the output stays one JSON object per line.
+var stdout io.Writer = os.Stdout
+
+// stopGracePeriod is how long the doctor waits for the diagnosis to stop
+// after it is interrupted or times out. Reading from stdin cannot be
+// cancelled, so the doctor exits when the diagnosis is waiting for input.
+const stopGracePeriod = 3 * time.Second
+
+// Subcommands that manage the refresh token instead of running the
+// diagnosis, e.g. oauthdoctor mint-token -language python -oauthtype web.
+const (
+	mintTokenCommand = "mint-token"
+	revokeCommand    = "revoke"
+	// editCommand changes the values of the config file in a menu, or the
+	// ones of -set.
+	editCommand = "edit"
+	// selfTestCommand checks the parsers and writers of the config files
+	// on this platform, and needs no flags.
+	selfTestCommand = "selftest"
+	// compareCommand prints the differences between two reports saved with
+	// -report, e.g. oauthdoctor compare before.json after.json.
+	compareCommand = "compare"
+)
+
+// usageError is returned by run when the command line flags are invalid.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// failedError is returned by run when a check failed with -failfast.
+type failedError struct {
+	msg string
+}
+
+func (e failedError) Error() string {
+	return e.msg
+}
+
 func main() {
 	log.SetOutput(os.Stdout)
+	var command string
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == mintTokenCommand || args[0] == revokeCommand || args[0] == editCommand || args[0] == selfTestCommand ||
+		args[0] == compareCommand) {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
+
+	ctx, cancel := interruptContext(context.Background())
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+	}
+	go func() {
+		<-ctx.Done()
+		time.Sleep(stopGracePeriod)
+		log.Print(i18n.Sprintf("The diagnosis was stopped: %s", ctx.Err()))
+		os.Exit(exitError)
+	}()
+
+	err := run(ctx, command)
+	cancel()
+	if err != nil {
+		log.Print(err)
+		if _, ok := err.(usageError); ok {
+			os.Exit(exitUsage)
+		}
+		if _, ok := err.(failedError); ok {
+			os.Exit(exitFailed)
+		}
+		os.Exit(exitError)
+	}
+	os.Exit(exitOK)
+}
+
+// interruptContext returns a context that is cancelled when the process
+// receives SIGINT or SIGTERM. A second signal terminates the process.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigc:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigc)
+	}()
+	return ctx, cancel
+}
 
-	if err := diag.MinGoVersion(); err != nil {
-		log.Fatal(err)
+// newReporter returns the reporter of -log-format and -log-file, and a
+// function that closes the log file. With -log-format json, the messages
+// printed with the standard logger are also written as JSON lines.
+func newReporter() (report.Reporter, func(), error) {
+	var reporter report.Reporter
+	switch *logFormat {
+	case textLog:
+		reporter = report.LogReporter{
+			Color: !*noColor && report.ColorSupported(os.Stdout),
+			Quiet: *quiet,
+		}
+	case jsonLog:
+		logger := report.NewLogger(os.Stdout)
+		log.SetFlags(0)
+		log.SetOutput(logger)
+		stdout = logger
+		reporter = report.JSONReporter{Logger: logger}
+	default:
+		return nil, nil, i18n.Errorf("Unknown log format %s. Values: %s, %s", *logFormat, textLog, jsonLog)
+	}
+	if *logFile == "" {
+		return reporter, func() {}, nil
 	}
+	f, err := os.OpenFile(*logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, i18n.Errorf("Cannot open the log file: %s", err)
+	}
+	file := report.JSONReporter{Logger: report.NewLogger(f)}
+	return report.Reporters{reporter, file}, func() { f.Close() }, nil
+}
 
-	flag.Parse()
+// run diagnoses the client library configuration given in the command line
+// flags and prints a summary of the results. The mint-token and revoke
+// commands only generate or revoke a refresh token, the edit command only
+// changes values of the config file, the selftest command only tests the
+// config file parsers, and the compare command only compares two reports.
+func run(ctx context.Context, command string) error {
+	if err := i18n.SetLanguage(*outputLang); err != nil {
+		return usageError{err.Error()}
+	}
+	reporter, closeLog, err := newReporter()
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	defer closeLog()
 
-	if flag.NFlag() < 2 {
-		log.Fatalf("Please provide --language and --oauthtype")
+	if !diag.Contains(report.Formats, *reportFormat) {
+		return usageError{i18n.Sprintf("Report format not supported: %s", *reportFormat)}
 	}
 
-	language := strings.ToLower(*language)
-	languages := diag.ListLanguages()
-	if ok := diag.Contains(languages, language); !ok {
-		l := strings.Join(languages, ",")
-		log.Fatalf("You specified %s. Supported languages are %s\n", language, l)
+	// The errors of -cacert and -client-cert are reported by the diagnosis.
+	client, tlsErr := httpClient()
+	var kb oauth.KnowledgeBase
+	if *knowledgeBase != "" {
+		// A published knowledge base is downloaded through the same proxy as
+		// the checks.
+		err := tlsErr
+		if err == nil {
+			kb, err = oauth.LoadKnowledgeBase(ctx, client, *knowledgeBase)
+		}
+		if err != nil {
+			log.Print(i18n.Sprintf("WARNING: The built-in error knowledge base is used: %s", err))
+		}
 	}
-	log.Printf("Client library language: %s\n", language)
 
-	// Print system info
-	if *sysinfo {
-		s := diag.SysInfo{}
-		s.Init()
-		s.Print()
-		diag.PrintIPv4(s.Host)
+	if *showVersion {
+		fmt.Printf("google-ads-doctor %s %s/%s %s\n", oauth.Build(), runtime.GOOS, runtime.GOARCH, runtime.Version())
+		return nil
+	}
 
-		err := diag.ConnEndpoint()
+	if *listChecks {
+		b, err := json.MarshalIndent(doctor.Checks(), "", "  ")
 		if err != nil {
-			log.Printf("Connect to endpoint error: %s", err)
-		} else {
-			fmt.Printf("Connected to %s\n", diag.ENDPOINT)
+			return err
 		}
+		fmt.Println(string(b))
+		return nil
 	}
 
-	// Verify the existence of the config file
-	cfg := diag.GetConfigFile(language, *configPath)
-	*configPath = cfg.GetFilepath()
-	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
-		log.Fatalf("Cannot find config file (%s): %s\n", *configPath, err)
+	if command == selfTestCommand {
+		return selfTest()
 	}
-	log.Printf("Google Ads API client library config file: %s\n", *configPath)
 
-	// Verify OAuth type
-	if ok := diag.Contains(oauthTypes, *oauthType); !ok {
-		log.Fatalf("OAuth type not supported: %s", *oauthType)
+	if command == compareCommand {
+		return compareReports(flag.Args())
 	}
 
-	var err error
-	// Parse config file and get a map of key:value
-	switch language {
-	case "dotnet":
-		cfg, err = diag.ParseXMLFile(*configPath, *oauthType)
-	default:
-		cfg, err = diag.ParseKeyValueFile(language, *configPath, *oauthType)
+	if *undo {
+		return undoChanges()
 	}
+
+	if *language == "" {
+		return usageError{i18n.T("Please provide --language")}
+	}
+
+	opts := doctor.Options{
+		Language:       *language,
+		OAuthType:      *oauthType,
+		ConfigPath:     *configPath,
+		ConfigFormat:   *configFormat,
+		DecryptCommand: *decryptCmd,
+		ClientSecrets:  *clientSecrets,
+		Keyring:        *useKeyring,
+		MigrateKeyring: *migrateKeyring,
+		CustomerID:     *customerId,
+		Endpoint:       *endpoint,
+		APIVersion:     *apiVersion,
+		CACert:         *caCert,
+		ClientCert:     *clientCert,
+		ClientKey:      *clientKey,
+		AuthURL:        *authEndpoint,
+		TokenURL:       *tokenEndpoint,
+		HidePII:        *hidePII,
+		SysInfo:        *sysinfo,
+		TCPCheck:       *tcpCheck,
+		NetPerf:        *netperf,
+		Mutate:         *mutate,
+		Verbose:        *verbose,
+		RequestTimeout: *reqTimeout,
+		CheckTimeout:   *checkTimeout,
+		TraceToken:     *traceToken,
+		FailFast:       *failFast,
+		AutoFix:        *autoFix,
+		QRCode:         *qrCode,
+		Clipboard:      *clipboard,
+		Record:         *record,
+		Replay:         *replayFile,
+		Traces:         *reportFile != "",
+		KnowledgeBase:  kb,
+	}
+	if tlsErr == nil {
+		opts.HTTPClient = client
+	}
+	if strings.ToLower(*language) == diag.RESTLanguage {
+		opts.Credentials = diag.ConfigKeys{
+			DevToken:        *devToken,
+			ClientID:        *clientID,
+			ClientSecret:    *clientSecret,
+			RefreshToken:    *refreshToken,
+			LoginCustomerID: *loginCID,
+		}
+	}
+	if *credFiles != "" {
+		files, err := parseCredentialFiles(*credFiles)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		opts.CredentialFiles = files
+	}
+	if *scopes != "" {
+		opts.Scopes = strings.Split(*scopes, ",")
+	}
+	if *plugins != "" {
+		opts.Plugins = strings.Split(*plugins, ",")
+	}
+	if *customerIDs != "" {
+		cids, err := doctor.ReadCustomerIDs(*customerIDs)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		opts.CustomerIDs = cids
+	}
+	if *nonInteractive {
+		opts.Prompter = prompt.NonInteractive{}
+	}
+	opts.Reporter = reporter
+	if err := opts.Validate(); err != nil {
+		return usageError{err.Error()}
+	}
+
+	if *templateDiff {
+		return printTemplateDiff(opts)
+	}
+
+	if len(setValues) > 0 && command == "" {
+		command = editCommand
+	}
+
+	// The values replaced by the doctor are listed at the end, even when the
+	// diagnosis fails, and saved for -undo.
+	defer func() {
+		changes := diag.Changes()
+		printChanges(changes)
+		if err := diag.SaveChanges(changes); err != nil {
+			log.Print(i18n.Sprintf("Cannot save the changes for -undo: %s", err))
+		}
+	}()
+
+	switch command {
+	case mintTokenCommand:
+		if err := doctor.MintToken(ctx, opts); err != nil {
+			if ctx.Err() != nil {
+				return i18n.Errorf("The diagnosis was stopped: %s", ctx.Err())
+			}
+			return err
+		}
+		return nil
+	case revokeCommand:
+		revoked, err := doctor.RevokeToken(ctx, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return i18n.Errorf("The diagnosis was stopped: %s", ctx.Err())
+			}
+			return err
+		}
+		if revoked {
+			fmt.Fprintln(stdout, i18n.Sprintf("Run oauthdoctor %s to generate a new refresh token.", mintTokenCommand))
+		}
+		return nil
+	case editCommand:
+		return doctor.EditConfig(opts, setValues)
+	}
+
+	r, err := doctor.Run(ctx, opts)
 	if err != nil {
-		log.Fatalf("Cannot parse %s: %s", *configPath, err)
+		if ctx.Err() != nil {
+			return i18n.Errorf("The diagnosis was stopped: %s", ctx.Err())
+		}
+		return err
 	}
 
-	cfg.Print(*hidePII)
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, i18n.T("Summary:"))
+	fmt.Fprintln(stdout, r.Narrative())
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, r.Health())
+	if *reportFile != "" {
+		if err := r.WriteFile(*reportFile, *reportFormat); err != nil {
+			log.Print(err)
+		} else {
+			fmt.Fprintln(stdout, i18n.Sprintf("The report was saved to %s.", *reportFile))
+		}
+	}
+	if ctx.Err() != nil {
+		return i18n.Errorf("The diagnosis was stopped: %s", ctx.Err())
+	}
+	if *emitSample {
+		printSample(r, opts)
+	}
+	if *shareOutcome {
+		shareOutcomeSummary(ctx, r, client, opts.Prompter)
+	}
+	if *failFast && r.Failed() {
+		return failedError{i18n.T("The diagnosis stopped at the first failed check.")}
+	}
+	return nil
+}
 
-	if ok, err := cfg.Validate(); !ok {
-		log.Printf("Config file validation failed: %s\n", err)
+// httpClient returns the HTTP client of the doctor, which trusts -cacert and
+// presents -client-cert, or http.DefaultClient without them.
+func httpClient() (*http.Client, error) {
+	config, err := diag.TLSConfig(*caCert, *clientCert, *clientKey)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return http.DefaultClient, nil
 	}
+	return &http.Client{Transport: diag.NewTransport(config)}, nil
+}
 
-	var cid string
-	if strings.TrimSpace(*customerId) == "" {
-		cid = oauth.ReadCustomerID()
+// parseCredentialFiles parses the NAME=PATH pairs of -credential-files.
+func parseCredentialFiles(s string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, i18n.Errorf("Invalid -credential-files pair %q: want NAME=PATH", pair)
+		}
+		files[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return files, nil
+}
+
+// printTemplateDiff prints the differences between the config file and the
+// one the client library expects.
+func printTemplateDiff(opts doctor.Options) error {
+	d, findings, err := doctor.TemplateDiff(opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout)
+	if d == "" {
+		fmt.Fprintln(stdout, i18n.T("The config file has the keys that the client library expects."))
 	} else {
-		cid = strings.ReplaceAll(*customerId, "-", "")
+		fmt.Fprintln(stdout, i18n.T("Differences with the config file that the client library expects (- in your file, + expected):"))
+		fmt.Fprintln(stdout, d)
+	}
+	for _, f := range findings {
+		fmt.Fprintln(stdout, i18n.Sprintf("%s: %s", f.Severity, f.Message))
 	}
+	return nil
+}
 
-	c := oauth.Config{
-		ConfigFile: cfg,
-		CustomerID: cid,
-		OAuthType:  *oauthType,
-		Verbose:    *verbose,
+// printSample prints the sample code of a first API call, which is only
+// useful when the credentials work.
+func printSample(r *report.Report, opts doctor.Options) {
+	fmt.Fprintln(stdout)
+	if c, ok := r.Check(report.OAuthCheck); !ok || c.Status != report.Pass || r.Failed() {
+		fmt.Fprintln(stdout, i18n.T("The sample code is only printed when all the checks pass."))
+		return
+	}
+	sample, err := doctor.Sample(opts.Language, opts.ConfigPath, r.CustomerID)
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return
+	}
+	fmt.Fprintln(stdout, i18n.T("Make your first API call with this code:"))
+	fmt.Fprintln(stdout, sample)
+}
+
+// printChanges prints the summary of the values of the configuration that
+// the doctor replaced during the session, with the backups to restore them
+// from.
+func printChanges(changes []diag.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, i18n.T("Changes made in this session:"))
+	for _, c := range changes {
+		line := i18n.Sprintf("%s  %s in %s (was %s)", c.Time.Format("2006-01-02 15:04:05"), c.Key, c.Location, c.OldValue)
+		if c.Backup != "" {
+			line += i18n.Sprintf(", backup: %s", c.Backup)
+		}
+		fmt.Fprintln(stdout, "  "+line)
+	}
+}
+
+// undoChanges restores the config files changed by the last run of the
+// doctor and prints what was restored.
+func undoChanges() error {
+	restored, skipped, err := diag.UndoChanges()
+	for _, r := range restored {
+		fmt.Fprintln(stdout, i18n.Sprintf("Restored %s from %s. The replaced file is backed up to %s.", r.File, r.From, r.Backup))
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range skipped {
+		fmt.Fprintln(stdout, i18n.Sprintf("%s in %s has no backup and cannot be restored. Its old value was %s.", c.Key, c.Location, c.OldValue))
+	}
+	return nil
+}
+
+// shareOutcomeSummary shows the anonymous outcome of the diagnosis and sends
+// it to the collection endpoint only if the user agrees. Errors are printed
+// but do not fail the run, since sharing is not part of the diagnosis.
+func shareOutcomeSummary(ctx context.Context, r *report.Report, client *http.Client, p prompt.Prompter) {
+	if *outcomeURL == "" {
+		log.Print(i18n.T("No collection endpoint is configured for -share-outcome. Set one with -outcome-url."))
+		return
+	}
+	o := r.Outcome()
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, i18n.Sprintf("This anonymous summary will be sent to %s:\n%s", *outcomeURL, o.JSON()))
+	if p == nil {
+		p = prompt.NewTerminal(os.Stdin, os.Stdout)
+	}
+	yes, err := p.Confirm(i18n.T("Enter Y to send it [Anything else is No] >> "))
+	if err != nil || !yes {
+		fmt.Fprintln(stdout, i18n.T("The summary was NOT sent."))
+		return
+	}
+	if err := report.PostOutcome(ctx, client, *outcomeURL, o); err != nil {
+		log.Print(i18n.Sprintf("Cannot send the summary: %s", err))
+		return
+	}
+	fmt.Fprintln(stdout, i18n.T("Thank you. The summary was sent."))
+}
+
+// selfTest runs the self-test of the parsers and writers of the config files
+// in a temporary directory and prints the results.
+func selfTest() error {
+	dir, err := ioutil.TempDir("", "oauthdoctor-selftest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	// The writer logs the backups of the files, which is noise here.
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+		defer log.SetOutput(os.Stdout)
+	}
+
+	failed := 0
+	for _, r := range diag.SelfTest(dir) {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintln(stdout, i18n.Sprintf("FAIL %s: %s", r.Name, r.Err))
+		} else {
+			fmt.Fprintln(stdout, i18n.Sprintf("PASS %s", r.Name))
+		}
+		for _, note := range r.Notes {
+			fmt.Fprintln(stdout, "     "+note)
+		}
+	}
+	if failed > 0 {
+		return i18n.Errorf("%d self-tests failed. Do not let this build of the doctor change your config files, "+
+			"and report the failures to the maintainers.", failed)
+	}
+	fmt.Fprintln(stdout, i18n.T("The self-test passed: this build of the doctor parses and rewrites the config files of all the languages on this platform."))
+	return nil
+}
+
+// compareReports prints the differences between the two JSON reports at
+// paths: the checks whose status changed, the config values and the
+// environment.
+func compareReports(paths []string) error {
+	if len(paths) != 2 {
+		return usageError{i18n.Sprintf("Usage: oauthdoctor %s BEFORE.json AFTER.json, with reports saved with -report", compareCommand)}
+	}
+	before, err := report.ReadFile(paths[0])
+	if err != nil {
+		return err
+	}
+	after, err := report.ReadFile(paths[1])
+	if err != nil {
+		return err
 	}
-	c.SimulateOAuthFlow()
+	fmt.Fprintln(stdout, report.Compare(before, after))
+	return nil
 }
@@ -0,0 +1,114 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prompt defines how the doctor asks the user for input, so that
+// front-ends other than a terminal can answer its questions.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// ErrNoInput is returned when a question requires an answer, but the user
+// cannot be asked.
+var ErrNoInput = errors.New("no input available in non-interactive mode")
+
+// Prompter asks the user for input.
+type Prompter interface {
+	// ReadLine displays msg and returns the line entered by the user
+	// without surrounding spaces.
+	ReadLine(msg string) (string, error)
+	// Confirm displays a yes/no question and returns true when the user
+	// answers yes.
+	Confirm(msg string) (bool, error)
+	// Select displays msg and the options, and returns the index of the
+	// option chosen by the user.
+	Select(msg string, options []string) (int, error)
+}
+
+// Terminal asks the user for input on a text terminal.
+type Terminal struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewTerminal returns a Terminal that writes the questions to out and reads
+// the answers from in.
+func NewTerminal(in io.Reader, out io.Writer) *Terminal {
+	return &Terminal{in: bufio.NewReader(in), out: out}
+}
+
+// ReadLine writes msg and reads a line.
+func (t *Terminal) ReadLine(msg string) (string, error) {
+	fmt.Fprint(t.out, msg)
+	line, err := t.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return line, err
+}
+
+// Confirm writes msg and returns true when the answer is Y or yes.
+func (t *Terminal) Confirm(msg string) (bool, error) {
+	answer, err := t.ReadLine(msg)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"), nil
+}
+
+// Select writes msg followed by the numbered options, and reads the number
+// of the chosen option until a valid one is entered.
+func (t *Terminal) Select(msg string, options []string) (int, error) {
+	fmt.Fprintln(t.out, msg)
+	for i, o := range options {
+		fmt.Fprintf(t.out, "  %d) %s\n", i+1, o)
+	}
+	for {
+		answer, err := t.ReadLine(i18n.Sprintf("Enter a number from 1 to %d >> ", len(options)))
+		if err != nil {
+			return -1, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+	}
+}
+
+// NonInteractive answers the questions without a user, e.g. when the doctor
+// runs in a CI job. Questions that need an answer fail with ErrNoInput, and
+// yes/no questions are answered with no, so that nothing is changed.
+type NonInteractive struct{}
+
+// ReadLine returns ErrNoInput.
+func (NonInteractive) ReadLine(msg string) (string, error) {
+	return "", ErrNoInput
+}
+
+// Confirm returns false.
+func (NonInteractive) Confirm(msg string) (bool, error) {
+	return false, nil
+}
+
+// Select returns ErrNoInput.
+func (NonInteractive) Select(msg string, options []string) (int, error) {
+	return -1, ErrNoInput
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompt
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func errstring(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "nil"
+}
+
+func TestTerminalReadLine(t *testing.T) {
+	tests := []struct {
+		desc   string
+		input  string
+		want   string
+		errstr string
+	}{
+		{
+			desc:   "Line is trimmed",
+			input:  "  1234567890 \r\n",
+			want:   "1234567890",
+			errstr: "nil",
+		},
+		{
+			desc:   "Last line without newline",
+			input:  "abc",
+			want:   "abc",
+			errstr: "nil",
+		},
+		{
+			desc:   "No input",
+			input:  "",
+			errstr: io.EOF.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		got, err := NewTerminal(strings.NewReader(test.input), &out).ReadLine("Question >> ")
+		if got != test.want || errstring(err) != test.errstr {
+			t.Errorf("%s\nReadLine() = (%q, %s), want: (%q, %s)", test.desc, got, errstring(err), test.want, test.errstr)
+		}
+		if out.String() != "Question >> " {
+			t.Errorf("%s\nReadLine() wrote %q, want: %q", test.desc, out.String(), "Question >> ")
+		}
+	}
+}
+
+func TestTerminalConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "Y\n", want: true},
+		{input: "yes\n", want: true},
+		{input: "N\n", want: false},
+		{input: "\n", want: false},
+	}
+
+	for _, test := range tests {
+		got, err := NewTerminal(strings.NewReader(test.input), ioutil.Discard).Confirm("Replace? ")
+		if got != test.want || err != nil {
+			t.Errorf("Confirm() with input %q = (%t, %s), want: (%t, nil)", test.input, got, errstring(err), test.want)
+		}
+	}
+}
+
+func TestTerminalSelect(t *testing.T) {
+	tests := []struct {
+		desc   string
+		input  string
+		want   int
+		errstr string
+	}{
+		{
+			desc:   "Valid choice",
+			input:  "2\n",
+			want:   1,
+			errstr: "nil",
+		},
+		{
+			desc:   "Invalid choices are asked again",
+			input:  "0\nabc\n3\n",
+			want:   2,
+			errstr: "nil",
+		},
+		{
+			desc:   "No valid choice",
+			input:  "4\n",
+			want:   -1,
+			errstr: io.EOF.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		var out strings.Builder
+		got, err := NewTerminal(strings.NewReader(test.input), &out).Select("Pick one:", []string{"a", "b", "c"})
+		if got != test.want || errstring(err) != test.errstr {
+			t.Errorf("%s\nSelect() = (%d, %s), want: (%d, %s)", test.desc, got, errstring(err), test.want, test.errstr)
+		}
+		if !strings.Contains(out.String(), "  2) b\n") {
+			t.Errorf("%s\nSelect() did not list the options:\n%s", test.desc, out.String())
+		}
+	}
+}
+
+func TestNonInteractive(t *testing.T) {
+	p := NonInteractive{}
+
+	if _, err := p.ReadLine("Question >> "); err != ErrNoInput {
+		t.Errorf("ReadLine() error: %s, want: %s", errstring(err), ErrNoInput)
+	}
+	if yes, err := p.Confirm("Replace? "); yes || err != nil {
+		t.Errorf("Confirm() = (%t, %s), want: (false, nil)", yes, errstring(err))
+	}
+	if _, err := p.Select("Pick one:", []string{"a"}); err != ErrNoInput {
+		t.Errorf("Select() error: %s, want: %s", errstring(err), ErrNoInput)
+	}
+}
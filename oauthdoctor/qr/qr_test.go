@@ -0,0 +1,253 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qr
+
+import (
+	"strings"
+	"testing"
+)
+
+// decode reads the text of c back: the format information, the codewords
+// under the mask, the error correction of each block and the byte mode
+// segment.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	format := readFormat(c)
+	copy2 := 0
+	for i := 0; i < 8; i++ {
+		copy2 |= bit(c.Dark(c.Size-1-i, 8)) << uint(i)
+	}
+	for i := 8; i < 15; i++ {
+		copy2 |= bit(c.Dark(8, c.Size-15+i)) << uint(i)
+	}
+	if format != copy2 {
+		t.Fatalf("format information copies differ: %015b and %015b", format, copy2)
+	}
+	format ^= 0x5412
+	if level := format >> 13; level != lowFormat {
+		t.Fatalf("format information level: %d, want: %d", level, lowFormat)
+	}
+	mask := format >> 10 & 7
+
+	// The function modules do not depend on the data.
+	fn := newCode(c.Version)
+	fn.drawFunctionPatterns()
+	var codewords []byte
+	var cur, n int
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if fn.function[y][x] {
+					continue
+				}
+				dark := c.Dark(x, y) != maskBit(mask, x, y)
+				cur = cur<<1 | bit(dark)
+				if n++; n%8 == 0 {
+					codewords = append(codewords, byte(cur))
+					cur = 0
+				}
+			}
+		}
+	}
+	raw := rawModules(c.Version) / 8
+	if len(codewords) != raw {
+		t.Fatalf("read %d codewords, want: %d", len(codewords), raw)
+	}
+
+	numBlocks, ecLen := ecBlocks[c.Version], ecCodewords[c.Version]
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortLen+1; i++ {
+		for j := range blocks {
+			if i != shortLen-ecLen || j >= numShort {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		// A codeword is divisible by the generator, whose roots are the
+		// powers of 2 from 2^0.
+		root := byte(1)
+		for i := 0; i < ecLen; i++ {
+			s := byte(0)
+			for _, b := range block {
+				s = gfMul(s, root) ^ b
+			}
+			if s != 0 {
+				t.Fatalf("block %d has syndrome %d at root %d", j, s, i)
+			}
+			root = gfMul(root, 2)
+		}
+		data = append(data, block[:len(block)-ecLen]...)
+	}
+
+	var bits []int
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bits = append(bits, int(b>>uint(i)&1))
+		}
+	}
+	read := func(n int) int {
+		v := 0
+		for _, b := range bits[:n] {
+			v = v<<1 | b
+		}
+		bits = bits[n:]
+		return v
+	}
+	if m := read(4); m != 4 {
+		t.Fatalf("mode: %04b, want: 0100", m)
+	}
+	text := make([]byte, read(countBits(c.Version)))
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
+
+// readFormat reads the copy of the format information around the top left
+// finder pattern.
+func readFormat(c *Code) int {
+	format := 0
+	for i := 0; i <= 5; i++ {
+		format |= bit(c.Dark(8, i)) << uint(i)
+	}
+	format |= bit(c.Dark(8, 7))<<6 | bit(c.Dark(8, 8))<<7 | bit(c.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		format |= bit(c.Dark(14-i, 8)) << uint(i)
+	}
+	return format
+}
+
+func bit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
+
+func TestEncode(t *testing.T) {
+	url := "https://accounts.google.com/o/oauth2/auth?access_type=offline&client_id=123456789012-" +
+		"abcdefghijklmnopqrstuvwxyz012345.apps.googleusercontent.com&redirect_uri=http%3A%2F%2Flocalhost%3A8080" +
+		"&response_type=code&scope=https%3A%2F%2Fwww.googleapis.com%2Fauth%2Fadwords&state=state"
+	tests := []struct {
+		desc        string
+		text        string
+		wantVersion int
+	}{
+		{desc: "Empty", text: "", wantVersion: 1},
+		{desc: "Version 1", text: "https://a.co/x", wantVersion: 1},
+		{desc: "Full version 1", text: strings.Repeat("a", 17), wantVersion: 1},
+		{desc: "Version 2", text: strings.Repeat("a", 18), wantVersion: 2},
+		{desc: "Version info", text: strings.Repeat("b", 154), wantVersion: 7},
+		{desc: "16-bit count", text: strings.Repeat("c", 250), wantVersion: 10},
+		{desc: "Consent URL", text: url, wantVersion: 11},
+		{desc: "Full version 40", text: strings.Repeat("d", 2953), wantVersion: 40},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(tt.text)
+		if err != nil {
+			t.Fatalf("[%s] Encode() error: %s", tt.desc, err)
+		}
+		if c.Version != tt.wantVersion || c.Size != 4*tt.wantVersion+17 {
+			t.Errorf("[%s] Encode() got version %d of size %d, want: %d", tt.desc, c.Version, c.Size, tt.wantVersion)
+		}
+		if got := decode(t, c); got != tt.text {
+			t.Errorf("[%s] decoded %q, want: %q", tt.desc, got, tt.text)
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("e", 2954)); err == nil {
+		t.Errorf("Encode() of 2954 bytes got no error, want: too long")
+	}
+}
+
+func TestFunctionPatterns(t *testing.T) {
+	c, err := Encode(strings.Repeat("b", 154))
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder := []string{"11111110", "10000010", "10111010", "10111010", "10111010", "10000010", "11111110", "00000000"}
+	for y, row := range finder {
+		for x := range row {
+			want := row[x] == '1'
+			if c.Dark(x, y) != want || c.Dark(c.Size-1-x, y) != want || c.Dark(x, c.Size-1-y) != want {
+				t.Errorf("finder pattern module %d,%d is not %t", x, y, want)
+			}
+		}
+	}
+	for i := 8; i < c.Size-8; i++ {
+		if c.Dark(i, 6) != (i%2 == 0) || c.Dark(6, i) != (i%2 == 0) {
+			t.Errorf("timing pattern module %d is not %t", i, i%2 == 0)
+		}
+	}
+	// The alignment patterns of version 7 are centered at 6, 22 and 38.
+	if got := c.alignmentPositions(); len(got) != 3 || got[0] != 6 || got[1] != 22 || got[2] != 38 {
+		t.Errorf("alignmentPositions() got: %v, want: [6 22 38]", got)
+	}
+	if !c.Dark(22, 22) || c.Dark(23, 22) || !c.Dark(24, 22) {
+		t.Errorf("alignment pattern at 22,22 is not drawn")
+	}
+	// The version information of version 7 is 000111110010010100.
+	want := 0x07C94
+	got := 0
+	for i := 0; i < 18; i++ {
+		got |= bit(c.Dark(c.Size-11+i%3, i/3)) << uint(i)
+	}
+	if got != want {
+		t.Errorf("version information got: %018b, want: %018b", got, want)
+	}
+	if !c.Dark(8, c.Size-8) {
+		t.Errorf("the dark module is light")
+	}
+	// The format information of the low level and mask 0 is
+	// 111011111000100.
+	c.drawFormat(0)
+	if got := readFormat(c); got != 0x77C4 {
+		t.Errorf("format information got: %015b, want: 111011111000100", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	c, err := Encode("https://a.co/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.String(), "\n"), "\n")
+	if want := (c.Size + 2*quietZone + 1) / 2; len(lines) != want {
+		t.Errorf("String() got %d lines, want: %d", len(lines), want)
+	}
+	for _, line := range lines {
+		if n := len([]rune(line)); n != c.Size+2*quietZone {
+			t.Errorf("String() got a line of %d columns, want: %d", n, c.Size+2*quietZone)
+		}
+	}
+	// The quiet zone is light, then the top rows of the finder pattern are
+	// dark and light.
+	if lines[0] != strings.Repeat("█", c.Size+2*quietZone) || !strings.HasPrefix(lines[1], "██ ▄▄▄▄▄ █") {
+		t.Errorf("String() got:\n%s", c.String())
+	}
+}
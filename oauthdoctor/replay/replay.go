@@ -0,0 +1,314 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replay records the HTTP interactions of a diagnosis to a fixture
+// file and replays them, so that an issue can be reproduced without access
+// to the user's credentials or network.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// Redacted replaces the secrets in the fixtures.
+const Redacted = "REDACTED"
+
+// secretParams are the form parameters, query parameters and JSON fields
+// whose values are redacted.
+var secretParams = map[string]bool{
+	"client_secret": true,
+	"refresh_token": true,
+	"code":          true,
+	"code_verifier": true,
+	"assertion":     true,
+	"access_token":  true,
+	"id_token":      true,
+}
+
+// secretHeaders are the HTTP headers whose values are redacted.
+var secretHeaders = []string{"Authorization", "Developer-Token"}
+
+// Interaction is an HTTP request and its response.
+type Interaction struct {
+	Method       string
+	URL          string
+	RequestBody  string `json:",omitempty"`
+	Status       int
+	Header       http.Header `json:",omitempty"`
+	ResponseBody string
+}
+
+// Fixture is the content of a fixture file.
+type Fixture struct {
+	// APIVersion is the Google Ads API version of the recorded requests,
+	// which are replayed with the same version.
+	APIVersion   string `json:",omitempty"`
+	Interactions []Interaction
+}
+
+// Recorder is an HTTP transport that records the interactions sent through
+// it.
+type Recorder struct {
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	secrets      []string
+	apiVersion   string
+}
+
+// NewRecorder returns a Recorder that sends the requests with base, or
+// http.DefaultTransport when base is nil.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base}
+}
+
+// RoundTrip sends the request and records it with its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := Interaction{Method: req.Method, URL: req.URL.String()}
+	for _, h := range secretHeaders {
+		if req.Header.Get(h) != "" {
+			r.Redact(req.Header.Get(h), strings.TrimPrefix(req.Header.Get(h), "Bearer "))
+		}
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		in.RequestBody = string(body)
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	in.Status = resp.StatusCode
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		in.Header = http.Header{"Content-Type": {ct}}
+	}
+	in.ResponseBody = string(body)
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Redact adds values, e.g. the secrets of a configuration file, that are
+// replaced wherever they appear in the saved fixture.
+func (r *Recorder) Redact(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range values {
+		if len(v) > 0 {
+			r.secrets = append(r.secrets, v)
+		}
+	}
+}
+
+// SetAPIVersion records the Google Ads API version of the requests.
+func (r *Recorder) SetAPIVersion(v string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apiVersion = v
+}
+
+// Fixture returns the recorded interactions with their secrets redacted.
+func (r *Recorder) Fixture() Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := Fixture{APIVersion: r.apiVersion}
+	for _, in := range r.interactions {
+		in.URL = redactURL(in.URL)
+		in.RequestBody = redactBody(in.RequestBody)
+		in.ResponseBody = redactBody(in.ResponseBody)
+		for _, s := range r.secrets {
+			in.URL = strings.Replace(in.URL, url.QueryEscape(s), Redacted, -1)
+			in.RequestBody = strings.Replace(in.RequestBody, url.QueryEscape(s), Redacted, -1)
+			in.URL = strings.Replace(in.URL, s, Redacted, -1)
+			in.RequestBody = strings.Replace(in.RequestBody, s, Redacted, -1)
+			in.ResponseBody = strings.Replace(in.ResponseBody, s, Redacted, -1)
+		}
+		f.Interactions = append(f.Interactions, in)
+	}
+	return f
+}
+
+// Save writes the redacted interactions to a fixture file.
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Fixture(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(b, '\n'), 0600); err != nil {
+		return i18n.Errorf("Cannot save the recorded HTTP traffic to %s: %s", path, err)
+	}
+	return nil
+}
+
+// Replayer is an HTTP transport that answers the requests with the responses
+// of a fixture instead of sending them.
+type Replayer struct {
+	apiVersion string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer of the interactions in f.
+func NewReplayer(f Fixture) *Replayer {
+	return &Replayer{apiVersion: f.APIVersion, interactions: f.Interactions, used: make([]bool, len(f.Interactions))}
+}
+
+// APIVersion returns the Google Ads API version of the recorded requests,
+// which is empty in the fixtures that do not record it.
+func (r *Replayer) APIVersion() string {
+	return r.apiVersion
+}
+
+// Load reads a fixture file saved by Recorder.Save.
+func Load(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, i18n.Errorf("Cannot find the recorded HTTP traffic %s", path)
+		}
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, i18n.Errorf("Cannot parse the recorded HTTP traffic %s: %s", path, err)
+	}
+	return NewReplayer(f), nil
+}
+
+// RoundTrip returns the response of the first unused interaction with the
+// method and URL of the request. The query string is ignored, since it may
+// contain redacted secrets.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	want := stripQuery(req.URL.String())
+	for i, in := range r.interactions {
+		if r.used[i] || in.Method != req.Method || stripQuery(in.URL) != want {
+			continue
+		}
+		r.used[i] = true
+		header := http.Header{}
+		for k, v := range in.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        http.StatusText(in.Status),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(in.ResponseBody)),
+			ContentLength: int64(len(in.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, i18n.Errorf("No recorded response for %s %s", req.Method, want)
+}
+
+// stripQuery removes the query string of a URL.
+func stripQuery(u string) string {
+	if i := strings.Index(u, "?"); i >= 0 {
+		return u[:i]
+	}
+	return u
+}
+
+// redactURL redacts the secret query parameters of a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	u.RawQuery = redactForm(u.RawQuery)
+	return u.String()
+}
+
+// redactBody redacts the secret parameters of a URL-encoded form or the
+// secret fields of a JSON object. Other bodies are returned unchanged.
+func redactBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+			return body
+		}
+		redacted := false
+		for k := range fields {
+			if secretParams[k] {
+				fields[k] = Redacted
+				redacted = true
+			}
+		}
+		if !redacted {
+			return body
+		}
+		b, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return body
+		}
+		return string(b)
+	}
+	if strings.Contains(trimmed, "=") && !strings.ContainsAny(trimmed, " \n<") {
+		return redactForm(trimmed)
+	}
+	return body
+}
+
+// redactForm redacts the secret parameters of a URL-encoded form.
+func redactForm(form string) string {
+	values, err := url.ParseQuery(form)
+	if err != nil {
+		return form
+	}
+	for k := range values {
+		if secretParams[k] {
+			values.Set(k, Redacted)
+		}
+	}
+	return values.Encode()
+}
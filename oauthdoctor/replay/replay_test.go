@@ -0,0 +1,145 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func errstring(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "nil"
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token": "ya29.secret", "token_type": "Bearer"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error": {"status": "PERMISSION_DENIED", "message": "devtoken123 is not approved"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	rec := NewRecorder(nil)
+	rec.SetAPIVersion("v8")
+	client := &http.Client{Transport: rec}
+	resp, err := client.PostForm(ts.URL+"/token", map[string][]string{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {"1/secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/v8/customers/123?access_token=ya29.secret", nil)
+	req.Header.Set("Developer-Token", "devtoken123")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save() error: %s", err)
+	}
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"1/secret", "1%2Fsecret", "ya29.secret", "devtoken123"} {
+		if strings.Contains(string(saved), secret) {
+			t.Errorf("Save() wrote secret %s:\n%s", secret, saved)
+		}
+	}
+
+	replayer, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %s", err)
+	}
+	if v := replayer.APIVersion(); v != "v8" {
+		t.Errorf("APIVersion() got %q, want v8", v)
+	}
+	client = &http.Client{Transport: replayer}
+	resp, err = client.Get(ts.URL + "/v8/customers/123?access_token=other")
+	if err != nil {
+		t.Fatalf("replayed Get() error: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "PERMISSION_DENIED") {
+		t.Errorf("replayed Get() got: %d %s, want: 403 PERMISSION_DENIED", resp.StatusCode, body)
+	}
+
+	// Each interaction is replayed once.
+	_, err = client.Get(ts.URL + "/v8/customers/123")
+	if !strings.Contains(errstring(err), "No recorded response for GET") {
+		t.Errorf("second replayed Get() error: %s, want: No recorded response", errstring(err))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+		errstr  string
+	}{
+		{
+			desc:   "Missing file",
+			errstr: "Cannot find the recorded HTTP traffic",
+		},
+		{
+			desc:    "Invalid JSON",
+			content: "not json",
+			errstr:  "Cannot parse the recorded HTTP traffic",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, tt := range tests {
+		path := filepath.Join(dir, "fixture"+string(rune('0'+i)))
+		if tt.content != "" {
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := Load(path); !strings.Contains(errstring(err), tt.errstr) {
+			t.Errorf("[%s] Load() error: %s, want: %s", tt.desc, errstring(err), tt.errstr)
+		}
+	}
+}
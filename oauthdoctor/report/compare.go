@@ -0,0 +1,193 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// volatileEnv are the values of the system information that change from run
+// to run on the same machine, so they are not compared.
+var volatileEnv = []string{"resources.memAvailable", "resources.diskFree"}
+
+// Difference is a value that differs between two reports. Before or After is
+// empty when the value is only in one of them, or "not run" for a check.
+type Difference struct {
+	Name   string
+	Before string
+	After  string
+}
+
+// Comparison is what changed between two reports, e.g. before and after a
+// fix, or of two machines.
+type Comparison struct {
+	// Checks are the checks whose status or error code changed.
+	Checks []Difference
+	// Config are the values of the configuration that differ.
+	Config []Difference
+	// Environment are the differences of the doctor, the language, the
+	// OAuth type and the system information.
+	Environment []Difference
+}
+
+// ReadFile reads a report saved in JSON by WriteFile.
+func ReadFile(path string) (*Report, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, i18n.Errorf("Cannot read the report %s: %s", path, err)
+	}
+	var r Report
+	if err := json.Unmarshal(content, &r); err != nil {
+		return nil, i18n.Errorf("%s is not a JSON report: %s", path, err)
+	}
+	return &r, nil
+}
+
+// Compare returns what changed from before to after.
+func Compare(before, after *Report) Comparison {
+	var c Comparison
+
+	seen := make(map[string]bool)
+	for _, b := range before.Checks {
+		seen[b.ID] = true
+		a, ok := after.Check(b.ID)
+		if !ok {
+			c.Checks = append(c.Checks, Difference{Name: b.Name, Before: checkOutcome(b), After: i18n.T("not run")})
+		} else if a.Status != b.Status || a.Code != b.Code {
+			c.Checks = append(c.Checks, Difference{Name: b.Name, Before: checkOutcome(b), After: checkOutcome(a)})
+		}
+	}
+	for _, a := range after.Checks {
+		if !seen[a.ID] {
+			c.Checks = append(c.Checks, Difference{Name: a.Name, Before: i18n.T("not run"), After: checkOutcome(a)})
+		}
+	}
+
+	c.Config = differences(before.Config, after.Config)
+	c.Environment = differences(environment(before), environment(after))
+	return c
+}
+
+// checkOutcome returns the status of c with its error code, if any.
+func checkOutcome(c Check) string {
+	if c.Code == "" {
+		return string(c.Status)
+	}
+	return fmt.Sprintf("%s (%s)", c.Status, c.Code)
+}
+
+// environment returns the values of r that describe where the diagnosis ran,
+// with the fields of the system information flattened, e.g.
+// resources.memTotal.
+func environment(r *Report) map[string]string {
+	env := map[string]string{
+		"language":  r.Language,
+		"oauthType": r.OAuthType,
+		"version":   r.Version,
+		"commit":    r.Commit,
+	}
+	if r.SysInfo != nil {
+		b, err := json.Marshal(r.SysInfo)
+		var fields map[string]interface{}
+		if err == nil && json.Unmarshal(b, &fields) == nil {
+			flatten("", fields, env)
+		}
+	}
+	for _, k := range volatileEnv {
+		delete(env, k)
+	}
+	return env
+}
+
+// flatten adds the values of fields to flat, with the keys of nested objects
+// joined by dots after prefix.
+func flatten(prefix string, fields map[string]interface{}, flat map[string]string) {
+	for k, v := range fields {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key+".", v, flat)
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			flat[key] = strings.Join(items, ", ")
+		case nil:
+		default:
+			flat[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// differences returns the keys whose values differ between before and after,
+// sorted by key. Empty values are the same as missing ones.
+func differences(before, after map[string]string) []Difference {
+	keys := make(map[string]bool)
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	var diffs []Difference
+	for k := range keys {
+		if before[k] != after[k] {
+			diffs = append(diffs, Difference{Name: k, Before: before[k], After: after[k]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// Empty returns true when the reports have the same check results,
+// configuration and environment.
+func (c Comparison) Empty() bool {
+	return len(c.Checks) == 0 && len(c.Config) == 0 && len(c.Environment) == 0
+}
+
+// String returns the differences, one per line, under a heading per kind.
+func (c Comparison) String() string {
+	if c.Empty() {
+		return i18n.T("The reports have the same check results, config values and environment.")
+	}
+	var lines []string
+	section := func(heading string, diffs []Difference) {
+		if len(diffs) == 0 {
+			return
+		}
+		lines = append(lines, heading)
+		for _, d := range diffs {
+			lines = append(lines, fmt.Sprintf("\t%s: %s -> %s", d.Name, orMissing(d.Before), orMissing(d.After)))
+		}
+	}
+	section(i18n.T("Checks that changed:"), c.Checks)
+	section(i18n.T("Config values that differ:"), c.Config)
+	section(i18n.T("Environment differences:"), c.Environment)
+	return strings.Join(lines, "\n")
+}
+
+// orMissing returns v, or a placeholder when it is empty.
+func orMissing(v string) string {
+	if v == "" {
+		return i18n.T("(none)")
+	}
+	return v
+}
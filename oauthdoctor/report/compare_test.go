@@ -0,0 +1,131 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestCompare(t *testing.T) {
+	before := &Report{
+		Language:  "python",
+		OAuthType: "installed_app",
+		Config:    map[string]string{"RefreshToken": "1//0a...abcd", "LoginCustomerID": "1234567890"},
+		SysInfo: &diag.SysInfo{OS: "linux", CPUs: 4, ProxyEnv: []string{"HTTPS_PROXY"},
+			Resources: diag.Resources{MemTotal: 8, DiskFree: 1}},
+		Checks: []Check{
+			{ID: ConfigCheck, Name: "Configuration", Status: Pass},
+			{ID: OAuthCheck, Name: "OAuth", Status: Fail, Code: "INVALID_REFRESH_TOKEN", RequestID: "a"},
+			{ID: TLSCheck, Name: "TLS", Status: Pass},
+		},
+	}
+	after := &Report{
+		Language:  "python",
+		OAuthType: "installed_app",
+		Config:    map[string]string{"RefreshToken": "1//0b...efgh", "LoginCustomerID": "1234567890", "Endpoint": "proxy:443"},
+		SysInfo:   &diag.SysInfo{OS: "linux", CPUs: 8, Resources: diag.Resources{MemTotal: 8, DiskFree: 2}},
+		Checks: []Check{
+			{ID: ConfigCheck, Name: "Configuration", Status: Pass, Duration: 1},
+			{ID: OAuthCheck, Name: "OAuth", Status: Pass, RequestID: "b"},
+			{ID: MutateCheck, Name: "Mutate", Status: Pass},
+		},
+	}
+
+	got := Compare(before, after)
+	want := Comparison{
+		Checks: []Difference{
+			{Name: "OAuth", Before: "FAIL (INVALID_REFRESH_TOKEN)", After: "PASS"},
+			{Name: "TLS", Before: "PASS", After: "not run"},
+			{Name: "Mutate", Before: "not run", After: "PASS"},
+		},
+		Config: []Difference{
+			{Name: "Endpoint", After: "proxy:443"},
+			{Name: "RefreshToken", Before: "1//0a...abcd", After: "1//0b...efgh"},
+		},
+		Environment: []Difference{
+			{Name: "cpus", Before: "4", After: "8"},
+			{Name: "proxyEnv", Before: "HTTPS_PROXY"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() got: %+v\nwant: %+v", got, want)
+	}
+	if same := Compare(before, before); !same.Empty() {
+		t.Errorf("Compare() of the same report got: %+v, want no differences", same)
+	}
+}
+
+func TestComparisonString(t *testing.T) {
+	tests := []struct {
+		desc string
+		c    Comparison
+		want string
+	}{
+		{
+			desc: "No differences",
+			want: "The reports have the same check results, config values and environment.",
+		},
+		{
+			desc: "Checks and environment",
+			c: Comparison{
+				Checks:      []Difference{{Name: "OAuth", Before: "FAIL (INVALID_REFRESH_TOKEN)", After: "PASS"}},
+				Environment: []Difference{{Name: "proxyEnv", Before: "HTTPS_PROXY"}},
+			},
+			want: "Checks that changed:\n\tOAuth: FAIL (INVALID_REFRESH_TOKEN) -> PASS\n" +
+				"Environment differences:\n\tproxyEnv: HTTPS_PROXY -> (none)",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("[%s] String() got:\n%s\nwant:\n%s", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := testReport()
+	path := filepath.Join(dir, "report.json")
+	if err := want.WriteFile(path, JSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %s", err)
+	}
+	if c := Compare(want, got); !c.Empty() {
+		t.Errorf("ReadFile() got differences with the saved report: %s", c)
+	}
+
+	html := filepath.Join(dir, "report.html")
+	if err := want.WriteFile(html, HTMLFormat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(html); err == nil || !strings.Contains(err.Error(), "is not a JSON report") {
+		t.Errorf("ReadFile() of an HTML report got error: %v, want: is not a JSON report", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// These are the formats of the report files, see Report.Write.
+const (
+	// JSONFormat is the indented JSON of the report, e.g. to compare reports
+	// or process them.
+	JSONFormat = "json"
+	// HTMLFormat is a self-contained HTML page, for readers who do not use
+	// the console.
+	HTMLFormat = "html"
+)
+
+// Formats are the supported formats of the report files.
+var Formats = []string{JSONFormat, HTMLFormat}
+
+// Write writes the report to w in format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case JSONFormat:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case HTMLFormat:
+		return r.writeHTML(w)
+	}
+	return i18n.Errorf("Report format not supported: %s", format)
+}
+
+// WriteFile writes the report to the file at path in format. The file may
+// hold the customer ID and the email of the user, so only the user can read
+// it.
+func (r *Report) WriteFile(path, format string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return i18n.Errorf("Cannot save the report to %s: %s", path, err)
+	}
+	if err := r.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/replay"
+)
+
+func testReport() *Report {
+	return &Report{
+		Language:  "python",
+		OAuthType: "installed_app",
+		Checks: []Check{
+			{ID: ConfigCheck, Name: "Configuration", Status: Pass, Duration: 1500 * time.Microsecond},
+			{ID: OAuthCheck, Name: "OAuth", Status: Fail, Code: "INVALID_REFRESH_TOKEN",
+				Message: "ERROR: Your refresh token may be invalid.\n<script>", RequestID: "abc123",
+				Link: "https://developers.google.com/google-ads/api/docs/common-errors"},
+			{ID: "plugin:bad", Name: "Bad link", Status: Warn, Link: "javascript:alert(1)"},
+		},
+		Traces: []replay.Interaction{{Method: "POST", URL: "https://oauth2.googleapis.com/token",
+			RequestBody: "refresh_token=" + replay.Redacted, Status: 400, ResponseBody: `{"error":"invalid_grant"}`}},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		desc    string
+		format  string
+		want    []string
+		notWant []string
+		wantErr bool
+	}{
+		{
+			desc:   "JSON",
+			format: JSONFormat,
+			want:   []string{`"Code": "INVALID_REFRESH_TOKEN"`, `"RequestID": "abc123"`, `"Method": "POST"`},
+		},
+		{
+			desc:   "HTML",
+			format: HTMLFormat,
+			want: []string{
+				"<!DOCTYPE html>",
+				`<details open>`,
+				`<span class="status FAIL">FAIL</span> OAuth (INVALID_REFRESH_TOKEN)`,
+				"&lt;script&gt;",
+				`<a href="https://developers.google.com/google-ads/api/docs/common-errors">`,
+				"<tr><th>Duration</th><td>2ms</td></tr>",
+				"<tr><th>Request ID</th><td>abc123</td></tr>",
+				"Health score:",
+				"POST https://oauth2.googleapis.com/token: 400",
+				"refresh_token=" + replay.Redacted,
+			},
+			notWant: []string{"<script>", "javascript:", "<link", "<script src"},
+		},
+		{
+			desc:    "Unknown format",
+			format:  "pdf",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := testReport().Write(&buf, tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("[%s] Write() error: %v, want error: %t", tt.desc, err, tt.wantErr)
+			continue
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("[%s] Write() got:\n%s\nwant substring: %s", tt.desc, buf.String(), w)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(buf.String(), w) {
+				t.Errorf("[%s] Write() got:\n%s\nwant no: %s", tt.desc, buf.String(), w)
+			}
+		}
+	}
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	want := testReport()
+	var buf bytes.Buffer
+	if err := want.Write(&buf, JSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Write() is not valid JSON: %s", err)
+	}
+	if len(got.Checks) != len(want.Checks) || !reflect.DeepEqual(got.Checks[1], want.Checks[1]) || len(got.Traces) != 1 {
+		t.Errorf("Write() got: %+v, want: %+v", got, *want)
+	}
+}
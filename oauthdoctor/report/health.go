@@ -0,0 +1,158 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// checkWeights are the weights of the checks in the health score, by how
+// much of the Google Ads API integration depends on them. The other checks,
+// e.g. the custom checks and the checks of customer accounts, weigh
+// defaultWeight.
+var checkWeights = map[string]int{
+	ConfigCheck:       25,
+	OAuthCheck:        30,
+	DNSCheck:          10,
+	ConnectivityCheck: 10,
+	TLSCheck:          10,
+	TransportCheck:    10,
+	APIVersionsCheck:  10,
+	MutateCheck:       5,
+	TokenCheck:        5,
+	HTTP2Check:        3,
+	NetPerfCheck:      3,
+	AllowListCheck:    3,
+	BuildEnvCheck:     3,
+	TCPCheck:          2,
+	SysInfoCheck:      1,
+}
+
+const defaultWeight = 2
+
+// blockingChecks are the checks without which no call to the Google Ads API
+// can succeed. The health score is at most blockedScore when one of them
+// failed, however the other checks did.
+var blockingChecks = []string{ConfigCheck, OAuthCheck, DNSCheck, ConnectivityCheck, TLSCheck, TransportCheck,
+	APIVersionsCheck}
+
+const blockedScore = 40
+
+// weight returns the weight of c in the health score.
+func weight(c Check) int {
+	if w, ok := checkWeights[c.ID]; ok {
+		return w
+	}
+	return defaultWeight
+}
+
+// failed returns true when c failed or did not complete in time.
+func failed(c Check) bool {
+	return c.Status == Fail || c.Status == Timeout
+}
+
+// Score returns the health score of the report from 0 to 100: the weighted
+// share of the checks that passed, where a warning counts half and a check
+// that timed out counts as failed. Skipped checks are left out.
+func (r *Report) Score() int {
+	var total, earned int
+	blocked := false
+	for _, c := range r.Checks {
+		w := weight(c)
+		switch {
+		case c.Status == Skip:
+			continue
+		case c.Status == Pass:
+			earned += 2 * w
+		case c.Status == Warn:
+			earned += w
+		case failed(c) && containsID(blockingChecks, c.ID):
+			blocked = true
+		}
+		total += 2 * w
+	}
+	if total == 0 {
+		return 100
+	}
+	score := (100*earned + total/2) / total
+	if blocked && score > blockedScore {
+		score = blockedScore
+	}
+	return score
+}
+
+func containsID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TopIssues returns at most n checks that did not pass, the failed ones
+// first, then by weight.
+func (r *Report) TopIssues(n int) []Check {
+	var issues []Check
+	for _, c := range r.Checks {
+		if c.Status != Pass && c.Status != Skip {
+			issues = append(issues, c)
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if failed(issues[i]) != failed(issues[j]) {
+			return failed(issues[i])
+		}
+		return weight(issues[i]) > weight(issues[j])
+	})
+	if len(issues) > n {
+		issues = issues[:n]
+	}
+	return issues
+}
+
+// Health returns the health score with a verdict, followed by the top three
+// issues to fix, in order, for readers who do not need the details of each
+// check.
+func (r *Report) Health() string {
+	score := r.Score()
+	var verdict string
+	switch {
+	case score >= 90:
+		verdict = i18n.T("healthy")
+	case score > blockedScore:
+		verdict = i18n.T("needs attention")
+	default:
+		verdict = i18n.T("blocked: API calls fail")
+	}
+	lines := []string{i18n.Sprintf("Health score: %d/100 (%s)", score, verdict)}
+	issues := r.TopIssues(3)
+	if len(issues) > 0 {
+		lines = append(lines, i18n.T("Fix first:"))
+	}
+	for i, c := range issues {
+		issue := c.Name
+		if c.Code != "" {
+			issue += " (" + c.Code + ")"
+		}
+		if msg := oneLine(c.Message); msg != "" {
+			issue += ": " + msg
+		}
+		lines = append(lines, i18n.Sprintf("\t%d. [%s] %s", i+1, c.Status, issue))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		desc   string
+		checks []Check
+		want   int
+	}{
+		{desc: "No checks", want: 100},
+		{
+			desc:   "All passed",
+			checks: []Check{{ID: ConfigCheck, Status: Pass}, {ID: OAuthCheck, Status: Pass}, {ID: NetPerfCheck, Status: Skip}},
+			want:   100,
+		},
+		{
+			desc:   "Warning counts half",
+			checks: []Check{{ID: ConfigCheck, Status: Warn}, {ID: OAuthCheck, Status: Pass}},
+			want:   77,
+		},
+		{
+			desc:   "Optional check failed",
+			checks: []Check{{ID: ConfigCheck, Status: Pass}, {ID: OAuthCheck, Status: Pass}, {ID: MutateCheck, Status: Fail}},
+			want:   92,
+		},
+		{
+			desc: "Blocking check failed",
+			checks: []Check{{ID: ConfigCheck, Status: Pass}, {ID: DNSCheck, Status: Pass}, {ID: TLSCheck, Status: Pass},
+				{ID: OAuthCheck, Status: Fail}},
+			want: 40,
+		},
+		{
+			desc:   "Timeout counts as failed",
+			checks: []Check{{ID: ConfigCheck, Status: Pass}, {ID: ConnectivityCheck, Status: Timeout}},
+			want:   40,
+		},
+		{
+			desc:   "Custom check",
+			checks: []Check{{ID: PluginCheckPrefix + "vpn", Status: Fail}, {ID: ConfigCheck, Status: Pass}},
+			want:   93,
+		},
+	}
+
+	for _, tt := range tests {
+		r := &Report{Checks: tt.checks}
+		if got := r.Score(); got != tt.want {
+			t.Errorf("[%s] Score() = %d, want: %d", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	r := &Report{Checks: []Check{
+		{ID: ConfigCheck, Name: "Configuration file", Status: Warn, Message: "LoginCustomerID has dashes.\n"},
+		{ID: NetPerfCheck, Name: "Network latency", Status: Warn, Message: "High latency"},
+		{ID: DNSCheck, Name: "DNS resolution", Status: Pass},
+		{ID: MutateCheck, Name: "Mutate call", Status: Fail, Code: "PERMISSION_DENIED"},
+		{ID: OAuthCheck, Name: "OAuth flow and API access", Status: Fail, Code: "INVALID_REFRESH_TOKEN",
+			Message: "invalid_grant"},
+	}}
+
+	want := "Health score: 33/100 (blocked: API calls fail)\n" +
+		"Fix first:\n" +
+		"\t1. [FAIL] OAuth flow and API access (INVALID_REFRESH_TOKEN): invalid_grant\n" +
+		"\t2. [FAIL] Mutate call (PERMISSION_DENIED)\n" +
+		"\t3. [WARN] Configuration file: LoginCustomerID has dashes"
+	if got := r.Health(); got != want {
+		t.Errorf("Health() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	r = &Report{Checks: []Check{{ID: ConfigCheck, Status: Pass}, {ID: OAuthCheck, Status: Pass}}}
+	if got := r.Health(); !strings.HasPrefix(got, "Health score: 100/100 (healthy)") || strings.Contains(got, "Fix first") {
+		t.Errorf("Health() got: %s, want a healthy score without issues", got)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+)
+
+// htmlFuncs are the functions of htmlTemplate. T translates the labels like
+// the console output.
+var htmlFuncs = template.FuncMap{
+	"T":        i18n.T,
+	"language": i18n.Language,
+	"issue": func(c Check) bool {
+		return c.Status != Pass && c.Status != Skip
+	},
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"json": func(v interface{}) (string, error) {
+		b, err := json.MarshalIndent(v, "", "  ")
+		return string(b), err
+	},
+}
+
+// htmlTemplate is the HTML report. It has no external resources, so that it
+// can be attached to an email or a support ticket. The sections of the
+// checks that did not pass are open.
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="{{language}}">
+<head>
+<meta charset="utf-8">
+<title>{{T "Google Ads API diagnosis"}}</title>
+<style>
+body { font-family: sans-serif; color: #202124; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+details { border: 1px solid #dadce0; border-radius: 4px; margin: 0.5em 0; padding: 0.5em 1em; }
+summary { cursor: pointer; font-weight: bold; }
+pre { background: #f8f9fa; padding: 0.5em; white-space: pre-wrap; word-break: break-all; }
+th, td { text-align: left; vertical-align: top; padding: 0.2em 1em 0.2em 0; }
+.status { display: inline-block; min-width: 5em; }
+.PASS { color: #188038; }
+.WARN { color: #b06000; }
+.FAIL, .TIMEOUT { color: #d93025; }
+.SKIP { color: #5f6368; }
+</style>
+</head>
+<body>
+<h1>{{T "Google Ads API diagnosis"}}</h1>
+<table>
+<tr><th>{{T "Language"}}</th><td>{{.Language}}</td></tr>
+{{- with .OAuthType}}
+<tr><th>{{T "OAuth type"}}</th><td>{{.}}</td></tr>
+{{- end}}
+{{- with .CustomerID}}
+<tr><th>{{T "Customer ID"}}</th><td>{{.}}</td></tr>
+{{- end}}
+{{- with .User}}
+<tr><th>{{T "User"}}</th><td>{{.}}</td></tr>
+{{- end}}
+{{- if .Version}}
+<tr><th>{{T "Doctor"}}</th><td>{{.Version}} {{.Commit}} {{.BuildDate}}</td></tr>
+{{- end}}
+</table>
+
+<h2>{{T "Summary"}}</h2>
+<p>{{.Narrative}}</p>
+<pre>{{.Health}}</pre>
+
+<h2>{{T "Checks"}}</h2>
+{{- range .Checks}}
+<details{{if issue .}} open{{end}}>
+<summary><span class="status {{.Status}}">{{.Status}}</span> {{.Name}}{{with .Code}} ({{.}}){{end}}</summary>
+{{- with .Message}}
+<pre>{{.}}</pre>
+{{- end}}
+<table>
+<tr><th>{{T "Check"}}</th><td>{{.ID}}</td></tr>
+{{- with .Duration}}
+<tr><th>{{T "Duration"}}</th><td>{{duration .}}</td></tr>
+{{- end}}
+{{- with .RequestID}}
+<tr><th>{{T "Request ID"}}</th><td>{{.}}</td></tr>
+{{- end}}
+</table>
+{{- with .Remediations}}
+<p>{{T "Fixes:"}}</p>
+<ul>
+{{- range .}}
+<li>{{.Describe}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with .Link}}
+<p><a href="{{.}}">{{T "How to fix it"}}</a></p>
+{{- end}}
+</details>
+{{- end}}
+{{- with .Config}}
+
+<h2>{{T "Configuration"}}</h2>
+<table>
+{{- range $key, $value := .}}
+<tr><th>{{$key}}</th><td>{{$value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Changes}}
+
+<h2>{{T "Changes"}}</h2>
+<table>
+<tr><th>{{T "Key"}}</th><th>{{T "Old value"}}</th><th>{{T "Location"}}</th><th>{{T "Backup"}}</th></tr>
+{{- range .}}
+<tr><td>{{.Key}}</td><td>{{.OldValue}}</td><td>{{.Location}}</td><td>{{.Backup}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .SysInfo}}
+
+<h2>{{T "System information"}}</h2>
+<details>
+<summary>{{.OS}}/{{.Arch}}</summary>
+<pre>{{json .}}</pre>
+</details>
+{{- end}}
+{{- with .Traces}}
+
+<h2>{{T "HTTP requests"}}</h2>
+<p>{{T "The secrets are redacted."}}</p>
+{{- range .}}
+<details>
+<summary>{{.Method}} {{.URL}}: {{.Status}}</summary>
+{{- with .RequestBody}}
+<p>{{T "Request"}}</p>
+<pre>{{.}}</pre>
+{{- end}}
+<p>{{T "Response"}}</p>
+<pre>{{.ResponseBody}}</pre>
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML writes the report to w as a self-contained HTML page with a
+// collapsible section per check.
+func (r *Report) writeHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// OutcomeSchema is the version of the Outcome payload. It is incremented
+// when a field is added, removed or changes meaning.
+const OutcomeSchema = 4
+
+// Outcome is the anonymous summary of a diagnosis that users can choose to
+// share with the maintainers, so they can prioritize the most common
+// failures. It only holds what the doctor supports and how each check ended;
+// it must never include identifiers such as customer IDs, emails, client
+// IDs, tokens, file paths, host names or free-form messages.
+//
+// The JSON payload is:
+//
+//	{
+//	  "schema": 4,
+//	  "version": "1.0.4",
+//	  "commit": "4f5d6f6a1b2c",
+//	  "buildDate": "2024-05-01T10:00:00Z",
+//	  "language": "python",
+//	  "oauthType": "installed_app",
+//	  "os": "linux",
+//	  "system": {
+//	    "osVersion": "Ubuntu 22.04.3 LTS, kernel 6.5.0-14-generic",
+//	    "locale": "en_US.UTF-8",
+//	    "timezone": "CET (UTC+01:00)",
+//	    "proxyEnv": ["HTTPS_PROXY"],
+//	    "vpn": true
+//	  },
+//	  "checks": [
+//	    {"id": "config", "status": "PASS"},
+//	    {"id": "oauth", "status": "FAIL", "code": "INVALID_REFRESH_TOKEN"}
+//	  ],
+//	  "changes": ["RefreshToken"]
+//	}
+type Outcome struct {
+	Schema    int    `json:"schema"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	Language  string `json:"language"`
+	OAuthType string `json:"oauthType"`
+	OS        string `json:"os"`
+	// System is the system information without identifiers, when it was
+	// collected.
+	System *OutcomeSystem `json:"system,omitempty"`
+	Checks []OutcomeCheck `json:"checks"`
+	// Changes are the keys of the values replaced by the doctor, without
+	// the values or the files.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// OutcomeSystem is the system information in an Outcome. The host name and
+// DNS servers are left out, as are the values of the proxy variables.
+type OutcomeSystem struct {
+	OSVersion string   `json:"osVersion,omitempty"`
+	Locale    string   `json:"locale,omitempty"`
+	Timezone  string   `json:"timezone"`
+	ProxyEnv  []string `json:"proxyEnv"`
+	VPN       bool     `json:"vpn"`
+	Container string   `json:"container,omitempty"`
+}
+
+// OutcomeCheck is the result of a check in an Outcome. Code is one of the
+// fixed error codes of the checks, e.g. INVALID_REFRESH_TOKEN.
+type OutcomeCheck struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	Code   string `json:"code,omitempty"`
+}
+
+// Outcome returns the anonymous summary of the report.
+func (r *Report) Outcome() Outcome {
+	o := Outcome{
+		Schema:    OutcomeSchema,
+		Version:   r.Version,
+		Commit:    r.Commit,
+		BuildDate: r.BuildDate,
+		Language:  r.Language,
+		OAuthType: r.OAuthType,
+		OS:        runtime.GOOS,
+		Checks:    []OutcomeCheck{},
+	}
+	if s := r.SysInfo; s != nil {
+		o.System = &OutcomeSystem{
+			OSVersion: s.OSVersion,
+			Locale:    s.Locale,
+			Timezone:  s.Timezone,
+			ProxyEnv:  s.ProxyEnv,
+			VPN:       len(s.VPNInterfaces) > 0,
+			Container: s.Container,
+		}
+	}
+	for _, c := range r.Checks {
+		// The checks of plugins are named by their organizations, and the
+		// IDs of the customer checks are customer IDs.
+		if strings.HasPrefix(c.ID, PluginCheckPrefix) || strings.HasPrefix(c.ID, CustomerCheckPrefix) {
+			continue
+		}
+		o.Checks = append(o.Checks, OutcomeCheck{ID: c.ID, Status: c.Status, Code: c.Code})
+	}
+	for _, c := range r.Changes {
+		o.Changes = append(o.Changes, c.Key)
+	}
+	return o
+}
+
+// JSON returns the payload of the outcome, indented so users can review it
+// before it is sent.
+func (o Outcome) JSON() string {
+	b, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// PostOutcome sends the outcome to the collection endpoint at url with
+// client.
+func PostOutcome(ctx context.Context, client *http.Client, url string, o Outcome) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader([]byte(o.JSON())))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+	return nil
+}
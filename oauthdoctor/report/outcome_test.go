@@ -0,0 +1,97 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+)
+
+func TestOutcome(t *testing.T) {
+	r := &Report{
+		Language:   "python",
+		OAuthType:  "installed_app",
+		Version:    "1.0.4",
+		Commit:     "4f5d6f6a1b2c",
+		CustomerID: "1234567890",
+		SysInfo: &diag.SysInfo{Host: "build-42.corp", OS: "linux", OSVersion: "Ubuntu 22.04.3 LTS", Timezone: "CET (UTC+01:00)",
+			ProxyEnv: []string{"HTTPS_PROXY"}, DNSServers: []string{"10.9.8.7"}, VPNInterfaces: []string{"tun0"}},
+		User: "someone@example.com",
+		Checks: []Check{
+			{ID: ConfigCheck, Name: "Configuration file", Status: Warn, Message: "ClientSecret looks like an API key"},
+			{ID: OAuthCheck, Name: "OAuth flow and API access", Status: Fail, Code: "INVALID_REFRESH_TOKEN",
+				Message: "invalid_grant for customer 1234567890"},
+			{ID: PluginCheckPrefix + "acme-proxy", Name: "ACME proxy", Status: Fail},
+			{ID: CustomerCheckPrefix + "2222222222", Name: "Access to customer 222-222-2222", Status: Fail},
+		},
+		Changes: []diag.Change{{Key: "RefreshToken", OldValue: "1//0...wxyz", Location: "/home/someone/google-ads.yaml",
+			Backup: "/home/someone/google-ads.yaml_2024-05-01_10-00-00"}},
+	}
+
+	o := r.Outcome()
+	want := []OutcomeCheck{
+		{ID: ConfigCheck, Status: Warn},
+		{ID: OAuthCheck, Status: Fail, Code: "INVALID_REFRESH_TOKEN"},
+	}
+	if o.Schema != OutcomeSchema || o.Version != "1.0.4" || o.Commit != "4f5d6f6a1b2c" || o.Language != "python" || o.OAuthType != "installed_app" ||
+		!reflect.DeepEqual(o.Checks, want) {
+		t.Errorf("Outcome() = %+v, want checks: %+v", o, want)
+	}
+	wantSystem := &OutcomeSystem{OSVersion: "Ubuntu 22.04.3 LTS", Timezone: "CET (UTC+01:00)", ProxyEnv: []string{"HTTPS_PROXY"}, VPN: true}
+	if !reflect.DeepEqual(o.System, wantSystem) {
+		t.Errorf("Outcome().System = %+v, want: %+v", o.System, wantSystem)
+	}
+	if !reflect.DeepEqual(o.Changes, []string{"RefreshToken"}) {
+		t.Errorf("Outcome().Changes = %v, want: [RefreshToken]", o.Changes)
+	}
+	for _, s := range []string{"1234567890", "someone@example.com", "API key", "Configuration file", "acme", "2222222222", "build-42", "10.9.8.7", "tun0",
+		"wxyz", "google-ads.yaml"} {
+		if strings.Contains(o.JSON(), s) {
+			t.Errorf("Outcome().JSON() contains %q:\n%s", s, o.JSON())
+		}
+	}
+}
+
+func TestPostOutcome(t *testing.T) {
+	var got Outcome
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r := &Report{Language: "java", OAuthType: "web", Checks: []Check{{ID: OAuthCheck, Status: Pass}}}
+	if err := PostOutcome(context.Background(), server.Client(), server.URL, r.Outcome()); err != nil {
+		t.Fatalf("PostOutcome() error: %s", err)
+	}
+	if !reflect.DeepEqual(got, r.Outcome()) {
+		t.Errorf("PostOutcome() sent %+v, want: %+v", got, r.Outcome())
+	}
+
+	if err := PostOutcome(context.Background(), server.Client(), server.URL+"/missing\x7f", r.Outcome()); err == nil {
+		t.Errorf("PostOutcome() to an invalid URL succeeded")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+// Remediation is a fix of the problem found by a check. Checks return their
+// fixes in Check.Remediations, so that the doctor offers all of them the
+// same way, see Offer.
+type Remediation interface {
+	// Describe tells what the fix changes.
+	Describe() string
+	// CanAutoApply returns true when the fix needs no input besides the
+	// confirmation, e.g. a value that is known, and false when Apply asks
+	// for values, e.g. a new developer token.
+	CanAutoApply() bool
+	// Apply makes the change, asking for the values it needs with p.
+	Apply(p prompt.Prompter) error
+}
+
+// Offer describes r and applies it when the user confirms. With auto, a fix
+// that can be applied automatically is applied without asking. It returns
+// true when the fix was applied.
+func Offer(r Remediation, p prompt.Prompter, out Reporter, auto bool) (bool, error) {
+	if auto && r.CanAutoApply() {
+		out.Print(i18n.Sprintf("Applying the fix: %s", r.Describe()))
+	} else {
+		out.Print(i18n.Sprintf("Fix: %s", r.Describe()))
+		yes, err := p.Confirm(i18n.T("Apply this fix now? Enter Y for Yes [Anything else is No] >> "))
+		if err != nil || !yes {
+			return false, err
+		}
+	}
+	if err := r.Apply(p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetValue is the fix that sets a value of the config file.
+type SetValue struct {
+	Config *diag.ConfigFile
+	// Key is the field of diag.ConfigKeys to set.
+	Key string
+	// Value is the new value. When it is empty, it is asked for.
+	Value string
+	// Description is returned by Describe.
+	Description string
+}
+
+// Describe returns the description of the fix.
+func (s *SetValue) Describe() string {
+	return s.Description
+}
+
+// CanAutoApply returns true when the new value is known.
+func (s *SetValue) CanAutoApply() bool {
+	return s.Value != ""
+}
+
+// Apply sets the value in the config file, asking for it if it is not known.
+func (s *SetValue) Apply(p prompt.Prompter) error {
+	value := s.Value
+	if value == "" {
+		var err error
+		value, err = p.ReadLine(i18n.Sprintf("New value of %s >> ", s.Config.GetConfigKeysInLang(s.Key)))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.Config.ReplaceConfig(s.Key, value)
+	return err
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/prompt"
+)
+
+// fakeFix records whether it was applied.
+type fakeFix struct {
+	auto    bool
+	err     error
+	applied bool
+}
+
+func (f *fakeFix) Describe() string   { return "Fake fix." }
+func (f *fakeFix) CanAutoApply() bool { return f.auto }
+func (f *fakeFix) Apply(p prompt.Prompter) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.applied = true
+	return nil
+}
+
+// printer collects the printed messages.
+type printer struct {
+	msgs []string
+}
+
+func (p *printer) Print(msg string) { p.msgs = append(p.msgs, msg) }
+func (p *printer) Result(c Check)   {}
+
+func TestOffer(t *testing.T) {
+	tests := []struct {
+		desc    string
+		fix     fakeFix
+		stdin   string
+		auto    bool
+		want    bool
+		wantMsg string
+		wantErr bool
+	}{
+		{
+			desc:    "Confirmed",
+			stdin:   "y\n",
+			want:    true,
+			wantMsg: "Fix: Fake fix.",
+		},
+		{
+			desc:    "Declined",
+			stdin:   "n\n",
+			wantMsg: "Fix: Fake fix.",
+		},
+		{
+			desc:    "Applied automatically",
+			fix:     fakeFix{auto: true},
+			auto:    true,
+			want:    true,
+			wantMsg: "Applying the fix: Fake fix.",
+		},
+		{
+			desc:    "Fix that needs input is confirmed",
+			stdin:   "n\n",
+			auto:    true,
+			wantMsg: "Fix: Fake fix.",
+		},
+		{
+			desc:    "Fix fails",
+			fix:     fakeFix{err: errors.New("read-only")},
+			stdin:   "y\n",
+			wantMsg: "Fix: Fake fix.",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		var out printer
+		p := prompt.NewTerminal(strings.NewReader(tt.stdin), ioutil.Discard)
+		got, err := Offer(&tt.fix, p, &out, tt.auto)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("[%s] Offer() error: %v, want error: %v", tt.desc, err, tt.wantErr)
+		}
+		if got != tt.want || tt.fix.applied != tt.want {
+			t.Errorf("[%s] Offer() = %v, applied: %v, want: %v", tt.desc, got, tt.fix.applied, tt.want)
+		}
+		if len(out.msgs) == 0 || out.msgs[0] != tt.wantMsg {
+			t.Errorf("[%s] Offer() printed %q, want: %s", tt.desc, out.msgs, tt.wantMsg)
+		}
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remediation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := "developer_tokn: GoodDevToken\nclient_id: 0123456789-GoodClientID.apps.googleusercontent.com\n" +
+		"client_secret: GoodClientSecret\nrefresh_token: 1//GoodRefreshToken\nuse_proto_plus: True\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "google-ads.yaml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := diag.ParseConfigFile("python", filepath.Join(dir, "google-ads.yaml"), diag.InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixes := c.KeyFixes()
+	if len(fixes) != 1 {
+		t.Fatalf("KeyFixes() = %v, want the misspelled developer token", fixes)
+	}
+	fix := &SetValue{Config: &c, Key: fixes[0].Field, Value: fixes[0].Value, Description: fixes[0].Reason}
+	if !fix.CanAutoApply() {
+		t.Errorf("CanAutoApply() = false, want: true")
+	}
+	if err := fix.Apply(prompt.NonInteractive{}); err != nil {
+		t.Fatalf("Apply() error: %s", err)
+	}
+
+	fixed, err := diag.ParseConfigFile("python", filepath.Join(dir, "google-ads.yaml"), diag.InstalledApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.DevToken != "GoodDevToken" || c.DevToken != "GoodDevToken" {
+		t.Errorf("Apply() got developer token %q in the file and %q in memory, want: GoodDevToken", fixed.DevToken, c.DevToken)
+	}
+
+	ask := &SetValue{Config: &c, Key: diag.DevToken}
+	if ask.CanAutoApply() {
+		t.Errorf("CanAutoApply() without a value = true, want: false")
+	}
+	if err := ask.Apply(prompt.NonInteractive{}); err != prompt.ErrNoInput {
+		t.Errorf("Apply() without input error: %v, want: %v", err, prompt.ErrNoInput)
+	}
+}
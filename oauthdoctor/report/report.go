@@ -0,0 +1,390 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report collects the results of the diagnostic checks and
+// summarizes them for the user.
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/googleads/google-ads-doctor/oauthdoctor/diag"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/i18n"
+	"github.com/googleads/google-ads-doctor/oauthdoctor/replay"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	// Pass means the check found no problem.
+	Pass Status = "PASS"
+	// Warn means the check found a problem that may not cause failures.
+	Warn Status = "WARN"
+	// Fail means the check found a problem that must be fixed.
+	Fail Status = "FAIL"
+	// Skip means the check did not run.
+	Skip Status = "SKIP"
+	// Timeout means the check did not complete before its deadline, e.g.
+	// because a network call is blocked.
+	Timeout Status = "TIMEOUT"
+)
+
+// These are the IDs of the built-in checks.
+const (
+	AllowListCheck    = "allowlist"
+	APIVersionsCheck  = "api-versions"
+	BuildEnvCheck     = "buildenv"
+	ConfigCheck       = "config"
+	ConnectivityCheck = "connectivity"
+	DNSCheck          = "dns"
+	HTTP2Check        = "http2"
+	MutateCheck       = "mutate"
+	NetPerfCheck      = "netperf"
+	OAuthCheck        = "oauth"
+	SysInfoCheck      = "sysinfo"
+	TCPCheck          = "tcp"
+	TLSCheck          = "tls"
+	TokenCheck        = "token"
+	TransportCheck    = "transport"
+)
+
+// PluginCheckPrefix starts the IDs of the checks added by plugins.
+const PluginCheckPrefix = "plugin:"
+
+// CustomerCheckPrefix starts the IDs of the checks of the access to a list of
+// customer accounts, which end with the customer ID.
+const CustomerCheckPrefix = "customer:"
+
+// Check is the result of a single diagnostic check.
+type Check struct {
+	ID     string
+	Name   string
+	Status Status
+	// Code identifies the problem found by a failed check, e.g.
+	// INVALID_REFRESH_TOKEN.
+	Code    string
+	Message string
+	// Duration is the time the check took to run.
+	Duration time.Duration `json:",omitempty"`
+	// RequestID is the ID of the last Google Ads API request of the check,
+	// if any, which the API support needs to look up the call.
+	RequestID string `json:",omitempty"`
+	// Link is a page that explains the problem found by the check and how
+	// to fix it, if known.
+	Link string `json:",omitempty"`
+	// Explanation is the message of the knowledge base for the problem
+	// found by the check, if any.
+	Explanation string `json:",omitempty"`
+	// Remediations are the fixes of the problem, which the doctor offers
+	// to apply.
+	Remediations []Remediation `json:"-"`
+}
+
+// Report is the collection of check results of a diagnosis run.
+type Report struct {
+	Language   string
+	OAuthType  string
+	CustomerID string
+	// User is the email of the user who authorized the OAuth client, if
+	// known.
+	User string
+	// Version, Commit and BuildDate identify the build of the doctor that
+	// made the report.
+	Version   string
+	Commit    string
+	BuildDate string
+	// Config are the values of the configuration file, by field of
+	// diag.ConfigKeys, with the PII redacted by diag.Redact, so that the
+	// reports of two machines can be compared without revealing secrets.
+	Config map[string]string `json:",omitempty"`
+	// SysInfo is the system information, when it was collected.
+	SysInfo *diag.SysInfo
+	Checks  []Check
+	// Changes are the values of the configuration that the doctor replaced
+	// during the diagnosis.
+	Changes []diag.Change
+	// Traces are the HTTP requests of the diagnosis and their responses,
+	// with the secrets redacted, when they were kept.
+	Traces []replay.Interaction `json:",omitempty"`
+}
+
+// Add appends the result of a check to the report.
+func (r *Report) Add(c Check) {
+	r.Checks = append(r.Checks, c)
+}
+
+// Failed returns true if any check failed or timed out.
+func (r *Report) Failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == Fail || c.Status == Timeout {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the result of the check with the given ID, and false if the
+// check did not run.
+func (r *Report) Check(id string) (Check, bool) {
+	for _, c := range r.Checks {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Check{}, false
+}
+
+// Narrative returns a short plain-language summary of the report that can
+// be pasted into an email.
+func (r *Report) Narrative() string {
+	var sentences []string
+
+	if c, ok := r.Check(ConfigCheck); ok {
+		switch c.Status {
+		case Pass:
+			sentences = append(sentences, i18n.Sprintf("Your %s client library configuration file passed validation.", r.Language))
+		case Warn:
+			sentences = append(sentences, i18n.Sprintf("Your %s client library configuration file passed validation, "+
+				"but some values look suspicious: %s.", r.Language, oneLine(c.Message)))
+		default:
+			sentences = append(sentences, i18n.Sprintf("Your %s client library configuration file has problems that need to be fixed: %s.",
+				r.Language, oneLine(c.Message)))
+		}
+	}
+
+	if c, ok := r.Check(DNSCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("This machine cannot resolve the host name of the Google Ads API (%s), "+
+			"so check your DNS settings.", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(ConnectivityCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("This machine cannot connect to the Google Ads API (%s), "+
+			"so check your network, proxy and firewall settings.", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(TLSCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("A secure connection to the Google Ads API cannot be established (%s); "+
+			"a proxy or antivirus software may be intercepting HTTPS traffic.", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(HTTP2Check); ok && c.Status == Warn {
+		sentences = append(sentences, i18n.Sprintf("The connection to the Google Ads API does not support HTTP/2 (%s), "+
+			"which gRPC requires: client libraries that use gRPC will fail while REST requests still work. "+
+			"A proxy or antivirus software is likely downgrading HTTPS traffic.", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(TransportCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("The requests of your client library do not reach the "+
+			"Google Ads API: %s", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(APIVersionsCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("Your client library calls a version of the Google Ads API that "+
+			"is not served, so all its requests fail: %s", oneLine(c.Message)))
+	}
+
+	if c, ok := r.Check(NetPerfCheck); ok && c.Status == Warn {
+		sentences = append(sentences, i18n.Sprintf("The network to the Google Ads API is slow (%s), so requests may "+
+			"fail with DEADLINE_EXCEEDED even though your credentials are valid.", oneLine(c.Message)))
+	}
+
+	for _, c := range r.Checks {
+		if !strings.HasPrefix(c.ID, PluginCheckPrefix) {
+			continue
+		}
+		switch c.Status {
+		case Fail:
+			sentences = append(sentences, i18n.Sprintf("The custom check %s failed: %s.", c.Name, oneLine(c.Message)))
+		case Warn:
+			sentences = append(sentences, i18n.Sprintf("The custom check %s found a possible problem: %s.", c.Name,
+				oneLine(c.Message)))
+		}
+	}
+
+	for _, c := range r.Checks {
+		if c.Status == Timeout {
+			sentences = append(sentences, i18n.Sprintf("The check %s %s, so a network call is likely blocked "+
+				"by a firewall or proxy that drops packets instead of refusing the connection.", c.Name, c.Message))
+		}
+	}
+
+	if c, ok := r.Check(OAuthCheck); ok {
+		sentences = append(sentences, r.oauthNarrative(c))
+	}
+
+	if c, ok := r.Check(MutateCheck); ok && c.Status == Fail {
+		sentences = append(sentences, i18n.Sprintf("The credentials can read the account, but a mutate call "+
+			"failed (%s), so your application cannot change it.", c.Code))
+	}
+
+	if c, ok := r.Check(TokenCheck); ok && c.Status == Warn {
+		sentences = append(sentences, i18n.Sprintf("Refresh tokens of your OAuth client may be revoked without "+
+			"notice (%s); generate one refresh token for each user and reuse it.", oneLine(c.Message)))
+	}
+
+	if s := r.customerNarrative(); s != "" {
+		sentences = append(sentences, s)
+	}
+
+	return strings.Join(sentences, " ")
+}
+
+// customerNarrative counts the customer accounts of the list that the
+// credentials can access, and returns an empty string when no list was
+// checked.
+func (r *Report) customerNarrative() string {
+	var total, reached int
+	for _, c := range r.Checks {
+		if !strings.HasPrefix(c.ID, CustomerCheckPrefix) {
+			continue
+		}
+		total++
+		if c.Status == Pass {
+			reached++
+		}
+	}
+	switch {
+	case total == 0:
+		return ""
+	case reached == total:
+		return i18n.Sprintf("The credentials can access all %d customer accounts of the list.", total)
+	}
+	return i18n.Sprintf("The credentials can access %d of the %d customer accounts of the list; "+
+		"the others are listed above with the reason.", reached, total)
+}
+
+// deadlineNarrative explains a DEADLINE_EXCEEDED error of the OAuth check
+// using the result of the network latency check, if it ran.
+func (r *Report) deadlineNarrative() string {
+	msg := i18n.T("The request to the Google Ads API did not complete before its deadline (DEADLINE_EXCEEDED).")
+	c, ok := r.Check(NetPerfCheck)
+	switch {
+	case !ok:
+		msg += " " + i18n.T("Run this tool again with -netperf to measure your network latency.")
+	case c.Status == Pass:
+		msg += " " + i18n.T("Your network latency is normal, so the request itself is slow; increase the "+
+			"timeout of your client library or request less data per call.")
+	default:
+		msg += " " + i18n.T("Your network is slow or unreliable, which is the likely cause; fix the network "+
+			"or increase the timeout of your client library.")
+	}
+	return msg
+}
+
+// oauthNarrative explains the result of the OAuth check.
+func (r *Report) oauthNarrative(c Check) string {
+	cid := FormatCustomerID(r.CustomerID)
+	user := r.User
+	if user == "" {
+		user = i18n.T("the user who authorized the OAuth client")
+	}
+
+	if c.Status == Pass && c.Message != "" {
+		return i18n.Sprintf("Your credentials are valid and can access account %s.", cid) + " " + c.Message
+	}
+	if c.Status == Pass {
+		return i18n.Sprintf("Your credentials are valid and can access account %s.", cid)
+	}
+
+	switch c.Code {
+	case "INVALID_CLIENT_INFO":
+		return i18n.T("The OAuth client ID or client secret is not valid; copy them again from the Google Cloud console.")
+	case "INVALID_REFRESH_TOKEN", "UNAUTHORIZED":
+		return i18n.Sprintf("The refresh token was rejected, either because it was revoked or generated with another "+
+			"OAuth client, or because its user has no access to account %s; generate a new refresh token while "+
+			"signed in as a user with access to that account.", cid)
+	case "UNAUTHENTICATED":
+		return i18n.Sprintf("Your credentials are valid, but the user you authorized has no access to account %s; "+
+			"ask an admin of that account to invite %s.", cid, user)
+	case "ACCESS_NOT_PERMITTED_FOR_MANAGER_ACCOUNT":
+		return i18n.Sprintf("Your credentials are valid, but account %s is a manager account and the request "+
+			"cannot be executed against it; use one of its client accounts instead.", cid)
+	case "GOOGLE_ADS_API_DISABLED":
+		return i18n.T("The Google Ads API is not enabled in the Google Cloud project of your OAuth client; " +
+			"enable it in the Google Cloud console.")
+	case "MISSING_DEV_TOKEN":
+		return i18n.T("The developer token is missing from your configuration file; copy it from the API Center " +
+			"of your Google Ads manager account.")
+	case "INVALID_CUSTOMER_ID":
+		return i18n.Sprintf("%s is not a valid Google Ads customer ID; use the 10-digit ID shown in the Google Ads UI.", cid)
+	case "REDIRECT_URI_MISMATCH":
+		return i18n.T("The redirect URI used by the OAuth flow is not registered for your OAuth client; add it " +
+			"to the authorized redirect URIs in the Google Cloud console.")
+	case "CONSENT_DENIED":
+		return i18n.T("The consent page did not grant access to the Google Ads API, which is usually caused by " +
+			"the OAuth consent screen of your Google Cloud project: add your Google account as a test user of an " +
+			"app in Testing, or sign in with an account of the organization of an Internal app.")
+	case "ADMIN_POLICY_ENFORCED":
+		return i18n.T("A policy of your Google Workspace organization, or the Advanced Protection Program of your " +
+			"Google account, blocked your OAuth client; ask an admin of the organization to trust the client, or " +
+			"use another Google account.")
+	case "INSUFFICIENT_SCOPE":
+		return i18n.T("Your refresh token was not authorized for the Google Ads API scope " +
+			"(https://www.googleapis.com/auth/adwords); generate a new refresh token that includes it.")
+	case "DEADLINE_EXCEEDED":
+		return r.deadlineNarrative()
+	case "CUSTOMER_NOT_ACTIVE":
+		return i18n.Sprintf("Your credentials are valid, but account %s is not active because of a policy or "+
+			"billing suspension; check the account status in the Google Ads UI.", cid)
+	case "DEVELOPER_TOKEN_NOT_APPROVED":
+		return i18n.Sprintf("Your credentials are valid, but your developer token only has test access and "+
+			"account %s is a production account; apply for Basic access, or test with a test account.", cid)
+	case "DEVELOPER_TOKEN_PROHIBITED":
+		return i18n.T("Your developer token is bound to another Google Cloud project than the one of your OAuth " +
+			"client; use an OAuth client of the project that first used the token, or apply for a new developer token.")
+	case "CUSTOMER_NOT_ENABLED":
+		return i18n.Sprintf("Your credentials are valid, but account %s is cancelled or not enabled; reactivate "+
+			"it in the Google Ads UI, or test with another account such as a test account.", cid)
+	case "FEDERATION_FAILED":
+		return i18n.Sprintf("Your service account uses workload identity federation, which failed before the "+
+			"Google Ads API was called (%s); check the credential source of the credentials file, the audience of "+
+			"the workload identity pool provider, and that the federated identity has the "+
+			"roles/iam.workloadIdentityUser role on the service account.", oneLine(c.Message))
+	case "UNTRUSTED_CERTIFICATE":
+		return i18n.T("This machine does not trust the certificate of the Google servers, usually because a proxy " +
+			"or antivirus software intercepts HTTPS traffic, so no request can succeed whatever your credentials; " +
+			"add the CA certificate of the proxy to the CA bundle of your client library, and pass it to this tool " +
+			"with -cacert.")
+	default:
+		// The knowledge base may explain the codes added to it at run time.
+		if c.Code != "UNKNOWN_ERROR" {
+			if msg := oneLine(strings.TrimPrefix(c.Explanation, "ERROR: ")); msg != "" {
+				return msg + "."
+			}
+		}
+		return i18n.T("The OAuth test failed for a reason that could not be determined; contact Google Ads API " +
+			"support and include the output of this tool.")
+	}
+}
+
+// FormatCustomerID formats a 10-digit customer ID as 123-456-7890. Other
+// values are returned unchanged.
+func FormatCustomerID(cid string) string {
+	if len(cid) != 10 || strings.Trim(cid, "0123456789") != "" {
+		return cid
+	}
+	return cid[:3] + "-" + cid[3:6] + "-" + cid[6:]
+}
+
+// oneLine joins the lines of a multi-line message with semicolons.
+func oneLine(msg string) string {
+	var parts []string
+	for _, line := range strings.Split(strings.TrimSpace(msg), "\n") {
+		if line = strings.TrimRight(strings.TrimSpace(line), "."); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,363 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNarrative(t *testing.T) {
+	tests := []struct {
+		desc   string
+		report Report
+		want   []string
+	}{
+		{
+			desc: "Everything passes",
+			report: Report{
+				Language:   "python",
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: ConfigCheck, Status: Pass},
+					{ID: OAuthCheck, Status: Pass},
+				},
+			},
+			want: []string{
+				"python client library configuration file passed validation.",
+				"credentials are valid and can access account 123-456-7890.",
+			},
+		},
+		{
+			desc: "User has no access to the account",
+			report: Report{
+				Language:   "java",
+				CustomerID: "1234567890",
+				User:       "dev@example.com",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "UNAUTHENTICATED"},
+				},
+			},
+			want: []string{"no access to account 123-456-7890; ask an admin of that account to invite dev@example.com."},
+		},
+		{
+			desc: "Read-only access",
+			report: Report{
+				Language:   "python",
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Pass},
+					{ID: MutateCheck, Status: Fail, Code: "READ_ONLY_ACCESS"},
+				},
+			},
+			want: []string{"a mutate call failed (READ_ONLY_ACCESS), so your application cannot change it."},
+		},
+		{
+			desc: "Refresh token revoked",
+			report: Report{
+				Language: "python",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "INVALID_REFRESH_TOKEN"},
+					{ID: TokenCheck, Status: Warn, Message: "at least 30 days old"},
+				},
+			},
+			want: []string{"Refresh tokens of your OAuth client may be revoked without notice (at least 30 days old); " +
+				"generate one refresh token for each user and reuse it."},
+		},
+		{
+			desc: "Config and connectivity problems",
+			report: Report{
+				Language: "php",
+				Checks: []Check{
+					{ID: ConfigCheck, Status: Fail, Message: "DevToken is empty.\nClientID is empty.\n"},
+					{ID: ConnectivityCheck, Status: Fail, Message: "connection refused"},
+				},
+			},
+			want: []string{
+				"need to be fixed: DevToken is empty; ClientID is empty.",
+				"cannot connect to the Google Ads API (connection refused)",
+			},
+		},
+		{
+			desc: "DNS and TLS problems",
+			report: Report{
+				Checks: []Check{
+					{ID: DNSCheck, Status: Fail, Message: "no such host"},
+					{ID: TLSCheck, Status: Fail, Message: "x509: certificate signed by unknown authority"},
+				},
+			},
+			want: []string{
+				"cannot resolve the host name of the Google Ads API (no such host)",
+				"secure connection to the Google Ads API cannot be established (x509: certificate signed by unknown authority)",
+			},
+		},
+		{
+			desc: "HTTP/2 not negotiated",
+			report: Report{
+				Checks: []Check{
+					{ID: HTTP2Check, Status: Warn, Message: "negotiated protocol: http/1.1"},
+				},
+			},
+			want: []string{"does not support HTTP/2 (negotiated protocol: http/1.1)"},
+		},
+		{
+			desc: "Transport blocked",
+			report: Report{
+				Checks: []Check{
+					{ID: TransportCheck, Status: Fail, Message: "The proxy blocks gRPC but lets REST through."},
+				},
+			},
+			want: []string{"do not reach the Google Ads API: The proxy blocks gRPC but lets REST through"},
+		},
+		{
+			desc: "Sunset API version",
+			report: Report{
+				Checks: []Check{
+					{ID: APIVersionsCheck, Status: Fail, Message: "v8 is sunset."},
+				},
+			},
+			want: []string{"calls a version of the Google Ads API that is not served, so all its requests fail: v8 is sunset"},
+		},
+		{
+			desc: "Check timed out",
+			report: Report{
+				Checks: []Check{
+					{ID: ConnectivityCheck, Name: "Connectivity", Status: Timeout, Message: "did not complete within 30s"},
+				},
+			},
+			want: []string{"The check Connectivity did not complete within 30s, so a network call is likely blocked"},
+		},
+		{
+			desc: "Slow network",
+			report: Report{
+				Checks: []Check{
+					{ID: NetPerfCheck, Status: Warn, Message: "90th percentile of the first byte latency: 3s"},
+				},
+			},
+			want: []string{"network to the Google Ads API is slow", "DEADLINE_EXCEEDED"},
+		},
+		{
+			desc: "Deadline exceeded without latency check",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "DEADLINE_EXCEEDED"},
+				},
+			},
+			want: []string{"DEADLINE_EXCEEDED", "with -netperf"},
+		},
+		{
+			desc: "Deadline exceeded on a fast network",
+			report: Report{
+				Checks: []Check{
+					{ID: NetPerfCheck, Status: Pass},
+					{ID: OAuthCheck, Status: Fail, Code: "DEADLINE_EXCEEDED"},
+				},
+			},
+			want: []string{"latency is normal"},
+		},
+		{
+			desc: "Deadline exceeded on a slow network",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "DEADLINE_EXCEEDED"},
+					{ID: NetPerfCheck, Status: Warn, Message: "90th percentile of the first byte latency: 3s"},
+				},
+			},
+			want: []string{"network is slow or unreliable", "network to the Google Ads API is slow"},
+		},
+		{
+			desc: "Insufficient scope",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "INSUFFICIENT_SCOPE"},
+				},
+			},
+			want: []string{"not authorized for the Google Ads API scope"},
+		},
+		{
+			desc: "Redirect URI mismatch",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "REDIRECT_URI_MISMATCH"},
+				},
+			},
+			want: []string{"redirect URI used by the OAuth flow is not registered"},
+		},
+		{
+			desc: "Consent denied",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "CONSENT_DENIED"},
+				},
+			},
+			want: []string{"add your Google account as a test user"},
+		},
+		{
+			desc: "Config warnings",
+			report: Report{
+				Language: "python",
+				Checks: []Check{
+					{ID: ConfigCheck, Status: Warn, Message: "Dev token contains whitespace."},
+				},
+			},
+			want: []string{"passed validation, but some values look suspicious: Dev token contains whitespace."},
+		},
+		{
+			desc: "Custom check fails",
+			report: Report{
+				Checks: []Check{
+					{ID: PluginCheckPrefix + "proxy", Name: "Proxy allow-list", Status: Fail,
+						Message: "googleads.googleapis.com is not allowed.\n"},
+				},
+			},
+			want: []string{"The custom check Proxy allow-list failed: googleads.googleapis.com is not allowed."},
+		},
+		{
+			desc: "Test account",
+			report: Report{
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Pass, Message: "Account 123-456-7890 is a test account."},
+				},
+			},
+			want: []string{"can access account 123-456-7890. Account 123-456-7890 is a test account."},
+		},
+		{
+			desc: "Developer token with test access",
+			report: Report{
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "DEVELOPER_TOKEN_NOT_APPROVED"},
+				},
+			},
+			want: []string{"only has test access and account 123-456-7890 is a production account"},
+		},
+		{
+			desc: "Blocked by an admin policy",
+			report: Report{
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "ADMIN_POLICY_ENFORCED"},
+				},
+			},
+			want: []string{"ask an admin of the organization to trust the client"},
+		},
+		{
+			desc: "Developer token bound to another project",
+			report: Report{
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "DEVELOPER_TOKEN_PROHIBITED"},
+				},
+			},
+			want: []string{"use an OAuth client of the project that first used the token"},
+		},
+		{
+			desc: "Account is cancelled",
+			report: Report{
+				CustomerID: "1234567890",
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "CUSTOMER_NOT_ENABLED"},
+				},
+			},
+			want: []string{"account 123-456-7890 is cancelled or not enabled; reactivate it"},
+		},
+		{
+			desc: "Some customer accounts cannot be accessed",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Pass},
+					{ID: CustomerCheckPrefix + "1111111111", Status: Pass},
+					{ID: CustomerCheckPrefix + "2222222222", Status: Fail, Code: "NOT_LINKED"},
+				},
+			},
+			want: []string{"The credentials can access 1 of the 2 customer accounts of the list"},
+		},
+		{
+			desc: "Workload identity federation failed",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "FEDERATION_FAILED",
+						Message: "The workload identity pool does not exist or is disabled."},
+				},
+			},
+			want: []string{"workload identity federation, which failed", "pool does not exist or is disabled"},
+		},
+		{
+			desc: "Code of the knowledge base file",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "QUOTA_EXHAUSTED",
+						Message:     "RESOURCE_EXHAUSTED",
+						Explanation: "ERROR: The developer token exceeded its quota. "},
+				},
+			},
+			want: []string{"The developer token exceeded its quota."},
+		},
+		{
+			desc: "Unknown error with an explanation",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "UNKNOWN_ERROR",
+						Explanation: "ERROR: Your credentials are invalid but we cannot determine the exact error. "},
+				},
+			},
+			want: []string{"could not be determined"},
+		},
+		{
+			desc: "Untrusted certificate",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "UNTRUSTED_CERTIFICATE"},
+				},
+			},
+			want: []string{"a proxy or antivirus software intercepts HTTPS traffic", "-cacert"},
+		},
+		{
+			desc: "Unknown error",
+			report: Report{
+				Checks: []Check{
+					{ID: OAuthCheck, Status: Fail, Code: "UNKNOWN_ERROR"},
+				},
+			},
+			want: []string{"could not be determined"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.report.Narrative()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("[%s] got: %s\nwant substring: %s", tt.desc, got, want)
+			}
+		}
+	}
+}
+
+func TestFormatCustomerID(t *testing.T) {
+	tests := []struct {
+		cid  string
+		want string
+	}{
+		{cid: "1234567890", want: "123-456-7890"},
+		{cid: "123456789", want: "123456789"},
+		{cid: "abcdefghij", want: "abcdefghij"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatCustomerID(tt.cid); got != tt.want {
+			t.Errorf("FormatCustomerID(%s) got: %s, want: %s", tt.cid, got, tt.want)
+		}
+	}
+}
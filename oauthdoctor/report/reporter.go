@@ -0,0 +1,145 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Level is the severity of a message of a diagnosis.
+type Level string
+
+const (
+	// Info is a progress or diagnostic message.
+	Info Level = "INFO"
+	// Warning is a problem that may not cause failures.
+	Warning Level = "WARN"
+	// Error is a problem that must be fixed.
+	Error Level = "ERROR"
+	// Success confirms that a test passed.
+	Success Level = "SUCCESS"
+)
+
+// levelPrefixes are the prefixes that give the level of a message.
+var levelPrefixes = []struct {
+	prefix string
+	level  Level
+}{
+	{"ERROR", Error},
+	{string(Fail), Error},
+	{"WARN", Warning},
+	{"SUCCESS", Success},
+}
+
+// LevelOf returns the level of msg from its prefix, e.g. ERROR: for Error,
+// so reporters can present messages by severity.
+func LevelOf(msg string) Level {
+	for _, p := range levelPrefixes {
+		if strings.HasPrefix(msg, p.prefix) {
+			return p.level
+		}
+	}
+	return Info
+}
+
+// colors are the ANSI escape codes of the levels.
+var colors = map[Level]string{
+	Error:   "\x1b[31m",
+	Warning: "\x1b[33m",
+	Success: "\x1b[32m",
+}
+
+const colorReset = "\x1b[0m"
+
+// Colorize returns msg in the color of its level for display in a terminal.
+// Info messages are not colored.
+func Colorize(msg string) string {
+	if c, ok := colors[LevelOf(msg)]; ok {
+		return c + msg + colorReset
+	}
+	return msg
+}
+
+// ColorSupported returns true when the output to f can be colored: f is a
+// terminal, and colors are not disabled with the NO_COLOR environment
+// variable or a dumb terminal. On Windows, only the terminals that are known
+// to interpret escape codes are colored, since the legacy console prints
+// them as is.
+func ColorSupported(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return colorSupported(runtime.GOOS, fi.Mode()&os.ModeCharDevice != 0, os.Getenv)
+}
+
+func colorSupported(goos string, terminal bool, getenv func(string) string) bool {
+	if !terminal || getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+	if goos == "windows" {
+		return getenv("WT_SESSION") != "" || getenv("ConEmuANSI") == "ON" || getenv("TERM") != ""
+	}
+	return true
+}
+
+// Reporter receives the output of a diagnosis as it runs.
+type Reporter interface {
+	// Print displays a progress or diagnostic message.
+	Print(msg string)
+	// Result is called with the result of each check when it completes.
+	Result(c Check)
+}
+
+// LogReporter prints messages with the standard logger. It ignores check
+// results, which are summarized by Report.Narrative, unless it is quiet.
+type LogReporter struct {
+	// Color colors the messages by level, see Colorize.
+	Color bool
+	// Quiet only prints warnings, errors and the checks that did not pass,
+	// e.g. for scheduled jobs.
+	Quiet bool
+}
+
+// Print prints msg with the standard logger.
+func (r LogReporter) Print(msg string) {
+	level := LevelOf(msg)
+	if r.Quiet && level != Warning && level != Error {
+		return
+	}
+	if r.Color {
+		msg = Colorize(msg)
+	}
+	log.Print(msg)
+}
+
+// Result prints the checks that did not pass when the reporter is quiet,
+// since not all their messages have a level.
+func (r LogReporter) Result(c Check) {
+	if !r.Quiet || c.Status == Pass || c.Status == Skip {
+		return
+	}
+	msg := fmt.Sprintf("%s: %s", c.Status, c.Name)
+	if c.Message != "" {
+		msg += ": " + oneLine(c.Message)
+	}
+	if r.Color {
+		msg = Colorize(msg)
+	}
+	log.Print(msg)
+}
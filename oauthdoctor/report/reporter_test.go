@@ -0,0 +1,90 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	tests := []struct {
+		msg       string
+		wantLevel Level
+		want      string
+	}{
+		{"ERROR: OAuth test failed.", Error, "\x1b[31mERROR: OAuth test failed.\x1b[0m"},
+		{"WARNING: ClientSecret is stored in plaintext", Warning, "\x1b[33mWARNING: ClientSecret is stored in plaintext\x1b[0m"},
+		{"SUCCESS: OAuth test passed", Success, "\x1b[32mSUCCESS: OAuth test passed\x1b[0m"},
+		{"Client library language: python", Info, "Client library language: python"},
+	}
+
+	for _, tt := range tests {
+		if got := LevelOf(tt.msg); got != tt.wantLevel {
+			t.Errorf("LevelOf(%q) = %s, want: %s", tt.msg, got, tt.wantLevel)
+		}
+		if got := Colorize(tt.msg); got != tt.want {
+			t.Errorf("Colorize(%q) = %q, want: %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestColorSupported(t *testing.T) {
+	tests := []struct {
+		desc     string
+		goos     string
+		terminal bool
+		env      map[string]string
+		want     bool
+	}{
+		{desc: "Terminal", goos: "linux", terminal: true, env: map[string]string{"TERM": "xterm"}, want: true},
+		{desc: "Pipe", goos: "linux", env: map[string]string{"TERM": "xterm"}},
+		{desc: "NO_COLOR", goos: "darwin", terminal: true, env: map[string]string{"NO_COLOR": "1"}},
+		{desc: "Dumb terminal", goos: "linux", terminal: true, env: map[string]string{"TERM": "dumb"}},
+		{desc: "Legacy Windows console", goos: "windows", terminal: true},
+		{desc: "Windows Terminal", goos: "windows", terminal: true, env: map[string]string{"WT_SESSION": "1"}, want: true},
+	}
+
+	for _, tt := range tests {
+		getenv := func(k string) string { return tt.env[k] }
+		if got := colorSupported(tt.goos, tt.terminal, getenv); got != tt.want {
+			t.Errorf("[%s] colorSupported() = %t, want: %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestQuietLogReporter(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	r := LogReporter{Quiet: true}
+	r.Print("Client library language: python")
+	r.Print("WARNING: ClientSecret is stored in plaintext")
+	r.Print("SUCCESS: OAuth test passed")
+	r.Print("ERROR: OAuth test failed.")
+	r.Result(Check{ID: ConfigCheck, Name: "Configuration file", Status: Fail, Message: "DevToken is empty.\n"})
+	r.Result(Check{ID: OAuthCheck, Name: "OAuth flow and API access", Status: Pass})
+
+	want := "WARNING: ClientSecret is stored in plaintext\nERROR: OAuth test failed.\nFAIL: Configuration file: DevToken is empty\n"
+	if got := out.String(); got != want {
+		t.Errorf("LogReporter{Quiet: true} printed %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Field is a key-value pair of a structured log record.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger writes structured log records as JSON lines, one object per line
+// with the time, level and message followed by the fields of the record, so
+// the output of a diagnosis can be collected by log pipelines.
+//
+// The log/slog package is not used since it requires Go 1.21, and the doctor
+// supports older versions of Go.
+type Logger struct {
+	w  io.Writer
+	mu sync.Mutex
+	// now returns the time of a record. It is replaced in tests.
+	now func() time.Time
+}
+
+// NewLogger returns a Logger that writes to w.
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{w: w, now: time.Now}
+}
+
+// Log writes a record of msg at level with fields. Fields with an empty
+// value are omitted.
+func (l *Logger) Log(level Level, msg string, fields ...Field) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeField(&buf, "time", l.now().UTC().Format(time.RFC3339Nano))
+	buf.WriteByte(',')
+	writeField(&buf, "level", level)
+	buf.WriteByte(',')
+	writeField(&buf, "msg", msg)
+	for _, f := range fields {
+		if f.Value == nil || f.Value == "" {
+			continue
+		}
+		buf.WriteByte(',')
+		writeField(&buf, f.Key, f.Value)
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(buf.Bytes())
+}
+
+// writeField writes "key":value to buf. A value that cannot be encoded is
+// written as its error.
+func writeField(buf *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(err.Error())
+	}
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(v)
+}
+
+// Write logs p as a record with the level of its prefix, so the messages
+// printed with the standard logger are also structured when it writes to l.
+// Blank lines, which only separate the messages of the text output, are
+// skipped.
+func (l *Logger) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	if strings.TrimSpace(msg) == "" {
+		return len(p), nil
+	}
+	l.Log(LevelOf(msg), msg)
+	return len(p), nil
+}
+
+// JSONReporter logs the messages and the check results of a diagnosis as
+// structured records, e.g. for the JSON output and the log file.
+type JSONReporter struct {
+	Logger *Logger
+}
+
+// Print logs msg with the level of its prefix.
+func (r JSONReporter) Print(msg string) {
+	r.Logger.Log(LevelOf(msg), strings.TrimRight(msg, "\n"))
+}
+
+// Result logs the result of c with its ID, status, code, duration and API
+// request ID as fields.
+func (r JSONReporter) Result(c Check) {
+	fields := []Field{{"check", c.ID}, {"status", c.Status}, {"code", c.Code}}
+	if c.Duration > 0 {
+		fields = append(fields, Field{"duration", c.Duration.Round(time.Millisecond).String()})
+	}
+	fields = append(fields, Field{"request_id", c.RequestID}, Field{"message", oneLine(c.Message)})
+	r.Logger.Log(statusLevels[c.Status], c.Name, fields...)
+}
+
+// statusLevels are the levels of the records of the check results by
+// status.
+var statusLevels = map[Status]Level{
+	Pass:    Success,
+	Warn:    Warning,
+	Fail:    Error,
+	Skip:    Info,
+	Timeout: Error,
+}
+
+// Reporters sends the output of a diagnosis to several reporters, e.g. the
+// terminal and the log file.
+type Reporters []Reporter
+
+// Print prints msg with each reporter.
+func (rs Reporters) Print(msg string) {
+	for _, r := range rs {
+		r.Print(msg)
+	}
+}
+
+// Result sends c to each reporter.
+func (rs Reporters) Result(c Check) {
+	for _, r := range rs {
+		r.Result(c)
+	}
+}
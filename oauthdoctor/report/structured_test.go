@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// records decodes the JSON lines written by a Logger.
+func records(t *testing.T, out string) []map[string]string {
+	var recs []map[string]string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		rec := make(map[string]string)
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("cannot decode record %q: %s", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestJSONReporter(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewLogger(&out)
+	logger.now = func() time.Time { return time.Date(2020, 8, 11, 6, 55, 22, 0, time.UTC) }
+	r := JSONReporter{Logger: logger}
+
+	r.Print("Client library language: python")
+	r.Print("WARNING: ClientSecret is stored in plaintext\n")
+	r.Result(Check{ID: OAuthCheck, Name: "OAuth flow and API access", Status: Fail, Code: "INVALID_REFRESH_TOKEN",
+		Message: "invalid_grant.\n", Duration: 1234567 * time.Microsecond, RequestID: "abc123"})
+	r.Result(Check{ID: ConfigCheck, Name: "Configuration file", Status: Pass})
+
+	want := []map[string]string{
+		{"time": "2020-08-11T06:55:22Z", "level": "INFO", "msg": "Client library language: python"},
+		{"time": "2020-08-11T06:55:22Z", "level": "WARN", "msg": "WARNING: ClientSecret is stored in plaintext"},
+		{"time": "2020-08-11T06:55:22Z", "level": "ERROR", "msg": "OAuth flow and API access", "check": OAuthCheck,
+			"status": "FAIL", "code": "INVALID_REFRESH_TOKEN", "duration": "1.235s", "request_id": "abc123",
+			"message": "invalid_grant"},
+		{"time": "2020-08-11T06:55:22Z", "level": "SUCCESS", "msg": "Configuration file", "check": ConfigCheck,
+			"status": "PASS"},
+	}
+	if got := records(t, out.String()); !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONReporter wrote %v, want: %v", got, want)
+	}
+	if !strings.HasPrefix(out.String(), `{"time":"2020-08-11T06:55:22Z","level":"INFO","msg":`) {
+		t.Errorf("JSONReporter wrote %s, want the time, level and message first", out.String())
+	}
+}
+
+func TestLoggerWrite(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(NewLogger(&out))
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	log.Print("ERROR: Cannot save the changes for -undo")
+	log.Print("Time taken by each step:\n\t1. Configuration: 1ms")
+	log.Print("")
+
+	recs := records(t, out.String())
+	if len(recs) != 2 {
+		t.Fatalf("log.Print() wrote %d records, want: 2", len(recs))
+	}
+	if recs[0]["level"] != "ERROR" || recs[0]["msg"] != "ERROR: Cannot save the changes for -undo" {
+		t.Errorf("log.Print() wrote %v, want an ERROR record", recs[0])
+	}
+	if recs[1]["level"] != "INFO" || recs[1]["msg"] != "Time taken by each step:\n\t1. Configuration: 1ms" {
+		t.Errorf("log.Print() wrote %v, want a single INFO record", recs[1])
+	}
+}
+
+func TestReporters(t *testing.T) {
+	var a, b bytes.Buffer
+	rs := Reporters{JSONReporter{Logger: NewLogger(&a)}, JSONReporter{Logger: NewLogger(&b)}}
+	rs.Print("SUCCESS: OAuth test passed")
+	rs.Result(Check{ID: OAuthCheck, Name: "OAuth flow and API access", Status: Pass})
+
+	for _, out := range []string{a.String(), b.String()} {
+		if got := len(records(t, out)); got != 2 {
+			t.Errorf("Reporters wrote %d records, want: 2", got)
+		}
+	}
+}